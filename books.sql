@@ -13,4 +13,127 @@ CREATE TABLE IF NOT EXISTS books (
                                      PRIMARY KEY (id),
                                      UNIQUE INDEX idx_isbn (isbn ASC),
                                      INDEX idx_books_deleted_at (deleted_at ASC)
-) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='图书表';
\ No newline at end of file
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='图书表';
+-- 书籍修订记录：每个版本一条完整快照，只追加，书籍彻底删除时一并清理
+CREATE TABLE IF NOT EXISTS book_revisions (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     created_at DATETIME(3) NULL DEFAULT NULL,
+                                     book_id BIGINT UNSIGNED NOT NULL COMMENT '书籍ID',
+                                     version INT NOT NULL COMMENT '版本号',
+                                     action VARCHAR(16) NOT NULL COMMENT 'create/update/rollback/baseline',
+                                     actor_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '操作者用户ID，0表示系统或未知',
+                                     api_key_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '使用的API Key',
+                                     fields VARCHAR(255) NULL COMMENT '本次修改的字段，逗号分隔',
+                                     restored_from INT NOT NULL DEFAULT 0 COMMENT '回滚时的来源版本',
+                                     snapshot LONGTEXT NOT NULL COMMENT '该版本的完整内容(JSON)',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_book_revision (book_id ASC, version ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='书籍修订记录';
+
+CREATE TABLE IF NOT EXISTS book_marc_records (
+                                     book_id BIGINT UNSIGNED NOT NULL COMMENT '书籍ID',
+                                     record LONGTEXT NOT NULL COMMENT '导入时的原始MARC记录(JSON)',
+                                     updated_at DATETIME(3) NULL DEFAULT NULL,
+                                     PRIMARY KEY (book_id)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='书籍MARC原始记录';
+
+CREATE TABLE IF NOT EXISTS book_metadata_cache (
+                                     isbn VARCHAR(13) NOT NULL COMMENT '去掉连字符的ISBN',
+                                     data TEXT NOT NULL COMMENT '外部书目信息(JSON)',
+                                     fetched_at DATETIME(3) NULL DEFAULT NULL COMMENT '查询时间',
+                                     PRIMARY KEY (isbn)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='按ISBN查询的外部书目信息缓存';
+
+-- 作者：同名的不同作者用 disambiguation 区分；books.author 保留为由著者生成的展示字符串
+CREATE TABLE IF NOT EXISTS authors (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     created_at DATETIME(3) NULL DEFAULT NULL,
+                                     updated_at DATETIME(3) NULL DEFAULT NULL,
+                                     deleted_at DATETIME(3) NULL DEFAULT NULL,
+                                     name VARCHAR(100) NOT NULL COMMENT '姓名',
+                                     disambiguation VARCHAR(255) NULL COMMENT '同名区分说明，如生卒年、国籍',
+                                     bio TEXT NULL COMMENT '简介',
+                                     PRIMARY KEY (id),
+                                     INDEX idx_author_name (name ASC),
+                                     INDEX idx_authors_deleted_at (deleted_at ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='作者表';
+
+-- 书籍贡献者：书籍与作者的多对多关系，书籍彻底删除时一并清理
+CREATE TABLE IF NOT EXISTS book_contributors (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     book_id BIGINT UNSIGNED NOT NULL COMMENT '书籍ID',
+                                     author_id BIGINT UNSIGNED NOT NULL COMMENT '作者ID',
+                                     role VARCHAR(16) NOT NULL COMMENT 'author/translator/editor/illustrator',
+                                     position INT NOT NULL DEFAULT 0 COMMENT '在书籍贡献者中的顺序',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_book_contributor (book_id ASC, author_id ASC, role ASC),
+                                     INDEX idx_contributor_author (author_id ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='书籍贡献者';
+
+-- 分类：树形结构，path 为从根到本节点的 ID 路径（如 /1/5/12/），用于查询子孙分类
+CREATE TABLE IF NOT EXISTS categories (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     created_at DATETIME(3) NULL DEFAULT NULL,
+                                     updated_at DATETIME(3) NULL DEFAULT NULL,
+                                     parent_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '上级分类ID，0 表示顶级分类',
+                                     code VARCHAR(32) NOT NULL COMMENT '中图法分类号或自定义分类号',
+                                     name VARCHAR(100) NOT NULL COMMENT '名称',
+                                     path VARCHAR(255) NOT NULL COMMENT '从根到本节点的 ID 路径',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_category_code (code ASC),
+                                     INDEX idx_category_parent (parent_id ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='分类表';
+
+-- 《中国图书馆分类法》22 个基本大类，可按需继续添加下级分类
+INSERT IGNORE INTO categories (id, created_at, updated_at, parent_id, code, name, path) VALUES
+    (1, NOW(3), NOW(3), 0, 'A', '马克思主义、列宁主义、毛泽东思想、邓小平理论', '/1/'),
+    (2, NOW(3), NOW(3), 0, 'B', '哲学、宗教', '/2/'),
+    (3, NOW(3), NOW(3), 0, 'C', '社会科学总论', '/3/'),
+    (4, NOW(3), NOW(3), 0, 'D', '政治、法律', '/4/'),
+    (5, NOW(3), NOW(3), 0, 'E', '军事', '/5/'),
+    (6, NOW(3), NOW(3), 0, 'F', '经济', '/6/'),
+    (7, NOW(3), NOW(3), 0, 'G', '文化、科学、教育、体育', '/7/'),
+    (8, NOW(3), NOW(3), 0, 'H', '语言、文字', '/8/'),
+    (9, NOW(3), NOW(3), 0, 'I', '文学', '/9/'),
+    (10, NOW(3), NOW(3), 0, 'J', '艺术', '/10/'),
+    (11, NOW(3), NOW(3), 0, 'K', '历史、地理', '/11/'),
+    (12, NOW(3), NOW(3), 0, 'N', '自然科学总论', '/12/'),
+    (13, NOW(3), NOW(3), 0, 'O', '数理科学和化学', '/13/'),
+    (14, NOW(3), NOW(3), 0, 'P', '天文学、地球科学', '/14/'),
+    (15, NOW(3), NOW(3), 0, 'Q', '生物科学', '/15/'),
+    (16, NOW(3), NOW(3), 0, 'R', '医药、卫生', '/16/'),
+    (17, NOW(3), NOW(3), 0, 'S', '农业科学', '/17/'),
+    (18, NOW(3), NOW(3), 0, 'T', '工业技术', '/18/'),
+    (19, NOW(3), NOW(3), 0, 'U', '交通运输', '/19/'),
+    (20, NOW(3), NOW(3), 0, 'V', '航空、航天', '/20/'),
+    (21, NOW(3), NOW(3), 0, 'X', '环境科学、安全科学', '/21/'),
+    (22, NOW(3), NOW(3), 0, 'Z', '综合性图书', '/22/');
+
+-- 书籍分类：书籍与分类的多对多关系，书籍彻底删除时一并清理
+CREATE TABLE IF NOT EXISTS book_categories (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     book_id BIGINT UNSIGNED NOT NULL COMMENT '书籍ID',
+                                     category_id BIGINT UNSIGNED NOT NULL COMMENT '分类ID',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_book_category (book_id ASC, category_id ASC),
+                                     INDEX idx_category_book (category_id ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='书籍分类';
+
+-- 标签：自由标签，为书籍设置标签时自动创建
+CREATE TABLE IF NOT EXISTS tags (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     created_at DATETIME(3) NULL DEFAULT NULL,
+                                     name VARCHAR(50) NOT NULL COMMENT '标签名',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_tag_name (name ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='标签表';
+
+-- 书籍标签：书籍与标签的多对多关系，书籍彻底删除时一并清理
+CREATE TABLE IF NOT EXISTS book_tags (
+                                     id BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+                                     book_id BIGINT UNSIGNED NOT NULL COMMENT '书籍ID',
+                                     tag_id BIGINT UNSIGNED NOT NULL COMMENT '标签ID',
+                                     PRIMARY KEY (id),
+                                     UNIQUE INDEX idx_book_tag (book_id ASC, tag_id ASC),
+                                     INDEX idx_tag_book (tag_id ASC)
+) ENGINE = InnoDB DEFAULT CHARACTER SET = utf8mb4 COLLATE = utf8mb4_unicode_ci, COMMENT='书籍标签';
@@ -1,5 +1,10 @@
 package api
 
+import (
+	"encoding/json"
+	"time"
+)
+
 type BookInfoReq struct {
 	Title string `json:"title" validate:"required"`
 	Count uint   `json:"count" validate:"required"`
@@ -8,11 +13,44 @@ type BookInfoReq struct {
 	Author  string `json:"author"`
 	Content string `json:"content"`
 	Summary string `json:"summary"`
+
+	// Contributors 书籍的全部贡献者，给出时 author 由其中的著者生成；
+	// 不给出时按 author 拆分（更新时仅在 author 变化后重新拆分）
+	Contributors []BookContributor `json:"contributors,omitempty" validate:"omitempty,max=50,dive"`
+
+	// CategoryIDs、Tags 书籍的分类和标签，给出时整体替换（空数组表示清空），更新时不给出表示不修改。
+	// 标签去掉首尾空白后去重，不存在的标签自动创建
+	CategoryIDs []uint   `json:"category_ids,omitempty" validate:"omitempty,max=20,dive,min=1"`
+	Tags        []string `json:"tags,omitempty" validate:"omitempty,max=30,dive,required,max=50"`
+
+	// Operator 操作者，由处理器根据登录信息填写，用于修订记录
+	Operator Operator `json:"-"`
+}
+
+// BookContributor 书籍的贡献者。请求中用 author_id 指定已有作者，
+// 或只给出 name：有同名作者时使用最早创建的一位，没有时新建作者
+type BookContributor struct {
+	AuthorID uint   `json:"author_id,omitempty"`
+	Name     string `json:"name,omitempty" validate:"required_without=AuthorID,max=100"`
+	Role     string `json:"role,omitempty" validate:"omitempty,oneof=author translator editor illustrator"` // 默认为 author
+}
+
+// Operator 发起修改的用户及其使用的 API Key
+type Operator struct {
+	UserID   uint
+	APIKeyID uint
 }
 
 type BookUpdateReq struct {
 	ID uint `json:"id" validate:"required"`
+	// Version 客户端读取时的版本号，与 If-Match 请求头二选一；与当前版本不一致时拒绝更新
+	Version *int `json:"version,omitempty" validate:"omitempty,min=1"`
 	BookInfoReq
+
+	// Fields 局部更新时实际修改的字段（JSON 字段名），为 nil 表示整体更新
+	Fields []string `json:"-"`
+	// RestoredFrom 回滚到历史版本时的来源版本号
+	RestoredFrom int `json:"-"`
 }
 
 type BookSearchReq struct {
@@ -20,15 +58,40 @@ type BookSearchReq struct {
 	ISBN  string `json:"isbn"`
 
 	Author   string `json:"author"`
+	AuthorID uint   `json:"author_id"` // 按作者记录筛选，包含其担任译者等其他角色的书籍
 	Content  string `json:"content"`   // 模糊搜索内容
+
+	CategoryID uint   `json:"category_id"` // 按分类筛选，包含子孙分类
+	Tag        string `json:"tag"`         // 按标签筛选（完全匹配）
+
 	Keyword  string `json:"keyword"`   // 全文搜索关键词
 	Page     int    `json:"page"`      // 分页页码
 	PageSize int    `json:"page_size"` // 每页大小
 }
 
+// BookTrashListReq 回收站查询条件
+type BookTrashListReq struct {
+	Title    string `form:"title"`                                  // 书名模糊匹配
+	ISBN     string `form:"isbn"`                                   // ISBN 精确匹配
+	Page     int    `form:"page"`                                   // 分页页码
+	PageSize int    `form:"page_size" validate:"omitempty,max=100"` // 每页大小
+}
+
+// BookRevisionListReq 修订记录分页参数
+type BookRevisionListReq struct {
+	Page     int `form:"page"`                                   // 分页页码
+	PageSize int `form:"page_size" validate:"omitempty,max=100"` // 每页大小
+}
+
+// BookRevisionDiffReq 比较两个版本
+type BookRevisionDiffReq struct {
+	From int `form:"from" validate:"required,min=1"`
+	To   int `form:"to" validate:"required,min=1"`
+}
+
 type RegisterReq struct {
 	Username string `json:"username" validate:"required,min=3,max=32"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,min=6,max=72"`  // 具体强度要求见 auth.password 配置
 	Role     string `json:"role" validate:"omitempty,oneof=user admin"` // 可选，默认为 user
 }
 
@@ -38,6 +101,91 @@ type LoginReq struct {
 	Password string `json:"password" validate:"required"`
 }
 
+// ChangePasswordReq 修改密码请求
+type ChangePasswordReq struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6,max=72"`
+}
+
+// ForgotPasswordReq 申请重置密码
+type ForgotPasswordReq struct {
+	Username string `json:"username" validate:"required"`
+}
+
+// ResetPasswordReq 使用重置令牌设置新密码
+type ResetPasswordReq struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=6,max=72"`
+}
+
+// MFALoginReq 登录第二步：提交挑战令牌和验证码（或恢复码）
+type MFALoginReq struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFACodeReq 提交一次验证码
+type MFACodeReq struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// MFADisableReq 关闭两步验证，需要同时校验密码和验证码
+type MFADisableReq struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// ProfileUpdateReq 修改个人资料，字段为 null 表示不修改，空字符串表示清空
+type ProfileUpdateReq struct {
+	DisplayName       *string `json:"display_name" validate:"omitempty,max=64"`
+	Email             *string `json:"email" validate:"omitempty,max=128,len=0|email"`
+	Phone             *string `json:"phone" validate:"omitempty,max=20,len=0|e164|numeric"`
+	PreferredLanguage *string `json:"preferred_language" validate:"omitempty,oneof=zh en"`
+}
+
+// APIKeyCreateReq 创建 API Key
+type APIKeyCreateReq struct {
+	Name          string   `json:"name" validate:"required,max=64"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,oneof=books:read books:write admin"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"` // 缺省为 90 天
+}
+
+// ServiceAPIKeyCreateReq 管理员为服务账号创建 API Key
+type ServiceAPIKeyCreateReq struct {
+	UserID uint `json:"user_id" validate:"required"`
+	APIKeyCreateReq
+}
+
+// UserListReq 管理员查询用户列表
+type UserListReq struct {
+	Username string `form:"username"`                                   // 用户名模糊匹配
+	Role     string `form:"role" validate:"omitempty,oneof=user admin"` // 角色过滤
+	Disabled *bool  `form:"disabled"`                                   // 是否禁用
+	Deleted  bool   `form:"deleted"`                                    // 为 true 时只查询已删除用户
+	Page     int    `form:"page"`                                       // 分页页码
+	PageSize int    `form:"page_size" validate:"omitempty,max=100"`     // 每页大小
+}
+
+// AuditListReq 审计日志查询条件，时间格式为 RFC 3339
+type AuditListReq struct {
+	ActorID    uint       `form:"actor_id"`
+	Action     string     `form:"action"` // 前缀匹配，如 book. 或 auth.login
+	TargetType string     `form:"target_type"`
+	TargetID   string     `form:"target_id"`
+	Outcome    string     `form:"outcome" validate:"omitempty,oneof=success failure"`
+	IP         string     `form:"ip"`
+	From       *time.Time `form:"from"`
+	To         *time.Time `form:"to"`
+	Page       int        `form:"page"`
+	PageSize   int        `form:"page_size" validate:"omitempty,max=100"`
+}
+
+// UserUpdateReq 管理员修改用户角色或启用状态，字段为空表示不修改
+type UserUpdateReq struct {
+	Role     *string `json:"role" validate:"omitempty,oneof=user admin"`
+	Disabled *bool   `json:"disabled"`
+}
+
 type BookInfoResp struct {
 	ID    uint   `json:"id"`
 	Title string `json:"title"`
@@ -47,6 +195,144 @@ type BookInfoResp struct {
 	Author  string `json:"author"`
 	Content string `json:"content,omitempty"` // 列表查询时可能不返回内容
 	Summary string `json:"summary"`
+
+	Contributors []BookContributor `json:"contributors,omitempty"` // 数据库列表查询时不返回
+	Categories   []CategoryRef     `json:"categories,omitempty"`   // 列表查询时不返回
+	Tags         []string          `json:"tags,omitempty"`         // 数据库列表查询时不返回
+
+	Version int `json:"version,omitempty"` // 乐观锁版本号，ES 搜索结果中不返回
+
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // 仅回收站列表返回
+}
+
+// 批量删除中单个 ID 的处理结果
+const (
+	DeleteDeleted         = "deleted"           // 已删除
+	DeleteNotFound        = "not_found"         // 书籍不存在或已在回收站中
+	DeleteInvalid         = "invalid"           // ID 格式错误
+	DeleteIndexSyncFailed = "index_sync_failed" // 数据库已删除，但 ES 同步失败
+	DeleteSkipped         = "skipped"           // 事务模式下因其他 ID 失败而未删除
+)
+
+// BookDeleteResult 批量删除中单个 ID 的结果
+type BookDeleteResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// BookDeleteResp 批量删除结果，Results 与请求中的 ID 顺序一致（重复的 ID 只保留一个）
+type BookDeleteResp struct {
+	Atomic  bool               `json:"atomic"`
+	Deleted int                `json:"deleted"` // 数据库中实际删除的数量（含 ES 同步失败的）
+	Results []BookDeleteResult `json:"results"`
+}
+
+// 批量导入模式
+const (
+	ImportSkip   = "skip"   // ISBN 已存在时跳过
+	ImportUpsert = "upsert" // ISBN 已存在时用文件中的内容更新
+)
+
+// BookImportReq 批量导入参数（multipart 表单，文件在 file 字段中）
+type BookImportReq struct {
+	Format string `form:"format" validate:"omitempty,oneof=csv tsv xlsx marc marcxml"` // 文件格式，默认按扩展名判断
+	Mode   string `form:"mode" validate:"omitempty,oneof=skip upsert"`                 // 默认 skip
+	DryRun bool   `form:"dry_run"`                                                     // 只校验并统计，不写入
+	// Mapping 列映射（JSON），键为书籍字段，值为表头中的列名，如 {"title":"书名"}；
+	// 未映射的字段按与字段名相同的列名匹配（不区分大小写）。MARC 文件按固定字段映射，忽略此参数
+	Mapping string `form:"mapping"`
+
+	// Columns 解析后的列映射
+	Columns map[string]string `form:"-"`
+	// Operator 操作者，用于修订记录
+	Operator Operator `form:"-"`
+}
+
+// BookImportRowError 导入失败的行
+type BookImportRowError struct {
+	Row  int    // 文件中的行号，表头为第 1 行；MARC 文件中为记录序号，从 1 开始
+	ISBN string // 解析出的 ISBN，可能为空
+	Err  error  // 失败原因，由处理器按请求语言输出错误码和提示
+}
+
+// BookImportResp 批量导入结果，dry_run 时各数量为预计结果
+type BookImportResp struct {
+	DryRun          bool                 `json:"dry_run"`
+	Mode            string               `json:"mode"`
+	Total           int                  `json:"total"` // 处理的数据行数（不含表头和空行），MARC 文件中为记录数
+	Created         int                  `json:"created"`
+	Updated         int                  `json:"updated"`
+	Unchanged       int                  `json:"unchanged"` // upsert 时内容与现有书籍一致
+	Skipped         int                  `json:"skipped"`   // skip 时 ISBN 已存在
+	Failed          int                  `json:"failed"`
+	IndexSyncFailed int                  `json:"index_sync_failed"` // 已写入数据库但同步搜索索引失败
+	Incomplete      bool                 `json:"incomplete"`        // 超过行数上限或文件损坏，之后的行未处理
+	Errors          []BookImportRowError `json:"-"`                 // 行级错误，由处理器本地化后输出
+}
+
+// 导出格式
+const (
+	ExportCSV   = "csv"
+	ExportJSONL = "jsonl" // JSON Lines，每行一个 JSON 对象（即 NDJSON）
+)
+
+// MARC 格式，导入和导出共用
+const (
+	FormatMARC    = "marc"    // MARC21 ISO 2709（.mrc）
+	FormatMARCXML = "marcxml" // MARCXML
+)
+
+// BookExportReq 导出参数（查询字符串），过滤条件与 BookSearchReq 相同
+type BookExportReq struct {
+	Format string `form:"format" validate:"omitempty,oneof=csv jsonl ndjson marc marcxml"` // 默认 csv，ndjson 与 jsonl 相同
+	Fields string `form:"fields"`                                                          // 导出的字段，逗号分隔，默认全部；MARC 格式忽略
+	Gzip   bool   `form:"gzip"`                                                            // 以 gzip 压缩文件下载
+
+	Title   string `form:"title"`
+	ISBN    string `form:"isbn"`
+	Author  string `form:"author"`
+	Content string `form:"content"`
+
+	// Columns 解析后的字段列表，为空表示全部
+	Columns []string `form:"-"`
+}
+
+// FieldChange 单个字段的变化
+type FieldChange struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+// BookRevisionResp 书籍修订记录
+type BookRevisionResp struct {
+	Version      int       `json:"version"`
+	Action       string    `json:"action"` // create / update / rollback / baseline
+	ActorID      uint      `json:"actor_id"`
+	APIKeyID     uint      `json:"api_key_id,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	Fields       []string  `json:"fields"`                  // 本次修改的字段
+	RestoredFrom int       `json:"restored_from,omitempty"` // 回滚时的来源版本
+
+	Changes  []FieldChange `json:"changes,omitempty"`  // 与上一版本的差异，列表中返回
+	Snapshot *BookInfoReq  `json:"snapshot,omitempty"` // 该版本的完整内容，查询单个版本时返回
+}
+
+type BookRevisionListResp struct {
+	BookID     uint               `json:"book_id"`
+	Revisions  []BookRevisionResp `json:"revisions"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+	TotalPages int                `json:"total_pages"`
+}
+
+// BookRevisionDiffResp 两个版本之间的差异
+type BookRevisionDiffResp struct {
+	BookID  uint          `json:"book_id"`
+	From    int           `json:"from"`
+	To      int           `json:"to"`
+	Changes []FieldChange `json:"changes"`
 }
 
 type BookSearchResp struct {
@@ -58,7 +344,322 @@ type BookSearchResp struct {
 }
 
 type LoginResp struct {
-	Token  string `json:"token"`
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	Token   string       `json:"token"`
+	UserID  uint         `json:"user_id"`
+	Role    string       `json:"role"`
+	Profile *ProfileResp `json:"profile,omitempty"`
+
+	// 启用两步验证的账号第一步登录只返回挑战令牌，需再提交验证码换取正式令牌
+	MFARequired bool   `json:"mfa_required,omitempty"`
+	MFAToken    string `json:"mfa_token,omitempty"`
+}
+
+// MFASetupResp 两步验证注册信息，provisioning_uri 可直接渲染为二维码
+type MFASetupResp struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResp 恢复码只在生成时返回一次
+type RecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ProfileResp 当前用户的个人资料
+type ProfileResp struct {
+	ID                uint      `json:"id"`
+	Username          string    `json:"username"`
+	Role              string    `json:"role"`
+	DisplayName       string    `json:"display_name"`
+	Email             string    `json:"email"`
+	EmailVerified     bool      `json:"email_verified"`
+	Phone             string    `json:"phone"`
+	PreferredLanguage string    `json:"preferred_language"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// UserInfoResp 用户信息（管理员视角）
+type UserInfoResp struct {
+	ID        uint       `json:"id"`
+	Username  string     `json:"username"`
+	Role      string     `json:"role"`
+	Disabled  bool       `json:"disabled"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+type UserListResp struct {
+	Users      []UserInfoResp `json:"users"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// APIKeyResp API Key 信息（不含明文）
+type APIKeyResp struct {
+	ID         uint       `json:"id"`
+	UserID     uint       `json:"user_id"`
+	Name       string     `json:"name"`
+	Kind       string     `json:"kind"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyCreateResp 创建结果，key 明文只返回这一次
+type APIKeyCreateResp struct {
+	APIKeyResp
+	Key string `json:"key"`
+}
+
+// OIDCAuthURLResp 单点登录授权地址，客户端需跳转到该地址
+type OIDCAuthURLResp struct {
+	AuthURL string `json:"auth_url"`
+}
+
+// IdentityResp 已绑定的外部身份
+type IdentityResp struct {
+	ID        uint      `json:"id"`
+	Provider  string    `json:"provider"`
+	Subject   string    `json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditLogResp 审计日志，before/after 为变更前后的 JSON 快照
+type AuditLogResp struct {
+	ID         uint            `json:"id"`
+	CreatedAt  time.Time       `json:"created_at"`
+	ActorID    uint            `json:"actor_id"`
+	ActorType  string          `json:"actor_type"`
+	APIKeyID   uint            `json:"api_key_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	IP         string          `json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	Outcome    string          `json:"outcome"`
+	Detail     string          `json:"detail,omitempty"`
+}
+
+type AuditListResp struct {
+	Logs       []AuditLogResp `json:"logs"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	PageSize   int            `json:"page_size"`
+	TotalPages int            `json:"total_pages"`
+}
+
+// BookHarvestReq OAI-PMH 收割时按日期戳（删除时间或更新时间）增量读取书籍，包含回收站中的书籍
+type BookHarvestReq struct {
+	From  time.Time // 包含，零值表示不限
+	Until time.Time // 不包含，零值表示不限
+
+	// 上一页最后一条记录的日期戳和 ID，AfterID 为 0 时从头读取
+	AfterTime time.Time
+	AfterID   uint
+	Limit     int
+}
+
+// OAIListReq ListIdentifiers/ListRecords 的参数，有 ResumptionToken 时其余参数为空
+type OAIListReq struct {
+	MetadataPrefix  string
+	From            string
+	Until           string
+	Set             string
+	ResumptionToken string
+}
+
+// OPDSPageReq OPDS feed 的分页参数
+type OPDSPageReq struct {
+	Page int `form:"page" validate:"omitempty,min=1"`
+}
+
+// OPDSAuthorReq 某位作者的书籍
+type OPDSAuthorReq struct {
+	AuthorID uint `form:"author_id" validate:"required"`
+	OPDSPageReq
+}
+
+// OPDSSearchReq OpenSearch 关键词搜索
+type OPDSSearchReq struct {
+	Query string `form:"q" validate:"required"`
+	OPDSPageReq
+}
+
+// BookFeedReq OPDS 目录和订阅 feed 的书目分页查询，按上架时间倒序，ByUpdated 时按更新时间倒序；
+// Author 不为空时只查询该作者（完全匹配），CategoryID 不为 0 时只查询该分类及其子孙分类
+type BookFeedReq struct {
+	AuthorID   uint // 作者 ID，不区分贡献者角色
+	CategoryID uint
+	ByUpdated  bool
+	Page       int
+	PageSize   int
+}
+
+// BookFeedItem feed 中的一本书，不含正文；HasContent 表示是否有正文可下载
+type BookFeedItem struct {
+	ID         uint      `json:"id"`
+	Title      string    `json:"title"`
+	ISBN       string    `json:"isbn"`
+	Author     string    `json:"author"`
+	Summary    string    `json:"summary"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	HasContent bool      `json:"has_content"`
+
+	Contributors []BookContributor `json:"contributors,omitempty" gorm:"-"` // 由服务层补充，只有 OPDS 目录会读取
+}
+
+type BookFeedResp struct {
+	Books    []BookFeedItem `json:"books"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// AuthorCount 作者及其书籍数量
+type AuthorCount struct {
+	AuthorID       uint   `json:"author_id"`
+	Author         string `json:"author"`
+	Disambiguation string `json:"disambiguation"`
+	Count          int64  `json:"count"`
+}
+
+type AuthorListResp struct {
+	Authors  []AuthorCount `json:"authors"`
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+}
+
+// FeedReq 订阅 feed 的筛选条件
+type FeedReq struct {
+	AuthorID uint `form:"author_id"` // 作者 ID
+	Category uint `form:"category"`  // 分类 ID，包含子孙分类
+}
+
+// BookMetadataReq 按 ISBN 查询外部书目信息
+type BookMetadataReq struct {
+	ISBN string `form:"isbn" validate:"required"`
+}
+
+// BookMetadataResp 外部书目信息，多位作者以逗号连接；Sources 为提供了信息的服务，Cached 表示来自缓存
+type BookMetadataResp struct {
+	ISBN    string   `json:"isbn"`
+	Title   string   `json:"title"`
+	Author  string   `json:"author"`
+	Summary string   `json:"summary"`
+	Sources []string `json:"sources"`
+	Cached  bool     `json:"cached"`
+}
+
+// AuthorInfoReq 新增或修改作者
+type AuthorInfoReq struct {
+	Name           string `json:"name" validate:"required,max=100"`
+	Disambiguation string `json:"disambiguation" validate:"max=255"`
+	Bio            string `json:"bio"`
+
+	// Operator 操作者，改名后重新生成书籍的 author 时用于修订记录
+	Operator Operator `json:"-"`
+}
+
+// AuthorSearchReq 作者列表查询条件
+type AuthorSearchReq struct {
+	Name     string `form:"name"` // 姓名模糊匹配
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size" validate:"omitempty,max=100"`
+}
+
+// AuthorBooksReq 作者的书籍分页查询
+type AuthorBooksReq struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size" validate:"omitempty,max=100"`
+}
+
+// AuthorInfoResp 作者详情，BookCount 为关联的书籍数量（不含回收站）
+type AuthorInfoResp struct {
+	ID             uint      `json:"id"`
+	Name           string    `json:"name"`
+	Disambiguation string    `json:"disambiguation"`
+	Bio            string    `json:"bio"`
+	BookCount      int64     `json:"book_count"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type AuthorSearchResp struct {
+	Authors    []AuthorInfoResp `json:"authors"`
+	Total      int64            `json:"total"`
+	Page       int              `json:"page"`
+	PageSize   int              `json:"page_size"`
+	TotalPages int              `json:"total_pages"`
+}
+
+// AuthorMigrateResp 把书籍的作者字符串迁移为贡献者的结果
+type AuthorMigrateResp struct {
+	Books          int    `json:"books"`                  // 建立了贡献者的书籍数量
+	AuthorsCreated int    `json:"authors_created"`        // 新建的作者数量
+	IndexFailed    []uint `json:"index_failed,omitempty"` // 同步到 ES 失败的书籍
+}
+
+// CategoryRef 书籍所属的分类
+type CategoryRef struct {
+	ID   uint   `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+// CategoryInfoReq 新增或修改分类，修改 ParentID 即移动分类（连同子孙分类）
+type CategoryInfoReq struct {
+	Code     string `json:"code" validate:"required,max=32"` // 中图法分类号或自定义分类号，全局唯一
+	Name     string `json:"name" validate:"required,max=100"`
+	ParentID uint   `json:"parent_id"` // 0 表示顶级分类
+}
+
+// CategoryBooksReq 分类下书籍的分页查询，包含子孙分类中的书籍
+type CategoryBooksReq struct {
+	Page     int `form:"page"`
+	PageSize int `form:"page_size" validate:"omitempty,max=100"`
+}
+
+// CategoryResp 分类。BookCount 为该分类及其子孙分类中的书籍数量（不含回收站，同一本书只计一次）；
+// 分类树中 Children 为全部子分类，分类详情中 Children 只包含直接子分类，Ancestors 为从顶级分类开始的上级分类
+type CategoryResp struct {
+	ID        uint           `json:"id"`
+	ParentID  uint           `json:"parent_id"`
+	Code      string         `json:"code"`
+	Name      string         `json:"name"`
+	BookCount int64          `json:"book_count"`
+	Ancestors []CategoryRef  `json:"ancestors,omitempty"`
+	Children  []CategoryResp `json:"children,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TagSearchReq 标签列表查询条件
+type TagSearchReq struct {
+	Name     string `form:"name"` // 前缀匹配
+	Page     int    `form:"page"`
+	PageSize int    `form:"page_size" validate:"omitempty,max=100"`
+}
+
+// TagCount 标签及其书籍数量（不含回收站）
+type TagCount struct {
+	Name      string `json:"name"`
+	BookCount int64  `json:"book_count"`
+}
+
+type TagListResp struct {
+	Tags       []TagCount `json:"tags"`
+	Total      int64      `json:"total"`
+	Page       int        `json:"page"`
+	PageSize   int        `json:"page_size"`
+	TotalPages int        `json:"total_pages"`
 }
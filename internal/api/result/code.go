@@ -2,18 +2,54 @@ package result
 
 import "net/http"
 
-// 定义状态码常量
+// 定义状态码常量，失败时响应码同时作为 HTTP 状态码返回
 const (
-	SuccessCode  = http.StatusOK
-	FailedCode   = http.StatusNotImplemented
-	RequiredCode = http.StatusBadRequest
+	SuccessCode              = http.StatusOK
+	FailedCode               = http.StatusInternalServerError
+	RequiredCode             = http.StatusBadRequest
+	UnauthorizedCode         = http.StatusUnauthorized
+	ForbiddenCode            = http.StatusForbidden
+	NotFoundCode             = http.StatusNotFound
+	ConflictCode             = http.StatusConflict
+	PreconditionFailedCode   = http.StatusPreconditionFailed
+	PreconditionRequiredCode = http.StatusPreconditionRequired
+	UnsupportedMediaCode     = http.StatusUnsupportedMediaType
+	UnavailableCode          = http.StatusServiceUnavailable
+	TooManyRequestsCode      = http.StatusTooManyRequests
+	PayloadTooLargeCode      = http.StatusRequestEntityTooLarge
 )
 
 // 状态码与信息映射
 var codeMessages = map[int]string{
-	SuccessCode:  "成功",
-	FailedCode:   "失败",
-	RequiredCode: "缺少必要参数",
+	SuccessCode:              "成功",
+	FailedCode:               "失败",
+	RequiredCode:             "缺少必要参数",
+	UnauthorizedCode:         "未认证",
+	ForbiddenCode:            "权限不足",
+	NotFoundCode:             "资源不存在",
+	ConflictCode:             "数据冲突",
+	PreconditionFailedCode:   "前置条件不满足",
+	PreconditionRequiredCode: "缺少前置条件",
+	UnsupportedMediaCode:     "不支持的媒体类型",
+	UnavailableCode:          "服务暂不可用",
+	TooManyRequestsCode:      "请求过于频繁",
+	PayloadTooLargeCode:      "请求数据过大",
+}
+
+// 状态码对应的默认错误码，处理器未给出具体业务错误时使用
+var errorCodes = map[int]string{
+	FailedCode:               "internal_error",
+	RequiredCode:             "invalid_request",
+	UnauthorizedCode:         "unauthorized",
+	ForbiddenCode:            "forbidden",
+	NotFoundCode:             "not_found",
+	ConflictCode:             "conflict",
+	PreconditionFailedCode:   "precondition_failed",
+	PreconditionRequiredCode: "precondition_required",
+	UnsupportedMediaCode:     "unsupported_media_type",
+	UnavailableCode:          "unavailable",
+	TooManyRequestsCode:      "too_many_requests",
+	PayloadTooLargeCode:      "payload_too_large",
 }
 
 // GetMessage 返回状态码对应的提示信息
@@ -23,3 +59,11 @@ func GetMessage(code int) string {
 	}
 	return "未知状态码"
 }
+
+// GetErrorCode 返回状态码对应的默认错误码
+func GetErrorCode(code int) string {
+	if errCode, ok := errorCodes[code]; ok {
+		return errCode
+	}
+	return "error"
+}
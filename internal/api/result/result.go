@@ -1,35 +1,193 @@
 package result
 
 import (
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/i18n"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// 写入 gin 上下文的响应码与提示，供审计等中间件读取处理结果；
+// MessageKey 保存未翻译的中文提示，审计日志不随请求语言变化
+const (
+	CodeKey      = "result_code"
+	MessageKey   = "result_message"
+	ErrorCodeKey = "result_error_code"
+)
+
+// ProblemContentType RFC 7807 错误响应的媒体类型
+const ProblemContentType = "application/problem+json"
+
+// ValidationErrorCode 参数校验失败的错误码
+const ValidationErrorCode = "validation_failed"
+
+var (
+	// ProblemJSON 为 true 时所有错误都以 RFC 7807 格式返回；
+	// 为 false 时仅在请求的 Accept 包含 application/problem+json 时使用
+	ProblemJSON bool
+	// ProblemTypeBase 与错误码拼接成 problem 的 type 字段
+	ProblemTypeBase = "/errors/"
+)
+
 type Result struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
+	Code      int               `json:"code"`
+	ErrorCode string            `json:"error_code,omitempty"`
+	Message   string            `json:"message"`
+	Errors    []i18n.FieldError `json:"errors,omitempty"`
+	Data      interface{}       `json:"data"`
 }
 
+// Problem RFC 7807 错误响应，code、errors 为扩展字段，与 Result 中的含义一致；
+// current 对应 Result.Data，为冲突时资源的当前内容
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail,omitempty"`
+	Instance string            `json:"instance,omitempty"`
+	Code     string            `json:"code"`
+	Errors   []i18n.FieldError `json:"errors,omitempty"`
+	Current  interface{}       `json:"current,omitempty"`
+}
+
+// Lang 按请求的 Accept-Language 选择提示语言
+func Lang(c *gin.Context) string {
+	return i18n.Lang(c.GetHeader("Accept-Language"))
+}
+
+// Success 返回成功响应，data 为字符串时视为提示信息并按请求语言翻译
 func Success(c *gin.Context, data interface{}) {
+	lang := Lang(c)
 	if data == nil {
 		data = gin.H{}
 	}
+	if text, ok := data.(string); ok {
+		data = i18n.T(lang, text)
+	}
 
 	res := Result{}
 	res.Code = SuccessCode
-	res.Message = GetMessage(SuccessCode)
+	res.Message = i18n.T(lang, GetMessage(SuccessCode))
 	res.Data = data
 
+	c.Set(CodeKey, res.Code)
 	c.JSON(http.StatusOK, res)
 }
 
+// Failed 返回失败响应，code 同时作为 HTTP 状态码，错误码取该状态码的默认值
 func Failed(c *gin.Context, code int, message string) {
-	res := Result{}
-	res.Code = code
-	res.Message = message
-	res.Data = gin.H{}
+	fail(c, code, GetErrorCode(code), message, i18n.T(Lang(c), message), nil, nil)
+}
 
-	c.JSON(http.StatusOK, res)
+// Error 按业务错误的分类返回对应的状态码和错误码；prefix 不为空时拼在提示信息前。
+// 未归类的错误按系统错误处理，原因只写日志，不返回给客户端
+func Error(c *gin.Context, prefix string, err error) {
+	ErrorWithData(c, prefix, err, nil)
+}
+
+// ErrorWithData 与 Error 相同，并在 data 中附带数据（如并发冲突时资源的当前内容）
+func ErrorWithData(c *gin.Context, prefix string, err error, data interface{}) {
+	e, ok := apperr.As(err)
+	if !ok {
+		log.Printf("%s %s 系统错误: %v", c.Request.Method, c.Request.URL.Path, err)
+		e = apperr.Internal(GetErrorCode(FailedCode), "系统错误")
+	} else if e.Kind == apperr.KindInternal || e.Kind == apperr.KindUnavailable {
+		log.Printf("%s %s %s: %v", c.Request.Method, c.Request.URL.Path, e.Code, e.Err)
+	}
+
+	fail(c, e.Kind.Status(), e.Code, i18n.Join(i18n.ZH, prefix, e.Message), i18n.Join(Lang(c), prefix, e.Message), nil, data)
+}
+
+// ValidationFailed 返回参数校验失败，errors 中逐项列出未通过校验的字段
+func ValidationFailed(c *gin.Context, err error) {
+	lang := Lang(c)
+	fields := i18n.FieldErrors(lang, err)
+	if len(fields) == 0 {
+		Failed(c, RequiredCode, GetMessage(RequiredCode))
+		return
+	}
+
+	// 审计日志记录中文原因，响应使用请求语言
+	source := validationMessage(i18n.ZH, i18n.FieldErrors(i18n.ZH, err))
+	fail(c, RequiredCode, ValidationErrorCode, source, validationMessage(lang, fields), fields, nil)
+}
+
+// validationMessage 提示信息同时包含各字段原因，便于只展示 message 的客户端
+func validationMessage(lang string, fields []i18n.FieldError) string {
+	reasons := make([]string, 0, len(fields))
+	for _, f := range fields {
+		reasons = append(reasons, f.Message)
+	}
+	if lang == i18n.ZH {
+		return "请求参数校验失败：" + strings.Join(reasons, "；")
+	}
+	return i18n.T(lang, "请求参数校验失败") + ": " + strings.Join(reasons, "; ")
+}
+
+// BindFailed 返回请求体解析失败；字段类型不匹配时指出字段名
+func BindFailed(c *gin.Context, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		lang := Lang(c)
+		fields := []i18n.FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: typeErr.Field + " " + i18n.T(lang, "字段类型错误"),
+		}}
+		fail(c, RequiredCode, ValidationErrorCode, "请求数据格式错误", i18n.T(lang, "请求数据格式错误"), fields, nil)
+		return
+	}
+	Failed(c, RequiredCode, "请求数据格式错误")
+}
+
+// Abort 供中间件使用：写入错误响应并终止后续处理
+func Abort(c *gin.Context, err error) {
+	Error(c, "", err)
+	c.Abort()
+}
+
+// fail 写入失败响应，source 为中文原文（记入上下文），message 为返回给客户端的本地化提示
+func fail(c *gin.Context, code int, errCode, source, message string, fields []i18n.FieldError, data interface{}) {
+	status := code
+	if status < http.StatusBadRequest || status > 599 {
+		status = http.StatusOK
+	}
+
+	c.Set(CodeKey, code)
+	c.Set(MessageKey, source)
+	c.Set(ErrorCodeKey, errCode)
+
+	if wantsProblem(c) {
+		c.Render(status, problemRender{Problem{
+			Type:     ProblemTypeBase + errCode,
+			Title:    http.StatusText(status),
+			Status:   status,
+			Detail:   message,
+			Instance: c.Request.URL.Path,
+			Code:     errCode,
+			Errors:   fields,
+			Current:  data,
+		}})
+		return
+	}
+
+	if data == nil {
+		data = gin.H{}
+	}
+	c.JSON(status, Result{
+		Code:      code,
+		ErrorCode: errCode,
+		Message:   message,
+		Errors:    fields,
+		Data:      data,
+	})
+}
+
+func wantsProblem(c *gin.Context) bool {
+	return ProblemJSON || strings.Contains(c.GetHeader("Accept"), ProblemContentType)
 }
@@ -0,0 +1,172 @@
+package result
+
+import (
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/i18n"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(handler gin.HandlerFunc, accept string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/books/:id", handler)
+
+	req := httptest.NewRequest(http.MethodGet, "/books/7", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestFailedUsesCodeAsStatus(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		Failed(c, RequiredCode, "ID格式错误")
+	}, "")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, RequiredCode, res.Code)
+	assert.Equal(t, "invalid_request", res.ErrorCode)
+	assert.Equal(t, "ID格式错误", res.Message)
+}
+
+func TestErrorMapsBusinessError(t *testing.T) {
+	notFound := apperr.NotFound("book_not_found", "书籍不存在")
+
+	var code, message interface{}
+	w := serve(func(c *gin.Context) {
+		Error(c, "书籍查询失败", fmt.Errorf("get: %w", notFound.Wrap(errors.New("record not found"))))
+		code, _ = c.Get(CodeKey)
+		message, _ = c.Get(MessageKey)
+	}, "")
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	var res Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "book_not_found", res.ErrorCode)
+	assert.Equal(t, "书籍查询失败:书籍不存在", res.Message)
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, "书籍查询失败:书籍不存在", message)
+}
+
+func TestErrorHidesUnknownError(t *testing.T) {
+	w := serve(func(c *gin.Context) {
+		Error(c, "", errors.New("dial tcp 10.0.0.1:3306: connection refused"))
+	}, "")
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "10.0.0.1")
+	assert.Contains(t, w.Body.String(), `"error_code":"internal_error"`)
+}
+
+func TestProblemJSON(t *testing.T) {
+	conflict := apperr.Conflict("book_version_conflict", "数据已被其他用户修改，请刷新后重试")
+	handler := func(c *gin.Context) { Error(c, "", conflict) }
+
+	check := func(w *httptest.ResponseRecorder) {
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), ProblemContentType)
+
+		var p Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+		assert.Equal(t, "/errors/book_version_conflict", p.Type)
+		assert.Equal(t, "Conflict", p.Title)
+		assert.Equal(t, http.StatusConflict, p.Status)
+		assert.Equal(t, conflict.Message, p.Detail)
+		assert.Equal(t, "/books/7", p.Instance)
+		assert.Equal(t, "book_version_conflict", p.Code)
+	}
+
+	// 客户端通过 Accept 协商
+	check(serve(handler, "application/problem+json, application/json"))
+
+	// 未协商时使用默认格式
+	w := serve(handler, "application/json")
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Contains(t, w.Body.String(), `"error_code":"book_version_conflict"`)
+
+	// 全局开启
+	ProblemJSON = true
+	t.Cleanup(func() { ProblemJSON = false })
+	check(serve(handler, ""))
+}
+
+func serveLang(handler gin.HandlerFunc, acceptLanguage string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/books", handler)
+
+	req := httptest.NewRequest(http.MethodPost, "/books", strings.NewReader(`{"title":"Go","count":"three"}`))
+	req.Header.Set("Accept-Language", acceptLanguage)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	w := serveLang(func(c *gin.Context) { Success(c, "书籍添加成功") }, "en-US,en;q=0.9")
+	assert.JSONEq(t, `{"code":200,"message":"success","data":"book added"}`, w.Body.String())
+
+	var source interface{}
+	w = serveLang(func(c *gin.Context) {
+		Error(c, "书籍查询失败", apperr.NotFound("book_not_found", "书籍不存在"))
+		source, _ = c.Get(MessageKey)
+	}, "en")
+	assert.Contains(t, w.Body.String(), `"message":"failed to query books: book not found"`)
+	// 审计使用的原文不随语言变化
+	assert.Equal(t, "书籍查询失败:书籍不存在", source)
+
+	w = serveLang(func(c *gin.Context) { Failed(c, RequiredCode, "ID格式错误") }, "zh-CN")
+	assert.Contains(t, w.Body.String(), `"message":"ID格式错误"`)
+}
+
+func TestValidationFailed(t *testing.T) {
+	type req struct {
+		Title string `json:"title" validate:"required"`
+		Count uint   `json:"count" validate:"required,max=10"`
+	}
+	handler := func(c *gin.Context) {
+		ValidationFailed(c, i18n.Validate.Struct(&req{Count: 20}))
+	}
+
+	w := serveLang(handler, "en")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, ValidationErrorCode, res.ErrorCode)
+	assert.Equal(t, "request validation failed: title is a required field; count must be 10 or less", res.Message)
+	assert.Equal(t, []i18n.FieldError{
+		{Field: "title", Rule: "required", Message: "title is a required field"},
+		{Field: "count", Rule: "max", Message: "count must be 10 or less"},
+	}, res.Errors)
+
+	w = serveLang(handler, "")
+	assert.Contains(t, w.Body.String(), "请求参数校验失败：title为必填字段；count必须小于或等于10")
+}
+
+func TestBindFailed(t *testing.T) {
+	w := serveLang(func(c *gin.Context) {
+		var body struct {
+			Count uint `json:"count"`
+		}
+		BindFailed(c, c.ShouldBindJSON(&body))
+	}, "en")
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var res Result
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+	assert.Equal(t, "malformed request body", res.Message)
+	assert.Equal(t, []i18n.FieldError{{Field: "count", Rule: "type", Message: "count field has the wrong type"}}, res.Errors)
+}
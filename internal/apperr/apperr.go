@@ -0,0 +1,118 @@
+// Package apperr 定义业务错误的分类。
+// 服务层把底层错误映射为 *Error，处理器据此返回对应的 HTTP 状态码和稳定的错误码
+package apperr
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Kind 错误分类，决定 HTTP 状态码
+type Kind int
+
+const (
+	KindInternal             Kind = iota // 未归类的服务端错误
+	KindValidation                       // 请求参数不合法
+	KindUnauthorized                     // 未认证或凭证无效
+	KindForbidden                        // 已认证但无权操作
+	KindNotFound                         // 资源不存在
+	KindConflict                         // 与当前状态冲突（重复、并发修改等）
+	KindPreconditionFailed               // 条件请求不满足（If-Match 等）
+	KindPreconditionRequired             // 缺少必需的条件请求头（如 If-Match）
+	KindUnsupportedMediaType             // 请求体的 Content-Type 不受支持
+	KindUnavailable                      // 依赖服务不可用（数据库、ES、外部服务）
+	KindTooManyRequests                  // 尝试次数过多，需等待后重试
+	KindPayloadTooLarge                  // 请求体或其产生的数据超过大小限制
+)
+
+// Status 分类对应的 HTTP 状态码
+func (k Kind) Status() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case KindPreconditionRequired:
+		return http.StatusPreconditionRequired
+	case KindUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case KindPayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error 业务错误。Code 是稳定的机器可读错误码，Message 是返回给用户的提示，
+// Err 为底层原因，只用于日志
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Validation(code, message string) *Error      { return New(KindValidation, code, message) }
+func Unauthorized(code, message string) *Error    { return New(KindUnauthorized, code, message) }
+func Forbidden(code, message string) *Error       { return New(KindForbidden, code, message) }
+func NotFound(code, message string) *Error        { return New(KindNotFound, code, message) }
+func Conflict(code, message string) *Error        { return New(KindConflict, code, message) }
+func Unavailable(code, message string) *Error     { return New(KindUnavailable, code, message) }
+func Internal(code, message string) *Error        { return New(KindInternal, code, message) }
+func TooManyRequests(code, message string) *Error { return New(KindTooManyRequests, code, message) }
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is 错误码相同即视为同一种错误，Wrap/WithMessage 得到的副本仍能匹配原错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap 返回附带底层原因的副本
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithMessage 返回替换了提示信息的副本
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// As 取出错误链中的业务错误
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
@@ -0,0 +1,52 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIs(t *testing.T) {
+	notFound := NotFound("book_not_found", "书籍不存在")
+
+	wrapped := fmt.Errorf("service: %w", notFound.Wrap(errors.New("record not found")))
+	assert.ErrorIs(t, wrapped, notFound)
+	assert.NotErrorIs(t, wrapped, Conflict("book_version_conflict", "冲突"))
+
+	e, ok := As(wrapped)
+	assert.True(t, ok)
+	assert.Equal(t, "book_not_found", e.Code)
+	assert.Equal(t, http.StatusNotFound, e.Kind.Status())
+	assert.Equal(t, "书籍不存在: record not found", e.Error())
+
+	// 替换提示后仍是同一种错误
+	custom := notFound.WithMessage("书籍 7 不存在")
+	assert.ErrorIs(t, custom, notFound)
+	assert.Equal(t, "书籍不存在", notFound.Message)
+
+	_, ok = As(errors.New("plain"))
+	assert.False(t, ok)
+}
+
+func TestKindStatus(t *testing.T) {
+	tests := map[Kind]int{
+		KindInternal:             http.StatusInternalServerError,
+		KindValidation:           http.StatusBadRequest,
+		KindUnauthorized:         http.StatusUnauthorized,
+		KindForbidden:            http.StatusForbidden,
+		KindNotFound:             http.StatusNotFound,
+		KindConflict:             http.StatusConflict,
+		KindPreconditionFailed:   http.StatusPreconditionFailed,
+		KindPreconditionRequired: http.StatusPreconditionRequired,
+		KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+		KindUnavailable:          http.StatusServiceUnavailable,
+		KindTooManyRequests:      http.StatusTooManyRequests,
+		KindPayloadTooLarge:      http.StatusRequestEntityTooLarge,
+	}
+	for kind, status := range tests {
+		assert.Equal(t, status, kind.Status())
+	}
+}
@@ -0,0 +1,81 @@
+package audit
+
+import (
+	"LibraryManagement/internal/model"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// entryKey 当前请求的审计记录在 gin 上下文中的键
+const entryKey = "audit_entry"
+
+// Begin 为当前请求创建审计记录，由审计中间件调用
+func Begin(c *gin.Context, action, targetType string) *model.AuditLog {
+	entry := &model.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   c.Param("id"),
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	c.Set(entryKey, entry)
+	return entry
+}
+
+// Active 当前请求是否需要审计，处理器可据此决定是否读取快照
+func Active(c *gin.Context) bool {
+	return entry(c) != nil
+}
+
+// SetTarget 设置操作对象
+func SetTarget(c *gin.Context, targetType, targetID string) {
+	if e := entry(c); e != nil {
+		e.TargetType = targetType
+		e.TargetID = targetID
+	}
+}
+
+// SetTargetID 设置数字 ID 形式的操作对象
+func SetTargetID(c *gin.Context, targetType string, id uint) {
+	SetTarget(c, targetType, strconv.FormatUint(uint64(id), 10))
+}
+
+// SetActor 登录等公开接口在认证成功后补充操作者
+func SetActor(c *gin.Context, userID uint) {
+	if e := entry(c); e != nil {
+		e.ActorID = userID
+	}
+}
+
+// SetChange 记录变更前后的快照，nil 表示不存在
+func SetChange(c *gin.Context, before, after interface{}) {
+	e := entry(c)
+	if e == nil {
+		return
+	}
+	if before != nil {
+		e.Before = Snapshot(before)
+	}
+	if after != nil {
+		e.After = Snapshot(after)
+	}
+}
+
+// Snapshot 将对象序列化为快照
+func Snapshot(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func entry(c *gin.Context) *model.AuditLog {
+	value, exists := c.Get(entryKey)
+	if !exists {
+		return nil
+	}
+	return value.(*model.AuditLog)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -11,10 +12,23 @@ type config struct {
 	Server        server              `yaml:"server"`
 	Db            db                  `yaml:"db"`
 	Elasticsearch elasticsearchConfig `yaml:"elasticsearch"`
+	Auth          authConfig          `yaml:"auth"`
+	Notify        notifyConfig        `yaml:"notify"`
+	OIDC          oidcConfig          `yaml:"oidc"`
+	Audit         auditConfig         `yaml:"audit"`
+	Trash         trashConfig         `yaml:"trash"`
+	Books         booksConfig         `yaml:"books"`
+	OAI           oaiConfig           `yaml:"oai"`
+	SRU           sruConfig           `yaml:"sru"`
+	OPDS          opdsConfig          `yaml:"opds"`
+	Feed          feedConfig          `yaml:"feed"`
+	Metadata      metadataConfig      `yaml:"metadata"`
 }
 
 type server struct {
 	Port string `yaml:"port"`
+	// ProblemJSON 为 true 时错误统一以 RFC 7807 application/problem+json 返回
+	ProblemJSON bool `yaml:"problem_json"`
 }
 
 type db struct {
@@ -33,6 +47,129 @@ type elasticsearchConfig struct {
 	Password string `yaml:"password"`
 }
 
+// authConfig 认证相关配置
+type authConfig struct {
+	ResetTokenTTL time.Duration  `yaml:"reset_token_ttl"` // 密码重置令牌有效期
+	ResetURL      string         `yaml:"reset_url"`       // 重置链接模板，%s 会被替换为令牌
+	MFA           mfaConfig      `yaml:"mfa"`
+	Password      passwordConfig `yaml:"password"`
+}
+
+// passwordConfig 密码策略，注册、修改密码、重置密码时生效
+type passwordConfig struct {
+	MinLength        int    `yaml:"min_length"`
+	RequireUpper     bool   `yaml:"require_upper"`
+	RequireLower     bool   `yaml:"require_lower"`
+	RequireDigit     bool   `yaml:"require_digit"`
+	RequireSymbol    bool   `yaml:"require_symbol"`
+	MinClasses       int    `yaml:"min_classes"`       // 大写、小写、数字、特殊字符中至少包含几类
+	DisallowUsername bool   `yaml:"disallow_username"` // 不能包含用户名
+	History          int    `yaml:"history"`           // 不能与最近 N 次使用过的密码相同，0 表示不限制
+	BreachedFile     string `yaml:"breached_file"`     // 泄露密码 SHA-1 列表文件，为空时不检查
+}
+
+// mfaConfig 两步验证配置
+type mfaConfig struct {
+	Issuer       string        `yaml:"issuer"`        // 验证器应用中显示的发行方
+	EnforceAdmin bool          `yaml:"enforce_admin"` // 管理员必须启用两步验证才能访问管理接口
+	ChallengeTTL time.Duration `yaml:"challenge_ttl"` // 登录挑战令牌有效期
+	MaxFailures  int           `yaml:"max_failures"`  // 登录时连续输错验证码的上限，达到后锁定
+	Lockout      time.Duration `yaml:"lockout"`       // 锁定时长
+}
+
+// notifyConfig 通知发送配置
+type notifyConfig struct {
+	Type     string `yaml:"type"`      // log | file
+	FilePath string `yaml:"file_path"` // type 为 file 时的输出文件
+}
+
+// oidcConfig 单点登录（OpenID Connect）配置
+type oidcConfig struct {
+	Enabled      bool     `yaml:"enabled"`
+	Issuer       string   `yaml:"issuer"` // 提供方地址，用于发现 /.well-known/openid-configuration
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"` // 回调地址，指向 /auth/oidc/callback
+	Scopes       []string `yaml:"scopes"`
+
+	UsernameClaim string `yaml:"username_claim"` // 新建用户时作为用户名的声明
+
+	// 角色映射：读取 RoleClaim 声明（字符串或数组），按 RoleMapping 转换为本地角色
+	RoleClaim   string            `yaml:"role_claim"`
+	RoleMapping map[string]string `yaml:"role_mapping"`
+	DefaultRole string            `yaml:"default_role"`
+	SyncRole    bool              `yaml:"sync_role"` // 每次登录都按映射结果更新本地角色
+
+	AutoLinkByEmail bool          `yaml:"auto_link_by_email"` // 双方邮箱都已验证且本地邮箱唯一时自动绑定
+	StateTTL        time.Duration `yaml:"state_ttl"`          // 授权请求有效期，授权状态只保存在本实例内存中
+}
+
+// auditConfig 审计日志配置
+type auditConfig struct {
+	Retention     time.Duration `yaml:"retention"`      // 保留时长，超过后清理
+	PurgeInterval time.Duration `yaml:"purge_interval"` // 清理任务执行间隔
+}
+
+// trashConfig 书籍回收站配置
+type trashConfig struct {
+	Retention     time.Duration `yaml:"retention"`      // 删除后保留时长，超过后彻底删除
+	PurgeInterval time.Duration `yaml:"purge_interval"` // 清理任务执行间隔
+}
+
+// booksConfig 书籍维护配置
+type booksConfig struct {
+	MaxBatchSize    int `yaml:"max_batch_size"`    // 批量删除单次最多处理的 ID 数
+	ImportMaxRows   int `yaml:"import_max_rows"`   // 单个导入文件最多处理的数据行数
+	ImportBatchSize int `yaml:"import_batch_size"` // 导入时每批写入数据库和 ES 的行数
+}
+
+// oaiConfig OAI-PMH 收割接口配置
+type oaiConfig struct {
+	Enabled              bool     `yaml:"enabled"`
+	RepositoryName       string   `yaml:"repository_name"`
+	BaseURL              string   `yaml:"base_url"`              // 对外的接口地址，出现在每个响应中
+	RepositoryIdentifier string   `yaml:"repository_identifier"` // 记录标识符 oai:<repository_identifier>:book/<id> 中的域名部分
+	AdminEmails          []string `yaml:"admin_emails"`
+	PageSize             int      `yaml:"page_size"` // ListIdentifiers/ListRecords 每页记录数
+}
+
+// sruConfig SRU 检索接口配置
+type sruConfig struct {
+	Enabled        bool   `yaml:"enabled"`
+	BaseURL        string `yaml:"base_url"`        // 对外的接口地址，explain 中的主机、端口和数据库由它得出
+	DatabaseTitle  string `yaml:"database_title"`  // explain 中的数据库名称
+	DefaultRecords int    `yaml:"default_records"` // 未指定 maximumRecords 时每次返回的记录数
+	MaxRecords     int    `yaml:"max_records"`     // maximumRecords 的上限
+}
+
+// opdsConfig OPDS 目录配置
+type opdsConfig struct {
+	PageSize int `yaml:"page_size"` // 获取 feed 和作者列表每页条数
+}
+
+// feedConfig 新书和更新订阅 feed（Atom/RSS）配置
+type feedConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	BaseURL string `yaml:"base_url"` // 对外的站点地址，feed 的 self 链接由它得出
+	Title   string `yaml:"title"`    // feed 标题的前缀
+	BookURL string `yaml:"book_url"` // 书籍页面地址，{id} 替换为书籍 ID；为空时条目不带链接
+	Limit   int    `yaml:"limit"`    // 每个 feed 的条目数
+}
+
+// metadataConfig 按 ISBN 查询外部书目信息的配置
+type metadataConfig struct {
+	Enabled   bool                     `yaml:"enabled"`
+	CacheTTL  time.Duration            `yaml:"cache_ttl"` // 查询结果的缓存时长
+	Providers []metadataProviderConfig `yaml:"providers"` // 按顺序查询，缺少的字段由后面的提供方补充
+}
+
+type metadataProviderConfig struct {
+	Name    string        `yaml:"name"`     // 出现在查询结果的 sources 中，默认与 type 相同
+	Type    string        `yaml:"type"`     // openlibrary
+	BaseURL string        `yaml:"base_url"` // 服务地址，可指向兼容的镜像
+	Timeout time.Duration `yaml:"timeout"`  // 单次请求超时
+}
+
 var Config *config
 
 func LoadConfig(path string) error {
@@ -45,5 +182,101 @@ func LoadConfig(path string) error {
 		return fmt.Errorf("无法解析配置文件: %w", err)
 	}
 
+	setDefaults()
+
 	return nil
 }
+
+// setDefaults 为未配置的选项填充默认值
+func setDefaults() {
+	if Config.Auth.ResetTokenTTL <= 0 {
+		Config.Auth.ResetTokenTTL = 30 * time.Minute
+	}
+	if Config.Auth.MFA.Issuer == "" {
+		Config.Auth.MFA.Issuer = "LibraryManagement"
+	}
+	if Config.Auth.MFA.ChallengeTTL <= 0 {
+		Config.Auth.MFA.ChallengeTTL = 5 * time.Minute
+	}
+	if Config.Auth.MFA.MaxFailures <= 0 {
+		Config.Auth.MFA.MaxFailures = 5
+	}
+	if Config.Auth.MFA.Lockout <= 0 {
+		Config.Auth.MFA.Lockout = 15 * time.Minute
+	}
+	if Config.OIDC.UsernameClaim == "" {
+		Config.OIDC.UsernameClaim = "preferred_username"
+	}
+	if Config.OIDC.DefaultRole == "" {
+		Config.OIDC.DefaultRole = "user"
+	}
+	if Config.OIDC.StateTTL <= 0 {
+		Config.OIDC.StateTTL = 10 * time.Minute
+	}
+	if Config.Auth.Password.MinLength <= 0 {
+		Config.Auth.Password.MinLength = 8
+	}
+	if Config.Audit.Retention <= 0 {
+		Config.Audit.Retention = 180 * 24 * time.Hour
+	}
+	if Config.Audit.PurgeInterval <= 0 {
+		Config.Audit.PurgeInterval = 24 * time.Hour
+	}
+	if Config.Trash.Retention <= 0 {
+		Config.Trash.Retention = 30 * 24 * time.Hour
+	}
+	if Config.Trash.PurgeInterval <= 0 {
+		Config.Trash.PurgeInterval = 24 * time.Hour
+	}
+	if Config.Books.MaxBatchSize <= 0 {
+		Config.Books.MaxBatchSize = 100
+	}
+	if Config.Books.ImportMaxRows <= 0 {
+		Config.Books.ImportMaxRows = 10000
+	}
+	if Config.Books.ImportBatchSize <= 0 {
+		Config.Books.ImportBatchSize = 500
+	}
+	if Config.OAI.RepositoryName == "" {
+		Config.OAI.RepositoryName = "LibraryManagement"
+	}
+	if Config.OAI.RepositoryIdentifier == "" {
+		Config.OAI.RepositoryIdentifier = "library.example.org"
+	}
+	if Config.OAI.PageSize <= 0 {
+		Config.OAI.PageSize = 100
+	}
+	if Config.SRU.DatabaseTitle == "" {
+		Config.SRU.DatabaseTitle = "LibraryManagement"
+	}
+	if Config.SRU.DefaultRecords <= 0 {
+		Config.SRU.DefaultRecords = 10
+	}
+	if Config.SRU.MaxRecords <= 0 {
+		Config.SRU.MaxRecords = 50
+	}
+	if Config.OPDS.PageSize <= 0 {
+		Config.OPDS.PageSize = 20
+	}
+	if Config.Feed.Title == "" {
+		Config.Feed.Title = "LibraryManagement"
+	}
+	if Config.Feed.Limit <= 0 {
+		Config.Feed.Limit = 50
+	}
+	if Config.Metadata.CacheTTL <= 0 {
+		Config.Metadata.CacheTTL = 720 * time.Hour
+	}
+	for i := range Config.Metadata.Providers {
+		provider := &Config.Metadata.Providers[i]
+		if provider.Name == "" {
+			provider.Name = provider.Type
+		}
+		if provider.Timeout <= 0 {
+			provider.Timeout = 5 * time.Second
+		}
+	}
+	if Config.Notify.Type == "" {
+		Config.Notify.Type = "log"
+	}
+}
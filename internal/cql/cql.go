@@ -0,0 +1,110 @@
+// Package cql 解析 CQL（Contextual Query Language）1.2 查询，生成语法树。
+// 检索点、关系和布尔运算是否支持由调用方判断
+package cql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ServerChoice 省略检索点时使用的默认检索点，关系为 =
+const ServerChoice = "cql.serverChoice"
+
+// Node 语法树节点，*Clause 或 *Boolean
+type Node interface {
+	node()
+}
+
+// Clause 检索子句 index relation term
+type Clause struct {
+	Index    string
+	Relation Relation
+	// Term 检索词，保留反斜杠转义，用 Value 取得实际值
+	Term string
+}
+
+// Relation 关系及其修饰符，如 =/relevant
+type Relation struct {
+	Name      string
+	Modifiers []Modifier
+}
+
+// Modifier 关系、布尔运算或排序键的修饰符，如 /rel.algorithm=cori
+type Modifier struct {
+	Name       string
+	Comparator string
+	Value      string
+}
+
+// Boolean 布尔运算，Op 为小写的 and、or、not 或 prox
+type Boolean struct {
+	Op        string
+	Modifiers []Modifier
+	Left      Node
+	Right     Node
+}
+
+func (*Clause) node()  {}
+func (*Boolean) node() {}
+
+// Prefix 前缀声明 > dc = "info:srw/cql-context-set/1/dc-v1.1"
+type Prefix struct {
+	Name string
+	URI  string
+}
+
+// SortKey sortby 子句中的排序键
+type SortKey struct {
+	Index     string
+	Modifiers []Modifier
+}
+
+// Query 解析结果
+type Query struct {
+	Root     Node
+	Prefixes []Prefix
+	SortKeys []SortKey
+}
+
+// SyntaxError 查询语法错误，Pos 为出错位置（字节偏移）
+type SyntaxError struct {
+	Pos     int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s（位置 %d）", e.Message, e.Pos)
+}
+
+// Value 去掉转义后的检索词；masked 表示包含未转义的截词符 * 或 ?，
+// anchored 表示包含未转义的定位符 ^
+func (c *Clause) Value() (value string, masked, anchored bool) {
+	var b strings.Builder
+	escaped := false
+	for _, r := range c.Term {
+		switch {
+		case escaped:
+			escaped = false
+			b.WriteRune(r)
+		case r == '\\':
+			escaped = true
+		case r == '*' || r == '?':
+			masked = true
+			b.WriteRune(r)
+		case r == '^':
+			anchored = true
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String(), masked, anchored
+}
+
+// IndexName 拆分检索点的上下文集前缀，如 dc.title 返回 dc 和 title
+func (c *Clause) IndexName() (set, name string) {
+	if i := strings.LastIndex(c.Index, "."); i >= 0 {
+		return c.Index[:i], c.Index[i+1:]
+	}
+	return "", c.Index
+}
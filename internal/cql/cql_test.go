@@ -0,0 +1,78 @@
+package cql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`dc.title any "go 编程" and (author = 张三 or isbn == 9787111111111) not 入门`)
+	require.NoError(t, err)
+
+	// 布尔运算从左到右结合
+	not, ok := q.Root.(*Boolean)
+	require.True(t, ok)
+	assert.Equal(t, "not", not.Op)
+	assert.Equal(t, &Clause{Index: ServerChoice, Relation: Relation{Name: "="}, Term: "入门"}, not.Right)
+
+	and := not.Left.(*Boolean)
+	assert.Equal(t, "and", and.Op)
+	assert.Equal(t, &Clause{Index: "dc.title", Relation: Relation{Name: "any"}, Term: "go 编程"}, and.Left)
+
+	or := and.Right.(*Boolean)
+	assert.Equal(t, "or", or.Op)
+	assert.Equal(t, &Clause{Index: "author", Relation: Relation{Name: "="}, Term: "张三"}, or.Left)
+	assert.Equal(t, &Clause{Index: "isbn", Relation: Relation{Name: "=="}, Term: "9787111111111"}, or.Right)
+}
+
+func TestParseModifiers(t *testing.T) {
+	q, err := Parse(`> dc = "info:srw/cql-context-set/1/dc-v1.1" title ADJ/cql.relevant cat and/rel.combine=sum dog sortby dc.title/sort.descending`)
+	require.NoError(t, err)
+	assert.Equal(t, []Prefix{{Name: "dc", URI: "info:srw/cql-context-set/1/dc-v1.1"}}, q.Prefixes)
+	assert.Equal(t, []SortKey{{Index: "dc.title", Modifiers: []Modifier{{Name: "sort.descending"}}}}, q.SortKeys)
+
+	and := q.Root.(*Boolean)
+	assert.Equal(t, []Modifier{{Name: "rel.combine", Comparator: "=", Value: "sum"}}, and.Modifiers)
+	assert.Equal(t, Relation{Name: "adj", Modifiers: []Modifier{{Name: "cql.relevant"}}}, and.Left.(*Clause).Relation)
+}
+
+func TestClauseValue(t *testing.T) {
+	q, err := Parse(`"say \"hi\" go\*" or fish* or ^cat`)
+	require.NoError(t, err)
+	or := q.Root.(*Boolean)
+	inner := or.Left.(*Boolean)
+
+	value, masked, anchored := inner.Left.(*Clause).Value()
+	assert.Equal(t, `say "hi" go*`, value)
+	assert.False(t, masked)
+	assert.False(t, anchored)
+
+	_, masked, _ = inner.Right.(*Clause).Value()
+	assert.True(t, masked)
+	_, _, anchored = or.Right.(*Clause).Value()
+	assert.True(t, anchored)
+
+	set, name := (&Clause{Index: "dc.title"}).IndexName()
+	assert.Equal(t, "dc", set)
+	assert.Equal(t, "title", name)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{
+		"",
+		`"unterminated`,
+		"title =",
+		"cat and",
+		"(cat or dog",
+		"cat dog",
+		"cat)",
+		"title = cat sortby",
+		"title =/ cat",
+	} {
+		_, err := Parse(query)
+		var syntaxErr *SyntaxError
+		assert.ErrorAs(t, err, &syntaxErr, query)
+	}
+}
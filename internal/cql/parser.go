@@ -0,0 +1,293 @@
+package cql
+
+import "strings"
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString // 带引号的字符串
+	tokSymbol // ( ) / = > < >= <= <> ==
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// lex 切分词法单元。引号内的 \" 是转义的引号，其余转义原样保留
+func lex(s string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == '/':
+			tokens = append(tokens, token{tokSymbol, string(c), i})
+			i++
+		case c == '=' || c == '<' || c == '>':
+			n := 1
+			if i+1 < len(s) {
+				switch s[i : i+2] {
+				case "==", ">=", "<=", "<>":
+					n = 2
+				}
+			}
+			tokens = append(tokens, token{tokSymbol, s[i : i+n], i})
+			i += n
+		case c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					if s[i+1] != '"' {
+						b.WriteByte('\\')
+					}
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			if i >= len(s) {
+				return nil, &SyntaxError{Pos: start, Message: "引号不匹配"}
+			}
+			i++
+			tokens = append(tokens, token{tokString, b.String(), start})
+		default:
+			start := i
+			for i < len(s) && !strings.ContainsRune(" \t\n\r()/=<>\"", rune(s[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokWord, s[start:i], start})
+		}
+	}
+	return append(tokens, token{kind: tokEOF, pos: len(s)}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	query  *Query
+}
+
+// Parse 解析 CQL 查询。布尔运算从左到右结合，优先级相同，用括号改变顺序
+func Parse(s string) (*Query, error) {
+	tokens, err := lex(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens, query: &Query{}}
+	if p.query.Root, err = p.cqlQuery(); err != nil {
+		return nil, err
+	}
+	if p.isKeyword("sortby") {
+		p.next()
+		if err := p.sortKeys(); err != nil {
+			return nil, err
+		}
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.unexpected(tok)
+	}
+	return p.query, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) isSymbol(text string) bool {
+	tok := p.peek()
+	return tok.kind == tokSymbol && tok.text == text
+}
+
+func (p *parser) isKeyword(word string) bool {
+	tok := p.peek()
+	return tok.kind == tokWord && strings.EqualFold(tok.text, word)
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokEOF {
+		return &SyntaxError{Pos: tok.pos, Message: "查询意外结束"}
+	}
+	return &SyntaxError{Pos: tok.pos, Message: "意外的 " + tok.text}
+}
+
+// isBoolean 判断词法单元是否为布尔运算符
+func isBoolean(tok token) bool {
+	if tok.kind != tokWord {
+		return false
+	}
+	switch strings.ToLower(tok.text) {
+	case "and", "or", "not", "prox":
+		return true
+	}
+	return false
+}
+
+// cqlQuery ::= prefixAssignment cqlQuery | scopedClause
+func (p *parser) cqlQuery() (Node, error) {
+	for p.isSymbol(">") {
+		p.next()
+		prefix := Prefix{}
+		tok := p.next()
+		if tok.kind != tokWord && tok.kind != tokString {
+			return nil, p.unexpected(tok)
+		}
+		if tok.kind == tokWord && p.isSymbol("=") {
+			p.next()
+			prefix.Name = tok.text
+			if tok = p.next(); tok.kind != tokWord && tok.kind != tokString {
+				return nil, p.unexpected(tok)
+			}
+		}
+		prefix.URI = tok.text
+		p.query.Prefixes = append(p.query.Prefixes, prefix)
+	}
+	return p.scopedClause()
+}
+
+// scopedClause ::= scopedClause boolean [modifiers] searchClause | searchClause
+func (p *parser) scopedClause() (Node, error) {
+	left, err := p.searchClause()
+	if err != nil {
+		return nil, err
+	}
+	for isBoolean(p.peek()) {
+		op := strings.ToLower(p.next().text)
+		modifiers, err := p.modifiers()
+		if err != nil {
+			return nil, err
+		}
+		right, err := p.searchClause()
+		if err != nil {
+			return nil, err
+		}
+		left = &Boolean{Op: op, Modifiers: modifiers, Left: left, Right: right}
+	}
+	return left, nil
+}
+
+// searchClause ::= '(' cqlQuery ')' | index relation term | term
+func (p *parser) searchClause() (Node, error) {
+	if p.isSymbol("(") {
+		p.next()
+		node, err := p.cqlQuery()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isSymbol(")") {
+			return nil, p.unexpected(p.peek())
+		}
+		p.next()
+		return node, nil
+	}
+
+	tok := p.next()
+	if tok.kind != tokWord && tok.kind != tokString {
+		return nil, p.unexpected(tok)
+	}
+	if tok.kind == tokWord && p.startsRelation() {
+		relation, err := p.relation()
+		if err != nil {
+			return nil, err
+		}
+		term := p.next()
+		if term.kind != tokWord && term.kind != tokString {
+			return nil, p.unexpected(term)
+		}
+		return &Clause{Index: tok.text, Relation: relation, Term: term.text}, nil
+	}
+	return &Clause{Index: ServerChoice, Relation: Relation{Name: "="}, Term: tok.text}, nil
+}
+
+// startsRelation 判断检索词之后是否为关系：比较符号，或者后面还跟着检索词的非布尔词
+func (p *parser) startsRelation() bool {
+	tok := p.peek()
+	switch tok.kind {
+	case tokSymbol:
+		switch tok.text {
+		case "=", ">", "<", ">=", "<=", "<>", "==":
+			return true
+		}
+		return false
+	case tokWord:
+		if isBoolean(tok) || strings.EqualFold(tok.text, "sortby") {
+			return false
+		}
+		next := p.peekAt(1)
+		return next.kind == tokWord || next.kind == tokString || (next.kind == tokSymbol && next.text == "/")
+	}
+	return false
+}
+
+func (p *parser) relation() (Relation, error) {
+	tok := p.next()
+	modifiers, err := p.modifiers()
+	if err != nil {
+		return Relation{}, err
+	}
+	name := tok.text
+	if tok.kind == tokWord {
+		name = strings.ToLower(name)
+	}
+	return Relation{Name: name, Modifiers: modifiers}, nil
+}
+
+// modifiers ::= ('/' name [comparator value])*
+func (p *parser) modifiers() ([]Modifier, error) {
+	var modifiers []Modifier
+	for p.isSymbol("/") {
+		p.next()
+		tok := p.next()
+		if tok.kind != tokWord {
+			return nil, p.unexpected(tok)
+		}
+		modifier := Modifier{Name: tok.text}
+		if cmp := p.peek(); cmp.kind == tokSymbol && cmp.text != "(" && cmp.text != ")" && cmp.text != "/" {
+			p.next()
+			value := p.next()
+			if value.kind != tokWord && value.kind != tokString {
+				return nil, p.unexpected(value)
+			}
+			modifier.Comparator, modifier.Value = cmp.text, value.text
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	return modifiers, nil
+}
+
+// sortKeys ::= (index modifiers)+
+func (p *parser) sortKeys() error {
+	for p.peek().kind == tokWord {
+		key := SortKey{Index: p.next().text}
+		modifiers, err := p.modifiers()
+		if err != nil {
+			return err
+		}
+		key.Modifiers = modifiers
+		p.query.SortKeys = append(p.query.SortKeys, key)
+	}
+	if len(p.query.SortKeys) == 0 {
+		return p.unexpected(p.peek())
+	}
+	return nil
+}
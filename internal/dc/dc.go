@@ -0,0 +1,14 @@
+// Package dc 简单都柏林核心元素，OAI-PMH 的 oai_dc 和 SRU 的 dc 记录格式共用
+package dc
+
+// Namespace 都柏林核心元素集命名空间，前缀为 dc
+const Namespace = "http://purl.org/dc/elements/1.1/"
+
+// Elements 记录中的元素，空值的元素不输出。嵌入各格式的根元素中使用
+type Elements struct {
+	Title       []string `xml:"dc:title,omitempty"`
+	Creator     []string `xml:"dc:creator,omitempty"`
+	Description []string `xml:"dc:description,omitempty"`
+	Type        []string `xml:"dc:type,omitempty"`
+	Identifier  []string `xml:"dc:identifier,omitempty"`
+}
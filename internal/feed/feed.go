@@ -0,0 +1,212 @@
+// Package feed 生成供订阅的 Atom 1.0 和 RSS 2.0 feed。
+// 调用方填写与格式无关的 Channel，再按需要输出为 Atom 或 RSS
+package feed
+
+import (
+	"LibraryManagement/internal/dc"
+	"encoding/xml"
+	"time"
+)
+
+// 媒体类型
+const (
+	AtomType = "application/atom+xml; charset=utf-8"
+	RSSType  = "application/rss+xml; charset=utf-8"
+)
+
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Channel 一个 feed 的内容
+type Channel struct {
+	ID          string // Atom feed 的 id
+	Title       string
+	Description string
+	Self        string // feed 自身的绝对地址
+	Link        string // 对应的网站地址，可为空
+	Author      string // feed 只包含该作者的书籍时为作者姓名，只用于标题，可为空
+	Category    string // feed 只包含该分类的书籍时为分类名称，可为空
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item feed 中的一条
+type Item struct {
+	ID        string // Atom entry 的 id，条目更新后保持不变
+	GUID      string // RSS guid，阅读器据此判断是否为新条目；为空时使用 ID
+	Title     string
+	Link      string // 条目页面地址，可为空
+	Author    string
+	Summary   string
+	Published time.Time // 首次发布时间，可为零值
+	Updated   time.Time
+}
+
+// AtomFeed Atom 1.0 feed
+type AtomFeed struct {
+	XMLName    xml.Name       `xml:"feed"`
+	Xmlns      string         `xml:"xmlns,attr"`
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Subtitle   string         `xml:"subtitle,omitempty"`
+	Updated    string         `xml:"updated"`
+	Links      []AtomLink     `xml:"link"`
+	Categories []AtomCategory `xml:"category"`
+	Entries    []AtomEntry    `xml:"entry"`
+}
+
+type AtomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomText 纯文本内容
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// AtomEntry 条目。没有 alternate 链接的条目必须有 content，摘要总是放在 content 中
+type AtomEntry struct {
+	ID        string       `xml:"id"`
+	Title     string       `xml:"title"`
+	Published string       `xml:"published,omitempty"`
+	Updated   string       `xml:"updated"`
+	Authors   []AtomPerson `xml:"author"`
+	Links     []AtomLink   `xml:"link"`
+	Content   AtomText     `xml:"content"`
+}
+
+// RSS RSS 2.0 文档，self 链接使用 atom:link，作者使用 dc:creator
+type RSS struct {
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	XmlnsAtom string     `xml:"xmlns:atom,attr"`
+	XmlnsDC   string     `xml:"xmlns:dc,attr"`
+	Channel   RSSChannel `xml:"channel"`
+}
+
+type RSSChannel struct {
+	Title         string      `xml:"title"`
+	Link          string      `xml:"link"`
+	Description   string      `xml:"description"`
+	Category      string      `xml:"category,omitempty"`
+	LastBuildDate string      `xml:"lastBuildDate"`
+	AtomLink      RSSAtomLink `xml:"atom:link"`
+	Items         []RSSItem   `xml:"item"`
+}
+
+type RSSAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	GUID        RSSGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type RSSGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// formatAtomTime Atom 时间格式（RFC 3339，UTC）
+func formatAtomTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatRSSTime RSS 时间格式（RFC 822，四位年份）
+func formatRSSTime(t time.Time) string {
+	return t.UTC().Format(time.RFC1123Z)
+}
+
+// Atom 输出为 Atom feed
+func (ch *Channel) Atom() *AtomFeed {
+	f := &AtomFeed{
+		Xmlns:    atomNamespace,
+		ID:       ch.ID,
+		Title:    ch.Title,
+		Subtitle: ch.Description,
+		Updated:  formatAtomTime(ch.Updated),
+		Links:    []AtomLink{{Rel: "self", Href: ch.Self, Type: "application/atom+xml"}},
+	}
+	if ch.Link != "" {
+		f.Links = append(f.Links, AtomLink{Rel: "alternate", Href: ch.Link, Type: "text/html"})
+	}
+	if ch.Category != "" {
+		f.Categories = []AtomCategory{{Term: ch.Category}}
+	}
+	for _, item := range ch.Items {
+		entry := AtomEntry{
+			ID:      item.ID,
+			Title:   item.Title,
+			Updated: formatAtomTime(item.Updated),
+			Content: AtomText{Type: "text", Value: item.Summary},
+		}
+		if !item.Published.IsZero() {
+			entry.Published = formatAtomTime(item.Published)
+		}
+		if item.Author != "" {
+			entry.Authors = []AtomPerson{{Name: item.Author}}
+		}
+		if item.Link != "" {
+			entry.Links = []AtomLink{{Rel: "alternate", Href: item.Link, Type: "text/html"}}
+		}
+		f.Entries = append(f.Entries, entry)
+	}
+	return f
+}
+
+// RSS 输出为 RSS 2.0。channel 的 link 为必填，没有网站地址时使用 feed 地址；
+// 条目的 pubDate 为 Published，为零值时使用 Updated
+func (ch *Channel) RSS() *RSS {
+	link := ch.Link
+	if link == "" {
+		link = ch.Self
+	}
+	r := &RSS{
+		Version:   "2.0",
+		XmlnsAtom: atomNamespace,
+		XmlnsDC:   dc.Namespace,
+		Channel: RSSChannel{
+			Title:         ch.Title,
+			Link:          link,
+			Description:   ch.Description,
+			Category:      ch.Category,
+			LastBuildDate: formatRSSTime(ch.Updated),
+			AtomLink:      RSSAtomLink{Href: ch.Self, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+	for _, item := range ch.Items {
+		guid, date := item.GUID, item.Published
+		if guid == "" {
+			guid = item.ID
+		}
+		if date.IsZero() {
+			date = item.Updated
+		}
+		r.Channel.Items = append(r.Channel.Items, RSSItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			Creator:     item.Author,
+			GUID:        RSSGUID{Value: guid},
+			PubDate:     formatRSSTime(date),
+		})
+	}
+	return r
+}
@@ -0,0 +1,61 @@
+package feed
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testChannel() *Channel {
+	cst := time.FixedZone("CST", 8*3600)
+	return &Channel{
+		ID:          "urn:x:feed:new",
+		Title:       "新书上架",
+		Description: "最近上架的书籍",
+		Self:        "https://library.example.com/feeds/new.atom",
+		Updated:     time.Date(2026, 3, 2, 16, 0, 0, 0, cst),
+		Items: []Item{
+			{
+				ID: "urn:x:book:7", Title: "Go <编程>", Link: "https://library.example.com/books/7",
+				Author: "张三", Summary: "入门 & 进阶",
+				Published: time.Date(2026, 3, 1, 16, 0, 0, 0, cst), Updated: time.Date(2026, 3, 2, 16, 0, 0, 0, cst),
+			},
+			{ID: "urn:x:book:8", GUID: "urn:x:book:8:1772438400", Title: "无作者", Updated: time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)},
+		},
+	}
+}
+
+func TestAtom(t *testing.T) {
+	data, err := xml.Marshal(testChannel().Atom())
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, `<feed xmlns="http://www.w3.org/2005/Atom"><id>urn:x:feed:new</id><title>新书上架</title><subtitle>最近上架的书籍</subtitle><updated>2026-03-02T08:00:00Z</updated><link rel="self" href="https://library.example.com/feeds/new.atom" type="application/atom+xml"></link>`)
+	assert.Contains(t, out, `<entry><id>urn:x:book:7</id><title>Go &lt;编程&gt;</title><published>2026-03-01T08:00:00Z</published><updated>2026-03-02T08:00:00Z</updated><author><name>张三</name></author><link rel="alternate" href="https://library.example.com/books/7" type="text/html"></link><content type="text">入门 &amp; 进阶</content></entry>`)
+	// 没有作者、链接和发布时间的条目只输出必填元素，Atom 中不使用 GUID
+	assert.Contains(t, out, `<entry><id>urn:x:book:8</id><title>无作者</title><updated>2026-03-02T08:00:00Z</updated><content type="text"></content></entry>`)
+	// 没有网站地址时不输出 alternate 链接
+	assert.NotContains(t, out, `<link rel="alternate" href="" `)
+}
+
+func TestRSS(t *testing.T) {
+	data, err := xml.Marshal(testChannel().RSS())
+	require.NoError(t, err)
+	out := string(data)
+
+	// channel 的 link 缺省为 feed 地址
+	assert.Contains(t, out, `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/elements/1.1/"><channel><title>新书上架</title><link>https://library.example.com/feeds/new.atom</link><description>最近上架的书籍</description><lastBuildDate>Mon, 02 Mar 2026 08:00:00 +0000</lastBuildDate><atom:link href="https://library.example.com/feeds/new.atom" rel="self" type="application/rss+xml"></atom:link>`)
+	// pubDate 为发布时间，guid 缺省为 ID
+	assert.Contains(t, out, `<item><title>Go &lt;编程&gt;</title><link>https://library.example.com/books/7</link><description>入门 &amp; 进阶</description><dc:creator>张三</dc:creator><guid isPermaLink="false">urn:x:book:7</guid><pubDate>Sun, 01 Mar 2026 08:00:00 +0000</pubDate></item>`)
+	// 没有发布时间时 pubDate 为更新时间
+	assert.Contains(t, out, `<item><title>无作者</title><guid isPermaLink="false">urn:x:book:8:1772438400</guid><pubDate>Mon, 02 Mar 2026 08:00:00 +0000</pubDate></item>`)
+
+	ch := testChannel()
+	ch.Link = "https://library.example.com/"
+	data, err = xml.Marshal(ch.RSS())
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<link>https://library.example.com/</link>`)
+}
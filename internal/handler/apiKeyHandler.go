@@ -0,0 +1,136 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// Create 为当前用户创建个人 API Key
+func (a *APIKeyHandler) Create(c *gin.Context) {
+	req := &api.APIKeyCreateReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	key, err := a.apiKeyService.Create(c.GetUint("user_id"), req)
+	if err != nil {
+		result.Error(c, "API Key创建失败", err)
+		return
+	}
+	log.Printf("创建API Key: user=%d prefix=%s", key.UserID, key.Prefix)
+	audit.SetTargetID(c, "api_key", key.ID)
+	audit.SetChange(c, nil, key.APIKeyResp) // 不记录明文
+
+	result.Success(c, key)
+}
+
+// List 列出当前用户的 API Key
+func (a *APIKeyHandler) List(c *gin.Context) {
+	keys, err := a.apiKeyService.List(c.GetUint("user_id"))
+	if err != nil {
+		result.Error(c, "API Key查询失败", err)
+		return
+	}
+
+	result.Success(c, keys)
+}
+
+// Revoke 吊销当前用户的 API Key
+func (a *APIKeyHandler) Revoke(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	if err := a.apiKeyService.Revoke(c.GetUint("user_id"), id); err != nil {
+		result.Error(c, "API Key吊销失败", err)
+		return
+	}
+
+	result.Success(c, "API Key已吊销")
+}
+
+// CreateService 管理员为服务账号创建 API Key
+func (a *APIKeyHandler) CreateService(c *gin.Context) {
+	req := &api.ServiceAPIKeyCreateReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	key, err := a.apiKeyService.CreateService(req)
+	if err != nil {
+		result.Error(c, "API Key创建失败", err)
+		return
+	}
+	log.Printf("创建服务API Key: user=%d prefix=%s", key.UserID, key.Prefix)
+	audit.SetTargetID(c, "api_key", key.ID)
+	audit.SetChange(c, nil, key.APIKeyResp)
+
+	result.Success(c, key)
+}
+
+// ListAll 管理员列出全部 API Key，可按 user_id 过滤
+func (a *APIKeyHandler) ListAll(c *gin.Context) {
+	userID, _ := strconv.ParseUint(c.DefaultQuery("user_id", "0"), 10, 32)
+
+	keys, err := a.apiKeyService.ListAll(uint(userID))
+	if err != nil {
+		result.Error(c, "API Key查询失败", err)
+		return
+	}
+
+	result.Success(c, keys)
+}
+
+// RevokeAny 管理员吊销任意 API Key
+func (a *APIKeyHandler) RevokeAny(c *gin.Context) {
+	id, ok := parseAPIKeyID(c)
+	if !ok {
+		return
+	}
+
+	if err := a.apiKeyService.RevokeAny(id); err != nil {
+		result.Error(c, "API Key吊销失败", err)
+		return
+	}
+
+	result.Success(c, "API Key已吊销")
+}
+
+// ---------- 工具函数 ----------
+
+func parseAPIKeyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock APIKeyService --------
+type MockAPIKeyService struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyService) Create(userID uint, req *api.APIKeyCreateReq) (*api.APIKeyCreateResp, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.APIKeyCreateResp), args.Error(1)
+}
+func (m *MockAPIKeyService) List(userID uint) ([]api.APIKeyResp, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]api.APIKeyResp), args.Error(1)
+}
+func (m *MockAPIKeyService) Revoke(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+func (m *MockAPIKeyService) CreateService(req *api.ServiceAPIKeyCreateReq) (*api.APIKeyCreateResp, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.APIKeyCreateResp), args.Error(1)
+}
+func (m *MockAPIKeyService) ListAll(userID uint) ([]api.APIKeyResp, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]api.APIKeyResp), args.Error(1)
+}
+func (m *MockAPIKeyService) RevokeAny(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+// -------- Tests --------
+func TestCreateAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/me/api-keys", asAdmin(4), handler.Create)
+
+	req := &api.APIKeyCreateReq{Name: "ingest", Scopes: []string{"books:write"}, ExpiresInDays: 30}
+	resp := &api.APIKeyCreateResp{APIKeyResp: api.APIKeyResp{ID: 1, Prefix: "lm_abcd1234"}, Key: "lm_abcd1234_secret"}
+	mockService.On("Create", uint(4), req).Return(resp, nil).Once()
+
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/me/api-keys", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"lm_abcd1234_secret"`)
+	assert.Contains(t, w.Body.String(), `"prefix":"lm_abcd1234"`)
+
+	mockService.On("Create", uint(4), req).Return(nil, service.ErrScopeNotAllowed).Once()
+	w2 := performRequest(r, http.MethodPost, "/me/api-keys", body)
+	assert.Contains(t, w2.Body.String(), "权限范围超出用户角色")
+
+	// 非法权限范围、过期天数
+	for _, bad := range []api.APIKeyCreateReq{
+		{Name: "x", Scopes: []string{"root"}},
+		{Name: "x", Scopes: []string{}},
+		{Name: "x", Scopes: []string{"books:read"}, ExpiresInDays: 1000},
+	} {
+		body, _ := json.Marshal(bad)
+		w := performRequest(r, http.MethodPost, "/me/api-keys", body)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+	}
+	mockService.AssertExpectations(t)
+}
+
+func TestListAndRevokeAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/me/api-keys", asAdmin(4), handler.List)
+	r.DELETE("/me/api-keys/:id", asAdmin(4), handler.Revoke)
+	r.GET("/admin/api-keys", handler.ListAll)
+	r.DELETE("/admin/api-keys/:id", handler.RevokeAny)
+
+	keys := []api.APIKeyResp{{ID: 1, Name: "ingest", Scopes: []string{"books:write"}}}
+	mockService.On("List", uint(4)).Return(keys, nil).Once()
+	w := performRequest(r, http.MethodGet, "/me/api-keys", nil)
+	assert.Contains(t, w.Body.String(), "ingest")
+	assert.NotContains(t, w.Body.String(), `"key"`)
+
+	mockService.On("Revoke", uint(4), uint(1)).Return(nil).Once()
+	w2 := performRequest(r, http.MethodDelete, "/me/api-keys/1", nil)
+	assert.Contains(t, w2.Body.String(), "API Key已吊销")
+
+	mockService.On("Revoke", uint(4), uint(2)).Return(service.ErrAPIKeyNotFound).Once()
+	w3 := performRequest(r, http.MethodDelete, "/me/api-keys/2", nil)
+	assert.Contains(t, w3.Body.String(), "API Key不存在")
+
+	mockService.On("ListAll", uint(9)).Return(keys, nil).Once()
+	w4 := performRequest(r, http.MethodGet, "/admin/api-keys?user_id=9", nil)
+	assert.Contains(t, w4.Body.String(), "ingest")
+
+	mockService.On("RevokeAny", uint(1)).Return(nil).Once()
+	w5 := performRequest(r, http.MethodDelete, "/admin/api-keys/1", nil)
+	assert.Contains(t, w5.Body.String(), "API Key已吊销")
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateServiceAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAPIKeyService)
+	handler := NewAPIKeyHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/admin/api-keys", handler.CreateService)
+
+	req := &api.ServiceAPIKeyCreateReq{
+		UserID:          8,
+		APIKeyCreateReq: api.APIKeyCreateReq{Name: "ingest-bot", Scopes: []string{"books:write"}},
+	}
+	resp := &api.APIKeyCreateResp{APIKeyResp: api.APIKeyResp{ID: 2, UserID: 8, Kind: "service"}, Key: "lm_x_y"}
+	mockService.On("CreateService", req).Return(resp, nil).Once()
+
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/admin/api-keys", body)
+	assert.Contains(t, w.Body.String(), `"kind":"service"`)
+
+	// 缺少 user_id
+	missing, _ := json.Marshal(api.ServiceAPIKeyCreateReq{APIKeyCreateReq: req.APIKeyCreateReq})
+	w2 := performRequest(r, http.MethodPost, "/admin/api-keys", missing)
+	assert.Contains(t, w2.Body.String(), `"error_code":"validation_failed"`)
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// List 查询审计日志
+func (a *AuditHandler) List(c *gin.Context) {
+	req := &api.AuditListReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	logs, err := a.auditService.List(req)
+	if err != nil {
+		log.Printf("查询审计日志失败: %v", err)
+		result.Error(c, "审计日志查询失败", err)
+		return
+	}
+
+	result.Success(c, logs)
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock AuditService --------
+type MockAuditService struct {
+	mock.Mock
+}
+
+func (m *MockAuditService) List(req *api.AuditListReq) (*api.AuditListResp, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuditListResp), args.Error(1)
+}
+func (m *MockAuditService) PurgeExpired() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// -------- Tests --------
+func TestListAuditLogs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAuditService)
+	handler := NewAuditHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/admin/audit", handler.List)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	expected := &api.AuditListReq{ActorID: 2, Action: "book.", From: &from, Page: 1}
+	mockService.On("List", expected).Return(&api.AuditListResp{
+		Logs:  []api.AuditLogResp{{ID: 1, Action: "book.delete", ActorID: 2}},
+		Total: 1,
+	}, nil).Once()
+
+	w := performRequest(r, http.MethodGet, "/admin/audit?actor_id=2&action=book.&from=2025-01-01T00:00:00Z&page=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "book.delete")
+
+	// 非法的结果过滤值
+	w2 := performRequest(r, http.MethodGet, "/admin/audit?outcome=unknown", nil)
+	assert.Contains(t, w2.Body.String(), `"error_code":"validation_failed"`)
+
+	// 时间格式错误
+	w3 := performRequest(r, http.MethodGet, "/admin/audit?from=yesterday", nil)
+	assert.Contains(t, w3.Body.String(), "查询参数格式错误")
+
+	mockService.On("List", &api.AuditListReq{}).Return(nil, errors.New("db down")).Once()
+	w4 := performRequest(r, http.MethodGet, "/admin/audit", nil)
+	assert.Contains(t, w4.Body.String(), "审计日志查询失败")
+
+	mockService.AssertExpectations(t)
+}
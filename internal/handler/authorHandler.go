@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthorHandler struct {
+	authorService service.AuthorService
+}
+
+func NewAuthorHandler(authorService service.AuthorService) *AuthorHandler {
+	return &AuthorHandler{authorService: authorService}
+}
+
+// List 分页查询作者，可按姓名模糊匹配
+func (a *AuthorHandler) List(c *gin.Context) {
+	req := &api.AuthorSearchReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	authors, err := a.authorService.List(req)
+	if err != nil {
+		result.Error(c, "作者查询失败", err)
+		return
+	}
+	result.Success(c, authors)
+}
+
+// Get 作者详情
+func (a *AuthorHandler) Get(c *gin.Context) {
+	id, ok := parseAuthorID(c)
+	if !ok {
+		return
+	}
+
+	author, err := a.authorService.Get(id)
+	if err != nil {
+		result.Error(c, "作者查询失败", err)
+		return
+	}
+	result.Success(c, author)
+}
+
+// Books 作者参与的书籍
+func (a *AuthorHandler) Books(c *gin.Context) {
+	id, ok := parseAuthorID(c)
+	if !ok {
+		return
+	}
+	req := &api.AuthorBooksReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	books, err := a.authorService.ListBooks(id, req)
+	if err != nil {
+		result.Error(c, "作者书籍查询失败", err)
+		return
+	}
+	result.Success(c, books)
+}
+
+// Create 新增作者
+func (a *AuthorHandler) Create(c *gin.Context) {
+	req := &api.AuthorInfoReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	author, err := a.authorService.Create(req)
+	if err != nil {
+		result.Error(c, "作者添加失败", err)
+		return
+	}
+	audit.SetTargetID(c, "author", author.ID)
+	audit.SetChange(c, nil, author)
+
+	result.Success(c, author)
+}
+
+// Update 修改作者，改名后其担任著者的书籍的 author 随之更新
+func (a *AuthorHandler) Update(c *gin.Context) {
+	id, ok := parseAuthorID(c)
+	if !ok {
+		return
+	}
+	req := &api.AuthorInfoReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	log.Printf("收到请求---修改作者: id=%d", id)
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+	req.Operator = operatorOf(c)
+
+	var before *api.AuthorInfoResp
+	if audit.Active(c) {
+		before, _ = a.authorService.Get(id)
+	}
+
+	author, err := a.authorService.Update(id, req)
+	if err != nil {
+		result.Error(c, "作者修改失败", err)
+		return
+	}
+	audit.SetChange(c, before, author)
+
+	result.Success(c, author)
+}
+
+// Delete 删除作者，仍关联书籍时拒绝
+func (a *AuthorHandler) Delete(c *gin.Context) {
+	id, ok := parseAuthorID(c)
+	if !ok {
+		return
+	}
+	log.Printf("收到请求---删除作者: id=%d", id)
+
+	if audit.Active(c) {
+		before, _ := a.authorService.Get(id)
+		audit.SetChange(c, before, nil)
+	}
+
+	if err := a.authorService.Delete(id); err != nil {
+		result.Error(c, "作者删除失败", err)
+		return
+	}
+	result.Success(c, "作者删除成功")
+}
+
+// Migrate 把书籍的作者字符串迁移为作者和贡献者
+func (a *AuthorHandler) Migrate(c *gin.Context) {
+	resp, err := a.authorService.MigrateBookAuthors()
+	if err != nil {
+		result.Error(c, "作者迁移失败", err)
+		return
+	}
+	result.Success(c, resp)
+}
+
+func parseAuthorID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return 0, false
+	}
+	return uint(id), true
+}
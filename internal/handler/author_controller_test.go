@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock AuthorService --------
+type MockAuthorService struct {
+	mock.Mock
+}
+
+func (m *MockAuthorService) Create(dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error) {
+	args := m.Called(dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorInfoResp), args.Error(1)
+}
+func (m *MockAuthorService) Get(id uint) (*api.AuthorInfoResp, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorInfoResp), args.Error(1)
+}
+func (m *MockAuthorService) List(dto *api.AuthorSearchReq) (*api.AuthorSearchResp, error) {
+	args := m.Called(dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorSearchResp), args.Error(1)
+}
+func (m *MockAuthorService) Update(id uint, dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error) {
+	args := m.Called(id, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorInfoResp), args.Error(1)
+}
+func (m *MockAuthorService) Delete(id uint) error {
+	return m.Called(id).Error(0)
+}
+func (m *MockAuthorService) ListBooks(id uint, dto *api.AuthorBooksReq) (*api.BookSearchResp, error) {
+	args := m.Called(id, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookSearchResp), args.Error(1)
+}
+func (m *MockAuthorService) MigrateBookAuthors() (*api.AuthorMigrateResp, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorMigrateResp), args.Error(1)
+}
+
+// -------- Tests --------
+func TestAuthorRead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAuthorService)
+	h := NewAuthorHandler(mockService)
+	r := gin.Default()
+	r.GET("/authors", h.List)
+	r.GET("/authors/:id", h.Get)
+	r.GET("/authors/:id/books", h.Books)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("list", func(t *testing.T) {
+		defer reset()
+		mockService.On("List", &api.AuthorSearchReq{Name: "张", Page: 2, PageSize: 5}).Return(&api.AuthorSearchResp{
+			Authors: []api.AuthorInfoResp{{ID: 1, Name: "张三", BookCount: 3}}, Total: 6, Page: 2, PageSize: 5, TotalPages: 2,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/authors?name=张&page=2&page_size=5", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"张三"`)
+		assert.Contains(t, w.Body.String(), `"book_count":3`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("list_invalid_page_size", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/authors?page_size=1000", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		w = performRequest(r, http.MethodGet, "/authors?page=x", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "List", mock.Anything)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		defer reset()
+		mockService.On("Get", uint(1)).Return(&api.AuthorInfoResp{ID: 1, Name: "张三", Disambiguation: "1950-"}, nil).Once()
+		mockService.On("Get", uint(2)).Return(nil, service.ErrAuthorNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/authors/1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"disambiguation":"1950-"`)
+
+		w = performRequest(r, http.MethodGet, "/authors/2", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"author_not_found"`)
+
+		w = performRequest(r, http.MethodGet, "/authors/abc", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("books", func(t *testing.T) {
+		defer reset()
+		mockService.On("ListBooks", uint(1), &api.AuthorBooksReq{Page: 1, PageSize: 20}).Return(&api.BookSearchResp{
+			Books: []api.BookInfoResp{{ID: 9, Title: "Go", Author: "张三",
+				Contributors: []api.BookContributor{{AuthorID: 1, Name: "张三", Role: "translator"}}}},
+			Total: 1, Page: 1, PageSize: 20, TotalPages: 1,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/authors/1/books?page=1&page_size=20", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"contributors":[{"author_id":1,"name":"张三","role":"translator"}]`)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestAuthorWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockAuthorService)
+	h := NewAuthorHandler(mockService)
+	r := gin.Default()
+	r.Use(func(c *gin.Context) { c.Set("user_id", uint(7)) })
+	r.POST("/authors", h.Create)
+	r.PUT("/authors/:id", h.Update)
+	r.DELETE("/authors/:id", h.Delete)
+	r.POST("/authors/migrate", h.Migrate)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("create", func(t *testing.T) {
+		defer reset()
+		mockService.On("Create", mock.MatchedBy(func(req *api.AuthorInfoReq) bool {
+			return req.Name == "张三" && req.Disambiguation == "1950-"
+		})).Return(&api.AuthorInfoResp{ID: 3, Name: "张三", Disambiguation: "1950-"}, nil).Once()
+
+		w := performRequest(r, http.MethodPost, "/authors", []byte(`{"name":"张三","disambiguation":"1950-"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":3`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("create_requires_name", func(t *testing.T) {
+		w := performRequest(r, http.MethodPost, "/authors", []byte(`{"bio":"简介"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("update", func(t *testing.T) {
+		defer reset()
+		mockService.On("Update", uint(3), mock.MatchedBy(func(req *api.AuthorInfoReq) bool {
+			// 改名时重新生成书籍的 author，修订记录需要操作者
+			return req.Name == "张三丰" && req.Operator.UserID == 7
+		})).Return(&api.AuthorInfoResp{ID: 3, Name: "张三丰"}, nil).Once()
+
+		w := performRequest(r, http.MethodPut, "/authors/3", []byte(`{"name":"张三丰"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"name":"张三丰"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		defer reset()
+		mockService.On("Delete", uint(3)).Return(nil).Once()
+		mockService.On("Delete", uint(4)).Return(service.ErrAuthorInUse).Once()
+
+		w := performRequest(r, http.MethodDelete, "/authors/3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequest(r, http.MethodDelete, "/authors/4", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"author_in_use"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		defer reset()
+		mockService.On("MigrateBookAuthors").Return(&api.AuthorMigrateResp{Books: 12, AuthorsCreated: 8}, nil).Once()
+
+		w := performRequest(r, http.MethodPost, "/authors/migrate", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"books":12,"authors_created":8`)
+		mockService.AssertExpectations(t)
+	})
+}
@@ -3,12 +3,19 @@ package handler
 import (
 	"LibraryManagement/internal/api"
 	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
 	"LibraryManagement/internal/service"
+	"LibraryManagement/internal/utils"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 type BookHandler struct {
@@ -22,34 +29,45 @@ func NewBookHandler(bookService service.BookService) *BookHandler {
 // AddBook 添加书籍
 func (b *BookHandler) AddBook(c *gin.Context) {
 	bookInfoReq := &api.BookInfoReq{}
-	err := c.BindJSON(bookInfoReq)
+	err := c.ShouldBindJSON(bookInfoReq)
 	if err != nil {
-		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		result.BindFailed(c, err)
 		return
 	}
 
 	fmt.Println("收到请求---bookAdd: ", bookInfoReq)
 
+	// enrich=true 时先按 ISBN 补全为空的书名、作者和简介，再校验
+	if c.Query("enrich") == "true" && bookInfoReq.ISBN != "" {
+		if err := b.bookService.Enrich(bookInfoReq); err != nil {
+			result.Error(c, "书籍添加失败", err)
+			return
+		}
+	}
+
 	// 验证器会根据结构体里写的 validate 标签，自动检查字段是否符合规则
-	err = validator.New().Struct(bookInfoReq)
+	err = i18n.Validate.Struct(bookInfoReq)
 	if err != nil {
-		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		result.ValidationFailed(c, err)
 		return
 	}
 	// TODO ISBN校验
+	bookInfoReq.Operator = operatorOf(c)
 
 	err = b.bookService.Add(bookInfoReq)
 
 	if err != nil {
-		result.Failed(c, result.FailedCode, "书籍添加失败:"+err.Error())
+		result.Error(c, "书籍添加失败", err)
 		return
 	}
+	audit.SetChange(c, nil, auditBookReq(bookInfoReq))
 
 	result.Success(c, "书籍添加成功")
 
 }
 
-// DeleteBook 删除书籍
+// DeleteBook 批量删除书籍，返回每个 ID 的处理结果。
+// atomic=true 时任一 ID 无效或不存在则整批不删除
 func (b *BookHandler) DeleteBook(c *gin.Context) {
 	var ids = make([]string, 0)
 	ids = c.QueryArray("ids")
@@ -60,41 +78,388 @@ func (b *BookHandler) DeleteBook(c *gin.Context) {
 		return
 	}
 
-	err := b.bookService.Delete(ids)
+	atomic, err := strconv.ParseBool(c.DefaultQuery("atomic", "false"))
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+
+	audit.SetTarget(c, "book", strings.Join(ids, ","))
+	if audit.Active(c) {
+		audit.SetChange(c, b.snapshotBooks(ids), nil)
+	}
 
+	resp, err := b.bookService.Delete(ids, atomic)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "书籍删除失败:"+err.Error())
+		if resp != nil {
+			// 事务模式被拒绝时同样返回每个 ID 的结果
+			result.ErrorWithData(c, "书籍删除失败", err, resp)
+		} else {
+			result.Error(c, "书籍删除失败", err)
+		}
 		return
 	}
 
-	result.Success(c, "书籍删除成功")
+	result.Success(c, resp)
 }
 
-// UpdateBook 更新书籍
+// UpdateBook 更新书籍，需通过 If-Match 请求头或 version 字段指明客户端读取时的版本
 func (b *BookHandler) UpdateBook(c *gin.Context) {
 	bookUpdateReq := &api.BookUpdateReq{}
-	err := c.BindJSON(bookUpdateReq)
+	err := c.ShouldBindJSON(bookUpdateReq)
 	if err != nil {
+		result.BindFailed(c, err)
 		return
 	}
 	fmt.Println("收到请求---bookUpdateReq: ", bookUpdateReq)
 
 	// 验证器会根据结构体里写的 validate 标签，自动检查字段是否符合规则
-	err = validator.New().Struct(bookUpdateReq)
+	err = i18n.Validate.Struct(bookUpdateReq)
 	if err != nil {
-		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	// If-Match 优先于请求体中的 version
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" {
+		version, err := parseVersionETag(ifMatch)
+		if err != nil {
+			result.Error(c, "书籍更新失败", err)
+			return
+		}
+		bookUpdateReq.Version = version
+	} else if bookUpdateReq.Version == nil {
+		result.Error(c, "书籍更新失败", errVersionRequired)
 		return
 	}
 
-	err = b.bookService.Update(bookUpdateReq)
+	bookUpdateReq.Operator = operatorOf(c)
+	audit.SetTargetID(c, "book", bookUpdateReq.ID)
+	var before *api.BookInfoResp
+	if audit.Active(c) {
+		before, _ = b.bookService.GetByID(bookUpdateReq.ID)
+	}
+
+	book, err := b.bookService.Update(bookUpdateReq)
+	if errors.Is(err, service.ErrBookVersionConflict) {
+		b.versionConflict(c, bookUpdateReq.ID, ifMatch != "", err)
+		return
+	}
 	if err != nil {
-		result.Failed(c, result.FailedCode, "书籍更新失败:"+err.Error())
+		result.Error(c, "书籍更新失败", err)
 		return
 	}
+	if audit.Active(c) {
+		audit.SetChange(c, auditBook(before), auditBook(book))
+	}
+	c.Header("ETag", versionETag(book.Version))
 	result.Success(c, "书籍更新成功")
 }
 
-// GetBook 获取单本书籍详情
+// PatchBook 局部更新书籍，请求体为 JSON Merge Patch（RFC 7396）或 JSON Patch（RFC 6902），
+// 只更新补丁实际修改的字段；版本前置条件与 UpdateBook 相同
+func (b *BookHandler) PatchBook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+
+	kind, err := patchType(c.ContentType())
+	if err != nil {
+		c.Header("Accept-Patch", acceptPatch)
+		result.Error(c, "书籍更新失败", err)
+		return
+	}
+
+	patch, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxPatchBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			result.Error(c, "书籍更新失败", errPatchTooLarge)
+			return
+		}
+		result.Failed(c, result.RequiredCode, "请求数据格式错误")
+		return
+	}
+	fmt.Println("收到请求---bookPatch: ", id, string(patch))
+
+	// If-Match 优先于 merge patch 中的 version
+	var version *int
+	if kind == mergePatchType {
+		if patch, version, err = splitPatchVersion(patch); err != nil {
+			result.Error(c, "书籍更新失败", err)
+			return
+		}
+	}
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" {
+		if version, err = parseVersionETag(ifMatch); err != nil {
+			result.Error(c, "书籍更新失败", err)
+			return
+		}
+	} else if version == nil {
+		result.Error(c, "书籍更新失败", errVersionRequired)
+		return
+	}
+
+	audit.SetTargetID(c, "book", uint(id))
+	current, err := b.bookService.GetByID(uint(id))
+	if err != nil {
+		result.Error(c, "书籍更新失败", err)
+		return
+	}
+
+	// 补丁基于客户端读取时的版本编写，版本已变化时不能应用到新内容上
+	if version != nil && *version != current.Version {
+		b.versionConflict(c, uint(id), ifMatch != "", service.ErrBookVersionConflict)
+		return
+	}
+
+	before := bookInfoReq(current)
+	patched, err := applyBookPatch(kind, current, patch)
+	if err != nil {
+		if _, ok := apperr.As(err); ok {
+			result.Error(c, "书籍更新失败", err)
+		} else {
+			result.BindFailed(c, err)
+		}
+		return
+	}
+	if err := i18n.Validate.Struct(patched); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	// 没有实际修改时不写库，版本号保持不变
+	fields := changedBookFields(before, patched)
+	patched.Operator = operatorOf(c)
+	if len(fields) == 0 {
+		c.Header("ETag", versionETag(current.Version))
+		result.Success(c, current)
+		return
+	}
+
+	book, err := b.bookService.Update(&api.BookUpdateReq{
+		ID:          uint(id),
+		Version:     &current.Version,
+		BookInfoReq: *patched,
+		Fields:      fields,
+	})
+	if errors.Is(err, service.ErrBookVersionConflict) {
+		b.versionConflict(c, uint(id), ifMatch != "", err)
+		return
+	}
+	if err != nil {
+		result.Error(c, "书籍更新失败", err)
+		return
+	}
+	audit.SetChange(c, auditBook(current), auditBook(book))
+
+	c.Header("ETag", versionETag(book.Version))
+	result.Success(c, book)
+}
+
+// versionConflict 乐观锁冲突时返回书籍当前内容和 ETag，客户端可据此合并后重试；
+// 使用 If-Match 时返回 412，否则返回 409
+func (b *BookHandler) versionConflict(c *gin.Context, id uint, ifMatch bool, err error) {
+	if ifMatch {
+		err = errPreconditionFailed.Wrap(err)
+	}
+	current, getErr := b.bookService.GetByID(id)
+	if getErr != nil {
+		result.Error(c, "书籍更新失败", err)
+		return
+	}
+	c.Header("ETag", versionETag(current.Version))
+	result.ErrorWithData(c, "书籍更新失败", err, current)
+}
+
+// ListTrash 分页查询回收站中的书籍
+func (b *BookHandler) ListTrash(c *gin.Context) {
+	req := &api.BookTrashListReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	books, err := b.bookService.ListTrash(req)
+	if err != nil {
+		result.Error(c, "回收站查询失败", err)
+		return
+	}
+
+	result.Success(c, books)
+}
+
+// RestoreBook 从回收站恢复书籍
+func (b *BookHandler) RestoreBook(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+	fmt.Println("收到请求---恢复书籍: ", id)
+	audit.SetTargetID(c, "book", uint(id))
+
+	book, err := b.bookService.Restore(uint(id))
+	if err != nil {
+		result.Error(c, "书籍恢复失败", err)
+		return
+	}
+	audit.SetChange(c, nil, auditBook(book))
+
+	c.Header("ETag", versionETag(book.Version))
+	result.Success(c, book)
+}
+
+// PurgeBooks 彻底删除回收站中的书籍，不在回收站中的 ID 会被忽略
+func (b *BookHandler) PurgeBooks(c *gin.Context) {
+	idStrs := c.QueryArray("ids")
+	fmt.Println("收到请求---彻底删除书籍: ", idStrs)
+
+	if len(idStrs) == 0 {
+		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		return
+	}
+
+	ids := make([]uint, 0, len(idStrs))
+	for _, idStr := range idStrs {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			result.Failed(c, result.RequiredCode, "ID格式错误")
+			return
+		}
+		ids = append(ids, uint(id))
+	}
+	audit.SetTarget(c, "book", strings.Join(idStrs, ","))
+
+	n, err := b.bookService.Purge(ids)
+	if err != nil {
+		result.Error(c, "书籍彻底删除失败", err)
+		return
+	}
+
+	result.Success(c, gin.H{"purged": n})
+}
+
+// ListRevisions 分页查询书籍的修订记录，每条附带与上一版本的字段差异
+func (b *BookHandler) ListRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+
+	req := &api.BookRevisionListReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	revisions, err := b.bookService.ListRevisions(uint(id), req)
+	if err != nil {
+		result.Error(c, "修订记录查询失败", err)
+		return
+	}
+
+	result.Success(c, revisions)
+}
+
+// GetRevision 查询书籍指定版本的完整内容
+func (b *BookHandler) GetRevision(c *gin.Context) {
+	id, version, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+
+	revision, err := b.bookService.GetRevision(id, version)
+	if err != nil {
+		result.Error(c, "修订记录查询失败", err)
+		return
+	}
+
+	result.Success(c, revision)
+}
+
+// CompareRevisions 比较两个版本的字段差异
+func (b *BookHandler) CompareRevisions(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+
+	req := &api.BookRevisionDiffReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	diff, err := b.bookService.CompareRevisions(uint(id), req.From, req.To)
+	if err != nil {
+		result.Error(c, "修订记录查询失败", err)
+		return
+	}
+
+	result.Success(c, diff)
+}
+
+// RollbackRevision 把书籍恢复为指定版本的内容，作为新版本保存。
+// If-Match 可选，提供时与当前版本不一致则返回 412
+func (b *BookHandler) RollbackRevision(c *gin.Context) {
+	id, version, ok := parseRevisionParams(c)
+	if !ok {
+		return
+	}
+	fmt.Println("收到请求---回滚书籍: ", id, version)
+
+	var expected *int
+	ifMatch := c.GetHeader("If-Match")
+	if ifMatch != "" {
+		var err error
+		if expected, err = parseVersionETag(ifMatch); err != nil {
+			result.Error(c, "书籍回滚失败", err)
+			return
+		}
+	}
+
+	audit.SetTargetID(c, "book", id)
+	var before *api.BookInfoResp
+	if audit.Active(c) {
+		before, _ = b.bookService.GetByID(id)
+	}
+
+	book, err := b.bookService.RollbackRevision(id, version, expected, operatorOf(c))
+	if errors.Is(err, service.ErrBookVersionConflict) {
+		b.versionConflict(c, id, ifMatch != "", err)
+		return
+	}
+	if err != nil {
+		result.Error(c, "书籍回滚失败", err)
+		return
+	}
+	audit.SetChange(c, auditBook(before), auditBook(book))
+
+	c.Header("ETag", versionETag(book.Version))
+	result.Success(c, book)
+}
+
+// GetBook 获取单本书籍详情，响应带 ETag，If-None-Match 命中时返回 304
 func (b *BookHandler) GetBook(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
@@ -105,7 +470,14 @@ func (b *BookHandler) GetBook(c *gin.Context) {
 
 	book, err := b.bookService.GetByID(uint(id))
 	if err != nil {
-		result.Failed(c, result.FailedCode, "书籍查询失败:"+err.Error())
+		result.Error(c, "书籍查询失败", err)
+		return
+	}
+
+	etag := versionETag(book.Version)
+	c.Header("ETag", etag)
+	if inm := c.GetHeader("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+		c.Status(http.StatusNotModified)
 		return
 	}
 
@@ -115,21 +487,22 @@ func (b *BookHandler) GetBook(c *gin.Context) {
 // BookList 批量查询
 func (b *BookHandler) BookList(c *gin.Context) {
 	bookSearchReq := &api.BookSearchReq{}
-	err := c.BindJSON(bookSearchReq)
+	err := c.ShouldBindJSON(bookSearchReq)
 	if err != nil {
+		result.BindFailed(c, err)
 		return
 	}
 	fmt.Println("收到请求---bookSearchReq: ", bookSearchReq)
 
 	// 验证器会根据结构体里写的 validate 标签，自动检查字段是否符合规则
-	err = validator.New().Struct(bookSearchReq)
+	err = i18n.Validate.Struct(bookSearchReq)
 	if err != nil {
-		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		result.ValidationFailed(c, err)
 		return
 	}
 	books, err := b.bookService.List(bookSearchReq)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "书籍查询失败:"+err.Error())
+		result.Error(c, "书籍查询失败", err)
 		return
 	}
 
@@ -139,8 +512,9 @@ func (b *BookHandler) BookList(c *gin.Context) {
 // SearchBooks ES综合搜索
 func (b *BookHandler) SearchBooks(c *gin.Context) {
 	bookSearchReq := &api.BookSearchReq{}
-	err := c.BindJSON(bookSearchReq)
+	err := c.ShouldBindJSON(bookSearchReq)
 	if err != nil {
+		result.BindFailed(c, err)
 		return
 	}
 
@@ -148,7 +522,7 @@ func (b *BookHandler) SearchBooks(c *gin.Context) {
 
 	books, err := b.bookService.SearchBooks(bookSearchReq)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "搜索失败:"+err.Error())
+		result.Error(c, "搜索失败", err)
 		return
 	}
 
@@ -170,7 +544,7 @@ func (b *BookHandler) SearchByTitle(c *gin.Context) {
 
 	books, err := b.bookService.SearchByTitle(title, exact)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "标题搜索失败:"+err.Error())
+		result.Error(c, "标题搜索失败", err)
 		return
 	}
 
@@ -189,7 +563,7 @@ func (b *BookHandler) SearchByContent(c *gin.Context) {
 
 	books, err := b.bookService.SearchByContent(content)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "内容搜索失败:"+err.Error())
+		result.Error(c, "内容搜索失败", err)
 		return
 	}
 
@@ -200,7 +574,7 @@ func (b *BookHandler) SearchByContent(c *gin.Context) {
 func (b *BookHandler) InitESIndex(c *gin.Context) {
 	err := b.bookService.InitializeESIndex()
 	if err != nil {
-		result.Failed(c, result.FailedCode, "初始化ES索引失败:"+err.Error())
+		result.Error(c, "初始化ES索引失败", err)
 		return
 	}
 
@@ -211,9 +585,85 @@ func (b *BookHandler) InitESIndex(c *gin.Context) {
 func (b *BookHandler) ReindexBooks(c *gin.Context) {
 	err := b.bookService.ReindexAllBooks()
 	if err != nil {
-		result.Failed(c, result.FailedCode, "重新索引失败:"+err.Error())
+		result.Error(c, "重新索引失败", err)
 		return
 	}
 
 	result.Success(c, "重新索引完成")
 }
+
+// snapshotBooks 读取待删除书籍的当前数据作为审计快照，不存在的 ID 跳过
+func (b *BookHandler) snapshotBooks(ids []string) []interface{} {
+	books := make([]interface{}, 0, len(ids))
+	for _, idStr := range ids {
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			continue
+		}
+		if book, err := b.bookService.GetByID(uint(id)); err == nil {
+			books = append(books, auditBook(book))
+		}
+	}
+	return books
+}
+
+// bookAudit 书籍审计快照。正文可能有数 MB，超出审计表字段的容量，
+// 只记录长度和 SHA-256，足以判断正文是否被修改
+type bookAudit struct {
+	*api.BookInfoResp
+	Content       string `json:"content,omitempty"` // 遮蔽嵌入结构体中的正文，始终为空
+	ContentLength int    `json:"content_length"`
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+}
+
+// bookReqAudit 新增书籍请求的审计快照，正文处理同 bookAudit
+type bookReqAudit struct {
+	*api.BookInfoReq
+	Content       string `json:"content,omitempty"`
+	ContentLength int    `json:"content_length"`
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+}
+
+// auditBook 生成书籍的审计快照，book 为 nil 时返回 nil，表示不存在
+func auditBook(book *api.BookInfoResp) interface{} {
+	if book == nil {
+		return nil
+	}
+	return &bookAudit{BookInfoResp: book, ContentLength: len(book.Content), ContentSHA256: contentHash(book.Content)}
+}
+
+func auditBookReq(req *api.BookInfoReq) interface{} {
+	return &bookReqAudit{BookInfoReq: req, ContentLength: len(req.Content), ContentSHA256: contentHash(req.Content)}
+}
+
+func contentHash(content string) string {
+	if content == "" {
+		return ""
+	}
+	return utils.HashToken(content)
+}
+
+// ---------- 工具函数 ----------
+
+// operatorOf 当前请求的操作者，用于修订记录
+func operatorOf(c *gin.Context) api.Operator {
+	return api.Operator{
+		UserID:   c.GetUint("user_id"),
+		APIKeyID: c.GetUint("api_key_id"),
+	}
+}
+
+// parseRevisionParams 解析路径中的书籍 ID 和版本号
+func parseRevisionParams(c *gin.Context) (uint, int, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		result.Failed(c, result.RequiredCode, "版本号格式错误")
+		return 0, 0, false
+	}
+	return uint(id), version, true
+}
@@ -2,12 +2,21 @@ package handler
 
 import (
 	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"LibraryManagement/internal/tabular"
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"errors"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
@@ -23,15 +32,25 @@ func (m *MockBookService) Add(req *api.BookInfoReq) error {
 	args := m.Called(req)
 	return args.Error(0)
 }
-
-func (m *MockBookService) Delete(ids []string) error {
-	args := m.Called(ids)
+func (m *MockBookService) Enrich(req *api.BookInfoReq) error {
+	args := m.Called(req)
 	return args.Error(0)
 }
 
-func (m *MockBookService) Update(req *api.BookUpdateReq) error {
+func (m *MockBookService) Delete(ids []string, atomic bool) (*api.BookDeleteResp, error) {
+	args := m.Called(ids, atomic)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookDeleteResp), args.Error(1)
+}
+
+func (m *MockBookService) Update(req *api.BookUpdateReq) (*api.BookInfoResp, error) {
 	args := m.Called(req)
-	return args.Error(0)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookInfoResp), args.Error(1)
 }
 
 func (m *MockBookService) GetByID(id uint) (*api.BookInfoResp, error) {
@@ -75,10 +94,88 @@ func (m *MockBookService) ReindexAllBooks() error {
 	return args.Error(0)
 }
 
+func (m *MockBookService) ListTrash(req *api.BookTrashListReq) (*api.BookSearchResp, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookSearchResp), args.Error(1)
+}
+
+func (m *MockBookService) Restore(id uint) (*api.BookInfoResp, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookInfoResp), args.Error(1)
+}
+
+func (m *MockBookService) Purge(ids []uint) (int64, error) {
+	args := m.Called(ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBookService) Import(file tabular.File, size int64, req *api.BookImportReq) (*api.BookImportResp, error) {
+	args := m.Called(file, size, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookImportResp), args.Error(1)
+}
+
+func (m *MockBookService) Export(req *api.BookExportReq, w io.Writer) error {
+	args := m.Called(req, w)
+	return args.Error(0)
+}
+
+func (m *MockBookService) PurgeExpiredTrash() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockBookService) ListRevisions(bookID uint, req *api.BookRevisionListReq) (*api.BookRevisionListResp, error) {
+	args := m.Called(bookID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookRevisionListResp), args.Error(1)
+}
+
+func (m *MockBookService) GetRevision(bookID uint, version int) (*api.BookRevisionResp, error) {
+	args := m.Called(bookID, version)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookRevisionResp), args.Error(1)
+}
+
+func (m *MockBookService) CompareRevisions(bookID uint, from, to int) (*api.BookRevisionDiffResp, error) {
+	args := m.Called(bookID, from, to)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookRevisionDiffResp), args.Error(1)
+}
+
+func (m *MockBookService) RollbackRevision(bookID uint, version int, expected *int, operator api.Operator) (*api.BookInfoResp, error) {
+	args := m.Called(bookID, version, expected, operator)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookInfoResp), args.Error(1)
+}
+
 // --------- Helper ---------
 func performRequest(r http.Handler, method, path string, body []byte) *httptest.ResponseRecorder {
+	return performRequestWithHeaders(r, method, path, body, nil)
+}
+
+func performRequestWithHeaders(r http.Handler, method, path string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
 	req := httptest.NewRequest(method, path, bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	return w
@@ -141,7 +238,7 @@ func TestAddBook(t *testing.T) {
 
 		t.Log("Response:", w.Body.String())
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "书籍添加失败")
 		mockService.AssertExpectations(t)
 	})
@@ -162,8 +259,60 @@ func TestAddBook(t *testing.T) {
 		body, _ := json.Marshal(req)
 		w := performRequest(r, http.MethodPost, "/books", body)
 
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+		assert.Contains(t, w.Body.String(), `{"field":"isbn","rule":"required","message":"isbn为必填字段"}`)
+	})
+
+	t.Run("enrich", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		// 补全后书名不再为空，通过校验
+		mockService.On("Enrich", mock.MatchedBy(func(req *api.BookInfoReq) bool {
+			return req.ISBN == "9787111547426" && req.Title == ""
+		})).Run(func(args mock.Arguments) {
+			req := args.Get(0).(*api.BookInfoReq)
+			req.Title, req.Author = "Go程序设计语言", "Donovan"
+		}).Return(nil).Once()
+		mockService.On("Add", mock.MatchedBy(func(req *api.BookInfoReq) bool {
+			return req.Title == "Go程序设计语言" && req.Author == "Donovan" && req.Count == 2
+		})).Return(nil).Once()
+
+		body, _ := json.Marshal(&api.BookInfoReq{ISBN: "9787111547426", Count: 2})
+		w := performRequest(r, http.MethodPost, "/books?enrich=true", body)
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Contains(t, w.Body.String(), "缺少必要参数")
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("enrich_not_found", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		// 没有查到时书名仍为空，校验失败
+		mockService.On("Enrich", mock.Anything).Return(nil).Once()
+		body, _ := json.Marshal(&api.BookInfoReq{ISBN: "9787111547426", Count: 2})
+		w := performRequest(r, http.MethodPost, "/books?enrich=true", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"title","rule":"required"`)
+		mockService.AssertNotCalled(t, "Add", mock.Anything)
+	})
+
+	t.Run("enrich_disabled", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Enrich", mock.Anything).Return(service.ErrMetadataDisabled).Once()
+		body, _ := json.Marshal(&api.BookInfoReq{ISBN: "9787111547426", Count: 2})
+		w := performRequest(r, http.MethodPost, "/books?enrich=true", body)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"metadata_disabled"`)
+	})
+
+	t.Run("validation_failed_english", func(t *testing.T) {
+		body, _ := json.Marshal(&api.BookInfoReq{Title: "Go", Count: 1})
+		w := performRequestWithHeaders(r, http.MethodPost, "/books", body, map[string]string{"Accept-Language": "en-US,en;q=0.9"})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"message":"request validation failed: isbn is a required field"`)
+		assert.Contains(t, w.Body.String(), `{"field":"isbn","rule":"required","message":"isbn is a required field"}`)
 	})
 }
 
@@ -177,30 +326,78 @@ func TestDeleteBook(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
 
-		ids := []string{"1", "2"}
-		mockService.On("Delete", ids).Return(nil).Once()
+		ids := []string{"1", "2", "x", "3"}
+		mockService.On("Delete", ids, false).Return(&api.BookDeleteResp{
+			Deleted: 2,
+			Results: []api.BookDeleteResult{
+				{ID: "1", Status: api.DeleteDeleted},
+				{ID: "2", Status: api.DeleteIndexSyncFailed},
+				{ID: "x", Status: api.DeleteInvalid},
+				{ID: "3", Status: api.DeleteNotFound},
+			},
+		}, nil).Once()
 
-		w := performRequest(r, http.MethodDelete, "/books?ids=1&ids=2", nil)
+		w := performRequest(r, http.MethodDelete, "/books?ids=1&ids=2&ids=x&ids=3", nil)
 
 		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Contains(t, w.Body.String(), "书籍删除成功")
+		assert.Contains(t, w.Body.String(), `"deleted":2`)
+		assert.Contains(t, w.Body.String(), `{"id":"2","status":"index_sync_failed"}`)
+		assert.Contains(t, w.Body.String(), `{"id":"x","status":"invalid"}`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("atomic_rejected", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Delete", []string{"1", "9"}, true).Return(&api.BookDeleteResp{
+			Atomic: true,
+			Results: []api.BookDeleteResult{
+				{ID: "1", Status: api.DeleteSkipped},
+				{ID: "9", Status: api.DeleteNotFound},
+			},
+		}, service.ErrBatchRejected).Once()
+
+		w := performRequest(r, http.MethodDelete, "/books?ids=1&ids=9&atomic=true", nil)
+
+		// 整批未删除，仍返回每个 ID 的结果
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"batch_rejected"`)
+		assert.Contains(t, w.Body.String(), `{"id":"1","status":"skipped"}`)
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("too_large", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Delete", mock.Anything, false).Return(nil, service.ErrBatchTooLarge.WithMessage("单次最多删除 1 本书籍")).Once()
+
+		w := performRequest(r, http.MethodDelete, "/books?ids=1&ids=2", nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"batch_too_large"`)
+		assert.Contains(t, w.Body.String(), "单次最多删除 1 本书籍")
+	})
+
+	t.Run("invalid_atomic", func(t *testing.T) {
+		w := performRequest(r, http.MethodDelete, "/books?ids=1&atomic=maybe", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+	})
+
 	t.Run("failure", func(t *testing.T) {
 		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
 
-		mockService.On("Delete", []string{"3"}).Return(errors.New("删除失败")).Once()
+		mockService.On("Delete", []string{"3"}, false).Return(nil, errors.New("删除失败")).Once()
 
 		w := performRequest(r, http.MethodDelete, "/books?ids=3", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "书籍删除失败")
 	})
 
 	t.Run("no_ids", func(t *testing.T) {
 		w := performRequest(r, http.MethodDelete, "/books", nil)
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "缺少必要参数")
 	})
 }
@@ -212,6 +409,8 @@ func TestUpdateBook(t *testing.T) {
 	r := gin.Default()
 	r.PUT("/books", h.UpdateBook)
 
+	version := func(v int) *int { return &v }
+
 	t.Run("success", func(t *testing.T) {
 		t.Cleanup(func() {
 			mockService.ExpectedCalls = nil
@@ -220,7 +419,8 @@ func TestUpdateBook(t *testing.T) {
 
 		// ✅ 提供所有 required 字段
 		req := &api.BookUpdateReq{
-			ID: 1,
+			ID:      1,
+			Version: version(1),
 			BookInfoReq: api.BookInfoReq{
 				Title:  "更新标题",
 				Count:  5,
@@ -228,16 +428,66 @@ func TestUpdateBook(t *testing.T) {
 				Author: "李四",
 			},
 		}
-		mockService.On("Update", req).Return(nil).Once()
+		mockService.On("Update", req).Return(&api.BookInfoResp{ID: 1, Version: 2}, nil).Once()
 
 		body, _ := json.Marshal(req)
 		w := performRequest(r, http.MethodPut, "/books", body)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "书籍更新成功")
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("if_match", func(t *testing.T) {
+		t.Cleanup(func() {
+			mockService.ExpectedCalls = nil
+			mockService.Calls = nil
+		})
+
+		// If-Match 优先于请求体中的 version
+		req := &api.BookUpdateReq{
+			ID:          1,
+			Version:     version(1),
+			BookInfoReq: api.BookInfoReq{Title: "更新标题", Count: 5, ISBN: "978-7-123-45678-9"},
+		}
+		mockService.On("Update", mock.MatchedBy(func(r *api.BookUpdateReq) bool {
+			return r.Version != nil && *r.Version == 3
+		})).Return(&api.BookInfoResp{ID: 1, Version: 4}, nil).Once()
+
+		body, _ := json.Marshal(req)
+		w := performRequestWithHeaders(r, http.MethodPut, "/books", body, map[string]string{"If-Match": `"3"`})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("version_required", func(t *testing.T) {
+		req := &api.BookUpdateReq{
+			ID:          1,
+			BookInfoReq: api.BookInfoReq{Title: "更新标题", Count: 5, ISBN: "978-7-123-45678-9"},
+		}
+		body, _ := json.Marshal(req)
+		w := performRequest(r, http.MethodPut, "/books", body)
+
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"version_required"`)
+		mockService.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("invalid_if_match", func(t *testing.T) {
+		req := &api.BookUpdateReq{
+			ID:          1,
+			BookInfoReq: api.BookInfoReq{Title: "更新标题", Count: 5, ISBN: "978-7-123-45678-9"},
+		}
+		body, _ := json.Marshal(req)
+		w := performRequestWithHeaders(r, http.MethodPut, "/books", body, map[string]string{"If-Match": `W/"3"`})
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"invalid_etag"`)
+	})
+
 	t.Run("failure", func(t *testing.T) {
 		t.Cleanup(func() {
 			mockService.ExpectedCalls = nil
@@ -246,23 +496,78 @@ func TestUpdateBook(t *testing.T) {
 
 		// ✅ 提供完整字段
 		req := &api.BookUpdateReq{
-			ID: 2,
+			ID:      2,
+			Version: version(1),
 			BookInfoReq: api.BookInfoReq{
 				Title: "错误数据",
 				Count: 3,
 				ISBN:  "978-7-123-45678-0",
 			},
 		}
-		mockService.On("Update", req).Return(errors.New("更新失败")).Once()
+		mockService.On("Update", req).Return(nil, errors.New("更新失败")).Once()
 
 		body, _ := json.Marshal(req)
 		w := performRequest(r, http.MethodPut, "/books", body)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "书籍更新失败")
 		mockService.AssertExpectations(t)
 	})
 
+	t.Run("version_conflict", func(t *testing.T) {
+		t.Cleanup(func() {
+			mockService.ExpectedCalls = nil
+			mockService.Calls = nil
+		})
+
+		req := &api.BookUpdateReq{
+			ID:      4,
+			Version: version(1),
+			BookInfoReq: api.BookInfoReq{
+				Title: "并发修改",
+				Count: 1,
+				ISBN:  "978-7-123-45678-1",
+			},
+		}
+		current := &api.BookInfoResp{ID: 4, Title: "他人修改", Version: 2}
+		mockService.On("Update", req).Return(nil, service.ErrBookVersionConflict).Once()
+		mockService.On("GetByID", uint(4)).Return(current, nil).Once()
+
+		body, _ := json.Marshal(req)
+		w := performRequest(r, http.MethodPut, "/books", body)
+
+		// 请求体携带 version 时返回 409，并附带当前内容
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_version_conflict"`)
+		assert.Contains(t, w.Body.String(), `"title":"他人修改"`)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("precondition_failed", func(t *testing.T) {
+		t.Cleanup(func() {
+			mockService.ExpectedCalls = nil
+			mockService.Calls = nil
+		})
+
+		req := &api.BookUpdateReq{
+			ID:          4,
+			BookInfoReq: api.BookInfoReq{Title: "并发修改", Count: 1, ISBN: "978-7-123-45678-1"},
+		}
+		mockService.On("Update", mock.Anything).Return(nil, service.ErrBookVersionConflict).Once()
+		mockService.On("GetByID", uint(4)).Return(&api.BookInfoResp{ID: 4, Version: 5}, nil).Once()
+
+		body, _ := json.Marshal(req)
+		w := performRequestWithHeaders(r, http.MethodPut, "/books", body, map[string]string{"If-Match": `"1"`})
+
+		// 使用 If-Match 时返回 412
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_precondition_failed"`)
+		assert.Contains(t, w.Body.String(), `"version":5`)
+		assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
 	t.Run("validation_failed", func(t *testing.T) {
 		// ❌ 缺少 Count 和 ISBN
 		req := &api.BookUpdateReq{
@@ -278,8 +583,8 @@ func TestUpdateBook(t *testing.T) {
 		w := performRequest(r, http.MethodPut, "/books", body)
 
 		// 验证失败应返回 400
-		assert.Equal(t, http.StatusOK, w.Code)
-		assert.Contains(t, w.Body.String(), "缺少必要参数")
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
 	})
 
 	t.Run("invalid_json", func(t *testing.T) {
@@ -288,6 +593,472 @@ func TestUpdateBook(t *testing.T) {
 	})
 }
 
+func TestPatchBook(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockBookService)
+	h := NewBookHandler(mockService)
+	r := gin.Default()
+	r.PATCH("/books/:id", h.PatchBook)
+
+	current := func() *api.BookInfoResp {
+		return &api.BookInfoResp{ID: 1, Title: "Go", Count: 3, ISBN: "978-7-111-11111-1", Author: "张三", Content: "正文", Version: 2}
+	}
+	mergePatch := func(ifMatch string) map[string]string {
+		headers := map[string]string{"Content-Type": "application/merge-patch+json"}
+		if ifMatch != "" {
+			headers["If-Match"] = ifMatch
+		}
+		return headers
+	}
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("merge_patch", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			// 只更新补丁涉及的字段，未涉及的字段保留原值
+			return req.ID == 1 && *req.Version == 2 &&
+				assert.ObjectsAreEqual([]string{"count", "author"}, req.Fields) &&
+				req.Count == 5 && req.Author == "" && req.Content == "正文" && req.Title == "Go"
+		})).Return(&api.BookInfoResp{ID: 1, Title: "Go", Count: 5, Version: 3}, nil).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"count":5,"author":null}`), mergePatch(`"2"`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `"count":5`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("version_in_body", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"summary"}, req.Fields) && req.Summary == "新摘要"
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+
+		// application/json 按 merge patch 处理
+		w := performRequest(r, http.MethodPatch, "/books/1", []byte(`{"version":2,"summary":"新摘要"}`))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("contributors", func(t *testing.T) {
+		defer reset()
+
+		withContributors := current()
+		withContributors.Contributors = []api.BookContributor{{AuthorID: 7, Name: "张三", Role: "author"}}
+		mockService.On("GetByID", uint(1)).Return(withContributors, nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"contributors"}, req.Fields) &&
+				assert.ObjectsAreEqual([]api.BookContributor{{AuthorID: 7, Name: "张三", Role: "author"}, {Name: "李四", Role: "translator"}}, req.Contributors)
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+		// 贡献者未修改时不传给服务，由服务根据 author 的变化重新拆分
+		mockService.On("GetByID", uint(1)).Return(withContributors, nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"author"}, req.Fields) && req.Contributors == nil
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+
+		headers := map[string]string{"Content-Type": "application/json-patch+json", "If-Match": `"2"`}
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1",
+			[]byte(`[{"op":"add","path":"/contributors/-","value":{"name":"李四","role":"translator"}}]`), headers)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"author":"李四"}`), mergePatch(`"2"`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("categories_and_tags", func(t *testing.T) {
+		defer reset()
+
+		labelled := current()
+		labelled.Categories = []api.CategoryRef{{ID: 3, Code: "TP312", Name: "程序语言"}}
+		labelled.Tags = []string{"Go"}
+		mockService.On("GetByID", uint(1)).Return(labelled, nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"tags"}, req.Fields) && req.CategoryIDs == nil &&
+				assert.ObjectsAreEqual([]string{"Go", "入门"}, req.Tags)
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+		// 删除分类视为清空
+		mockService.On("GetByID", uint(1)).Return(labelled, nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"category_ids"}, req.Fields) &&
+				req.CategoryIDs != nil && len(req.CategoryIDs) == 0 && req.Tags == nil
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+
+		headers := map[string]string{"Content-Type": "application/json-patch+json", "If-Match": `"2"`}
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1",
+			[]byte(`[{"op":"add","path":"/tags/-","value":"入门"}]`), headers)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"category_ids":null}`), mergePatch(`"2"`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("json_patch", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+		mockService.On("Update", mock.MatchedBy(func(req *api.BookUpdateReq) bool {
+			return assert.ObjectsAreEqual([]string{"title"}, req.Fields) && req.Title == "Go语言编程"
+		})).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+
+		body := []byte(`[{"op":"test","path":"/title","value":"Go"},{"op":"replace","path":"/title","value":"Go语言编程"}]`)
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", body, map[string]string{
+			"Content-Type": "application/json-patch+json",
+			"If-Match":     `"2"`,
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("json_patch_test_failed", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+
+		body := []byte(`[{"op":"test","path":"/title","value":"Rust"},{"op":"replace","path":"/title","value":"x"}]`)
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", body, map[string]string{
+			"Content-Type": "application/json-patch+json",
+			"If-Match":     `"2"`,
+		})
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"patch_test_failed"`)
+		mockService.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("json_patch_too_large", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Twice()
+		jsonPatch := map[string]string{"Content-Type": "application/json-patch+json", "If-Match": `"2"`}
+
+		// 反复复制 contributors，文档每个操作翻倍
+		ops := make([]string, 30)
+		for i := range ops {
+			ops[i] = `{"op":"copy","from":"/contributors","path":"/contributors/-"}`
+		}
+		body := []byte(`[{"op":"add","path":"/contributors","value":[{"name":"` + strings.Repeat("张", 100) + `"}]},` + strings.Join(ops, ",") + "]")
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", body, jsonPatch)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"patch_too_large"`)
+
+		// 操作数超过上限
+		ops = make([]string, maxPatchOperations+1)
+		for i := range ops {
+			ops[i] = `{"op":"test","path":"/title","value":"Go"}`
+		}
+		w = performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte("["+strings.Join(ops, ",")+"]"), jsonPatch)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+		// 请求体超过上限时不查询书籍
+		big := []byte(`{"content":"` + strings.Repeat("a", maxPatchBodySize) + `"}`)
+		w = performRequestWithHeaders(r, http.MethodPatch, "/books/1", big, mergePatch(`"2"`))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		mockService.AssertNotCalled(t, "Update", mock.Anything)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("unchanged", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"title":"Go"}`), mergePatch(`"2"`))
+
+		// 没有实际修改时不写库，版本号不变
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		mockService.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("stale_if_match", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Twice()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"count":5}`), mergePatch(`"1"`))
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_precondition_failed"`)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		mockService.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("version_required", func(t *testing.T) {
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"count":5}`), mergePatch(""))
+
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"version_required"`)
+	})
+
+	t.Run("unsupported_media_type", func(t *testing.T) {
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`count=5`), map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"If-Match":     `"2"`,
+		})
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Header().Get("Accept-Patch"), "application/merge-patch+json")
+	})
+
+	t.Run("validation_failed", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+
+		// 删除必填字段
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"title":null}`), mergePatch(`"2"`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+		assert.Contains(t, w.Body.String(), `"field":"title"`)
+	})
+
+	t.Run("unknown_field", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"price":10}`), mergePatch(`"2"`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"invalid_patch"`)
+	})
+
+	t.Run("wrong_type", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(1)).Return(current(), nil).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/1", []byte(`{"count":"many"}`), mergePatch(`"2"`))
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"count"`)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetByID", uint(9)).Return((*api.BookInfoResp)(nil), service.ErrBookNotFound).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPatch, "/books/9", []byte(`{"count":5}`), mergePatch(`"2"`))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_not_found"`)
+	})
+}
+
+func TestBookTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockBookService)
+	h := NewBookHandler(mockService)
+	r := gin.Default()
+	r.GET("/books/trash", h.ListTrash)
+	r.POST("/books/:id/restore", h.RestoreBook)
+	r.DELETE("/books/trash", h.PurgeBooks)
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("list", func(t *testing.T) {
+		defer reset()
+
+		deletedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+		mockService.On("ListTrash", &api.BookTrashListReq{Title: "Go", Page: 2, PageSize: 5}).Return(&api.BookSearchResp{
+			Books: []api.BookInfoResp{{ID: 3, Title: "Go", DeletedAt: &deletedAt}},
+			Total: 1, Page: 2, PageSize: 5, TotalPages: 1,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/trash?title=Go&page=2&page_size=5", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"deleted_at":"2026-01-02T03:04:05Z"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("list_page_size_too_large", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/books/trash?page_size=1000", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("Restore", uint(3)).Return(&api.BookInfoResp{ID: 3, Title: "Go", Version: 4}, nil).Once()
+
+		w := performRequest(r, http.MethodPost, "/books/3/restore", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("restore_not_in_trash", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("Restore", uint(5)).Return(nil, service.ErrBookNotInTrash).Once()
+
+		w := performRequest(r, http.MethodPost, "/books/5/restore", nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_not_in_trash"`)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("Purge", []uint{3, 5}).Return(int64(1), nil).Once()
+
+		w := performRequest(r, http.MethodDelete, "/books/trash?ids=3&ids=5", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"purged":1`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("purge_invalid_id", func(t *testing.T) {
+		w := performRequest(r, http.MethodDelete, "/books/trash?ids=abc", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "ID格式错误")
+	})
+
+	t.Run("purge_missing_ids", func(t *testing.T) {
+		w := performRequest(r, http.MethodDelete, "/books/trash", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Purge", mock.Anything)
+	})
+}
+
+func TestBookRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockBookService)
+	h := NewBookHandler(mockService)
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	})
+	r.GET("/books/:id/revisions", h.ListRevisions)
+	r.GET("/books/:id/revisions/diff", h.CompareRevisions)
+	r.GET("/books/:id/revisions/:version", h.GetRevision)
+	r.POST("/books/:id/revisions/:version/restore", h.RollbackRevision)
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("list", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("ListRevisions", uint(1), &api.BookRevisionListReq{Page: 1, PageSize: 5}).Return(&api.BookRevisionListResp{
+			BookID: 1,
+			Revisions: []api.BookRevisionResp{{
+				Version: 2, Action: "update", ActorID: 7, Fields: []string{"title"},
+				Changes: []api.FieldChange{{Field: "title", Old: "Go", New: "Go语言编程"}},
+			}},
+			Total: 2, Page: 1, PageSize: 5, TotalPages: 1,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/1/revisions?page=1&page_size=5", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"changes":[{"field":"title","old":"Go","new":"Go语言编程"}]`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("list_book_not_found", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("ListRevisions", uint(9), mock.Anything).Return(nil, service.ErrBookNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/9/revisions", nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"book_not_found"`)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetRevision", uint(1), 1).Return(&api.BookRevisionResp{
+			Version: 1, Action: "create", Snapshot: &api.BookInfoReq{Title: "Go", Count: 1, ISBN: "978-7-111-11111-1"},
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/1/revisions/1", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"snapshot":{"title":"Go"`)
+	})
+
+	t.Run("get_invalid_version", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/books/1/revisions/0", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), "版本号格式错误")
+	})
+
+	t.Run("get_not_found", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("GetRevision", uint(1), 8).Return(nil, service.ErrRevisionNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/1/revisions/8", nil)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"revision_not_found"`)
+	})
+
+	t.Run("diff", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("CompareRevisions", uint(1), 1, 3).Return(&api.BookRevisionDiffResp{
+			BookID: 1, From: 1, To: 3,
+			Changes: []api.FieldChange{{Field: "count", Old: 1, New: 5}},
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/1/revisions/diff?from=1&to=3", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `{"field":"count","old":1,"new":5}`)
+	})
+
+	t.Run("diff_missing_params", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/books/1/revisions/diff?from=1", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("RollbackRevision", uint(1), 1, (*int)(nil), api.Operator{UserID: 7}).
+			Return(&api.BookInfoResp{ID: 1, Title: "Go", Version: 4}, nil).Once()
+
+		w := performRequest(r, http.MethodPost, "/books/1/revisions/1/restore", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("rollback_stale_if_match", func(t *testing.T) {
+		defer reset()
+
+		mockService.On("RollbackRevision", uint(1), 1, mock.MatchedBy(func(v *int) bool { return v != nil && *v == 2 }), mock.Anything).
+			Return(nil, service.ErrBookVersionConflict).Once()
+		mockService.On("GetByID", uint(1)).Return(&api.BookInfoResp{ID: 1, Version: 3}, nil).Once()
+
+		w := performRequestWithHeaders(r, http.MethodPost, "/books/1/revisions/1/restore", nil, map[string]string{"If-Match": `"2"`})
+
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		mockService.AssertExpectations(t)
+	})
+}
+
 func TestGetBook(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	mockService := new(MockBookService)
@@ -298,29 +1069,47 @@ func TestGetBook(t *testing.T) {
 	t.Run("success", func(t *testing.T) {
 		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
 
-		resp := &api.BookInfoResp{ID: 1, Title: "Go", Author: "张三"}
+		resp := &api.BookInfoResp{ID: 1, Title: "Go", Author: "张三", Version: 3}
 		mockService.On("GetByID", uint(1)).Return(resp, nil).Once()
 
 		w := performRequest(r, http.MethodGet, "/books/1", nil)
 
 		assert.Equal(t, http.StatusOK, w.Code)
 		assert.Contains(t, w.Body.String(), "Go")
+		assert.Contains(t, w.Body.String(), `"version":3`)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("not_modified", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		resp := &api.BookInfoResp{ID: 1, Title: "Go", Version: 3}
+		mockService.On("GetByID", uint(1)).Return(resp, nil).Twice()
+
+		w := performRequestWithHeaders(r, http.MethodGet, "/books/1", nil, map[string]string{"If-None-Match": `"2", W/"3"`})
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+
+		// 版本已变化时返回完整内容
+		w = performRequestWithHeaders(r, http.MethodGet, "/books/1", nil, map[string]string{"If-None-Match": `"2"`})
+		assert.Equal(t, http.StatusOK, w.Code)
 	})
 
 	t.Run("not_found", func(t *testing.T) {
 		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
 
-		mockService.On("GetByID", uint(2)).Return((*api.BookInfoResp)(nil), errors.New("not found")).Once()
+		mockService.On("GetByID", uint(2)).Return((*api.BookInfoResp)(nil), service.ErrBookNotFound).Once()
 
 		w := performRequest(r, http.MethodGet, "/books/2", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusNotFound, w.Code)
 		assert.Contains(t, w.Body.String(), "书籍查询失败")
 	})
 
 	t.Run("invalid_id", func(t *testing.T) {
 		w := performRequest(r, http.MethodGet, "/books/abc", nil)
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "ID格式错误")
 	})
 }
@@ -424,7 +1213,7 @@ func TestBookList(t *testing.T) {
 		body, _ := json.Marshal(req)
 		w := performRequest(r, http.MethodPost, "/books/search", body)
 
-		assert.Equal(t, http.StatusOK, w.Code) // 注意：你返回的是 200 + error message
+		assert.Equal(t, http.StatusInternalServerError, w.Code) // 注意：你返回的是 200 + error message
 		assert.Contains(t, w.Body.String(), "书籍查询失败")
 		mockService.AssertExpectations(t)
 	})
@@ -464,7 +1253,7 @@ func TestSearchBooks(t *testing.T) {
 		body, _ := json.Marshal(req)
 		w := performRequest(r, http.MethodPost, "/books/search", body)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "搜索失败")
 	})
 }
@@ -507,13 +1296,13 @@ func TestSearchByTitle(t *testing.T) {
 
 		w := performRequest(r, http.MethodGet, "/books/title?title=Java", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "标题搜索失败")
 	})
 
 	t.Run("missing_title", func(t *testing.T) {
 		w := performRequest(r, http.MethodGet, "/books/title", nil)
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "标题参数不能为空")
 	})
 }
@@ -544,13 +1333,13 @@ func TestSearchByContent(t *testing.T) {
 
 		w := performRequest(r, http.MethodGet, "/books/content?content=AI", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "内容搜索失败")
 	})
 
 	t.Run("missing_content", func(t *testing.T) {
 		w := performRequest(r, http.MethodGet, "/books/content", nil)
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
 		assert.Contains(t, w.Body.String(), "内容参数不能为空")
 	})
 }
@@ -580,7 +1369,7 @@ func TestInitESIndex(t *testing.T) {
 
 		w := performRequest(r, http.MethodPost, "/books/init", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "初始化ES索引失败")
 	})
 }
@@ -610,7 +1399,271 @@ func TestReindexBooks(t *testing.T) {
 
 		w := performRequest(r, http.MethodPost, "/books/reindex", nil)
 
-		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
 		assert.Contains(t, w.Body.String(), "重新索引失败")
 	})
 }
+
+// uploadRequest 构造 multipart 上传请求
+func uploadRequest(r http.Handler, path, filename, content string, fields map[string]string, headers map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		_ = mw.WriteField(k, v)
+	}
+	if filename != "" {
+		fw, _ := mw.CreateFormFile("file", filename)
+		_, _ = fw.Write([]byte(content))
+	}
+	_ = mw.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportBooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockBookService)
+	h := NewBookHandler(mockService)
+	r := gin.Default()
+	r.POST("/admin/books/import", h.ImportBooks)
+
+	csv := "书名,isbn,count\nGo,978-1,1\n"
+
+	t.Run("success", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		// 校验失败的行按字段列出，业务错误给出错误码
+		validationErr := i18n.Validate.Struct(&api.BookInfoReq{Title: "x", ISBN: "978-2"})
+		mockService.On("Import", mock.Anything, int64(len(csv)), mock.MatchedBy(func(req *api.BookImportReq) bool {
+			return req.Format == tabular.CSV && req.Mode == api.ImportUpsert && req.DryRun &&
+				req.Columns["title"] == "书名"
+		})).Return(&api.BookImportResp{
+			DryRun: true, Mode: api.ImportUpsert, Total: 3, Created: 1, Failed: 2,
+			Errors: []api.BookImportRowError{
+				{Row: 3, ISBN: "978-2", Err: validationErr},
+				{Row: 4, ISBN: "978-3", Err: service.ErrISBNInTrash},
+			},
+		}, nil).Once()
+
+		w := uploadRequest(r, "/admin/books/import", "books.csv", csv, map[string]string{
+			"mode": "upsert", "dry_run": "true", "mapping": `{"title":"书名"}`,
+		}, map[string]string{"Accept-Language": "en"})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `"dry_run":true`)
+		assert.Contains(t, body, `"created":1`)
+		assert.Contains(t, body, `{"row":3,"isbn":"978-2","error_code":"validation_failed","message":"count is a required field","errors":[{"field":"count","rule":"required","message":"count is a required field"}]}`)
+		assert.Contains(t, body, `{"row":4,"isbn":"978-3","error_code":"isbn_in_trash"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("aborted", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Import", mock.Anything, mock.Anything, mock.Anything).Return(&api.BookImportResp{
+			Mode: api.ImportSkip, Total: 500, Created: 500, Incomplete: true,
+		}, service.ErrDBUnavailable).Once()
+
+		w := uploadRequest(r, "/admin/books/import", "books.csv", csv, nil, nil)
+
+		// 中途失败时返回已处理部分的结果
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Contains(t, w.Body.String(), `"created":500`)
+		assert.Contains(t, w.Body.String(), `"incomplete":true`)
+	})
+
+	t.Run("file_error", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Import", mock.Anything, mock.Anything, mock.MatchedBy(func(req *api.BookImportReq) bool {
+			return req.Format == ""
+		})).Return(nil, service.ErrImportFormat).Once()
+
+		w := uploadRequest(r, "/admin/books/import", "books.xls", csv, nil, nil)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"unsupported_import_format"`)
+	})
+
+	t.Run("marc", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		// 按扩展名识别 MARC 格式
+		for filename, format := range map[string]string{"books.mrc": api.FormatMARC, "books.xml": api.FormatMARCXML} {
+			mockService.On("Import", mock.Anything, mock.Anything, mock.MatchedBy(func(req *api.BookImportReq) bool {
+				return req.Format == format
+			})).Return(&api.BookImportResp{Mode: api.ImportSkip, Total: 1, Created: 1, Errors: []api.BookImportRowError{}}, nil).Once()
+
+			w := uploadRequest(r, "/admin/books/import", filename, "x", nil, nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("bad_request", func(t *testing.T) {
+		// 缺少文件
+		w := uploadRequest(r, "/admin/books/import", "", "", nil, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		// 非法的导入模式
+		w = uploadRequest(r, "/admin/books/import", "books.csv", csv, map[string]string{"mode": "replace"}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"mode"`)
+
+		// 列映射不是合法的 JSON
+		w = uploadRequest(r, "/admin/books/import", "books.csv", csv, map[string]string{"mapping": "title=书名"}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"invalid_import_mapping"`)
+
+		mockService.AssertNotCalled(t, "Import", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestExportBooks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockBookService)
+	h := NewBookHandler(mockService)
+	r := gin.Default()
+	r.GET("/admin/books/export", h.ExportBooks)
+
+	// writeRows 模拟服务层写出导出内容
+	writeRows := func(content string) func(mock.Arguments) {
+		return func(args mock.Arguments) {
+			_, _ = io.WriteString(args.Get(1).(io.Writer), content)
+		}
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.MatchedBy(func(req *api.BookExportReq) bool {
+			return req.Format == api.ExportCSV && req.Title == "Go" &&
+				assert.ObjectsAreEqual([]string{"id", "title"}, req.Columns)
+		}), mock.Anything).Run(writeRows("id,title\n1,Go\n")).Return(nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export?title=Go&fields=id,%20title,", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Regexp(t, `attachment; filename="books-\d{8}\.csv"`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "id,title\n1,Go\n", w.Body.String())
+		assert.Equal(t, "complete", w.Header().Get("X-Export-Status"))
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("jsonl_gzip", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.MatchedBy(func(req *api.BookExportReq) bool {
+			return req.Format == api.ExportJSONL && req.Gzip && len(req.Columns) == 0
+		}), mock.Anything).Run(writeRows(`{"id":1}` + "\n")).Return(nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export?format=ndjson&gzip=true", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/gzip", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `.jsonl.gz"`)
+
+		gz, err := gzip.NewReader(w.Body)
+		assert.NoError(t, err)
+		content, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"id":1}`+"\n", string(content))
+	})
+
+	t.Run("marc", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.MatchedBy(func(req *api.BookExportReq) bool {
+			return req.Format == api.FormatMARC
+		}), mock.Anything).Run(writeRows("00026nam a2200025   4500\x1e\x1d")).Return(nil).Once()
+		mockService.On("Export", mock.MatchedBy(func(req *api.BookExportReq) bool {
+			return req.Format == api.FormatMARCXML
+		}), mock.Anything).Run(writeRows("<collection/>")).Return(nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export?format=marc", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/marc", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `.mrc"`)
+
+		w = performRequest(r, http.MethodGet, "/admin/books/export?format=marcxml", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/marcxml+xml", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), `.xml"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.Anything, mock.Anything).Return(nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export?format=jsonl", nil)
+
+		// 没有匹配的书籍时返回空文件
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Body.String())
+	})
+
+	t.Run("error_before_output", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.Anything, mock.Anything).Return(service.ErrExportFields.WithMessage("导出字段 price 不存在")).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export?fields=price", nil)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"invalid_export_fields"`)
+		assert.Empty(t, w.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("error_after_output", func(t *testing.T) {
+		defer func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }()
+
+		mockService.On("Export", mock.Anything, mock.Anything).
+			Run(writeRows("id\n1\n")).Return(service.ErrDBUnavailable).Once()
+
+		w := performRequest(r, http.MethodGet, "/admin/books/export", nil)
+
+		// 已开始输出时只能通过 trailer 说明文件不完整
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "id\n1\n", w.Body.String())
+		assert.Equal(t, "failed", w.Header().Get("X-Export-Status"))
+	})
+
+	t.Run("invalid_format", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/admin/books/export?format=xml", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"format"`)
+		mockService.AssertNotCalled(t, "Export", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuditBookOmitsContent(t *testing.T) {
+	content := strings.Repeat("正文", 50000)
+	snapshot := audit.Snapshot(auditBook(&api.BookInfoResp{ID: 1, Title: "Go", Content: content}))
+
+	var got map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(snapshot), &got))
+	assert.NotContains(t, got, "content")
+	assert.Equal(t, "Go", got["title"])
+	assert.Equal(t, float64(len(content)), got["content_length"])
+	assert.Len(t, got["content_sha256"], 64)
+	assert.Less(t, len(snapshot), 1024)
+
+	// 不存在的书籍不生成快照
+	assert.Nil(t, auditBook(nil))
+
+	req := audit.Snapshot(auditBookReq(&api.BookInfoReq{Title: "Go", Content: content}))
+	assert.NotContains(t, req, "正文")
+	assert.Contains(t, req, `"content_length":300000`)
+}
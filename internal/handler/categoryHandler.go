@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"log"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CategoryHandler struct {
+	categoryService service.CategoryService
+}
+
+func NewCategoryHandler(categoryService service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{categoryService: categoryService}
+}
+
+// Tree 完整的分类树
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	tree, err := h.categoryService.Tree()
+	if err != nil {
+		result.Error(c, "分类查询失败", err)
+		return
+	}
+	result.Success(c, tree)
+}
+
+// Get 分类详情，包含上级分类和直接子分类
+func (h *CategoryHandler) Get(c *gin.Context) {
+	id, ok := parseCategoryID(c)
+	if !ok {
+		return
+	}
+
+	category, err := h.categoryService.Get(id)
+	if err != nil {
+		result.Error(c, "分类查询失败", err)
+		return
+	}
+	result.Success(c, category)
+}
+
+// Books 分类及其子孙分类中的书籍
+func (h *CategoryHandler) Books(c *gin.Context) {
+	id, ok := parseCategoryID(c)
+	if !ok {
+		return
+	}
+	req := &api.CategoryBooksReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	books, err := h.categoryService.ListBooks(id, req)
+	if err != nil {
+		result.Error(c, "分类书籍查询失败", err)
+		return
+	}
+	result.Success(c, books)
+}
+
+// Create 新增分类
+func (h *CategoryHandler) Create(c *gin.Context) {
+	req := &api.CategoryInfoReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	category, err := h.categoryService.Create(req)
+	if err != nil {
+		result.Error(c, "分类添加失败", err)
+		return
+	}
+	audit.SetTargetID(c, "category", category.ID)
+	audit.SetChange(c, nil, category)
+
+	result.Success(c, category)
+}
+
+// Update 修改分类，修改 parent_id 时连同子孙分类一起移动
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, ok := parseCategoryID(c)
+	if !ok {
+		return
+	}
+	req := &api.CategoryInfoReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	log.Printf("收到请求---修改分类: id=%d", id)
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	var before *api.CategoryResp
+	if audit.Active(c) {
+		before, _ = h.categoryService.Get(id)
+	}
+
+	category, err := h.categoryService.Update(id, req)
+	if err != nil {
+		result.Error(c, "分类修改失败", err)
+		return
+	}
+	audit.SetChange(c, before, category)
+
+	result.Success(c, category)
+}
+
+// Delete 删除分类，仍有子分类或关联书籍时拒绝
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, ok := parseCategoryID(c)
+	if !ok {
+		return
+	}
+	log.Printf("收到请求---删除分类: id=%d", id)
+
+	if audit.Active(c) {
+		before, _ := h.categoryService.Get(id)
+		audit.SetChange(c, before, nil)
+	}
+
+	if err := h.categoryService.Delete(id); err != nil {
+		result.Error(c, "分类删除失败", err)
+		return
+	}
+	result.Success(c, "分类删除成功")
+}
+
+// Tags 分页查询标签，可按名称前缀匹配
+func (h *CategoryHandler) Tags(c *gin.Context) {
+	req := &api.TagSearchReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	tags, err := h.categoryService.Tags(req)
+	if err != nil {
+		result.Error(c, "标签查询失败", err)
+		return
+	}
+	result.Success(c, tags)
+}
+
+func parseCategoryID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return 0, false
+	}
+	return uint(id), true
+}
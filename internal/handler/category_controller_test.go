@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock CategoryService --------
+type MockCategoryService struct {
+	mock.Mock
+}
+
+func (m *MockCategoryService) Tree() ([]api.CategoryResp, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]api.CategoryResp), args.Error(1)
+}
+func (m *MockCategoryService) Get(id uint) (*api.CategoryResp, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.CategoryResp), args.Error(1)
+}
+func (m *MockCategoryService) Create(dto *api.CategoryInfoReq) (*api.CategoryResp, error) {
+	args := m.Called(dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.CategoryResp), args.Error(1)
+}
+func (m *MockCategoryService) Update(id uint, dto *api.CategoryInfoReq) (*api.CategoryResp, error) {
+	args := m.Called(id, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.CategoryResp), args.Error(1)
+}
+func (m *MockCategoryService) Delete(id uint) error {
+	return m.Called(id).Error(0)
+}
+func (m *MockCategoryService) ListBooks(id uint, dto *api.CategoryBooksReq) (*api.BookSearchResp, error) {
+	args := m.Called(id, dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookSearchResp), args.Error(1)
+}
+func (m *MockCategoryService) Tags(dto *api.TagSearchReq) (*api.TagListResp, error) {
+	args := m.Called(dto)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.TagListResp), args.Error(1)
+}
+
+// -------- Tests --------
+func TestCategoryRead(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockCategoryService)
+	h := NewCategoryHandler(mockService)
+	r := gin.Default()
+	r.GET("/categories", h.Tree)
+	r.GET("/categories/:id", h.Get)
+	r.GET("/categories/:id/books", h.Books)
+	r.GET("/tags", h.Tags)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("tree", func(t *testing.T) {
+		defer reset()
+		mockService.On("Tree").Return([]api.CategoryResp{{ID: 1, Code: "T", Name: "工业技术", BookCount: 2,
+			Children: []api.CategoryResp{{ID: 5, ParentID: 1, Code: "TP", Name: "自动化技术、计算机技术", BookCount: 2}}}}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/categories", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"children":[{"id":5,"parent_id":1,"code":"TP"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("get", func(t *testing.T) {
+		defer reset()
+		mockService.On("Get", uint(5)).Return(&api.CategoryResp{ID: 5, ParentID: 1, Code: "TP", Name: "自动化技术、计算机技术",
+			Ancestors: []api.CategoryRef{{ID: 1, Code: "T", Name: "工业技术"}}}, nil).Once()
+		mockService.On("Get", uint(6)).Return(nil, service.ErrCategoryNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/categories/5", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"ancestors":[{"id":1,"code":"T","name":"工业技术"}]`)
+
+		w = performRequest(r, http.MethodGet, "/categories/6", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"category_not_found"`)
+
+		w = performRequest(r, http.MethodGet, "/categories/abc", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("books", func(t *testing.T) {
+		defer reset()
+		mockService.On("ListBooks", uint(5), &api.CategoryBooksReq{Page: 2, PageSize: 20}).Return(&api.BookSearchResp{
+			Books: []api.BookInfoResp{{ID: 9, Title: "Go"}}, Total: 21, Page: 2, PageSize: 20, TotalPages: 2,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/categories/5/books?page=2&page_size=20", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"total":21`)
+
+		w = performRequest(r, http.MethodGet, "/categories/5/books?page_size=1000", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		defer reset()
+		mockService.On("Tags", &api.TagSearchReq{Name: "G"}).Return(&api.TagListResp{
+			Tags: []api.TagCount{{Name: "Go", BookCount: 3}}, Total: 1, Page: 1, PageSize: 10, TotalPages: 1,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/tags?name=G", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"tags":[{"name":"Go","book_count":3}]`)
+
+		w = performRequest(r, http.MethodGet, "/tags?page=x", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+}
+
+func TestCategoryWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockCategoryService)
+	h := NewCategoryHandler(mockService)
+	r := gin.Default()
+	r.POST("/categories", h.Create)
+	r.PUT("/categories/:id", h.Update)
+	r.DELETE("/categories/:id", h.Delete)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("create", func(t *testing.T) {
+		defer reset()
+		mockService.On("Create", &api.CategoryInfoReq{Code: "TP312", Name: "程序语言、算法语言", ParentID: 5}).
+			Return(&api.CategoryResp{ID: 8, ParentID: 5, Code: "TP312", Name: "程序语言、算法语言"}, nil).Once()
+		mockService.On("Create", &api.CategoryInfoReq{Code: "TP", Name: "重复"}).Return(nil, service.ErrCategoryCodeExists).Once()
+
+		w := performRequest(r, http.MethodPost, "/categories", []byte(`{"code":"TP312","name":"程序语言、算法语言","parent_id":5}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"id":8`)
+
+		w = performRequest(r, http.MethodPost, "/categories", []byte(`{"code":"TP","name":"重复"}`))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"category_code_exists"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("create_requires_code", func(t *testing.T) {
+		w := performRequest(r, http.MethodPost, "/categories", []byte(`{"name":"程序语言"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("move", func(t *testing.T) {
+		defer reset()
+		mockService.On("Update", uint(5), &api.CategoryInfoReq{Code: "TP", Name: "计算机技术", ParentID: 8}).
+			Return(nil, service.ErrCategoryCycle).Once()
+		mockService.On("Update", uint(5), &api.CategoryInfoReq{Code: "TP", Name: "计算机技术"}).
+			Return(&api.CategoryResp{ID: 5, Code: "TP", Name: "计算机技术"}, nil).Once()
+
+		w := performRequest(r, http.MethodPut, "/categories/5", []byte(`{"code":"TP","name":"计算机技术","parent_id":8}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"category_cycle"`)
+
+		w = performRequest(r, http.MethodPut, "/categories/5", []byte(`{"code":"TP","name":"计算机技术","parent_id":0}`))
+		assert.Equal(t, http.StatusOK, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		defer reset()
+		mockService.On("Delete", uint(8)).Return(nil).Once()
+		mockService.On("Delete", uint(5)).Return(service.ErrCategoryHasChildren).Once()
+
+		w := performRequest(r, http.MethodDelete, "/categories/8", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		w = performRequest(r, http.MethodDelete, "/categories/5", nil)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"category_has_children"`)
+		mockService.AssertExpectations(t)
+	})
+}
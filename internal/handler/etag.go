@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"LibraryManagement/internal/apperr"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 条件请求相关的错误
+var (
+	errVersionRequired    = apperr.New(apperr.KindPreconditionRequired, "version_required", "更新书籍需要提供 If-Match 请求头或 version 字段")
+	errPreconditionFailed = apperr.New(apperr.KindPreconditionFailed, "book_precondition_failed", "书籍已被修改，If-Match 与当前版本不一致")
+	errInvalidETag        = apperr.Validation("invalid_etag", "If-Match 格式错误")
+)
+
+// versionETag 由版本号生成强 ETag，如 "3"
+func versionETag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseVersionETag 解析 If-Match 中的版本号；"*" 表示不限版本，返回 nil
+func parseVersionETag(header string) (*int, error) {
+	tag := strings.TrimSpace(header)
+	if tag == "*" {
+		return nil, nil
+	}
+
+	// If-Match 按强比较，不接受弱 ETag
+	unquoted, err := strconv.Unquote(tag)
+	if err != nil || strings.HasPrefix(tag, "W/") {
+		return nil, errInvalidETag
+	}
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return nil, errInvalidETag
+	}
+	return &version, nil
+}
+
+// etagMatches 判断 If-None-Match 是否命中当前 ETag（弱比较，支持逗号分隔的多个值和 *）
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified 写入 ETag 和 Last-Modified，并按条件请求判断客户端缓存是否仍然有效：
+// 有 If-None-Match 时只比较 ETag，否则比较 If-Modified-Since（精确到秒）。modified 为零值时不使用 Last-Modified
+func notModified(c *gin.Context, etag string, modified time.Time) bool {
+	c.Header("ETag", etag)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		return etagMatches(inm, etag)
+	}
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+	return false
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportStatusTrailer 导出完成后以 HTTP trailer 返回的状态：complete 或 failed。
+// 开始输出后出错无法再改变状态码，客户端可据此判断文件是否完整
+const exportStatusTrailer = "X-Export-Status"
+
+// ExportBooks 流式导出书籍，格式为 CSV、JSON Lines、MARC21 或 MARCXML，可选 gzip 压缩
+func (b *BookHandler) ExportBooks(c *gin.Context) {
+	req := &api.BookExportReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+	fmt.Println("收到请求---导出书籍: ", req.Format, req.Fields)
+
+	if req.Format == "" {
+		req.Format = api.ExportCSV
+	} else if req.Format == "ndjson" {
+		req.Format = api.ExportJSONL
+	}
+	for _, field := range strings.Split(req.Fields, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			req.Columns = append(req.Columns, field)
+		}
+	}
+
+	out := &exportWriter{c: c, req: req}
+	var w io.Writer = out
+	var gz *gzip.Writer
+	if req.Gzip {
+		gz = gzip.NewWriter(out)
+		w = gz
+	}
+
+	err := b.bookService.Export(req, w)
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if err != nil {
+		if !out.started {
+			result.Error(c, "书籍导出失败", err)
+			return
+		}
+		log.Printf("导出书籍中途失败: %v", err)
+		c.Writer.Header().Set(exportStatusTrailer, "failed")
+		return
+	}
+
+	// 没有任何输出（如 JSON Lines 没有匹配的书籍）时也返回下载头
+	out.start()
+	c.Writer.Header().Set(exportStatusTrailer, "complete")
+}
+
+// exportWriter 第一次写入时才发送响应头，在此之前出错仍可返回 JSON 错误
+type exportWriter struct {
+	c       *gin.Context
+	req     *api.BookExportReq
+	started bool
+}
+
+func (w *exportWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+
+	contentType := "text/csv; charset=utf-8"
+	ext := "csv"
+	switch w.req.Format {
+	case api.ExportJSONL:
+		contentType = "application/x-ndjson"
+		ext = "jsonl"
+	case api.FormatMARC:
+		contentType = "application/marc"
+		ext = "mrc"
+	case api.FormatMARCXML:
+		contentType = "application/marcxml+xml"
+		ext = "xml"
+	}
+	if w.req.Gzip {
+		contentType = "application/gzip"
+		ext += ".gz"
+	}
+
+	header := w.c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="books-%s.%s"`, time.Now().Format("20060102"), ext))
+	header.Set("Trailer", exportStatusTrailer)
+	w.c.Status(http.StatusOK)
+}
+
+func (w *exportWriter) Write(p []byte) (int, error) {
+	w.start()
+	return w.c.Writer.Write(p)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/feed"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feed 的标题和说明
+var feedTitles = map[string][2]string{
+	service.FeedNew:     {"新书上架", "最近上架的书籍"},
+	service.FeedUpdated: {"最近更新", "最近新增或修改的书籍"},
+}
+
+type FeedHandler struct {
+	feedService service.FeedService
+}
+
+func NewFeedHandler(feedService service.FeedService) *FeedHandler {
+	return &FeedHandler{feedService: feedService}
+}
+
+// NewAtom 新书上架（Atom）
+func (f *FeedHandler) NewAtom(c *gin.Context) {
+	f.serve(c, service.FeedNew, feed.AtomType)
+}
+
+// NewRSS 新书上架（RSS 2.0）
+func (f *FeedHandler) NewRSS(c *gin.Context) {
+	f.serve(c, service.FeedNew, feed.RSSType)
+}
+
+// UpdatedAtom 最近更新（Atom）
+func (f *FeedHandler) UpdatedAtom(c *gin.Context) {
+	f.serve(c, service.FeedUpdated, feed.AtomType)
+}
+
+// UpdatedRSS 最近更新（RSS 2.0）
+func (f *FeedHandler) UpdatedRSS(c *gin.Context) {
+	f.serve(c, service.FeedUpdated, feed.RSSType)
+}
+
+// serve 输出 feed。内容未变化时按 If-None-Match / If-Modified-Since 返回 304，不再查询书目
+func (f *FeedHandler) serve(c *gin.Context, kind, contentType string) {
+	req := &api.FeedReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	modified, err := f.feedService.LastModified(req)
+	if err != nil {
+		result.Error(c, "订阅 feed 查询失败", err)
+		return
+	}
+
+	// 标题随语言变化，ETag 也区分语言
+	lang := result.Lang(c)
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d|%s|%d", kind, contentType, req.AuthorID, req.Category, lang, modified.UnixNano())))
+	etag := `W/"` + hex.EncodeToString(sum[:8]) + `"`
+	c.Header("Vary", "Accept-Language")
+	if notModified(c, etag, modified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	ch, err := f.feedService.Channel(kind, req, c.Request.URL.RequestURI(), modified)
+	if err != nil {
+		result.Error(c, "订阅 feed 查询失败", err)
+		return
+	}
+	title := feedTitles[kind]
+	ch.Title = fmt.Sprintf("%s - %s", ch.Title, i18n.T(lang, title[0]))
+	if ch.Author != "" {
+		ch.Title = fmt.Sprintf("%s - %s", ch.Title, ch.Author)
+	}
+	if ch.Category != "" {
+		ch.Title = fmt.Sprintf("%s - %s", ch.Title, ch.Category)
+	}
+	ch.Description = i18n.T(lang, title[1])
+
+	var doc interface{} = ch.Atom()
+	if contentType == feed.RSSType {
+		doc = ch.RSS()
+	}
+	data, err := xml.Marshal(doc)
+	if err != nil {
+		result.Error(c, "订阅 feed 查询失败", err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
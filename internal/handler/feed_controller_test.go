@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/feed"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock FeedService --------
+type MockFeedService struct {
+	mock.Mock
+}
+
+func (m *MockFeedService) LastModified(req *api.FeedReq) (time.Time, error) {
+	args := m.Called(req)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+func (m *MockFeedService) Channel(kind string, req *api.FeedReq, self string, updated time.Time) (*feed.Channel, error) {
+	args := m.Called(kind, req, self, updated)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*feed.Channel), args.Error(1)
+}
+
+// -------- Tests --------
+func TestFeeds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockFeedService)
+	h := NewFeedHandler(mockService)
+	r := gin.Default()
+	r.GET("/feeds/new.atom", h.NewAtom)
+	r.GET("/feeds/new.rss", h.NewRSS)
+	r.GET("/feeds/updated.rss", h.UpdatedRSS)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+	modified := time.Date(2026, 3, 2, 8, 0, 0, 500, time.UTC)
+	channel := func() *feed.Channel {
+		return &feed.Channel{
+			ID: "urn:librarymanagement:feed:new", Title: "图书馆", Self: "http://localhost:8080/feeds/new.atom", Updated: modified,
+			Items: []feed.Item{{ID: "urn:librarymanagement:book:7", Title: "Go 编程", Author: "张三", Updated: modified}},
+		}
+	}
+
+	var etag string
+	t.Run("atom", func(t *testing.T) {
+		defer reset()
+		mockService.On("LastModified", &api.FeedReq{}).Return(modified, nil).Once()
+		mockService.On("Channel", service.FeedNew, &api.FeedReq{}, "/feeds/new.atom", modified).Return(channel(), nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/feeds/new.atom", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, feed.AtomType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "Mon, 02 Mar 2026 08:00:00 GMT", w.Header().Get("Last-Modified"))
+		etag = w.Header().Get("ETag")
+		assert.True(t, strings.HasPrefix(etag, `W/"`))
+		body := w.Body.String()
+		assert.Contains(t, body, `<title>图书馆 - 新书上架</title><subtitle>最近上架的书籍</subtitle>`)
+		assert.Contains(t, body, `<entry><id>urn:librarymanagement:book:7</id><title>Go 编程</title>`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("not_modified", func(t *testing.T) {
+		defer reset()
+		mockService.On("LastModified", &api.FeedReq{}).Return(modified, nil)
+
+		// ETag 命中，不再查询书目
+		req := httptest.NewRequest(http.MethodGet, "/feeds/new.atom", nil)
+		req.Header.Set("If-None-Match", etag)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		assert.Empty(t, w.Body.String())
+		assert.Equal(t, etag, w.Header().Get("ETag"))
+
+		// Last-Modified 精确到秒
+		req = httptest.NewRequest(http.MethodGet, "/feeds/new.atom", nil)
+		req.Header.Set("If-Modified-Since", "Mon, 02 Mar 2026 08:00:00 GMT")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotModified, w.Code)
+		mockService.AssertNotCalled(t, "Channel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		defer reset()
+		mockService.On("LastModified", &api.FeedReq{}).Return(modified, nil)
+		mockService.On("Channel", service.FeedNew, &api.FeedReq{}, "/feeds/new.atom", modified).Return(channel(), nil)
+
+		// 同一 feed 的 RSS 格式、其他语言的 ETag 不同
+		req := httptest.NewRequest(http.MethodGet, "/feeds/new.atom", nil)
+		req.Header.Set("If-None-Match", etag)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<title>图书馆 - New arrivals</title>`)
+
+		// 有 If-None-Match 时不看 If-Modified-Since
+		req = httptest.NewRequest(http.MethodGet, "/feeds/new.atom", nil)
+		req.Header.Set("If-None-Match", `W/"other"`)
+		req.Header.Set("If-Modified-Since", "Mon, 02 Mar 2026 08:00:00 GMT")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/feeds/new.atom", nil)
+		req.Header.Set("If-Modified-Since", "Mon, 02 Mar 2026 07:59:59 GMT")
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("rss_by_author", func(t *testing.T) {
+		defer reset()
+		ch := channel()
+		ch.Author = "张三"
+		mockService.On("LastModified", &api.FeedReq{AuthorID: 3}).Return(modified, nil).Once()
+		mockService.On("Channel", service.FeedUpdated, &api.FeedReq{AuthorID: 3}, "/feeds/updated.rss?author_id=3", modified).Return(ch, nil).Once()
+		mockService.On("LastModified", &api.FeedReq{AuthorID: 99}).Return(time.Time{}, service.ErrAuthorNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/feeds/updated.rss?author_id=3", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, feed.RSSType, w.Header().Get("Content-Type"))
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `<channel><title>图书馆 - 最近更新 - 张三</title>`)
+		assert.Contains(t, w.Body.String(), `<dc:creator>张三</dc:creator>`)
+
+		w = performRequest(r, http.MethodGet, "/feeds/updated.rss?author_id=99", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"author_not_found"`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("atom_by_category", func(t *testing.T) {
+		defer reset()
+		ch := channel()
+		ch.Category = "程序设计"
+		mockService.On("LastModified", &api.FeedReq{Category: 5}).Return(modified, nil).Once()
+		mockService.On("Channel", service.FeedNew, &api.FeedReq{Category: 5}, "/feeds/new.atom?category=5", modified).Return(ch, nil).Once()
+		mockService.On("LastModified", &api.FeedReq{Category: 6}).Return(time.Time{}, service.ErrCategoryNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/feeds/new.atom?category=5", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotEqual(t, etag, w.Header().Get("ETag"))
+		assert.Contains(t, w.Body.String(), `<title>图书馆 - 新书上架 - 程序设计</title>`)
+		assert.Contains(t, w.Body.String(), `<category term="程序设计"></category>`)
+
+		w = performRequest(r, http.MethodGet, "/feeds/new.atom?category=6", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"category_not_found"`)
+
+		w = performRequest(r, http.MethodGet, "/feeds/new.atom?category=x", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		defer reset()
+		mockService.On("LastModified", &api.FeedReq{AuthorID: 5}).Return(time.Time{}, nil).Once()
+		mockService.On("Channel", service.FeedNew, &api.FeedReq{AuthorID: 5}, mock.Anything, time.Time{}).Return(&feed.Channel{Title: "图书馆"}, nil).Once()
+
+		// 没有书籍时不返回 Last-Modified
+		req := httptest.NewRequest(http.MethodGet, "/feeds/new.rss?author_id=5", nil)
+		req.Header.Set("If-Modified-Since", "Mon, 02 Mar 2026 08:00:00 GMT")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Last-Modified"))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		defer reset()
+		mockService.On("LastModified", &api.FeedReq{}).Return(time.Time{}, service.ErrFeedDisabled).Once()
+
+		w := performRequest(r, http.MethodGet, "/feeds/new.rss", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"feed_disabled"`)
+	})
+
+	t.Run("invalid_author_id", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/feeds/new.rss?author_id=%E5%BC%A0%E4%B8%89", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportBooks 从上传的 CSV/TSV/XLSX/MARC/MARCXML 文件批量导入书籍，返回行级错误报告
+func (b *BookHandler) ImportBooks(c *gin.Context) {
+	req := &api.BookImportReq{}
+	if err := c.ShouldBind(req); err != nil {
+		result.Failed(c, result.RequiredCode, "请求数据格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "请上传要导入的文件")
+		return
+	}
+	fmt.Println("收到请求---导入书籍: ", fileHeader.Filename, req.Mode, req.DryRun)
+
+	if req.Mapping != "" {
+		if err := json.Unmarshal([]byte(req.Mapping), &req.Columns); err != nil {
+			result.Error(c, "书籍导入失败", service.ErrImportMapping.Wrap(err))
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = service.ImportFormatOf(fileHeader.Filename)
+	}
+	req.Operator = operatorOf(c)
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		result.Error(c, "书籍导入失败", err)
+		return
+	}
+	defer file.Close()
+
+	resp, err := b.bookService.Import(file, fileHeader.Size, req)
+	var report *importReport
+	if resp != nil {
+		report = newImportReport(result.Lang(c), resp)
+		audit.SetChange(c, nil, gin.H{
+			"file": fileHeader.Filename, "mode": resp.Mode, "dry_run": resp.DryRun,
+			"created": resp.Created, "updated": resp.Updated, "failed": resp.Failed,
+		})
+	}
+	if err != nil {
+		if resp != nil {
+			// 中途失败时同样返回已处理部分的结果
+			result.ErrorWithData(c, "书籍导入失败", err, report)
+		} else {
+			result.Error(c, "书籍导入失败", err)
+		}
+		return
+	}
+
+	result.Success(c, report)
+}
+
+// importReport 导入结果，行级错误按请求语言输出
+type importReport struct {
+	*api.BookImportResp
+	Errors []importRowError `json:"errors"`
+}
+
+type importRowError struct {
+	Row     int               `json:"row"`
+	ISBN    string            `json:"isbn,omitempty"`
+	Code    string            `json:"error_code"`
+	Message string            `json:"message"`
+	Errors  []i18n.FieldError `json:"errors,omitempty"` // 字段校验失败时逐项列出
+}
+
+func newImportReport(lang string, resp *api.BookImportResp) *importReport {
+	report := &importReport{BookImportResp: resp, Errors: make([]importRowError, 0, len(resp.Errors))}
+	for _, e := range resp.Errors {
+		row := importRowError{Row: e.Row, ISBN: e.ISBN}
+
+		if fields := i18n.FieldErrors(lang, e.Err); len(fields) > 0 {
+			messages := make([]string, 0, len(fields))
+			for _, f := range fields {
+				messages = append(messages, f.Message)
+			}
+			sep := "; "
+			if lang == i18n.ZH {
+				sep = "；"
+			}
+			row.Code = result.ValidationErrorCode
+			row.Message = strings.Join(messages, sep)
+			row.Errors = fields
+		} else if ae, ok := apperr.As(e.Err); ok && ae.Kind != apperr.KindInternal {
+			row.Code = ae.Code
+			row.Message = i18n.T(lang, ae.Message)
+		} else {
+			// 未归类的错误只写日志
+			log.Printf("导入书籍第 %d 行失败: %v", e.Row, e.Err)
+			row.Code = result.GetErrorCode(result.FailedCode)
+			row.Message = i18n.T(lang, "系统错误")
+		}
+
+		report.Errors = append(report.Errors, row)
+	}
+	return report
+}
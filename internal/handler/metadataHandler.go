@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MetadataHandler struct {
+	metadataService service.MetadataService
+}
+
+func NewMetadataHandler(metadataService service.MetadataService) *MetadataHandler {
+	return &MetadataHandler{metadataService: metadataService}
+}
+
+// Lookup 按 ISBN 查询外部书目信息，供编目时预填书名、作者和简介
+func (m *MetadataHandler) Lookup(c *gin.Context) {
+	req := &api.BookMetadataReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	resp, err := m.metadataService.Lookup(req.ISBN)
+	if err != nil {
+		result.Error(c, "书目信息查询失败", err)
+		return
+	}
+	result.Success(c, resp)
+}
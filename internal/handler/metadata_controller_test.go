@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock MetadataService --------
+type MockMetadataService struct {
+	mock.Mock
+}
+
+func (m *MockMetadataService) Lookup(isbn string) (*api.BookMetadataResp, error) {
+	args := m.Called(isbn)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookMetadataResp), args.Error(1)
+}
+func (m *MockMetadataService) Enrich(req *api.BookInfoReq) error {
+	return m.Called(req).Error(0)
+}
+
+// -------- Tests --------
+func TestMetadataLookup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockMetadataService)
+	h := NewMetadataHandler(mockService)
+	r := gin.Default()
+	r.GET("/books/metadata", h.Lookup)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("success", func(t *testing.T) {
+		defer reset()
+		mockService.On("Lookup", "978-7-111-54742-6").Return(&api.BookMetadataResp{
+			ISBN: "9787111547426", Title: "Go程序设计语言", Author: "Donovan, Kernighan",
+			Sources: []string{"openlibrary"}, Cached: true,
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/metadata?isbn=978-7-111-54742-6", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"isbn":"9787111547426","title":"Go程序设计语言","author":"Donovan, Kernighan","summary":"","sources":["openlibrary"],"cached":true`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("missing_isbn", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/books/metadata", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNotCalled(t, "Lookup", mock.Anything)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		defer reset()
+		mockService.On("Lookup", "123").Return(nil, service.ErrInvalidISBN).Once()
+		mockService.On("Lookup", "9780000000002").Return(nil, service.ErrMetadataNotFound).Once()
+		mockService.On("Lookup", "9787111547426").Return(nil, service.ErrMetadataUnavailable).Once()
+
+		w := performRequest(r, http.MethodGet, "/books/metadata?isbn=123", nil)
+		assert.Contains(t, w.Body.String(), `"error_code":"invalid_isbn"`)
+		w = performRequest(r, http.MethodGet, "/books/metadata?isbn=9780000000002", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"metadata_not_found"`)
+		w = performRequest(r, http.MethodGet, "/books/metadata?isbn=9787111547426", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// VerifyLogin 登录第二步：提交验证码换取正式令牌
+func (m *MFAHandler) VerifyLogin(c *gin.Context) {
+	req := &api.MFALoginReq{}
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	err = i18n.Validate.Struct(req)
+	if err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	loginResp, err := m.mfaService.VerifyLogin(req)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	audit.SetActor(c, loginResp.UserID)
+
+	result.Success(c, loginResp)
+}
+
+// Setup 生成两步验证密钥和二维码链接
+func (m *MFAHandler) Setup(c *gin.Context) {
+	setup, err := m.mfaService.Setup(c.GetUint("user_id"))
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+
+	result.Success(c, setup)
+}
+
+// Enable 提交验证码确认启用两步验证
+func (m *MFAHandler) Enable(c *gin.Context) {
+	req := &api.MFACodeReq{}
+	if !bindMFAReq(c, req) {
+		return
+	}
+
+	codes, err := m.mfaService.Enable(c.GetUint("user_id"), req)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	log.Printf("用户启用两步验证: id=%d", c.GetUint("user_id"))
+
+	result.Success(c, codes)
+}
+
+// Disable 关闭两步验证
+func (m *MFAHandler) Disable(c *gin.Context) {
+	req := &api.MFADisableReq{}
+	if !bindMFAReq(c, req) {
+		return
+	}
+
+	err := m.mfaService.Disable(c.GetUint("user_id"), req)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	log.Printf("用户关闭两步验证: id=%d", c.GetUint("user_id"))
+
+	result.Success(c, "两步验证已关闭")
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码
+func (m *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	req := &api.MFACodeReq{}
+	if !bindMFAReq(c, req) {
+		return
+	}
+
+	codes, err := m.mfaService.RegenerateRecoveryCodes(c.GetUint("user_id"), req)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+
+	result.Success(c, codes)
+}
+
+// ---------- 工具函数 ----------
+
+func bindMFAReq(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return false
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return false
+	}
+	return true
+}
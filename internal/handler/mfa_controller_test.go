@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock MFAService --------
+type MockMFAService struct {
+	mock.Mock
+}
+
+func (m *MockMFAService) Setup(userID uint) (*api.MFASetupResp, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.MFASetupResp), args.Error(1)
+}
+func (m *MockMFAService) Enable(userID uint, req *api.MFACodeReq) (*api.RecoveryCodesResp, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.RecoveryCodesResp), args.Error(1)
+}
+func (m *MockMFAService) Disable(userID uint, req *api.MFADisableReq) error {
+	args := m.Called(userID, req)
+	return args.Error(0)
+}
+func (m *MockMFAService) RegenerateRecoveryCodes(userID uint, req *api.MFACodeReq) (*api.RecoveryCodesResp, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.RecoveryCodesResp), args.Error(1)
+}
+func (m *MockMFAService) VerifyLogin(req *api.MFALoginReq) (*api.LoginResp, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.LoginResp), args.Error(1)
+}
+
+// -------- Tests --------
+func TestMFAVerifyLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/login/mfa", handler.VerifyLogin)
+
+	req := api.MFALoginReq{MFAToken: "challenge", Code: "123456"}
+	mockService.On("VerifyLogin", &req).Return(&api.LoginResp{Token: "token123"}, nil).Once()
+
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/login/mfa", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "token123")
+
+	mockService.On("VerifyLogin", &req).Return(nil, service.ErrInvalidMFACode).Once()
+	w2 := performRequest(r, http.MethodPost, "/login/mfa", body)
+	assert.Contains(t, w2.Body.String(), "验证码错误")
+
+	mockService.On("VerifyLogin", &req).Return(nil, service.ErrInvalidMFAToken).Once()
+	w3 := performRequest(r, http.MethodPost, "/login/mfa", body)
+	assert.Contains(t, w3.Body.String(), "登录已过期")
+
+	// 错误次数过多被锁定
+	mockService.On("VerifyLogin", &req).Return(nil, service.ErrMFALocked).Once()
+	w5 := performRequest(r, http.MethodPost, "/login/mfa", body)
+	assert.Equal(t, http.StatusTooManyRequests, w5.Code)
+	assert.Contains(t, w5.Body.String(), `"error_code":"mfa_locked"`)
+
+	// 缺少验证码
+	missing, _ := json.Marshal(api.MFALoginReq{MFAToken: "challenge"})
+	w4 := performRequest(r, http.MethodPost, "/login/mfa", missing)
+	assert.Contains(t, w4.Body.String(), `"error_code":"validation_failed"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestMFAEnrollment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/me/mfa/setup", asAdmin(3), handler.Setup)
+	r.POST("/me/mfa/enable", asAdmin(3), handler.Enable)
+	r.POST("/me/mfa/recovery-codes", asAdmin(3), handler.RegenerateRecoveryCodes)
+
+	setup := &api.MFASetupResp{Secret: "JBSWY3DPEHPK3PXP", ProvisioningURI: "otpauth://totp/x"}
+	mockService.On("Setup", uint(3)).Return(setup, nil).Once()
+	w := performRequest(r, http.MethodPost, "/me/mfa/setup", nil)
+	assert.Contains(t, w.Body.String(), "otpauth://totp/x")
+
+	mockService.On("Setup", uint(3)).Return(nil, service.ErrMFAAlreadyEnabled).Once()
+	w2 := performRequest(r, http.MethodPost, "/me/mfa/setup", nil)
+	assert.Contains(t, w2.Body.String(), "两步验证已启用")
+
+	req := &api.MFACodeReq{Code: "123456"}
+	codes := &api.RecoveryCodesResp{RecoveryCodes: []string{"abcde-12345"}}
+	mockService.On("Enable", uint(3), req).Return(codes, nil).Once()
+	body, _ := json.Marshal(req)
+	w3 := performRequest(r, http.MethodPost, "/me/mfa/enable", body)
+	assert.Contains(t, w3.Body.String(), "abcde-12345")
+
+	mockService.On("Enable", uint(3), req).Return(nil, service.ErrMFASetupRequired).Once()
+	w4 := performRequest(r, http.MethodPost, "/me/mfa/enable", body)
+	assert.Contains(t, w4.Body.String(), "请先生成两步验证密钥")
+
+	mockService.On("RegenerateRecoveryCodes", uint(3), req).Return(codes, nil).Once()
+	w5 := performRequest(r, http.MethodPost, "/me/mfa/recovery-codes", body)
+	assert.Contains(t, w5.Body.String(), "abcde-12345")
+	mockService.AssertExpectations(t)
+}
+
+func TestMFADisable(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockMFAService)
+	handler := NewMFAHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/me/mfa/disable", asAdmin(3), handler.Disable)
+
+	req := &api.MFADisableReq{Password: "123456", Code: "654321"}
+	body, _ := json.Marshal(req)
+
+	mockService.On("Disable", uint(3), req).Return(nil).Once()
+	w := performRequest(r, http.MethodPost, "/me/mfa/disable", body)
+	assert.Contains(t, w.Body.String(), "两步验证已关闭")
+
+	mockService.On("Disable", uint(3), req).Return(service.ErrMFAEnforced).Once()
+	w2 := performRequest(r, http.MethodPost, "/me/mfa/disable", body)
+	assert.Contains(t, w2.Body.String(), "管理员账号必须启用两步验证")
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/oai"
+	"LibraryManagement/internal/service"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OAIHandler struct {
+	oaiService service.OAIService
+}
+
+func NewOAIHandler(oaiService service.OAIService) *OAIHandler {
+	return &OAIHandler{oaiService: oaiService}
+}
+
+// Handle OAI-PMH 2.0 请求入口，参数在查询字符串（GET）或表单（POST）中。
+// 协议错误按规范以 HTTP 200 在 <error> 中返回，数据库不可用等系统错误返回 JSON 错误
+func (o *OAIHandler) Handle(c *gin.Context) {
+	baseURL, err := o.oaiService.BaseURL()
+	if err != nil {
+		result.Error(c, "OAI-PMH 请求失败", err)
+		return
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		result.Failed(c, result.RequiredCode, "请求参数格式错误")
+		return
+	}
+	args := c.Request.Form
+	fmt.Println("收到请求---OAI-PMH: ", args.Encode())
+
+	resp := oai.NewResponse(baseURL, args, time.Now())
+	if oaiErr := oai.CheckArguments(args); oaiErr != nil {
+		writeOAI(c, resp, oaiErr)
+		return
+	}
+
+	list := &api.OAIListReq{
+		MetadataPrefix:  args.Get("metadataPrefix"),
+		From:            args.Get("from"),
+		Until:           args.Get("until"),
+		Set:             args.Get("set"),
+		ResumptionToken: args.Get("resumptionToken"),
+	}
+	switch args.Get("verb") {
+	case oai.VerbIdentify:
+		resp.Identify, err = o.oaiService.Identify()
+	case oai.VerbListMetadataFormats:
+		resp.ListMetadataFormats, err = o.oaiService.ListMetadataFormats(args.Get("identifier"))
+	case oai.VerbListSets:
+		err = o.oaiService.ListSets()
+	case oai.VerbListIdentifiers:
+		resp.ListIdentifiers, err = o.oaiService.ListIdentifiers(list)
+	case oai.VerbListRecords:
+		resp.ListRecords, err = o.oaiService.ListRecords(list)
+	case oai.VerbGetRecord:
+		resp.GetRecord, err = o.oaiService.GetRecord(args.Get("identifier"), args.Get("metadataPrefix"))
+	}
+
+	var oaiErr *oai.Error
+	if err != nil && !errors.As(err, &oaiErr) {
+		log.Printf("OAI-PMH 请求失败: %v", err)
+		result.Error(c, "OAI-PMH 请求失败", err)
+		return
+	}
+	writeOAI(c, resp, oaiErr)
+}
+
+// writeOAI 输出 XML 响应，错误信息按请求语言翻译
+func writeOAI(c *gin.Context, resp *oai.Response, oaiErr *oai.Error) {
+	if oaiErr != nil {
+		resp.Fail(&oai.Error{Code: oaiErr.Code, Message: i18n.T(result.Lang(c), oaiErr.Message)})
+	}
+	data, err := xml.Marshal(resp)
+	if err != nil {
+		result.Error(c, "OAI-PMH 请求失败", err)
+		return
+	}
+	c.Data(http.StatusOK, "text/xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
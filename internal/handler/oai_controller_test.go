@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/oai"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock OAIService --------
+type MockOAIService struct {
+	mock.Mock
+}
+
+func (m *MockOAIService) BaseURL() (string, error) {
+	args := m.Called()
+	return args.String(0), args.Error(1)
+}
+func (m *MockOAIService) Identify() (*oai.Identify, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oai.Identify), args.Error(1)
+}
+func (m *MockOAIService) ListMetadataFormats(identifier string) (*oai.ListMetadataFormats, error) {
+	args := m.Called(identifier)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oai.ListMetadataFormats), args.Error(1)
+}
+func (m *MockOAIService) ListSets() error {
+	return m.Called().Error(0)
+}
+func (m *MockOAIService) ListIdentifiers(req *api.OAIListReq) (*oai.ListIdentifiers, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oai.ListIdentifiers), args.Error(1)
+}
+func (m *MockOAIService) ListRecords(req *api.OAIListReq) (*oai.ListRecords, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oai.ListRecords), args.Error(1)
+}
+func (m *MockOAIService) GetRecord(identifier, metadataPrefix string) (*oai.GetRecord, error) {
+	args := m.Called(identifier, metadataPrefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*oai.GetRecord), args.Error(1)
+}
+
+// -------- Tests --------
+func TestOAIHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOAIService)
+	h := NewOAIHandler(mockService)
+	r := gin.Default()
+	r.GET("/oai", h.Handle)
+	r.POST("/oai", h.Handle)
+
+	const baseURL = "http://example.org/oai"
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("list_records", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return(baseURL, nil)
+		mockService.On("ListRecords", &api.OAIListReq{MetadataPrefix: "oai_dc", From: "2026-01-01"}).Return(&oai.ListRecords{
+			Records: []oai.Record{
+				{Header: oai.Header{Identifier: "oai:x:book/1", Datestamp: "2026-01-02T00:00:00Z"}, Metadata: &oai.Metadata{Content: []byte("<oai_dc:dc/>")}},
+				{Header: oai.Header{Status: "deleted", Identifier: "oai:x:book/2", Datestamp: "2026-01-03T00:00:00Z"}},
+			},
+			ResumptionToken: &oai.ResumptionToken{Value: "next", CompleteListSize: 5, Cursor: 0},
+		}, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/oai?verb=ListRecords&metadataPrefix=oai_dc&from=2026-01-01", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/xml; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`))
+		assert.Contains(t, body, `<request verb="ListRecords" metadataPrefix="oai_dc" from="2026-01-01">http://example.org/oai</request>`)
+		assert.Contains(t, body, `<metadata><oai_dc:dc/></metadata>`)
+		assert.Contains(t, body, `<header status="deleted"><identifier>oai:x:book/2</identifier>`)
+		assert.Contains(t, body, `<resumptionToken completeListSize="5" cursor="0">next</resumptionToken>`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("post", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return(baseURL, nil)
+		mockService.On("GetRecord", "oai:x:book/9", "marc21").
+			Return(nil, oai.NewError(oai.CodeIDDoesNotExist, "记录不存在：%s", "oai:x:book/9")).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/oai", strings.NewReader("verb=GetRecord&identifier=oai:x:book/9&metadataPrefix=marc21"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// 协议错误以 HTTP 200 返回，保留请求回显
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<request verb="GetRecord" identifier="oai:x:book/9" metadataPrefix="marc21">`)
+		assert.Contains(t, w.Body.String(), `<error code="idDoesNotExist">record does not exist: oai:x:book/9</error>`)
+	})
+
+	t.Run("malformed_query", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return(baseURL, nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/oai?verb=%zz", nil)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// 无法解析的参数不是协议错误，返回 JSON
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"message":"malformed request parameters"`)
+	})
+
+	t.Run("bad_argument", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return(baseURL, nil)
+
+		w := performRequest(r, http.MethodGet, "/oai?verb=ListRecords&metadataPrefix=oai_dc&foo=1", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<request>http://example.org/oai</request><error code="badArgument">`)
+
+		w = performRequest(r, http.MethodGet, "/oai?verb=Harvest", nil)
+		assert.Contains(t, w.Body.String(), `<error code="badVerb">`)
+		mockService.AssertNotCalled(t, "ListRecords", mock.Anything)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return("", service.ErrOAIDisabled)
+
+		w := performRequest(r, http.MethodGet, "/oai?verb=Identify", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"oai_disabled"`)
+	})
+
+	t.Run("db_unavailable", func(t *testing.T) {
+		defer reset()
+		mockService.On("BaseURL").Return(baseURL, nil)
+		mockService.On("Identify").Return(nil, service.ErrDBUnavailable).Once()
+
+		w := performRequest(r, http.MethodGet, "/oai?verb=Identify", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/service"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcBindingCookie 发起授权时下发给浏览器的随机值，回调时校验，只在 /auth/oidc 路径下发送
+const (
+	oidcBindingCookie = "oidc_binding"
+	oidcCookiePath    = "/auth/oidc"
+)
+
+type OIDCHandler struct {
+	oidcService service.OIDCService
+}
+
+func NewOIDCHandler(oidcService service.OIDCService) *OIDCHandler {
+	return &OIDCHandler{oidcService: oidcService}
+}
+
+// Login 跳转到身份提供方进行单点登录
+func (o *OIDCHandler) Login(c *gin.Context) {
+	authURL, binding, err := o.oidcService.AuthURL(c.Request.Context(), 0)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	setOIDCBinding(c, binding)
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback 身份提供方回调，返回与本地登录相同的登录结果
+func (o *OIDCHandler) Callback(c *gin.Context) {
+	if errCode := c.Query("error"); errCode != "" {
+		log.Printf("单点登录被拒绝: %s %s", errCode, c.Query("error_description"))
+		result.Failed(c, result.UnauthorizedCode, "单点登录失败:"+errCode)
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		return
+	}
+
+	// 没有 Cookie 说明授权不是由这个浏览器发起的
+	binding, err := c.Cookie(oidcBindingCookie)
+	if err != nil || binding == "" {
+		result.Error(c, "", service.ErrInvalidOIDCState)
+		return
+	}
+	setOIDCBinding(c, "")
+
+	loginResp, err := o.oidcService.Callback(c.Request.Context(), code, state, binding)
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	audit.SetActor(c, loginResp.UserID)
+
+	result.Success(c, loginResp)
+}
+
+// LinkURL 为当前用户生成绑定外部身份的授权地址。
+// 响应同时下发 Cookie，授权地址只能在同一浏览器中打开，其他人打开时回调被拒绝
+func (o *OIDCHandler) LinkURL(c *gin.Context) {
+	authURL, binding, err := o.oidcService.AuthURL(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	setOIDCBinding(c, binding)
+
+	result.Success(c, &api.OIDCAuthURLResp{AuthURL: authURL})
+}
+
+// ListIdentities 查看当前用户绑定的外部身份
+func (o *OIDCHandler) ListIdentities(c *gin.Context) {
+	identities, err := o.oidcService.ListIdentities(c.GetUint("user_id"))
+	if err != nil {
+		result.Error(c, "", err)
+		return
+	}
+
+	result.Success(c, identities)
+}
+
+// Unlink 解除外部身份绑定
+func (o *OIDCHandler) Unlink(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+
+	if err := o.oidcService.Unlink(c.GetUint("user_id"), uint(id)); err != nil {
+		result.Error(c, "", err)
+		return
+	}
+	log.Printf("用户解除单点登录绑定: id=%d identity=%d", c.GetUint("user_id"), id)
+
+	result.Success(c, "解除绑定成功")
+}
+
+// ---------- 工具函数 ----------
+
+// setOIDCBinding 写入（value 为空时清除）授权绑定 Cookie。
+// SameSite=Lax 保证从提供方跳转回来的顶层 GET 请求会携带它，跨站的子请求不会
+func setOIDCBinding(c *gin.Context, value string) {
+	maxAge := 0
+	if value == "" {
+		maxAge = -1
+	}
+	secure := c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https"
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcBindingCookie, value, maxAge, oidcCookiePath, "", secure, true)
+}
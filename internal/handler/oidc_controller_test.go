@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/service"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock OIDCService --------
+type MockOIDCService struct {
+	mock.Mock
+}
+
+func (m *MockOIDCService) AuthURL(ctx context.Context, linkUserID uint) (string, string, error) {
+	args := m.Called(linkUserID)
+	return args.String(0), args.String(1), args.Error(2)
+}
+func (m *MockOIDCService) Callback(ctx context.Context, code, state, binding string) (*api.LoginResp, error) {
+	args := m.Called(code, state, binding)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.LoginResp), args.Error(1)
+}
+func (m *MockOIDCService) ListIdentities(userID uint) ([]api.IdentityResp, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]api.IdentityResp), args.Error(1)
+}
+func (m *MockOIDCService) Unlink(userID, id uint) error {
+	args := m.Called(userID, id)
+	return args.Error(0)
+}
+
+// -------- Tests --------
+func TestOIDCLogin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOIDCService)
+	handler := NewOIDCHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/auth/oidc/login", handler.Login)
+
+	mockService.On("AuthURL", uint(0)).Return("https://sso.example.com/authorize?state=abc", "b1", nil).Once()
+	w := performRequest(r, http.MethodGet, "/auth/oidc/login", nil)
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://sso.example.com/authorize?state=abc", w.Header().Get("Location"))
+
+	// 授权与浏览器绑定
+	cookie := w.Header().Get("Set-Cookie")
+	assert.Contains(t, cookie, "oidc_binding=b1")
+	assert.Contains(t, cookie, "Path=/auth/oidc")
+	assert.Contains(t, cookie, "HttpOnly")
+	assert.Contains(t, cookie, "SameSite=Lax")
+
+	mockService.On("AuthURL", uint(0)).Return("", "", service.ErrOIDCDisabled).Once()
+	w2 := performRequest(r, http.MethodGet, "/auth/oidc/login", nil)
+	assert.Contains(t, w2.Body.String(), "未启用单点登录")
+}
+
+func TestOIDCCallback(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOIDCService)
+	handler := NewOIDCHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/auth/oidc/callback", handler.Callback)
+
+	callback := func(query, binding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/auth/oidc/callback?"+query, nil)
+		if binding != "" {
+			req.AddCookie(&http.Cookie{Name: "oidc_binding", Value: binding})
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	mockService.On("Callback", "c1", "s1", "b1").Return(&api.LoginResp{Token: "token123", UserID: 7}, nil).Once()
+	w := callback("code=c1&state=s1", "b1")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "token123")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "oidc_binding=;")
+
+	mockService.On("Callback", "c1", "bad", "b1").Return(nil, service.ErrInvalidOIDCState).Once()
+	w2 := callback("code=c1&state=bad", "b1")
+	assert.Contains(t, w2.Body.String(), "登录请求已过期")
+
+	mockService.On("Callback", "c1", "s2", "b1").Return(nil, service.ErrIdentityLinked).Once()
+	w3 := callback("code=c1&state=s2", "b1")
+	assert.Contains(t, w3.Body.String(), "已绑定其他用户")
+
+	// 没有绑定 Cookie（在其他浏览器中完成授权）时直接拒绝
+	w6 := callback("code=c1&state=s1", "")
+	assert.Equal(t, http.StatusBadRequest, w6.Code)
+	assert.Contains(t, w6.Body.String(), `"error_code":"invalid_oidc_state"`)
+
+	// 提供方返回错误
+	w4 := performRequest(r, http.MethodGet, "/auth/oidc/callback?error=access_denied&state=s1", nil)
+	assert.Contains(t, w4.Body.String(), "access_denied")
+
+	// 缺少参数
+	w5 := performRequest(r, http.MethodGet, "/auth/oidc/callback?state=s1", nil)
+	assert.Contains(t, w5.Body.String(), "缺少必要参数")
+
+	mockService.AssertExpectations(t)
+}
+
+func TestOIDCLinkAndUnlink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOIDCService)
+	handler := NewOIDCHandler(mockService)
+
+	r := gin.Default()
+	r.Use(asAdmin(3))
+	r.GET("/api/me/oidc/link", handler.LinkURL)
+	r.GET("/api/me/oidc/identities", handler.ListIdentities)
+	r.DELETE("/api/me/oidc/identities/:id", handler.Unlink)
+
+	mockService.On("AuthURL", uint(3)).Return("https://sso.example.com/authorize?state=link", "b3", nil).Once()
+	w := performRequest(r, http.MethodGet, "/api/me/oidc/link", nil)
+	assert.Contains(t, w.Body.String(), "state=link")
+	assert.Contains(t, w.Header().Get("Set-Cookie"), "oidc_binding=b3")
+
+	mockService.On("ListIdentities", uint(3)).Return([]api.IdentityResp{{ID: 1, Subject: "staff-001"}}, nil).Once()
+	w2 := performRequest(r, http.MethodGet, "/api/me/oidc/identities", nil)
+	assert.Contains(t, w2.Body.String(), "staff-001")
+
+	mockService.On("Unlink", uint(3), uint(1)).Return(nil).Once()
+	w3 := performRequest(r, http.MethodDelete, "/api/me/oidc/identities/1", nil)
+	assert.Contains(t, w3.Body.String(), "解除绑定成功")
+
+	mockService.On("Unlink", uint(3), uint(2)).Return(service.ErrLastIdentity).Once()
+	w4 := performRequest(r, http.MethodDelete, "/api/me/oidc/identities/2", nil)
+	assert.Contains(t, w4.Body.String(), "设置本地密码")
+
+	w5 := performRequest(r, http.MethodDelete, "/api/me/oidc/identities/abc", nil)
+	assert.Contains(t, w5.Body.String(), "ID格式错误")
+
+	mockService.AssertExpectations(t)
+}
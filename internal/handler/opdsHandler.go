@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/opds"
+	"LibraryManagement/internal/service"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OPDS 目录的路径，feed 中的链接使用以 / 开头的相对地址
+const (
+	opdsRoot        = "/opds"
+	opdsNew         = "/opds/new"
+	opdsAuthors     = "/opds/authors"
+	opdsAuthorBooks = "/opds/authors/books"
+	opdsSearch      = "/opds/search"
+	opdsOpenSearch  = "/opds/opensearch.xml"
+
+	opdsIDPrefix = "urn:librarymanagement:opds:"
+)
+
+type OPDSHandler struct {
+	opdsService service.OPDSService
+}
+
+func NewOPDSHandler(opdsService service.OPDSService) *OPDSHandler {
+	return &OPDSHandler{opdsService: opdsService}
+}
+
+// Root 导航 feed：最新上架、按作者浏览和搜索
+func (o *OPDSHandler) Root(c *gin.Context) {
+	lang := result.Lang(c)
+	now := time.Now()
+	feed := opds.NewFeed(opdsIDPrefix+"root", i18n.T(lang, "图书馆目录"), opdsRoot, opds.NavigationType, opdsRoot, now)
+	feed.AddLink(opds.RelSearch, opdsOpenSearch, opds.OpenSearchType, i18n.T(lang, "搜索书目"))
+
+	navigation := func(id, title, content, href, kind, rel string) opds.Entry {
+		return opds.Entry{
+			Title:   i18n.T(lang, title),
+			ID:      opdsIDPrefix + id,
+			Updated: opds.FormatTime(now),
+			Content: &opds.Text{Type: "text", Value: i18n.T(lang, content)},
+			Links:   []opds.Link{{Rel: rel, Href: href, Type: kind}},
+		}
+	}
+	feed.Entries = []opds.Entry{
+		navigation("new", "最新上架", "按上架时间倒序浏览全部书籍", opdsNew, opds.AcquisitionType, opds.RelNew),
+		navigation("authors", "按作者浏览", "按作者分类浏览书籍", opdsAuthors, opds.NavigationType, opds.RelSubsection),
+	}
+	writeOPDS(c, opds.NavigationType, feed)
+}
+
+// NewArrivals 获取 feed：全部书籍，新上架的在前
+func (o *OPDSHandler) NewArrivals(c *gin.Context) {
+	req := &api.OPDSPageReq{}
+	if !bindOPDS(c, req) {
+		return
+	}
+	books, err := o.opdsService.NewArrivals(req.Page)
+	if err != nil {
+		result.Error(c, "OPDS 目录查询失败", err)
+		return
+	}
+
+	feed := bookFeed(c, "new", i18n.T(result.Lang(c), "最新上架"), opdsNew, nil, books)
+	feed.AddLink(opds.RelUp, opdsRoot, opds.NavigationType, "")
+	writeOPDS(c, opds.AcquisitionType, feed)
+}
+
+// Authors 导航 feed：作者列表，每位作者指向其书籍
+func (o *OPDSHandler) Authors(c *gin.Context) {
+	req := &api.OPDSPageReq{}
+	if !bindOPDS(c, req) {
+		return
+	}
+	authors, err := o.opdsService.Authors(req.Page)
+	if err != nil {
+		result.Error(c, "OPDS 目录查询失败", err)
+		return
+	}
+
+	lang := result.Lang(c)
+	self := pageHref(opdsAuthors, nil, authors.Page)
+	feed := opds.NewFeed(opdsIDPrefix+"authors", i18n.T(lang, "按作者浏览"), self, opds.NavigationType, opdsRoot, time.Now())
+	feed.AddLink(opds.RelUp, opdsRoot, opds.NavigationType, "")
+	feed.AddLink(opds.RelSearch, opdsOpenSearch, opds.OpenSearchType, "")
+	feed.Paginate(opdsAuthors, nil, opds.NavigationType, authors.Page, authors.PageSize, authors.Total)
+	for _, author := range authors.Authors {
+		feed.Entries = append(feed.Entries, opds.Entry{
+			Title:   authorTitle(author.Author, author.Disambiguation),
+			ID:      opdsIDPrefix + "author:" + strconv.FormatUint(uint64(author.AuthorID), 10),
+			Updated: feed.Updated,
+			Content: &opds.Text{Type: "text", Value: i18n.T(lang, fmt.Sprintf("共 %d 本", author.Count))},
+			Links:   []opds.Link{{Rel: opds.RelSubsection, Href: authorHref(author.AuthorID), Type: opds.AcquisitionType}},
+		})
+	}
+	writeOPDS(c, opds.NavigationType, feed)
+}
+
+// AuthorBooks 获取 feed：某位作者的书籍
+func (o *OPDSHandler) AuthorBooks(c *gin.Context) {
+	req := &api.OPDSAuthorReq{}
+	if !bindOPDS(c, req) {
+		return
+	}
+	author, books, err := o.opdsService.AuthorBooks(req.AuthorID, req.Page)
+	if err != nil {
+		result.Error(c, "OPDS 目录查询失败", err)
+		return
+	}
+
+	id := strconv.FormatUint(uint64(req.AuthorID), 10)
+	query := url.Values{"author_id": {id}}
+	feed := bookFeed(c, "author:"+id, authorTitle(author.Name, author.Disambiguation), opdsAuthorBooks, query, books)
+	feed.AddLink(opds.RelUp, opdsAuthors, opds.NavigationType, "")
+	writeOPDS(c, opds.AcquisitionType, feed)
+}
+
+// Search 获取 feed：关键词搜索结果，按相关度排序
+func (o *OPDSHandler) Search(c *gin.Context) {
+	req := &api.OPDSSearchReq{}
+	if !bindOPDS(c, req) {
+		return
+	}
+	books, err := o.opdsService.Search(req.Query, req.Page)
+	if err != nil {
+		result.Error(c, "OPDS 搜索失败", err)
+		return
+	}
+
+	title := i18n.T(result.Lang(c), fmt.Sprintf("搜索：%s", req.Query))
+	feed := bookFeed(c, "search:"+url.QueryEscape(req.Query), title, opdsSearch, url.Values{"q": {req.Query}}, books)
+	feed.AddLink(opds.RelUp, opdsRoot, opds.NavigationType, "")
+	writeOPDS(c, opds.AcquisitionType, feed)
+}
+
+// OpenSearch 搜索描述文档。模板使用绝对地址，部分阅读器不解析相对地址
+func (o *OPDSHandler) OpenSearch(c *gin.Context) {
+	lang := result.Lang(c)
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	template := scheme + "://" + c.Request.Host + opdsSearch + "?q={searchTerms}"
+	writeOPDS(c, opds.OpenSearchType, opds.NewOpenSearchDescription(i18n.T(lang, "图书馆目录"), i18n.T(lang, "搜索书名、作者、内容和简介"), template))
+}
+
+// Content 下载书籍正文（纯文本）
+func (o *OPDSHandler) Content(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return
+	}
+	book, err := o.opdsService.Content(uint(id))
+	if err != nil {
+		result.Error(c, "书籍下载失败", err)
+		return
+	}
+
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": book.Title + ".txt"}))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(book.Content))
+}
+
+// bindOPDS 读取并校验查询参数，失败时写入错误响应
+func bindOPDS(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return false
+	}
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return false
+	}
+	return true
+}
+
+// bookFeed 由书目生成分页的获取 feed，更新时间为其中最近修改的书籍
+func bookFeed(c *gin.Context, id, title, path string, query url.Values, books *api.BookFeedResp) *opds.Feed {
+	lang := result.Lang(c)
+	updated := time.Time{}
+	for _, book := range books.Books {
+		if book.UpdatedAt.After(updated) {
+			updated = book.UpdatedAt
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	feed := opds.NewFeed(opdsIDPrefix+id, title, pageHref(path, query, books.Page), opds.AcquisitionType, opdsRoot, updated)
+	feed.AddLink(opds.RelSearch, opdsOpenSearch, opds.OpenSearchType, "")
+	feed.Paginate(path, query, opds.AcquisitionType, books.Page, books.PageSize, books.Total)
+	for _, book := range books.Books {
+		entry := opds.Entry{
+			Title:      book.Title,
+			ID:         fmt.Sprintf("urn:librarymanagement:book:%d", book.ID),
+			Updated:    opds.FormatTime(book.UpdatedAt),
+			Identifier: "urn:isbn:" + book.ISBN,
+		}
+		// 著者及其全部书籍的链接；没有贡献者记录的旧书籍只列出 author
+		for _, contributor := range book.Contributors {
+			if contributor.Role != model.ContributorAuthor {
+				continue
+			}
+			entry.Authors = append(entry.Authors, opds.Person{Name: contributor.Name})
+			entry.Links = append(entry.Links, opds.Link{
+				Rel: "related", Href: authorHref(contributor.AuthorID), Type: opds.AcquisitionType,
+				Title: i18n.T(lang, fmt.Sprintf("%s的全部书籍", contributor.Name)),
+			})
+		}
+		if len(entry.Authors) == 0 && book.Author != "" {
+			entry.Authors = []opds.Person{{Name: book.Author}}
+		}
+		if book.Summary != "" {
+			entry.Summary = &opds.Text{Type: "text", Value: book.Summary}
+		}
+		if book.HasContent {
+			entry.Links = append(entry.Links, opds.Link{Rel: opds.RelAcquisition, Href: fmt.Sprintf("%s/books/%d/content", opdsRoot, book.ID), Type: "text/plain"})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return feed
+}
+
+func pageHref(path string, query url.Values, page int) string {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	if page > 1 {
+		q.Set("page", strconv.Itoa(page))
+	}
+	if len(q) == 0 {
+		return path
+	}
+	return path + "?" + q.Encode()
+}
+
+func authorHref(authorID uint) string {
+	return pageHref(opdsAuthorBooks, url.Values{"author_id": {strconv.FormatUint(uint64(authorID), 10)}}, 1)
+}
+
+// authorTitle 作者的显示名称，同名作者以说明区分
+func authorTitle(name, disambiguation string) string {
+	if disambiguation == "" {
+		return name
+	}
+	return fmt.Sprintf("%s（%s）", name, disambiguation)
+}
+
+func writeOPDS(c *gin.Context, contentType string, v interface{}) {
+	data, err := xml.Marshal(v)
+	if err != nil {
+		result.Error(c, "OPDS 目录查询失败", err)
+		return
+	}
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), data...))
+}
@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/opds"
+	"LibraryManagement/internal/service"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock OPDSService --------
+type MockOPDSService struct {
+	mock.Mock
+}
+
+func (m *MockOPDSService) NewArrivals(page int) (*api.BookFeedResp, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookFeedResp), args.Error(1)
+}
+func (m *MockOPDSService) Authors(page int) (*api.AuthorListResp, error) {
+	args := m.Called(page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.AuthorListResp), args.Error(1)
+}
+func (m *MockOPDSService) AuthorBooks(authorID uint, page int) (*api.AuthorInfoResp, *api.BookFeedResp, error) {
+	args := m.Called(authorID, page)
+	if args.Get(0) == nil {
+		return nil, nil, args.Error(2)
+	}
+	return args.Get(0).(*api.AuthorInfoResp), args.Get(1).(*api.BookFeedResp), args.Error(2)
+}
+func (m *MockOPDSService) Search(query string, page int) (*api.BookFeedResp, error) {
+	args := m.Called(query, page)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookFeedResp), args.Error(1)
+}
+func (m *MockOPDSService) Content(id uint) (*api.BookInfoResp, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.BookInfoResp), args.Error(1)
+}
+
+// -------- Tests --------
+func TestOPDSFeeds(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOPDSService)
+	h := NewOPDSHandler(mockService)
+	r := gin.Default()
+	r.GET("/opds", h.Root)
+	r.GET("/opds/new", h.NewArrivals)
+	r.GET("/opds/authors", h.Authors)
+	r.GET("/opds/authors/books", h.AuthorBooks)
+	r.GET("/opds/search", h.Search)
+	r.GET("/opds/opensearch.xml", h.OpenSearch)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+	updated := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	books := &api.BookFeedResp{
+		Books: []api.BookFeedItem{
+			{ID: 7, Title: "Go 编程", ISBN: "9787111111111", Author: "张三", Summary: "入门", UpdatedAt: updated, HasContent: true,
+				Contributors: []api.BookContributor{{AuthorID: 3, Name: "张三", Role: "author"}, {AuthorID: 4, Name: "李四", Role: "translator"}}},
+			{ID: 8, Title: "无正文", ISBN: "9787222222222", Author: "王五", UpdatedAt: updated.Add(-time.Hour)},
+		},
+		Total: 45, Page: 2, PageSize: 20,
+	}
+
+	t.Run("root", func(t *testing.T) {
+		w := performRequest(r, http.MethodGet, "/opds", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, opds.NavigationType, w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`))
+		assert.Contains(t, body, `<link rel="search" href="/opds/opensearch.xml" type="application/opensearchdescription+xml" title="搜索书目"></link>`)
+		assert.Contains(t, body, `<link rel="http://opds-spec.org/sort/new" href="/opds/new" type="`+opds.AcquisitionType+`"></link>`)
+		assert.Contains(t, body, `<link rel="subsection" href="/opds/authors" type="`+opds.NavigationType+`"></link>`)
+	})
+
+	t.Run("new_arrivals", func(t *testing.T) {
+		defer reset()
+		mockService.On("NewArrivals", 2).Return(books, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/opds/new?page=2", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, opds.AcquisitionType, w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		// feed 的更新时间为最近修改的书籍
+		assert.Contains(t, body, `<id>urn:librarymanagement:opds:new</id><title>最新上架</title><updated>2026-03-01T08:00:00Z</updated>`)
+		assert.Contains(t, body, `<link rel="self" href="/opds/new?page=2"`)
+		assert.Contains(t, body, `<link rel="next" href="/opds/new?page=3"`)
+		assert.Contains(t, body, `<opensearch:totalResults>45</opensearch:totalResults><opensearch:itemsPerPage>20</opensearch:itemsPerPage><opensearch:startIndex>21</opensearch:startIndex>`)
+		assert.Contains(t, body, `<entry><title>Go 编程</title><id>urn:librarymanagement:book:7</id><updated>2026-03-01T08:00:00Z</updated><author><name>张三</name></author><dc:identifier>urn:isbn:9787111111111</dc:identifier><summary type="text">入门</summary>`)
+		assert.Contains(t, body, `<link rel="http://opds-spec.org/acquisition" href="/opds/books/7/content" type="text/plain"></link>`)
+		// 著者链接到按作者 ID 浏览，译者不列为作者
+		assert.Contains(t, body, `<link rel="related" href="/opds/authors/books?author_id=3" type="`+opds.AcquisitionType+`" title="张三的全部书籍"></link>`)
+		assert.NotContains(t, body, `author_id=4`)
+		// 没有贡献者记录的书籍只列出作者，不提供链接
+		assert.Contains(t, body, `<author><name>王五</name></author>`)
+		// 没有正文的书籍不提供获取链接
+		assert.NotContains(t, body, `/opds/books/8/content`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("authors", func(t *testing.T) {
+		defer reset()
+		mockService.On("Authors", 0).Return(&api.AuthorListResp{
+			Authors: []api.AuthorCount{{AuthorID: 3, Author: "张 三", Count: 3}, {AuthorID: 9, Author: "张 三", Disambiguation: "1970-", Count: 1}},
+			Total:   2, Page: 1, PageSize: 20,
+		}, nil).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/opds/authors", nil)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		body := w.Body.String()
+		assert.Contains(t, body, `<title>Browse by author</title>`)
+		assert.Contains(t, body, `<title>张 三</title><id>urn:librarymanagement:opds:author:3</id>`)
+		assert.Contains(t, body, `<content type="text">3 books</content><link rel="subsection" href="/opds/authors/books?author_id=3"`)
+		// 同名作者以说明区分
+		assert.Contains(t, body, `<title>张 三（1970-）</title><id>urn:librarymanagement:opds:author:9</id>`)
+	})
+
+	t.Run("author_books", func(t *testing.T) {
+		defer reset()
+		mockService.On("AuthorBooks", uint(3), 0).Return(&api.AuthorInfoResp{ID: 3, Name: "张三"}, &api.BookFeedResp{Page: 1, PageSize: 20}, nil).Once()
+		mockService.On("AuthorBooks", uint(99), 0).Return(nil, nil, service.ErrAuthorNotFound).Once()
+
+		w := performRequest(r, http.MethodGet, "/opds/authors/books?author_id=3", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<id>urn:librarymanagement:opds:author:3</id><title>张三</title>`)
+		assert.Contains(t, w.Body.String(), `<link rel="self" href="/opds/authors/books?author_id=3"`)
+		assert.Contains(t, w.Body.String(), `<link rel="up" href="/opds/authors"`)
+
+		w = performRequest(r, http.MethodGet, "/opds/authors/books?author_id=99", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		// 缺少 author_id 参数
+		w = performRequest(r, http.MethodGet, "/opds/authors/books", nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		mockService.AssertNumberOfCalls(t, "AuthorBooks", 2)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		defer reset()
+		mockService.On("Search", "go", 0).Return(books, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/opds/search?q=go", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<title>搜索：go</title>`)
+		assert.Contains(t, w.Body.String(), `<link rel="next" href="/opds/search?page=3&amp;q=go"`)
+	})
+
+	t.Run("search_unavailable", func(t *testing.T) {
+		defer reset()
+		mockService.On("Search", "go", 0).Return(nil, service.ErrSearchUnavailable).Once()
+
+		w := performRequest(r, http.MethodGet, "/opds/search?q=go", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+
+	t.Run("opensearch", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/opds/opensearch.xml", nil)
+		req.Host = "library.example.com"
+		req.Header.Set("X-Forwarded-Proto", "https")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, opds.OpenSearchType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), `template="https://library.example.com/opds/search?q={searchTerms}"`)
+	})
+}
+
+func TestOPDSContent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockOPDSService)
+	h := NewOPDSHandler(mockService)
+	r := gin.Default()
+	r.GET("/opds/books/:id/content", h.Content)
+
+	mockService.On("Content", uint(7)).Return(&api.BookInfoResp{ID: 7, Title: "Go 编程", Content: "正文"}, nil).Once()
+	w := performRequest(r, http.MethodGet, "/opds/books/7/content", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename*=utf-8''Go%20%E7%BC%96%E7%A8%8B.txt`, w.Header().Get("Content-Disposition"))
+	assert.Equal(t, "正文", w.Body.String())
+
+	mockService.On("Content", uint(8)).Return(nil, service.ErrBookNoContent).Once()
+	w = performRequest(r, http.MethodGet, "/opds/books/8/content", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"book_content_not_found"`)
+
+	w = performRequest(r, http.MethodGet, "/opds/books/abc/content", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/jsonpatch"
+	"LibraryManagement/internal/model"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+)
+
+// 局部更新支持的补丁格式
+const (
+	mergePatchType = "application/merge-patch+json" // RFC 7396
+	jsonPatchType  = "application/json-patch+json"  // RFC 6902
+
+	acceptPatch = mergePatchType + ", " + jsonPatchType
+)
+
+// 局部更新的大小限制：JSON Patch 的 copy 可以让文档成倍增长，必须限制操作数和结果大小
+const (
+	maxPatchBodySize     = 1 << 20 // 请求体最大 1 MiB
+	maxPatchOperations   = 100     // JSON Patch 最多 100 个操作
+	maxPatchDocumentSize = 4 << 20 // 应用补丁后的书籍内容最大 4 MiB
+)
+
+// 局部更新相关的错误
+var (
+	errUnsupportedPatch = apperr.New(apperr.KindUnsupportedMediaType, "unsupported_patch_type", "不支持的补丁格式，请使用 application/merge-patch+json 或 application/json-patch+json")
+	errInvalidPatch     = apperr.Validation("invalid_patch", "补丁格式错误")
+	errPatchPath        = apperr.Validation("invalid_patch_path", "补丁路径不存在")
+	errPatchTestFailed  = apperr.Conflict("patch_test_failed", "补丁 test 操作未通过")
+	errPatchTooLarge    = apperr.New(apperr.KindPayloadTooLarge, "patch_too_large", "补丁或应用补丁后的书籍内容过大")
+)
+
+// patchType 根据 Content-Type 确定补丁格式，application/json 按 merge patch 处理
+func patchType(contentType string) (string, error) {
+	switch contentType {
+	case mergePatchType, "application/json":
+		return mergePatchType, nil
+	case jsonPatchType:
+		return jsonPatchType, nil
+	}
+	return "", errUnsupportedPatch
+}
+
+// splitPatchVersion 取出 merge patch 中的 version 字段作为版本前置条件，其余内容作为补丁
+func splitPatchVersion(patch []byte) ([]byte, *int, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &fields); err != nil {
+		// 非对象补丁交由后续步骤报错
+		return patch, nil, nil
+	}
+
+	raw, ok := fields["version"]
+	if !ok {
+		return patch, nil, nil
+	}
+	delete(fields, "version")
+
+	var version *int
+	if err := json.Unmarshal(raw, &version); err != nil || (version != nil && *version < 1) {
+		return nil, nil, errInvalidPatch.WithMessage("补丁中的 version 必须为正整数")
+	}
+
+	rest, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rest, version, nil
+}
+
+// applyBookPatch 把补丁应用到书籍当前内容上，得到更新后的完整书籍信息
+func applyBookPatch(kind string, current *api.BookInfoResp, patch []byte) (*api.BookInfoReq, error) {
+	doc, err := json.Marshal(bookInfoReq(current))
+	if err != nil {
+		return nil, err
+	}
+
+	var patched []byte
+	if kind == jsonPatchType {
+		// 正文已经很长的书籍，允许在原有大小上再增加一个请求体的内容
+		limits := jsonpatch.Limits{MaxOperations: maxPatchOperations, MaxSize: max(maxPatchDocumentSize, len(doc)+maxPatchBodySize)}
+		patched, err = jsonpatch.Apply(doc, patch, limits)
+	} else {
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	}
+	switch {
+	case errors.Is(err, jsonpatch.ErrTooLarge):
+		return nil, errPatchTooLarge.Wrap(err)
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return nil, errPatchTestFailed.Wrap(err)
+	case errors.Is(err, jsonpatch.ErrPathNotFound):
+		return nil, errPatchPath.Wrap(err)
+	case err != nil:
+		return nil, errInvalidPatch.Wrap(err)
+	}
+
+	// 补丁不能引入书籍之外的字段
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	req := &api.BookInfoReq{}
+	if err := dec.Decode(req); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, err
+		}
+		return nil, errInvalidPatch.Wrap(err)
+	}
+	return req, nil
+}
+
+// bookInfoReq 书籍当前内容，作为补丁的目标文档
+func bookInfoReq(book *api.BookInfoResp) *api.BookInfoReq {
+	return &api.BookInfoReq{
+		Title:   book.Title,
+		Count:   book.Count,
+		ISBN:    book.ISBN,
+		Author:  book.Author,
+		Content: book.Content,
+		Summary: book.Summary,
+
+		Contributors: book.Contributors,
+		CategoryIDs:  categoryRefIDs(book.Categories),
+		Tags:         book.Tags,
+	}
+}
+
+func categoryRefIDs(categories []api.CategoryRef) []uint {
+	if len(categories) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(categories))
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// changedBookFields 对比补丁前后的内容，返回实际修改的字段（JSON 字段名）。
+// 贡献者没有修改时清空 after.Contributors，由服务根据 author 是否变化决定是否重新拆分；
+// 分类和标签没有修改时同样清空，删除（补丁中为 null）视为清空全部
+func changedBookFields(before, after *api.BookInfoReq) []string {
+	fields := bookSnapshot(before).ChangedFields(bookSnapshot(after))
+	if reflect.DeepEqual(before.Contributors, after.Contributors) {
+		after.Contributors = nil
+	} else {
+		fields = append(fields, "contributors")
+	}
+	if slices.Equal(before.CategoryIDs, after.CategoryIDs) {
+		after.CategoryIDs = nil
+	} else {
+		if after.CategoryIDs == nil {
+			after.CategoryIDs = []uint{}
+		}
+		fields = append(fields, "category_ids")
+	}
+	if slices.Equal(before.Tags, after.Tags) {
+		after.Tags = nil
+	} else {
+		if after.Tags == nil {
+			after.Tags = []string{}
+		}
+		fields = append(fields, "tags")
+	}
+	return fields
+}
+
+func bookSnapshot(req *api.BookInfoReq) model.BookSnapshot {
+	return model.BookSnapshot{
+		Title:   req.Title,
+		Count:   req.Count,
+		ISBN:    req.ISBN,
+		Author:  req.Author,
+		Content: req.Content,
+		Summary: req.Summary,
+	}
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/service"
+	"LibraryManagement/internal/sru"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SRUHandler struct {
+	sruService service.SRUService
+}
+
+func NewSRUHandler(sruService service.SRUService) *SRUHandler {
+	return &SRUHandler{sruService: sruService}
+}
+
+// Handle SRU 2.0 请求入口，参数在查询字符串（GET）或表单（POST）中。
+// 有 query 参数时为 searchRetrieve，否则为 explain。
+// 协议错误以 HTTP 200 在 <diagnostics> 中返回，搜索服务不可用等系统错误返回 JSON 错误
+func (s *SRUHandler) Handle(c *gin.Context) {
+	if err := s.sruService.Enabled(); err != nil {
+		result.Error(c, "SRU 请求失败", err)
+		return
+	}
+	if err := c.Request.ParseForm(); err != nil {
+		result.Failed(c, result.RequiredCode, "请求参数格式错误")
+		return
+	}
+	args := c.Request.Form
+	fmt.Println("收到请求---SRU: ", args.Encode())
+
+	req, diag := sru.ParseRequest(args)
+	if diag != nil {
+		// 按请求的操作选择响应类型，无法判断时使用 explain
+		var resp interface{ Fail(*sru.Diagnostic) } = sru.NewExplainResponse()
+		if _, ok := args["query"]; ok || args.Get("operation") == sru.OperationSearchRetrieve {
+			resp = sru.NewSearchRetrieveResponse()
+		}
+		resp.Fail(translateDiagnostic(c, diag))
+		writeSRU(c, resp)
+		return
+	}
+
+	if req.Operation == sru.OperationExplain {
+		resp := sru.NewExplainResponse()
+		record, err := s.sruService.Explain()
+		if err != nil {
+			log.Printf("SRU 请求失败: %v", err)
+			result.Error(c, "SRU 请求失败", err)
+			return
+		}
+		resp.Record = record
+		writeSRU(c, resp)
+		return
+	}
+
+	resp, err := s.sruService.SearchRetrieve(req)
+	if errors.As(err, &diag) {
+		resp = sru.NewSearchRetrieveResponse()
+		resp.Fail(translateDiagnostic(c, diag))
+	} else if err != nil {
+		log.Printf("SRU 请求失败: %v", err)
+		result.Error(c, "SRU 请求失败", err)
+		return
+	}
+	writeSRU(c, resp)
+}
+
+// translateDiagnostic 按请求语言翻译诊断说明
+func translateDiagnostic(c *gin.Context, diag *sru.Diagnostic) *sru.Diagnostic {
+	translated := *diag
+	translated.Message = i18n.T(result.Lang(c), diag.Message)
+	return &translated
+}
+
+func writeSRU(c *gin.Context, resp interface{}) {
+	data, err := xml.Marshal(resp)
+	if err != nil {
+		result.Error(c, "SRU 请求失败", err)
+		return
+	}
+	c.Data(http.StatusOK, "application/sru+xml; charset=utf-8", append([]byte(xml.Header), data...))
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"LibraryManagement/internal/service"
+	"LibraryManagement/internal/sru"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// -------- Mock SRUService --------
+type MockSRUService struct {
+	mock.Mock
+}
+
+func (m *MockSRUService) Enabled() error {
+	return m.Called().Error(0)
+}
+func (m *MockSRUService) Explain() (*sru.Record, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sru.Record), args.Error(1)
+}
+func (m *MockSRUService) SearchRetrieve(req *sru.Request) (*sru.SearchRetrieveResponse, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*sru.SearchRetrieveResponse), args.Error(1)
+}
+
+// -------- Tests --------
+func TestSRUHandle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockSRUService)
+	h := NewSRUHandler(mockService)
+	r := gin.Default()
+	r.GET("/sru", h.Handle)
+	r.POST("/sru", h.Handle)
+
+	reset := func() { mockService.ExpectedCalls, mockService.Calls = nil, nil }
+
+	t.Run("search_retrieve", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+		resp := sru.NewSearchRetrieveResponse()
+		resp.NumberOfRecords = 3
+		resp.Records = &sru.Records{Items: []sru.Record{sru.NewRecord(sru.SchemaDC, sru.EscapingXML, []byte("<srw_dc:dc/>"), 2)}}
+		resp.NextRecordPosition = 3
+		mockService.On("SearchRetrieve", mock.MatchedBy(func(req *sru.Request) bool {
+			return req.Query == `dc.title = "go 编程"` && req.StartRecord == 2 && req.MaximumRecords == 1
+		})).Return(resp, nil).Once()
+
+		w := performRequest(r, http.MethodGet, "/sru?query=dc.title+%3D+%22go+%E7%BC%96%E7%A8%8B%22&startRecord=2&maximumRecords=1", nil)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/sru+xml; charset=utf-8", w.Header().Get("Content-Type"))
+		body := w.Body.String()
+		assert.True(t, strings.HasPrefix(body, `<?xml version="1.0" encoding="UTF-8"?>`))
+		assert.Contains(t, body, `<sruResponse:numberOfRecords>3</sruResponse:numberOfRecords>`)
+		assert.Contains(t, body, `<sruResponse:recordData><srw_dc:dc/></sruResponse:recordData><sruResponse:recordPosition>2</sruResponse:recordPosition>`)
+		assert.Contains(t, body, `<sruResponse:nextRecordPosition>3</sruResponse:nextRecordPosition>`)
+		mockService.AssertExpectations(t)
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+		record := sru.NewRecord(sru.ExplainNamespace, sru.EscapingXML, []byte("<zr:explain/>"), 0)
+		mockService.On("Explain").Return(&record, nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/sru", strings.NewReader("operation=explain"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<sruResponse:explainResponse xmlns:sruResponse="http://docs.oasis-open.org/ns/search-ws/sruResponse"><sruResponse:version>2.0</sruResponse:version><sruResponse:record><sruResponse:recordSchema>http://explain.z3950.org/dtd/2.0/</sruResponse:recordSchema>`)
+		assert.Contains(t, w.Body.String(), `<sruResponse:recordData><zr:explain/></sruResponse:recordData>`)
+	})
+
+	t.Run("diagnostic", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+		mockService.On("SearchRetrieve", mock.Anything).Return(nil, sru.NewDiagnostic(sru.CodeUnsupportedIndex, "dc.publisher")).Once()
+
+		req := httptest.NewRequest(http.MethodGet, "/sru?query=dc.publisher%3Dx", nil)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// 协议错误以 HTTP 200 返回，诊断说明按请求语言翻译
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `<sruResponse:numberOfRecords>0</sruResponse:numberOfRecords><sruResponse:diagnostics>`)
+		assert.Contains(t, w.Body.String(), `<diag:uri>info:srw/diagnostic/1/16</diag:uri><diag:details>dc.publisher</diag:details><diag:message>unsupported index</diag:message>`)
+	})
+
+	t.Run("malformed_query", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+
+		req := httptest.NewRequest(http.MethodGet, "/sru?query=%zz", nil)
+		req.Header.Set("Accept-Language", "en")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		// 无法解析的参数不是协议错误，返回 JSON
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"message":"malformed request parameters"`)
+	})
+
+	t.Run("bad_parameter", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+
+		w := performRequest(r, http.MethodGet, "/sru?query=go&startRecord=0", nil)
+		assert.Contains(t, w.Body.String(), `<sruResponse:searchRetrieveResponse`)
+		assert.Contains(t, w.Body.String(), `<diag:uri>info:srw/diagnostic/1/6</diag:uri><diag:details>startRecord</diag:details>`)
+
+		w = performRequest(r, http.MethodGet, "/sru?operation=scan", nil)
+		assert.Contains(t, w.Body.String(), `<sruResponse:explainResponse`)
+		assert.Contains(t, w.Body.String(), `<diag:uri>info:srw/diagnostic/1/4</diag:uri>`)
+		mockService.AssertNotCalled(t, "SearchRetrieve", mock.Anything)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(service.ErrSRUDisabled)
+
+		w := performRequest(r, http.MethodGet, "/sru?query=go", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), `"error_code":"sru_disabled"`)
+	})
+
+	t.Run("search_unavailable", func(t *testing.T) {
+		defer reset()
+		mockService.On("Enabled").Return(nil)
+		mockService.On("SearchRetrieve", mock.Anything).Return(nil, service.ErrSearchUnavailable).Once()
+
+		w := performRequest(r, http.MethodGet, "/sru?query=go", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	})
+}
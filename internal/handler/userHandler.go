@@ -3,12 +3,13 @@ package handler
 import (
 	"LibraryManagement/internal/api"
 	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/i18n"
 	"LibraryManagement/internal/service"
-	"errors"
 	"log"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 type UserHandler struct {
@@ -22,24 +23,21 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 // Login 登入
 func (u *UserHandler) Login(c *gin.Context) {
 	loginReq := &api.LoginReq{}
-	err := c.BindJSON(loginReq)
+	err := c.ShouldBindJSON(loginReq)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "请求数据格式错误")
+		result.BindFailed(c, err)
 		return
 	}
 	log.Println("收到请求---登入: ", loginReq)
+	audit.SetTarget(c, "user", loginReq.Username)
 
 	// 调用服务层
 	loginResp, err := u.userService.Login(loginReq)
 	if err != nil {
-		switch {
-		case errors.Is(err, service.ErrInvalidCredentials):
-			result.Failed(c, result.FailedCode, "用户名或密码错误")
-		default:
-			result.Failed(c, result.FailedCode, "系统错误")
-		}
+		result.Error(c, "", err)
 		return
 	}
+	audit.SetActor(c, loginResp.UserID)
 
 	result.Success(c, loginResp)
 }
@@ -47,9 +45,9 @@ func (u *UserHandler) Login(c *gin.Context) {
 // Register 注册
 func (u *UserHandler) Register(c *gin.Context) {
 	registerReq := &api.RegisterReq{}
-	err := c.BindJSON(registerReq)
+	err := c.ShouldBindJSON(registerReq)
 	if err != nil {
-		result.Failed(c, result.FailedCode, "请求数据格式错误")
+		result.BindFailed(c, err)
 		return
 	}
 	log.Println("收到请求---注册: ", registerReq)
@@ -59,25 +57,253 @@ func (u *UserHandler) Register(c *gin.Context) {
 	}
 
 	// 验证器会根据结构体里写的 validate 标签，自动检查字段是否符合规则
-	err = validator.New().Struct(registerReq)
+	err = i18n.Validate.Struct(registerReq)
 	if err != nil {
-		result.Failed(c, result.RequiredCode, result.GetMessage(result.RequiredCode))
+		result.ValidationFailed(c, err)
 		return
 	}
 
 	err = u.userService.CreateUser(registerReq)
 
 	if err != nil {
-		// 区分错误类型
-		switch {
-		case errors.Is(err, service.ErrUserExists):
-			result.Failed(c, result.FailedCode, "用户名已存在")
-		default:
-			result.Failed(c, result.FailedCode, registerReq.Role+"创建失败")
-		}
+		result.Error(c, registerReq.Role+"创建失败", err)
 		return
 	}
 
+	audit.SetTarget(c, "user", registerReq.Username)
+	audit.SetChange(c, nil, gin.H{"username": registerReq.Username, "role": registerReq.Role})
+
 	result.Success(c, registerReq.Role+"创建成功")
 
 }
+
+// GetProfile 获取当前用户资料
+func (u *UserHandler) GetProfile(c *gin.Context) {
+	profile, err := u.userService.GetProfile(c.GetUint("user_id"))
+	if err != nil {
+		result.Error(c, "用户资料查询失败", err)
+		return
+	}
+
+	result.Success(c, profile)
+}
+
+// UpdateProfile 修改当前用户资料
+func (u *UserHandler) UpdateProfile(c *gin.Context) {
+	req := &api.ProfileUpdateReq{}
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	err = i18n.Validate.Struct(req)
+	if err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	profile, err := u.userService.UpdateProfile(c.GetUint("user_id"), req)
+	if err != nil {
+		result.Error(c, "用户资料修改失败", err)
+		return
+	}
+
+	result.Success(c, profile)
+}
+
+// ChangePassword 修改当前用户密码
+func (u *UserHandler) ChangePassword(c *gin.Context) {
+	req := &api.ChangePasswordReq{}
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	err = i18n.Validate.Struct(req)
+	if err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	userID := c.GetUint("user_id")
+	err = u.userService.ChangePassword(userID, req)
+	if err != nil {
+		result.Error(c, "密码修改失败", err)
+		return
+	}
+
+	result.Success(c, "密码修改成功，请重新登录")
+}
+
+// ForgotPassword 申请重置密码
+func (u *UserHandler) ForgotPassword(c *gin.Context) {
+	req := &api.ForgotPasswordReq{}
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	log.Println("收到请求---申请重置密码: ", req.Username)
+	audit.SetTarget(c, "user", req.Username)
+
+	err = i18n.Validate.Struct(req)
+	if err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	if err := u.userService.RequestPasswordReset(req); err != nil {
+		log.Printf("生成重置令牌失败: %v", err)
+		result.Error(c, "", err)
+		return
+	}
+
+	// 无论账号是否存在都返回相同提示
+	result.Success(c, "如果账号存在，重置令牌已发送")
+}
+
+// ResetPassword 使用重置令牌设置新密码
+func (u *UserHandler) ResetPassword(c *gin.Context) {
+	req := &api.ResetPasswordReq{}
+	err := c.ShouldBindJSON(req)
+	if err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+
+	err = i18n.Validate.Struct(req)
+	if err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	err = u.userService.ResetPassword(req)
+	if err != nil {
+		result.Error(c, "密码重置失败", err)
+		return
+	}
+
+	result.Success(c, "密码重置成功，请重新登录")
+}
+
+// ListUsers 管理员分页查询用户
+func (u *UserHandler) ListUsers(c *gin.Context) {
+	req := &api.UserListReq{}
+	if err := c.ShouldBindQuery(req); err != nil {
+		result.Failed(c, result.RequiredCode, "查询参数格式错误")
+		return
+	}
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	users, err := u.userService.ListUsers(req)
+	if err != nil {
+		result.Error(c, "用户查询失败", err)
+		return
+	}
+
+	result.Success(c, users)
+}
+
+// GetUser 管理员查看用户详情
+func (u *UserHandler) GetUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	user, err := u.userService.GetUser(id)
+	if err != nil {
+		result.Error(c, "用户查询失败", err)
+		return
+	}
+
+	result.Success(c, user)
+}
+
+// UpdateUser 管理员修改用户角色或启用状态
+func (u *UserHandler) UpdateUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+
+	req := &api.UserUpdateReq{}
+	if err := c.ShouldBindJSON(req); err != nil {
+		result.BindFailed(c, err)
+		return
+	}
+	log.Printf("收到请求---修改用户: id=%d", id)
+
+	if err := i18n.Validate.Struct(req); err != nil {
+		result.ValidationFailed(c, err)
+		return
+	}
+
+	var before *api.UserInfoResp
+	if audit.Active(c) {
+		before, _ = u.userService.GetUser(id)
+	}
+
+	user, err := u.userService.UpdateUser(c.GetUint("user_id"), id, req)
+	if err != nil {
+		result.Error(c, "用户修改失败", err)
+		return
+	}
+	audit.SetChange(c, before, user)
+
+	result.Success(c, user)
+}
+
+// DeleteUser 管理员软删除用户
+func (u *UserHandler) DeleteUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	log.Printf("收到请求---删除用户: id=%d", id)
+
+	if audit.Active(c) {
+		before, _ := u.userService.GetUser(id)
+		audit.SetChange(c, before, nil)
+	}
+
+	if err := u.userService.DeleteUser(c.GetUint("user_id"), id); err != nil {
+		result.Error(c, "用户删除失败", err)
+		return
+	}
+
+	result.Success(c, "用户删除成功")
+}
+
+// RestoreUser 管理员恢复已删除用户
+func (u *UserHandler) RestoreUser(c *gin.Context) {
+	id, ok := parseUserID(c)
+	if !ok {
+		return
+	}
+	log.Printf("收到请求---恢复用户: id=%d", id)
+
+	if err := u.userService.RestoreUser(id); err != nil {
+		result.Error(c, "用户恢复失败", err)
+		return
+	}
+
+	result.Success(c, "用户恢复成功")
+}
+
+// ---------- 工具函数 ----------
+
+func parseUserID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		result.Failed(c, result.RequiredCode, "ID格式错误")
+		return 0, false
+	}
+	return uint(id), true
+}
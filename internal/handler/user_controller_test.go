@@ -2,6 +2,7 @@ package handler
 
 import (
 	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/password"
 	"LibraryManagement/internal/service"
 	"encoding/json"
 	"errors"
@@ -26,6 +27,61 @@ func (m *MockUserService) CreateUser(req *api.RegisterReq) error {
 	args := m.Called(req)
 	return args.Error(0)
 }
+func (m *MockUserService) GetProfile(userID uint) (*api.ProfileResp, error) {
+	args := m.Called(userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.ProfileResp), args.Error(1)
+}
+func (m *MockUserService) UpdateProfile(userID uint, req *api.ProfileUpdateReq) (*api.ProfileResp, error) {
+	args := m.Called(userID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.ProfileResp), args.Error(1)
+}
+func (m *MockUserService) ChangePassword(userID uint, req *api.ChangePasswordReq) error {
+	args := m.Called(userID, req)
+	return args.Error(0)
+}
+func (m *MockUserService) RequestPasswordReset(req *api.ForgotPasswordReq) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+func (m *MockUserService) ResetPassword(req *api.ResetPasswordReq) error {
+	args := m.Called(req)
+	return args.Error(0)
+}
+func (m *MockUserService) ListUsers(req *api.UserListReq) (*api.UserListResp, error) {
+	args := m.Called(req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.UserListResp), args.Error(1)
+}
+func (m *MockUserService) GetUser(id uint) (*api.UserInfoResp, error) {
+	args := m.Called(id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.UserInfoResp), args.Error(1)
+}
+func (m *MockUserService) UpdateUser(operatorID, id uint, req *api.UserUpdateReq) (*api.UserInfoResp, error) {
+	args := m.Called(operatorID, id, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*api.UserInfoResp), args.Error(1)
+}
+func (m *MockUserService) DeleteUser(operatorID, id uint) error {
+	args := m.Called(operatorID, id)
+	return args.Error(0)
+}
+func (m *MockUserService) RestoreUser(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
 
 // -------- Tests --------
 func TestLogin(t *testing.T) {
@@ -38,7 +94,7 @@ func TestLogin(t *testing.T) {
 
 	// 成功
 	loginReq := api.LoginReq{Username: "alice", Password: "123456"}
-	loginResp := &api.LoginResp{Token: "token123"}
+	loginResp := &api.LoginResp{Token: "token123", Profile: &api.ProfileResp{DisplayName: "Alice"}}
 	mockService.On("Login", &loginReq).Return(loginResp, nil).Once()
 
 	body, _ := json.Marshal(loginReq)
@@ -46,6 +102,7 @@ func TestLogin(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "token123")
+	assert.Contains(t, w.Body.String(), `"display_name":"Alice"`)
 
 	// 失败：用户名或密码错误
 	mockService.On("Login", &loginReq).Return(&api.LoginResp{}, service.ErrInvalidCredentials).Once()
@@ -58,6 +115,11 @@ func TestLogin(t *testing.T) {
 	body3, _ := json.Marshal(loginReq)
 	w3 := performRequest(r, http.MethodPost, "/login", body3)
 	assert.Contains(t, w3.Body.String(), "系统错误")
+
+	// 失败：账号已禁用
+	mockService.On("Login", &loginReq).Return(&api.LoginResp{}, service.ErrUserDisabled).Once()
+	w4 := performRequest(r, http.MethodPost, "/login", body)
+	assert.Contains(t, w4.Body.String(), "账号已被禁用")
 }
 
 func TestRegister(t *testing.T) {
@@ -88,4 +150,237 @@ func TestRegister(t *testing.T) {
 	body3, _ := json.Marshal(registerReq)
 	w3 := performRequest(r, http.MethodPost, "/register", body3)
 	assert.Contains(t, w3.Body.String(), "创建失败")
+
+	// 失败：命中泄露密码库
+	mockService.On("CreateUser", &registerReq).Return(password.NewPolicyError("该密码已出现在公开泄露的密码库中，请更换")).Once()
+	w4 := performRequest(r, http.MethodPost, "/register", body)
+	assert.Contains(t, w4.Body.String(), "泄露的密码库")
+}
+
+func TestChangePassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/me/password", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	}, handler.ChangePassword)
+
+	req := api.ChangePasswordReq{OldPassword: "123456", NewPassword: "654321"}
+
+	// 成功
+	mockService.On("ChangePassword", uint(7), &req).Return(nil).Once()
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/me/password", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "密码修改成功")
+
+	// 失败：当前密码错误
+	mockService.On("ChangePassword", uint(7), &req).Return(service.ErrWrongPassword).Once()
+	w2 := performRequest(r, http.MethodPost, "/me/password", body)
+	assert.Contains(t, w2.Body.String(), "当前密码错误")
+
+	// 失败：新密码过短
+	short, _ := json.Marshal(api.ChangePasswordReq{OldPassword: "123456", NewPassword: "1"})
+	w3 := performRequest(r, http.MethodPost, "/me/password", short)
+	assert.Contains(t, w3.Body.String(), `"error_code":"validation_failed"`)
+
+	// 失败：不符合密码策略，返回具体原因
+	weak := &password.PolicyError{Violations: []string{"密码长度至少为 8 位", "密码不能包含用户名或与用户名过于相似"}}
+	mockService.On("ChangePassword", uint(7), &req).Return(weak).Once()
+	w4 := performRequest(r, http.MethodPost, "/me/password", body)
+	assert.Contains(t, w4.Body.String(), "密码长度至少为 8 位；密码不能包含用户名或与用户名过于相似")
+	assert.Contains(t, w4.Body.String(), `"code":400`)
+	mockService.AssertExpectations(t)
+}
+
+func TestForgotPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/password/forgot", handler.ForgotPassword)
+
+	req := api.ForgotPasswordReq{Username: "alice"}
+	mockService.On("RequestPasswordReset", &req).Return(nil).Once()
+
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/password/forgot", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "重置令牌已发送")
+
+	mockService.On("RequestPasswordReset", &req).Return(errors.New("db down")).Once()
+	w2 := performRequest(r, http.MethodPost, "/password/forgot", body)
+	assert.Contains(t, w2.Body.String(), "系统错误")
+}
+
+func TestResetPassword(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.POST("/password/reset", handler.ResetPassword)
+
+	req := api.ResetPasswordReq{Token: "abc", NewPassword: "newpass"}
+
+	mockService.On("ResetPassword", &req).Return(nil).Once()
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPost, "/password/reset", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "密码重置成功")
+
+	mockService.On("ResetPassword", &req).Return(service.ErrInvalidResetToken).Once()
+	w2 := performRequest(r, http.MethodPost, "/password/reset", body)
+	assert.Contains(t, w2.Body.String(), "重置令牌无效或已过期")
+
+	reused := password.NewPolicyError("不能与最近 5 次使用过的密码相同")
+	mockService.On("ResetPassword", &req).Return(reused).Once()
+	w3 := performRequest(r, http.MethodPost, "/password/reset", body)
+	assert.Contains(t, w3.Body.String(), "不能与最近 5 次使用过的密码相同")
+}
+
+// asAdmin 模拟 AuthMiddleware 写入的当前用户
+func asAdmin(id uint) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", id)
+		c.Set("user_role", "admin")
+		c.Next()
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/admin/users", handler.ListUsers)
+
+	disabled := true
+	req := &api.UserListReq{Username: "al", Role: "user", Disabled: &disabled, Page: 1, PageSize: 20}
+	resp := &api.UserListResp{Users: []api.UserInfoResp{{ID: 2, Username: "alice", Role: "user", Disabled: true}}, Total: 1}
+	mockService.On("ListUsers", req).Return(resp, nil).Once()
+
+	w := performRequest(r, http.MethodGet, "/admin/users?username=al&role=user&disabled=true&page=1&page_size=20", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "alice")
+	mockService.AssertExpectations(t)
+
+	// 非法角色
+	w2 := performRequest(r, http.MethodGet, "/admin/users?role=root", nil)
+	assert.Contains(t, w2.Body.String(), `"error_code":"validation_failed"`)
+}
+
+func TestGetUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/admin/users/:id", handler.GetUser)
+
+	mockService.On("GetUser", uint(2)).Return(&api.UserInfoResp{ID: 2, Username: "alice"}, nil).Once()
+	w := performRequest(r, http.MethodGet, "/admin/users/2", nil)
+	assert.Contains(t, w.Body.String(), "alice")
+
+	mockService.On("GetUser", uint(3)).Return(nil, service.ErrUserNotFound).Once()
+	w2 := performRequest(r, http.MethodGet, "/admin/users/3", nil)
+	assert.Contains(t, w2.Body.String(), "用户不存在")
+
+	w3 := performRequest(r, http.MethodGet, "/admin/users/abc", nil)
+	assert.Contains(t, w3.Body.String(), "ID格式错误")
+}
+
+func TestUpdateUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.PATCH("/admin/users/:id", asAdmin(1), handler.UpdateUser)
+
+	role := "admin"
+	disabled := false
+	req := &api.UserUpdateReq{Role: &role, Disabled: &disabled}
+	mockService.On("UpdateUser", uint(1), uint(2), req).
+		Return(&api.UserInfoResp{ID: 2, Username: "alice", Role: "admin"}, nil).Once()
+
+	body, _ := json.Marshal(req)
+	w := performRequest(r, http.MethodPatch, "/admin/users/2", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"role":"admin"`)
+
+	// 不能修改自己
+	mockService.On("UpdateUser", uint(1), uint(1), req).Return(nil, service.ErrModifySelf).Once()
+	w2 := performRequest(r, http.MethodPatch, "/admin/users/1", body)
+	assert.Contains(t, w2.Body.String(), "不能修改或删除自己的账号")
+
+	// 非法角色
+	bad, _ := json.Marshal(map[string]string{"role": "root"})
+	w3 := performRequest(r, http.MethodPatch, "/admin/users/2", bad)
+	assert.Contains(t, w3.Body.String(), `"error_code":"validation_failed"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestDeleteAndRestoreUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.DELETE("/admin/users/:id", asAdmin(1), handler.DeleteUser)
+	r.POST("/admin/users/:id/restore", handler.RestoreUser)
+
+	mockService.On("DeleteUser", uint(1), uint(2)).Return(nil).Once()
+	w := performRequest(r, http.MethodDelete, "/admin/users/2", nil)
+	assert.Contains(t, w.Body.String(), "用户删除成功")
+
+	mockService.On("RestoreUser", uint(2)).Return(nil).Once()
+	w2 := performRequest(r, http.MethodPost, "/admin/users/2/restore", nil)
+	assert.Contains(t, w2.Body.String(), "用户恢复成功")
+
+	mockService.On("RestoreUser", uint(9)).Return(service.ErrUserNotFound).Once()
+	w3 := performRequest(r, http.MethodPost, "/admin/users/9/restore", nil)
+	assert.Contains(t, w3.Body.String(), "用户不存在")
+	mockService.AssertExpectations(t)
+}
+
+func TestProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mockService := new(MockUserService)
+	handler := NewUserHandler(mockService)
+
+	r := gin.Default()
+	r.GET("/me", asAdmin(5), handler.GetProfile)
+	r.PATCH("/me", asAdmin(5), handler.UpdateProfile)
+
+	profile := &api.ProfileResp{ID: 5, Username: "alice", Email: "alice@example.com", PreferredLanguage: "en"}
+	mockService.On("GetProfile", uint(5)).Return(profile, nil).Once()
+	w := performRequest(r, http.MethodGet, "/me", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "alice@example.com")
+
+	// 修改：清空手机号，修改邮箱
+	email, phone := "new@example.com", ""
+	req := &api.ProfileUpdateReq{Email: &email, Phone: &phone}
+	mockService.On("UpdateProfile", uint(5), req).Return(&api.ProfileResp{ID: 5, Email: email}, nil).Once()
+	body, _ := json.Marshal(req)
+	w2 := performRequest(r, http.MethodPatch, "/me", body)
+	assert.Contains(t, w2.Body.String(), "new@example.com")
+
+	// 校验失败：邮箱格式、语言
+	for _, bad := range []map[string]string{
+		{"email": "not-an-email"},
+		{"preferred_language": "fr"},
+		{"phone": "abc"},
+	} {
+		body, _ := json.Marshal(bad)
+		w := performRequest(r, http.MethodPatch, "/me", body)
+		assert.Contains(t, w.Body.String(), `"error_code":"validation_failed"`)
+	}
+	mockService.AssertExpectations(t)
 }
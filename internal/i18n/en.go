@@ -0,0 +1,259 @@
+package i18n
+
+// enMessages 英文翻译目录。新增中文提示时在此补充译文，
+// 含 %d/%s 的条目按模板匹配，占位符的值原样保留
+var enMessages = map[string]string{
+	// 通用
+	"成功":       "success",
+	"失败":       "failed",
+	"缺少必要参数":   "missing required parameters",
+	"请求参数校验失败": "request validation failed",
+	"%s格式不正确":  "%s has an invalid format",
+	"字段类型错误":   "field has the wrong type",
+	"未认证":      "unauthenticated",
+	"权限不足":     "permission denied",
+	"资源不存在":    "resource not found",
+	"数据冲突":     "conflict",
+	"前置条件不满足":  "precondition failed",
+	"缺少前置条件":   "precondition required",
+	"不支持的媒体类型": "unsupported media type",
+	"服务暂不可用":   "service unavailable",
+	"请求过于频繁":   "too many requests",
+	"请求数据过大":   "payload too large",
+	"未知状态码":    "unknown status code",
+	"系统错误":     "internal server error",
+	"请求数据格式错误": "malformed request body",
+	"查询参数格式错误": "malformed query parameters",
+	"请求参数格式错误": "malformed request parameters",
+	"ID格式错误":   "invalid ID",
+
+	// 书籍
+	"书籍不存在": "book not found",
+	"数据已被其他用户修改，请刷新后重试": "the data has been modified by another user, please refresh and retry",
+	"ISBN已存在": "ISBN already exists",
+	"该ISBN的书籍在回收站中，请先恢复或彻底删除": "a book with this ISBN is in the trash, restore or purge it first",
+	"回收站中不存在该书籍":              "book not found in the trash",
+	"回收站查询失败":                 "failed to query the trash",
+	"书籍恢复失败":                  "failed to restore book",
+	"书籍彻底删除失败":                "failed to purge books",
+	"数据已存在":                   "record already exists",
+	"数据库暂不可用，请稍后重试":           "database is temporarily unavailable, please retry later",
+	"搜索服务暂不可用，请稍后重试":          "search service is temporarily unavailable, please retry later",
+	"书籍添加成功":                  "book added",
+	"书籍添加失败":                  "failed to add book",
+	"书籍删除成功":                  "books deleted",
+	"书籍删除失败":                  "failed to delete books",
+	"批量操作的数量超过上限":             "too many items in one batch",
+	"单次最多删除 %d 本书籍":           "at most %d books can be deleted at once",
+	"书籍导入失败":                  "failed to import books",
+	"请上传要导入的文件":               "please upload a file to import",
+	"不支持的导入文件格式，请上传 CSV、TSV、XLSX、MARC 或 MARCXML 文件": "unsupported import file format, please upload a CSV, TSV, XLSX, MARC or MARCXML file",
+	"导入文件内容无法解析":                  "the import file could not be parsed",
+	"导入文件为空":                      "the import file is empty",
+	"列映射格式错误":                     "malformed column mapping",
+	"列映射中的字段 %s 无效":               "invalid field %s in column mapping",
+	"导入文件缺少必要的列":                  "the import file is missing required columns",
+	"导入文件缺少必要的列：%s":               "the import file is missing required columns: %s",
+	"导入文件的行数超过上限":                 "the import file has too many rows",
+	"文件超过 %d 行，其余行未导入":            "the file exceeds %d rows, remaining rows were not imported",
+	"文件中ISBN重复":                   "duplicate ISBN in file",
+	"ISBN与第 %d 行重复":               "ISBN duplicates row %d",
+	"数量必须为正整数":                    "count must be a positive integer",
+	"书籍导出失败":                      "failed to export books",
+	"导出字段不存在":                     "unknown export field",
+	"OAI-PMH 请求失败":                "OAI-PMH request failed",
+	"未启用 OAI-PMH 收割接口":            "OAI-PMH harvesting is not enabled",
+	"verb 参数缺失或重复":                "missing or repeated verb argument",
+	"不支持的 verb：%s":                "illegal verb: %s",
+	"%s 不支持参数 %s":                 "%s does not accept argument %s",
+	"参数 %s 重复":                    "repeated argument %s",
+	"resumptionToken 不能与其他参数同时使用": "resumptionToken is an exclusive argument",
+	"缺少参数 %s":                     "missing required argument %s",
+	"from 和 until 的粒度不同":          "from and until have different granularities",
+	"from 晚于 until":               "from is later than until",
+	"%s 的日期格式错误：%s":               "invalid %s date: %s",
+	"resumptionToken 无效":          "invalid resumptionToken",
+	"不支持集合":                       "this repository does not support sets",
+	"不支持的元数据格式：%s":                "unsupported metadata format: %s",
+	"记录不存在：%s":                    "record does not exist: %s",
+	"没有符合条件的记录":                   "no records match the request",
+	"SRU 请求失败":                    "SRU request failed",
+	"未启用 SRU 检索接口":                "SRU search is not enabled",
+	"不支持的操作":                      "unsupported operation",
+	"不支持的协议版本":                    "unsupported version",
+	"不支持的参数值":                     "unsupported parameter value",
+	"缺少必填参数":                      "mandatory parameter not supplied",
+	"不支持的参数":                      "unsupported parameter",
+	"查询语法错误":                      "query syntax error",
+	"不支持的上下文集":                    "unsupported context set",
+	"不支持的检索点":                     "unsupported index",
+	"不支持的关系":                      "unsupported relation",
+	"不支持的关系修饰符":                   "unsupported relation modifier",
+	"不支持空检索词":                     "empty term unsupported",
+	"不支持截词符":                      "masking character not supported",
+	"不支持定位符":                      "anchoring character not supported",
+	"不支持的布尔运算符":                   "unsupported boolean operator",
+	"不支持的布尔修饰符":                   "unsupported boolean modifier",
+	"不支持的查询功能":                    "query feature unsupported",
+	"起始记录位置超出范围":                  "first record position out of range",
+	"不支持的记录格式":                    "unknown schema for retrieval",
+	"不支持的记录编码方式":                  "unsupported record XML escaping",
+	"不支持排序":                       "sort not supported",
+	"OPDS 目录查询失败":                 "failed to query OPDS catalog",
+	"OPDS 搜索失败":                   "OPDS search failed",
+	"书籍下载失败":                      "failed to download book",
+	"书籍没有正文":                      "the book has no content",
+	"图书馆目录":                       "Library catalog",
+	"搜索书目":                        "Search the catalog",
+	"搜索书名、作者、内容和简介":               "Search titles, authors, content and summaries",
+	"最新上架":                        "New arrivals",
+	"按上架时间倒序浏览全部书籍":               "All books, newest first",
+	"按作者浏览":                       "Browse by author",
+	"按作者分类浏览书籍":                   "Books grouped by author",
+	"共 %d 本":                      "%d books",
+	"搜索：%s":                       "Search: %s",
+	"%s的全部书籍":                     "All books by %s",
+	"订阅 feed 查询失败":                "failed to query feed",
+	"未启用订阅 feed":                  "feeds are not enabled",
+	"新书上架":                        "New arrivals",
+	"最近上架的书籍":                     "Recently added books",
+	"最近更新":                        "Recently updated",
+	"最近新增或修改的书籍":                  "Recently added or modified books",
+	"书目信息查询失败":                    "failed to look up book metadata",
+	"未启用书目信息查询":                   "book metadata lookup is not enabled",
+	"未查到该 ISBN 的书目信息":             "no metadata found for this ISBN",
+	"书目信息服务暂不可用，请稍后重试":            "book metadata providers are unavailable, please try again later",
+	"ISBN 格式或校验位错误":               "invalid ISBN or check digit",
+	"作者不存在":                       "author not found",
+	"作者仍关联书籍（包括回收站中的书籍），不能删除": "the author is still linked to books (including books in the trash) and cannot be deleted",
+	"贡献者引用的作者不存在":             "a contributor refers to an author that does not exist",
+	"作者查询失败":                  "failed to query authors",
+	"作者书籍查询失败":                "failed to query the author's books",
+	"作者添加失败":                  "failed to add author",
+	"作者修改失败":                  "failed to update author",
+	"作者删除失败":                  "failed to delete author",
+	"作者删除成功":                  "author deleted",
+	"作者迁移失败":                  "failed to migrate book authors",
+	"分类不存在":                   "category not found",
+	"分类号已存在":                  "the category code already exists",
+	"上级分类不存在":                 "the parent category does not exist",
+	"不能把分类移动到自身或其子孙分类下":       "a category cannot be moved under itself or its descendants",
+	"分类层级超过上限":                "the category tree is too deep",
+	"分类仍有子分类，不能删除":            "the category still has subcategories and cannot be deleted",
+	"分类仍关联书籍（包括回收站中的书籍），不能删除": "the category is still linked to books (including books in the trash) and cannot be deleted",
+	"书籍引用的分类不存在":              "a book refers to a category that does not exist",
+	"分类查询失败":                  "failed to query categories",
+	"分类书籍查询失败":                "failed to query the category's books",
+	"分类添加失败":                  "failed to add category",
+	"分类修改失败":                  "failed to update category",
+	"分类删除失败":                  "failed to delete category",
+	"分类删除成功":                  "category deleted",
+	"标签查询失败":                  "failed to query tags",
+	"书籍内容超过 MARC 记录的长度上限，请改用 MARCXML 导出":   "a book exceeds the MARC record length limit, please export as MARCXML",
+	"书籍 %d 超过 MARC 记录的长度上限，请改用 MARCXML 导出": "book %d exceeds the MARC record length limit, please export as MARCXML",
+	"导出字段 %s 不存在":                       "unknown export field %s",
+	"部分书籍不存在或ID无效，整批未删除":                "some books do not exist or have invalid IDs, nothing was deleted",
+	"书籍更新成功":                            "book updated",
+	"书籍更新失败":                            "failed to update book",
+	"更新书籍需要提供 If-Match 请求头或 version 字段": "updating a book requires an If-Match header or a version field",
+	"书籍已被修改，If-Match 与当前版本不一致":          "the book has been modified, If-Match does not match the current version",
+	"If-Match 格式错误":                     "malformed If-Match header",
+	"不支持的补丁格式，请使用 application/merge-patch+json 或 application/json-patch+json": "unsupported patch format, use application/merge-patch+json or application/json-patch+json",
+	"补丁格式错误":              "malformed patch",
+	"补丁路径不存在":             "patch path does not exist",
+	"补丁 test 操作未通过":       "patch test operation failed",
+	"补丁中的 version 必须为正整数": "version in the patch must be a positive integer",
+	"JSON 之后存在多余内容":       "unexpected data after JSON value",
+	"补丁或应用补丁后的书籍内容过大":     "the patch or the patched book is too large",
+	"修订记录查询失败":            "failed to query book revisions",
+	"书籍回滚失败":              "failed to restore book revision",
+	"版本号格式错误":             "malformed version number",
+	"书籍版本不存在":             "book revision not found",
+	"书籍版本 %d 不存在":         "book revision %d not found",
+	"书籍查询失败":              "failed to query books",
+	"搜索失败":                "search failed",
+	"标题搜索失败":              "title search failed",
+	"内容搜索失败":              "content search failed",
+	"标题参数不能为空":            "title must not be empty",
+	"内容参数不能为空":            "content must not be empty",
+	"ES索引初始化成功":           "ES index initialized",
+	"初始化ES索引失败":           "failed to initialize ES index",
+	"重新索引完成":              "reindex completed",
+	"重新索引失败":              "reindex failed",
+
+	// 用户与认证
+	"用户名已存在":             "username already exists",
+	"用户名或密码错误":           "invalid username or password",
+	"当前密码错误":             "current password is incorrect",
+	"重置令牌无效或已过期":         "reset token is invalid or expired",
+	"账号已被禁用":             "account is disabled",
+	"用户不存在":              "user not found",
+	"不能修改或删除自己的账号":       "you cannot modify or delete your own account",
+	"%s创建成功":             "%s created",
+	"%s创建失败":             "failed to create %s",
+	"用户资料查询失败":           "failed to load profile",
+	"用户资料修改失败":           "failed to update profile",
+	"密码修改成功，请重新登录":       "password changed, please sign in again",
+	"密码修改失败":             "failed to change password",
+	"如果账号存在，重置令牌已发送":     "if the account exists, a reset token has been sent",
+	"密码重置成功，请重新登录":       "password reset, please sign in again",
+	"密码重置失败":             "failed to reset password",
+	"用户查询失败":             "failed to query users",
+	"用户修改失败":             "failed to update user",
+	"用户删除成功":             "user deleted",
+	"用户删除失败":             "failed to delete user",
+	"用户恢复成功":             "user restored",
+	"用户恢复失败":             "failed to restore user",
+	"未提供认证令牌":            "missing authentication token",
+	"无效或过期的令牌":           "invalid or expired token",
+	"令牌已失效，请重新登录":        "token has been revoked, please sign in again",
+	"管理员账号需先启用两步验证":      "admin accounts must enable two-factor authentication first",
+	"该接口不支持使用API Key访问":  "this endpoint cannot be accessed with an API key",
+	"API Key 权限不足，需要 %s": "API key lacks the required scope: %s",
+
+	// 密码策略
+	"密码不符合安全要求":    "password does not meet the security requirements",
+	"密码长度至少为 %d 位": "password must be at least %d characters long",
+	"密码必须包含大写字母":   "password must contain an uppercase letter",
+	"密码必须包含小写字母":   "password must contain a lowercase letter",
+	"密码必须包含数字":     "password must contain a digit",
+	"密码必须包含特殊字符":   "password must contain a special character",
+	"密码至少需要包含大写字母、小写字母、数字、特殊字符中的 %d 类": "password must contain at least %d of: uppercase letters, lowercase letters, digits, special characters",
+	"密码不能包含用户名或与用户名过于相似":               "password must not contain or resemble the username",
+	"不能与最近 %d 次使用过的密码相同":               "password must differ from the last %d passwords",
+	"该密码已出现在公开泄露的密码库中，请更换":             "this password appears in a public breach list, please choose another",
+
+	// 两步验证
+	"两步验证已启用":         "two-factor authentication is already enabled",
+	"两步验证未启用":         "two-factor authentication is not enabled",
+	"请先生成两步验证密钥":      "generate a two-factor secret first",
+	"管理员账号必须启用两步验证":   "two-factor authentication is required for admin accounts",
+	"验证码错误":           "invalid verification code",
+	"登录已过期，请重新登录":     "login session expired, please sign in again",
+	"验证码错误次数过多，请稍后再试": "too many invalid verification codes, please try again later",
+	"两步验证已关闭":         "two-factor authentication disabled",
+
+	// API Key
+	"API Key不存在":         "API key not found",
+	"权限范围超出用户角色":         "requested scopes exceed the user's role",
+	"管理员需先启用两步验证才能申请该权限": "admin accounts must enable two-factor authentication before requesting this scope",
+	"无效或过期的API Key":      "invalid or expired API key",
+	"API Key所属账号已禁用或删除":  "the API key owner is disabled or deleted",
+	"API Key创建失败":        "failed to create API key",
+	"API Key查询失败":        "failed to query API keys",
+	"API Key吊销失败":        "failed to revoke API key",
+	"API Key已吊销":         "API key revoked",
+
+	// 单点登录
+	"未启用单点登录":            "single sign-on is not enabled",
+	"登录请求已过期，请重新登录":      "sign-in request expired, please sign in again",
+	"单点登录失败":             "single sign-on failed",
+	"单点登录失败:%s":          "single sign-on failed: %s",
+	"该外部账号已绑定其他用户":       "this external account is linked to another user",
+	"绑定关系不存在":            "linked identity not found",
+	"请先设置本地密码再解除唯一的登录方式": "set a local password before unlinking your only sign-in method",
+	"解除绑定成功":             "identity unlinked",
+
+	// 审计
+	"审计日志查询失败": "failed to query audit logs",
+}
@@ -0,0 +1,86 @@
+package i18n
+
+import (
+	"LibraryManagement/internal/api"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLang(t *testing.T) {
+	tests := map[string]string{
+		"":                        ZH,
+		"en":                      EN,
+		"en-US,en;q=0.9":          EN,
+		"zh-CN,zh;q=0.9,en;q=0.8": ZH,
+		"fr-FR,en;q=0.5,zh;q=0.3": EN,
+		"zh;q=0.2,en-GB;q=0.7":    EN,
+		"de,fr":                   ZH,
+		"en;q=0,zh-TW":            ZH,
+		"en;q=abc,zh;q=0.1":       ZH,
+	}
+	for header, want := range tests {
+		assert.Equal(t, want, Lang(header), header)
+	}
+}
+
+func TestT(t *testing.T) {
+	// 中文原样返回
+	assert.Equal(t, "书籍不存在", T(ZH, "书籍不存在"))
+
+	// 完整匹配
+	assert.Equal(t, "book not found", T(EN, "书籍不存在"))
+
+	// 占位符模板
+	assert.Equal(t, "password must be at least 8 characters long", T(EN, "密码长度至少为 8 位"))
+	assert.Equal(t, "failed to create admin", T(EN, "admin创建失败"))
+	assert.Equal(t, "single sign-on failed: access_denied", T(EN, "单点登录失败:access_denied"))
+	assert.Equal(t, "book revision 3 not found", T(EN, "书籍版本 3 不存在"))
+
+	// 多条原因逐条翻译
+	assert.Equal(t,
+		"password must be at least 8 characters long; password must contain a digit",
+		T(EN, "密码长度至少为 8 位；密码必须包含数字"))
+
+	// 没有译文时保留原文
+	assert.Equal(t, "未收录的提示", T(EN, "未收录的提示"))
+	assert.Equal(t, "书籍不存在", T("fr", "书籍不存在"))
+}
+
+func TestJoin(t *testing.T) {
+	assert.Equal(t, "书籍查询失败:书籍不存在", Join(ZH, "书籍查询失败", "书籍不存在"))
+	assert.Equal(t, "failed to query books: book not found", Join(EN, "书籍查询失败", "书籍不存在"))
+	assert.Equal(t, "book not found", Join(EN, "", "书籍不存在"))
+}
+
+func TestFieldErrors(t *testing.T) {
+	// 内嵌的 BookInfoReq 不出现在字段路径中
+	err := Validate.Struct(&api.BookUpdateReq{ID: 1, BookInfoReq: api.BookInfoReq{Title: "Go"}})
+
+	zh := FieldErrors(ZH, err)
+	assert.Equal(t, []FieldError{
+		{Field: "count", Rule: "required", Message: "count为必填字段"},
+		{Field: "isbn", Rule: "required", Message: "isbn为必填字段"},
+	}, zh)
+
+	en := FieldErrors(EN, err)
+	assert.Equal(t, "count is a required field", en[0].Message)
+
+	// 切片元素带下标
+	err = Validate.Struct(&api.APIKeyCreateReq{Name: "bot", Scopes: []string{"books:read", "root"}})
+	fields := FieldErrors(EN, err)
+	assert.Len(t, fields, 1)
+	assert.Equal(t, "scopes[1]", fields[0].Field)
+	assert.Equal(t, "oneof", fields[0].Rule)
+
+	// 组合规则没有内置翻译，使用通用提示
+	email := "not-an-email"
+	fields = FieldErrors(EN, Validate.Struct(&api.ProfileUpdateReq{Email: &email}))
+	assert.Equal(t, []FieldError{{Field: "email", Rule: "len=0|email", Message: "email has an invalid format"}}, fields)
+
+	// 表单查询参数取 form 标签
+	fields = FieldErrors(ZH, Validate.Struct(&api.UserListReq{PageSize: 500}))
+	assert.Equal(t, "page_size", fields[0].Field)
+
+	assert.Nil(t, FieldErrors(ZH, nil))
+}
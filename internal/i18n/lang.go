@@ -0,0 +1,60 @@
+// Package i18n 提供中英文提示信息与参数校验错误的本地化。
+// 源码中的提示统一使用中文书写，作为翻译目录的键（与 gettext 的 msgid 类似），
+// 英文翻译见 en.go
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 支持的语言
+const (
+	ZH = "zh"
+	EN = "en"
+)
+
+// Default 未指定或无法识别 Accept-Language 时使用的语言
+const Default = ZH
+
+// Lang 按 Accept-Language 的权重选择支持的语言，如 "en-US,en;q=0.9,zh;q=0.8" 返回 en
+func Lang(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang := normalize(tag); lang != "" && q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	// 权重相同时保持原顺序
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	if len(candidates) > 0 {
+		return candidates[0].lang
+	}
+	return Default
+}
+
+// normalize 把语言标签归一为支持的语言，不支持时返回空串
+func normalize(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	switch primary {
+	case ZH, EN:
+		return primary
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// listSeparators 多条原因拼接时使用的分隔符
+var listSeparators = map[string]string{
+	ZH: "；",
+	EN: "; ",
+}
+
+// catalogs 各语言的翻译目录，键为中文原文
+var catalogs = map[string]map[string]string{
+	EN: enMessages,
+}
+
+// template 含 %d/%s 占位符的翻译条目，如 "密码长度至少为 %d 位"
+type template struct {
+	pattern *regexp.Regexp
+	parts   []string // 译文按占位符切分后的片段
+}
+
+var (
+	verbPattern = regexp.MustCompile(`%[dsv]`)
+	templates   = map[string][]template{}
+)
+
+func init() {
+	for lang, catalog := range catalogs {
+		for source, target := range catalog {
+			if !verbPattern.MatchString(source) {
+				continue
+			}
+			pattern := verbPattern.ReplaceAllStringFunc(regexp.QuoteMeta(source), func(verb string) string {
+				if verb == "%d" {
+					return `(-?\d+)`
+				}
+				return `(.+?)`
+			})
+			templates[lang] = append(templates[lang], template{
+				pattern: regexp.MustCompile("^" + pattern + "$"),
+				parts:   verbPattern.Split(target, -1),
+			})
+		}
+		// 原文越长越具体，优先匹配，保证结果确定
+		sort.Slice(templates[lang], func(i, j int) bool {
+			return len(templates[lang][i].pattern.String()) > len(templates[lang][j].pattern.String())
+		})
+	}
+}
+
+// T 把中文提示翻译为指定语言。依次尝试：完整匹配、占位符模板、
+// 按 "；" 拆分的多条原因逐条翻译；找不到译文时原样返回
+func T(lang, message string) string {
+	if lang == ZH || message == "" {
+		return message
+	}
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return message
+	}
+
+	if target, ok := catalog[message]; ok {
+		return target
+	}
+	if target, ok := fromTemplate(lang, message); ok {
+		return target
+	}
+
+	sep := listSeparators[ZH]
+	if strings.Contains(message, sep) {
+		items := strings.Split(message, sep)
+		for i, item := range items {
+			items[i] = T(lang, item)
+		}
+		return strings.Join(items, listSeparators[lang])
+	}
+	return message
+}
+
+// Join 按语言拼接操作前缀与原因，如 "书籍查询失败:书籍不存在"
+func Join(lang, prefix, message string) string {
+	if prefix == "" {
+		return T(lang, message)
+	}
+	if lang == ZH {
+		return prefix + ":" + message
+	}
+	return T(lang, prefix) + ": " + T(lang, message)
+}
+
+func fromTemplate(lang, message string) (string, bool) {
+	for _, t := range templates[lang] {
+		match := t.pattern.FindStringSubmatch(message)
+		if match == nil || len(match)-1 != len(t.parts)-1 {
+			continue
+		}
+
+		var b strings.Builder
+		for i, part := range t.parts {
+			b.WriteString(part)
+			if i+1 < len(match) {
+				// 占位符的值可能本身也是需要翻译的文本（如角色名），能翻译则翻译
+				b.WriteString(T(lang, match[i+1]))
+			}
+		}
+		return b.String(), true
+	}
+	return "", false
+}
@@ -0,0 +1,106 @@
+package i18n
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	enlocale "github.com/go-playground/locales/en"
+	zhlocale "github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entrans "github.com/go-playground/validator/v10/translations/en"
+	zhtrans "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 请求中的字段名（json 或 form 标签）
+	Rule    string `json:"rule"`    // 未通过的校验规则，如 required、max
+	Message string `json:"message"` // 本地化后的提示
+}
+
+var (
+	// Validate 共享的校验器，字段名取 json/form 标签，错误可按语言翻译
+	Validate    *validator.Validate
+	universal   *ut.UniversalTranslator
+	translators = map[string]ut.Translator{}
+)
+
+func init() {
+	Validate = validator.New()
+	Validate.RegisterTagNameFunc(fieldName)
+
+	zhLocale, enLocale := zhlocale.New(), enlocale.New()
+	universal = ut.New(zhLocale, zhLocale, enLocale)
+
+	zhT, _ := universal.GetTranslator(ZH)
+	enT, _ := universal.GetTranslator(EN)
+	if err := zhtrans.RegisterDefaultTranslations(Validate, zhT); err != nil {
+		panic(err)
+	}
+	if err := entrans.RegisterDefaultTranslations(Validate, enT); err != nil {
+		panic(err)
+	}
+	translators[ZH], translators[EN] = zhT, enT
+}
+
+// FieldErrors 把校验错误翻译为字段错误列表；err 不是校验错误时返回 nil
+func FieldErrors(lang string, err error) []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	trans, ok := translators[lang]
+	if !ok {
+		trans = translators[Default]
+	}
+
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		message := fe.Translate(trans)
+		// 没有注册翻译的规则（如 len=0|email 这类组合规则）Translate 会返回英文原始错误
+		if message == fe.Error() {
+			message = T(lang, fe.Field()+"格式不正确")
+		}
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Message: message,
+		})
+	}
+	return fields
+}
+
+// fieldName 取 json 标签，其次 form 标签，都没有时用结构体字段名
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldPath 去掉顶层结构体名和内嵌结构体名，如 BookUpdateReq.BookInfoReq.title → title，
+// 切片元素保留下标，如 scopes[0]
+func fieldPath(fe validator.FieldError) string {
+	parts := strings.Split(fe.Namespace(), ".")[1:]
+	path := make([]string, 0, len(parts))
+	for _, part := range parts {
+		// 内嵌结构体没有标签，名称以大写字母开头
+		if part != "" && part[0] >= 'A' && part[0] <= 'Z' {
+			continue
+		}
+		path = append(path, part)
+	}
+	if len(path) == 0 {
+		return fe.Field()
+	}
+	return strings.Join(path, ".")
+}
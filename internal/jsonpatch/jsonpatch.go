@@ -0,0 +1,394 @@
+// Package jsonpatch 实现 JSON Merge Patch（RFC 7396）与 JSON Patch（RFC 6902），
+// 在通用 JSON 文档上应用补丁，字段含义与校验由调用方负责
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPatch = errors.New("补丁格式错误")
+	ErrPathNotFound = errors.New("补丁路径不存在")
+	ErrTestFailed   = errors.New("补丁 test 操作未通过")
+	ErrTooLarge     = errors.New("补丁或应用后的文档超过大小限制")
+)
+
+// MergePatch 按 RFC 7396 把 patch 合并到 doc：对象逐键合并，null 表示删除，其余值整体替换
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergeValue(t[k], v)
+	}
+	return t
+}
+
+// Operation JSON Patch 中的一条操作
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Limits 应用 JSON Patch 时的限制，零值表示不限制。
+// copy 可以反复复制文档中已有的内容，少量操作就能让文档成倍增长，处理外部请求时必须设置
+type Limits struct {
+	MaxOperations int // 补丁最多包含的操作数
+	MaxSize       int // 每个操作之后文档编码的最大字节数
+}
+
+// Apply 按 RFC 6902 依次执行 patch 中的操作，任一操作失败则整体失败，doc 不受影响
+func Apply(doc, patch []byte, limits Limits) ([]byte, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	if limits.MaxOperations > 0 && len(ops) > limits.MaxOperations {
+		return nil, fmt.Errorf("%w: 操作数 %d 超过上限 %d", ErrTooLarge, len(ops), limits.MaxOperations)
+	}
+
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// size 为文档大小的上界：每个操作加上写入值的大小，超过限制时再重新编码得到准确值
+	size := len(doc)
+	for i, op := range ops {
+		var added int
+		target, added, err = applyOp(target, op)
+		if err == nil && limits.MaxSize > 0 && added > 0 {
+			size, err = checkSize(target, size+added, limits.MaxSize)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 个操作（%s %s）: %w", i+1, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+// checkSize 上界超过限制时重新编码文档，返回准确大小；准确大小仍超过限制时返回 ErrTooLarge
+func checkSize(doc interface{}, bound, max int) (int, error) {
+	if bound <= max {
+		return bound, nil
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) > max {
+		return 0, fmt.Errorf("%w: 文档超过 %d 字节", ErrTooLarge, max)
+	}
+	return len(data), nil
+}
+
+// applyOp 执行一个操作，返回新文档和写入值编码后的字节数（add、replace、copy 之外为 0）
+func applyOp(doc interface{}, op Operation) (interface{}, int, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, 0, fmt.Errorf("%w: 缺少 value", ErrInvalidPatch)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			doc, err = add(doc, path, value)
+			return doc, len(op.Value), err
+		case "replace":
+			if _, err := get(doc, path); err != nil {
+				return nil, 0, err
+			}
+			doc, err = remove(doc, path)
+			if err != nil {
+				return nil, 0, err
+			}
+			doc, err = add(doc, path, value)
+			return doc, len(op.Value), err
+		default:
+			current, err := get(doc, path)
+			if err != nil {
+				return nil, 0, err
+			}
+			if !equal(current, value) {
+				return nil, 0, ErrTestFailed
+			}
+			return doc, 0, nil
+		}
+
+	case "remove":
+		doc, err = remove(doc, path)
+		return doc, 0, err
+
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, 0, err
+		}
+		added := 0
+		if op.Op == "move" {
+			// 不能把节点移动到自己的子节点下
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, 0, fmt.Errorf("%w: 不能移动到自身的子路径", ErrInvalidPatch)
+			}
+			if doc, err = remove(doc, from); err != nil {
+				return nil, 0, err
+			}
+		} else if value, added, err = deepCopy(value); err != nil {
+			return nil, 0, err
+		}
+		doc, err = add(doc, path, value)
+		return doc, added, err
+	}
+
+	return nil, 0, fmt.Errorf("%w: 不支持的操作 %q", ErrInvalidPatch, op.Op)
+}
+
+// ---------- JSON Pointer（RFC 6901） ----------
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: 路径必须以 / 开头", ErrInvalidPatch)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			child, ok := n[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			node = child
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return node, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token, rest := path[0], path[1:]
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		if len(rest) == 0 {
+			n[token] = value
+			return n, nil
+		}
+		child, ok := n[token]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		child, err := add(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+
+	case []interface{}:
+		if len(rest) == 0 {
+			if token == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(n[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, ErrPathNotFound
+}
+
+func remove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: 不能删除整个文档", ErrInvalidPatch)
+	}
+
+	token, rest := path[0], path[1:]
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		child, ok := n[token]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		if len(rest) == 0 {
+			delete(n, token)
+			return n, nil
+		}
+		child, err := remove(child, rest)
+		if err != nil {
+			return nil, err
+		}
+		n[token] = child
+		return n, nil
+
+	case []interface{}:
+		i, err := arrayIndex(token, len(n)-1)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			return append(n[:i], n[i+1:]...), nil
+		}
+		child, err := remove(n[i], rest)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = child
+		return n, nil
+	}
+	return nil, ErrPathNotFound
+}
+
+// arrayIndex 解析数组下标，允许的最大值为 max（add 可以等于长度，其余操作必须小于长度）
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: 非法的数组下标 %q", ErrInvalidPatch, token)
+	}
+	if i > max {
+		return 0, ErrPathNotFound
+	}
+	return i, nil
+}
+
+// ---------- 工具函数 ----------
+
+// decode 解析 JSON，数字保留为 json.Number，避免大整数丢失精度
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.New("JSON 之后存在多余内容")
+	}
+	return v, nil
+}
+
+// deepCopy 复制 JSON 值，同时返回其编码后的字节数
+func deepCopy(v interface{}) (interface{}, int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, 0, err
+	}
+	copied, err := decode(data)
+	return copied, len(data), err
+}
+
+// equal 比较两个 JSON 值，数字按数值比较（1 与 1.0 相等）
+func equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
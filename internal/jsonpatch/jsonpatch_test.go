@@ -0,0 +1,131 @@
+package jsonpatch
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergePatch(t *testing.T) {
+	// RFC 7396 附录 A 中的示例
+	tests := []struct {
+		doc, patch, want string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		got, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+		require.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(got), "doc=%s patch=%s", tt.doc, tt.patch)
+	}
+
+	_, err := MergePatch([]byte(`{}`), []byte(`{invalid`))
+	assert.ErrorIs(t, err, ErrInvalidPatch)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"title":"Go","count":1,"tags":["a","c"],"meta":{"a/b":1,"m~n":2}}`
+
+	tests := []struct {
+		name, patch, want string
+	}{
+		{"add_member", `[{"op":"add","path":"/author","value":"张三"}]`,
+			`{"title":"Go","count":1,"author":"张三","tags":["a","c"],"meta":{"a/b":1,"m~n":2}}`},
+		{"add_array_index", `[{"op":"add","path":"/tags/1","value":"b"}]`,
+			`{"title":"Go","count":1,"tags":["a","b","c"],"meta":{"a/b":1,"m~n":2}}`},
+		{"add_array_end", `[{"op":"add","path":"/tags/-","value":"d"}]`,
+			`{"title":"Go","count":1,"tags":["a","c","d"],"meta":{"a/b":1,"m~n":2}}`},
+		{"remove_escaped", `[{"op":"remove","path":"/meta/a~1b"},{"op":"remove","path":"/meta/m~0n"}]`,
+			`{"title":"Go","count":1,"tags":["a","c"],"meta":{}}`},
+		{"replace", `[{"op":"replace","path":"/count","value":5}]`,
+			`{"title":"Go","count":5,"tags":["a","c"],"meta":{"a/b":1,"m~n":2}}`},
+		{"move", `[{"op":"move","from":"/title","path":"/name"}]`,
+			`{"name":"Go","count":1,"tags":["a","c"],"meta":{"a/b":1,"m~n":2}}`},
+		{"copy", `[{"op":"copy","from":"/tags/0","path":"/tags/-"}]`,
+			`{"title":"Go","count":1,"tags":["a","c","a"],"meta":{"a/b":1,"m~n":2}}`},
+		{"test_then_replace", `[{"op":"test","path":"/count","value":1.0},{"op":"replace","path":"/title","value":"Rust"}]`,
+			`{"title":"Rust","count":1,"tags":["a","c"],"meta":{"a/b":1,"m~n":2}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(doc), []byte(tt.patch), Limits{})
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := []byte(`{"title":"Go","tags":["a"]}`)
+
+	tests := []struct {
+		name, patch string
+		want        error
+	}{
+		{"test_failed", `[{"op":"test","path":"/title","value":"Rust"}]`, ErrTestFailed},
+		{"replace_missing", `[{"op":"replace","path":"/author","value":"x"}]`, ErrPathNotFound},
+		{"remove_missing", `[{"op":"remove","path":"/tags/3"}]`, ErrPathNotFound},
+		{"add_missing_parent", `[{"op":"add","path":"/meta/a","value":1}]`, ErrPathNotFound},
+		{"unknown_op", `[{"op":"merge","path":"/title","value":"x"}]`, ErrInvalidPatch},
+		{"missing_value", `[{"op":"add","path":"/title"}]`, ErrInvalidPatch},
+		{"bad_pointer", `[{"op":"remove","path":"title"}]`, ErrInvalidPatch},
+		{"leading_zero_index", `[{"op":"remove","path":"/tags/00"}]`, ErrInvalidPatch},
+		{"move_into_child", `[{"op":"move","from":"/tags","path":"/tags/0"}]`, ErrInvalidPatch},
+		{"not_array", `{"op":"remove","path":"/title"}`, ErrInvalidPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Apply(doc, []byte(tt.patch), Limits{})
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	// 失败的补丁不影响原文档（操作是原子的）
+	_, err := Apply(doc, []byte(`[{"op":"replace","path":"/title","value":"Rust"},{"op":"test","path":"/title","value":"Go"}]`), Limits{})
+	assert.ErrorIs(t, err, ErrTestFailed)
+	assert.JSONEq(t, `{"title":"Go","tags":["a"]}`, string(doc))
+}
+
+func TestApplyLimits(t *testing.T) {
+	doc := []byte(`{"title":"Go","tags":["a","b","c","d"]}`)
+
+	// 反复把数组复制到自身末尾，文档每次翻倍
+	ops := make([]string, 30)
+	for i := range ops {
+		ops[i] = `{"op":"copy","from":"/tags","path":"/tags/-"}`
+	}
+	bomb := []byte("[" + strings.Join(ops, ",") + "]")
+
+	_, err := Apply(doc, bomb, Limits{MaxOperations: 100, MaxSize: 1 << 16})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	_, err = Apply(doc, bomb, Limits{MaxOperations: 20})
+	assert.ErrorIs(t, err, ErrTooLarge)
+
+	// 限制之内正常应用；删除后腾出的空间可以再次使用
+	got, err := Apply(doc, []byte(`[
+		{"op":"copy","from":"/tags","path":"/tags/-"},
+		{"op":"remove","path":"/tags/4"},
+		{"op":"add","path":"/author","value":"张三"}
+	]`), Limits{MaxOperations: 3, MaxSize: 60})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Go","tags":["a","b","c","d"],"author":"张三"}`, string(got))
+}
@@ -0,0 +1,186 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ISO 2709 分隔符
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+
+	leaderLen         = 24
+	directoryEntryLen = 12
+	maxRecordLen      = 99999
+	maxFieldLen       = 9999
+)
+
+// Reader 读取 ISO 2709（.mrc）格式的记录
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+func (r *Reader) Read() (*Record, error) {
+	// 记录之间可能有换行等空白
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+		if b[0] != '\n' && b[0] != '\r' && b[0] != ' ' {
+			break
+		}
+		_, _ = r.r.Discard(1)
+	}
+
+	head := make([]byte, 5)
+	if _, err := io.ReadFull(r.r, head); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+	}
+	length, ok := parseDigits(head)
+	if !ok || length < leaderLen+2 {
+		return nil, fmt.Errorf("%w: 记录长度 %q 无效", ErrInvalidRecord, head)
+	}
+
+	data := make([]byte, length)
+	copy(data, head)
+	if _, err := io.ReadFull(r.r, data[5:]); err != nil {
+		return nil, fmt.Errorf("%w: 记录不完整: %v", ErrInvalidRecord, err)
+	}
+	return parseRecord(data)
+}
+
+func parseRecord(data []byte) (*Record, error) {
+	if data[len(data)-1] != recordTerminator {
+		return nil, fmt.Errorf("%w: 缺少记录结束符", ErrInvalidRecord)
+	}
+	if !utf8.Valid(data) {
+		return nil, fmt.Errorf("%w: 只支持 UTF-8 编码的记录", ErrInvalidRecord)
+	}
+
+	rec := &Record{Leader: string(data[:leaderLen])}
+	base, ok := parseDigits(data[12:17])
+	if !ok || base <= leaderLen || base > len(data) || data[base-1] != fieldTerminator {
+		return nil, fmt.Errorf("%w: 数据基地址无效", ErrInvalidRecord)
+	}
+
+	directory := data[leaderLen : base-1]
+	if len(directory)%directoryEntryLen != 0 {
+		return nil, fmt.Errorf("%w: 目录区长度无效", ErrInvalidRecord)
+	}
+	for i := 0; i < len(directory); i += directoryEntryLen {
+		entry := directory[i : i+directoryEntryLen]
+		tag := string(entry[:3])
+		length, ok1 := parseDigits(entry[3:7])
+		start, ok2 := parseDigits(entry[7:12])
+		end := base + start + length
+		if !ok1 || !ok2 || length < 1 || end > len(data)-1 {
+			return nil, fmt.Errorf("%w: 字段 %s 的目录项无效", ErrInvalidRecord, tag)
+		}
+
+		value := bytes.TrimSuffix(data[base+start:end], []byte{fieldTerminator})
+		field, err := parseField(tag, value)
+		if err != nil {
+			return nil, err
+		}
+		rec.Fields = append(rec.Fields, field)
+	}
+	return rec, nil
+}
+
+// parseDigits 解析定长数字字段，只接受 ASCII 数字（strconv.Atoi 还会接受正负号）
+func parseDigits(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+func parseField(tag string, value []byte) (Field, error) {
+	if IsControlTag(tag) {
+		return Field{Tag: tag, Value: string(value)}, nil
+	}
+	if len(value) < 2 {
+		return Field{}, fmt.Errorf("%w: 字段 %s 缺少指示符", ErrInvalidRecord, tag)
+	}
+
+	field := Field{Tag: tag, Ind1: string(value[0]), Ind2: string(value[1])}
+	// 第一个分隔符之前没有内容
+	for _, part := range bytes.Split(value[2:], []byte{subfieldDelimiter})[1:] {
+		if len(part) == 0 {
+			continue
+		}
+		field.Subfields = append(field.Subfields, Subfield{Code: string(part[0]), Value: string(part[1:])})
+	}
+	return field, nil
+}
+
+// Writer 以 ISO 2709 格式写入记录
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Write(rec *Record) error {
+	var directory, body bytes.Buffer
+	for _, f := range rec.Fields {
+		if len(f.Tag) != 3 {
+			return fmt.Errorf("%w: 字段号 %q 无效", ErrInvalidRecord, f.Tag)
+		}
+		start := body.Len()
+		if IsControlTag(f.Tag) {
+			body.WriteString(f.Value)
+		} else {
+			body.WriteByte(indicator(f.Ind1))
+			body.WriteByte(indicator(f.Ind2))
+			for _, sf := range f.Subfields {
+				body.WriteByte(subfieldDelimiter)
+				body.WriteString(sf.Code)
+				body.WriteString(sf.Value)
+			}
+		}
+		body.WriteByte(fieldTerminator)
+
+		length := body.Len() - start
+		if length > maxFieldLen {
+			return fmt.Errorf("%w: 字段 %s", ErrRecordTooLong, f.Tag)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", f.Tag, length, start)
+	}
+
+	base := leaderLen + directory.Len() + 1
+	total := base + body.Len() + 1
+	if total > maxRecordLen {
+		return ErrRecordTooLong
+	}
+
+	l := leader(rec.Leader)
+	copy(l[0:5], fmt.Sprintf("%05d", total))
+	copy(l[12:17], fmt.Sprintf("%05d", base))
+
+	w.w.Write(l)
+	w.w.Write(directory.Bytes())
+	w.w.WriteByte(fieldTerminator)
+	w.w.Write(body.Bytes())
+	return w.w.WriteByte(recordTerminator)
+}
+
+func (w *Writer) Close() error {
+	return w.w.Flush()
+}
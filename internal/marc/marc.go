@@ -0,0 +1,187 @@
+// Package marc 读写 MARC21 书目记录，支持 ISO 2709 二进制格式和 MARCXML。
+// 只处理记录结构，字段含义由调用方解释；只支持 UTF-8 编码（头标区第 9 位为 a）
+package marc
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidRecord = errors.New("MARC 记录格式错误")
+	ErrRecordTooLong = errors.New("MARC 记录超过 ISO 2709 允许的长度")
+)
+
+// DefaultLeader 新建记录的头标区：新记录、文字资料、专著、UTF-8
+const DefaultLeader = "00000nam a2200000   4500"
+
+// Record 一条 MARC 记录
+type Record struct {
+	Leader string  `json:"leader"`
+	Fields []Field `json:"fields"`
+}
+
+// Field 控制字段（00X）只有 Value，数据字段有指示符和子字段
+type Field struct {
+	Tag       string     `json:"tag"`
+	Value     string     `json:"value,omitempty"`
+	Ind1      string     `json:"ind1,omitempty"`
+	Ind2      string     `json:"ind2,omitempty"`
+	Subfields []Subfield `json:"subfields,omitempty"`
+}
+
+// Subfield 子字段，Code 为单个字符
+type Subfield struct {
+	Code  string `json:"code"`
+	Value string `json:"value"`
+}
+
+// RecordReader 逐条读取记录，读完返回 io.EOF
+type RecordReader interface {
+	Read() (*Record, error)
+}
+
+// RecordWriter 逐条写入记录，Close 写入结尾并刷新缓冲
+type RecordWriter interface {
+	Write(rec *Record) error
+	Close() error
+}
+
+// IsControlTag 00X 为控制字段
+func IsControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+// Field 返回第一个指定字段，不存在时返回 nil
+func (r *Record) Field(tag string) *Field {
+	for i := range r.Fields {
+		if r.Fields[i].Tag == tag {
+			return &r.Fields[i]
+		}
+	}
+	return nil
+}
+
+// SubfieldValue 第一个字段的第一个指定子字段的值
+func (r *Record) SubfieldValue(tag, code string) string {
+	if f := r.Field(tag); f != nil {
+		return f.Subfield(code)
+	}
+	return ""
+}
+
+// SetControl 设置控制字段的值，不存在时按字段号顺序插入
+func (r *Record) SetControl(tag, value string) {
+	if f := r.Field(tag); f != nil {
+		f.Value = value
+		return
+	}
+	r.insert(Field{Tag: tag, Value: value})
+}
+
+// SetSubfield 设置第一个字段的第一个指定子字段；value 为空时删除该子字段，字段没有子字段后一并删除。
+// 字段不存在时新建（指示符为空格）
+func (r *Record) SetSubfield(tag, code, value string) {
+	f := r.Field(tag)
+	if f == nil {
+		if value == "" {
+			return
+		}
+		r.insert(Field{Tag: tag, Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: code, Value: value}}})
+		return
+	}
+
+	f.setSubfield(code, value)
+	if len(f.Subfields) == 0 {
+		r.remove(tag)
+	}
+}
+
+// RemoveSubfield 删除第一个指定字段中的全部指定子字段
+func (r *Record) RemoveSubfield(tag, code string) {
+	f := r.Field(tag)
+	if f == nil {
+		return
+	}
+	kept := f.Subfields[:0]
+	for _, sf := range f.Subfields {
+		if sf.Code != code {
+			kept = append(kept, sf)
+		}
+	}
+	f.Subfields = kept
+	if len(f.Subfields) == 0 {
+		r.remove(tag)
+	}
+}
+
+// insert 按字段号顺序插入到同号字段之后
+func (r *Record) insert(field Field) {
+	i := len(r.Fields)
+	for j, f := range r.Fields {
+		if f.Tag > field.Tag {
+			i = j
+			break
+		}
+	}
+	r.Fields = append(r.Fields, Field{})
+	copy(r.Fields[i+1:], r.Fields[i:])
+	r.Fields[i] = field
+}
+
+// remove 删除第一个指定字段
+func (r *Record) remove(tag string) {
+	for i := range r.Fields {
+		if r.Fields[i].Tag == tag {
+			r.Fields = append(r.Fields[:i], r.Fields[i+1:]...)
+			return
+		}
+	}
+}
+
+// Subfield 第一个指定子字段的值
+func (f *Field) Subfield(code string) string {
+	for _, sf := range f.Subfields {
+		if sf.Code == code {
+			return sf.Value
+		}
+	}
+	return ""
+}
+
+func (f *Field) setSubfield(code, value string) {
+	for i, sf := range f.Subfields {
+		if sf.Code != code {
+			continue
+		}
+		if value == "" {
+			f.Subfields = append(f.Subfields[:i], f.Subfields[i+1:]...)
+		} else {
+			f.Subfields[i].Value = value
+		}
+		return
+	}
+	if value != "" {
+		f.Subfields = append(f.Subfields, Subfield{Code: code, Value: value})
+	}
+}
+
+// leader 规范化头标区：长度不是 24 时使用默认值，字符编码固定为 UTF-8
+func leader(l string) []byte {
+	if len(l) != 24 {
+		l = DefaultLeader
+	}
+	b := []byte(l)
+	b[9] = 'a'
+	b[10], b[11] = '2', '2'
+	copy(b[20:], "4500")
+	return b
+}
+
+// indicator 指示符为单个字符，缺省为空格
+func indicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
@@ -0,0 +1,167 @@
+package marc
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll 读取全部记录
+func readAll(t *testing.T, r RecordReader) []*Record {
+	var records []*Record
+	for {
+		rec, err := r.Read()
+		if err == io.EOF {
+			return records
+		}
+		require.NoError(t, err)
+		records = append(records, rec)
+	}
+}
+
+func sampleRecord() *Record {
+	return &Record{
+		Leader: DefaultLeader,
+		Fields: []Field{
+			{Tag: "001", Value: "ocm12345"},
+			{Tag: "020", Ind1: " ", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: "9787111213826"}, {Code: "q", Value: "平装"}}},
+			{Tag: "100", Ind1: "1", Ind2: " ", Subfields: []Subfield{{Code: "a", Value: "Eckel, Bruce,"}}},
+			{Tag: "245", Ind1: "1", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Java 编程思想 :"}, {Code: "b", Value: "第4版 /"}, {Code: "c", Value: "Bruce Eckel"}}},
+			{Tag: "650", Ind1: " ", Ind2: "0", Subfields: []Subfield{{Code: "a", Value: "Java"}}},
+		},
+	}
+}
+
+func TestISO2709RoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Write(&Record{Fields: []Field{{Tag: "001", Value: "2"}}}))
+	require.NoError(t, w.Close())
+
+	// 记录长度和数据基地址写入头标区
+	data := buf.Bytes()
+	assert.Equal(t, byte(recordTerminator), data[len(data)-1])
+
+	records := readAll(t, NewReader(bytes.NewReader(data)))
+	require.Len(t, records, 2)
+	assert.Equal(t, sampleRecord().Fields, records[0].Fields)
+	assert.Equal(t, "a", records[0].Leader[9:10])
+	assert.Equal(t, "2", records[1].Field("001").Value)
+	assert.Equal(t, "9787111213826", records[0].SubfieldValue("020", "a"))
+
+	// 记录之间的换行被忽略
+	withNewlines := append(append([]byte{}, data...), '\n')
+	assert.Len(t, readAll(t, NewReader(bytes.NewReader(withNewlines))), 2)
+}
+
+func TestISO2709Invalid(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+	valid := buf.Bytes()
+
+	// 修改第一个目录项（头标区之后 12 字节：字段号、4 位长度、5 位起始位置）
+	withEntry := func(entry string) []byte {
+		data := append([]byte{}, valid...)
+		copy(data[leaderLen:leaderLen+directoryEntryLen], entry)
+		return data
+	}
+
+	cases := map[string][]byte{
+		"长度不是数字":   []byte("abcde" + strings.Repeat(" ", 30)),
+		"目录起始位置为负": withEntry("0010005-9999"),
+		"目录长度带符号":  withEntry("001+00500000"),
+		"目录项超出记录":  withEntry("001999900000"),
+		"记录不完整":    valid[:len(valid)-10],
+		"缺少结束符":    append(append([]byte{}, valid[:len(valid)-1]...), ' '),
+		"非UTF-8":   bytes.Replace(valid, []byte("Java"), []byte{'J', 0xff, 'v', 'a'}, 1),
+	}
+	for name, data := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewReader(bytes.NewReader(data)).Read()
+			assert.ErrorIs(t, err, ErrInvalidRecord)
+		})
+	}
+
+	// 超过 99999 字节的记录无法写入
+	long := &Record{}
+	for i := 0; i < 20; i++ {
+		long.Fields = append(long.Fields, Field{Tag: "500", Subfields: []Subfield{{Code: "a", Value: strings.Repeat("x", 9000)}}})
+	}
+	assert.ErrorIs(t, NewWriter(io.Discard).Write(long), ErrRecordTooLong)
+}
+
+func TestMARCXML(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewXMLWriter(&buf)
+	require.NoError(t, w.Write(sampleRecord()))
+	require.NoError(t, w.Close())
+
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<?xml"))
+	assert.Contains(t, out, `<collection xmlns="http://www.loc.gov/MARC21/slim">`)
+	assert.Contains(t, out, `<datafield tag="245" ind1="1" ind2="0">`)
+	assert.True(t, strings.HasSuffix(out, "</collection>\n"))
+
+	records := readAll(t, NewXMLReader(strings.NewReader(out)))
+	require.Len(t, records, 1)
+	assert.Equal(t, sampleRecord().Fields, records[0].Fields)
+
+	// 带命名空间前缀的单条记录
+	single := `<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim">
+  <marc:leader>00000nam a2200000   4500</marc:leader>
+  <marc:controlfield tag="001">42</marc:controlfield>
+  <marc:datafield tag="245" ind1="0" ind2="0"><marc:subfield code="a">Go 语言</marc:subfield></marc:datafield>
+</marc:record>`
+	records = readAll(t, NewXMLReader(strings.NewReader(single)))
+	require.Len(t, records, 1)
+	assert.Equal(t, "42", records[0].Field("001").Value)
+	assert.Equal(t, "Go 语言", records[0].SubfieldValue("245", "a"))
+
+	_, err := NewXMLReader(strings.NewReader("<collection><record><leader>")).Read()
+	assert.ErrorIs(t, err, ErrInvalidRecord)
+
+	// 嵌入其他文档的单条记录带命名空间
+	data, err := MarshalRecord(sampleRecord())
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `<record xmlns="http://www.loc.gov/MARC21/slim"><leader>`))
+	records = readAll(t, NewXMLReader(bytes.NewReader(data)))
+	require.Len(t, records, 1)
+	assert.Equal(t, sampleRecord().Fields, records[0].Fields)
+
+	// 没有记录时输出空的 collection
+	buf.Reset()
+	require.NoError(t, NewXMLWriter(&buf).Close())
+	assert.Empty(t, readAll(t, NewXMLReader(&buf)))
+}
+
+func TestSetSubfield(t *testing.T) {
+	rec := sampleRecord()
+
+	rec.SetSubfield("245", "a", "Thinking in Java")
+	assert.Equal(t, "Thinking in Java", rec.SubfieldValue("245", "a"))
+
+	rec.RemoveSubfield("245", "b")
+	assert.Equal(t, []Subfield{{Code: "a", Value: "Thinking in Java"}, {Code: "c", Value: "Bruce Eckel"}}, rec.Field("245").Subfields)
+
+	// 新字段按字段号插入
+	rec.SetSubfield("520", "a", "摘要")
+	rec.SetControl("005", "20260101000000.0")
+	var tags []string
+	for _, f := range rec.Fields {
+		tags = append(tags, f.Tag)
+	}
+	assert.Equal(t, []string{"001", "005", "020", "100", "245", "520", "650"}, tags)
+
+	// 删除最后一个子字段时字段一并删除
+	rec.SetSubfield("520", "a", "")
+	assert.Nil(t, rec.Field("520"))
+	rec.SetSubfield("100", "a", "")
+	assert.Nil(t, rec.Field("100"))
+}
@@ -0,0 +1,141 @@
+package marc
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// Namespace MARCXML 命名空间
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName  xml.Name          `xml:"record"`
+	Xmlns    string            `xml:"xmlns,attr,omitempty"`
+	Leader   string            `xml:"leader"`
+	Controls []xmlControlField `xml:"controlfield"`
+	Data     []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// XMLReader 读取 MARCXML，文件可以是 <collection> 或单个 <record>
+type XMLReader struct {
+	dec *xml.Decoder
+}
+
+func NewXMLReader(r io.Reader) *XMLReader {
+	return &XMLReader{dec: xml.NewDecoder(r)}
+}
+
+func (r *XMLReader) Read() (*Record, error) {
+	for {
+		tok, err := r.dec.Token()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var x xmlRecord
+		if err := r.dec.DecodeElement(&x, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecord, err)
+		}
+		return fromXML(&x), nil
+	}
+}
+
+// fromXML 控制字段按 MARCXML 的约定排在数据字段之前
+func fromXML(x *xmlRecord) *Record {
+	rec := &Record{Leader: x.Leader, Fields: make([]Field, 0, len(x.Controls)+len(x.Data))}
+	for _, c := range x.Controls {
+		rec.Fields = append(rec.Fields, Field{Tag: c.Tag, Value: c.Value})
+	}
+	for _, d := range x.Data {
+		field := Field{Tag: d.Tag, Ind1: d.Ind1, Ind2: d.Ind2}
+		for _, sf := range d.Subfields {
+			field.Subfields = append(field.Subfields, Subfield{Code: sf.Code, Value: sf.Value})
+		}
+		rec.Fields = append(rec.Fields, field)
+	}
+	return rec
+}
+
+// XMLWriter 以 MARCXML <collection> 写入记录
+type XMLWriter struct {
+	w       *bufio.Writer
+	enc     *xml.Encoder
+	started bool
+}
+
+func NewXMLWriter(w io.Writer) *XMLWriter {
+	bw := bufio.NewWriter(w)
+	return &XMLWriter{w: bw, enc: xml.NewEncoder(bw)}
+}
+
+func (w *XMLWriter) start() {
+	if !w.started {
+		w.started = true
+		w.w.WriteString(xml.Header + `<collection xmlns="` + Namespace + `">` + "\n")
+	}
+}
+
+func (w *XMLWriter) Write(rec *Record) error {
+	w.start()
+	if err := w.enc.Encode(toXML(rec)); err != nil {
+		return err
+	}
+	_, err := w.w.WriteString("\n")
+	return err
+}
+
+// Close 写入 </collection>；没有任何记录时输出空的 collection
+func (w *XMLWriter) Close() error {
+	w.start()
+	w.w.WriteString("</collection>\n")
+	return w.w.Flush()
+}
+
+// MarshalRecord 把单条记录编码为带命名空间的 <record> 元素，用于嵌入其他 XML 文档
+func MarshalRecord(rec *Record) ([]byte, error) {
+	x := toXML(rec)
+	x.Xmlns = Namespace
+	return xml.Marshal(x)
+}
+
+func toXML(rec *Record) *xmlRecord {
+	x := &xmlRecord{Leader: string(leader(rec.Leader))}
+	for _, f := range rec.Fields {
+		if IsControlTag(f.Tag) {
+			x.Controls = append(x.Controls, xmlControlField{Tag: f.Tag, Value: f.Value})
+			continue
+		}
+		d := xmlDataField{Tag: f.Tag, Ind1: string(indicator(f.Ind1)), Ind2: string(indicator(f.Ind2))}
+		for _, sf := range f.Subfields {
+			d.Subfields = append(d.Subfields, xmlSubfield{Code: sf.Code, Value: sf.Value})
+		}
+		x.Data = append(x.Data, d)
+	}
+	return x
+}
@@ -0,0 +1,165 @@
+// Package metadata 按 ISBN 从外部书目服务查询书名、作者和简介。
+// Chain 依次查询多个提供方并合并结果，查询结果写入缓存
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidISBN = errors.New("invalid isbn")
+	ErrNotFound    = errors.New("metadata not found")
+	// ErrUnavailable 没有查到结果，且至少一个提供方请求失败（超时、服务错误等）
+	ErrUnavailable = errors.New("metadata providers unavailable")
+)
+
+// Metadata 书目信息，Sources 为提供了字段的提供方名称
+type Metadata struct {
+	ISBN    string   `json:"isbn"`
+	Title   string   `json:"title"`
+	Authors []string `json:"authors"`
+	Summary string   `json:"summary"`
+	Sources []string `json:"sources"`
+}
+
+// complete 书名、作者和简介都已填写
+func (m *Metadata) complete() bool {
+	return m.Title != "" && len(m.Authors) > 0 && m.Summary != ""
+}
+
+// merge 用 other 填写空字段，返回是否填写了任何字段
+func (m *Metadata) merge(other *Metadata) bool {
+	filled := false
+	if m.Title == "" && other.Title != "" {
+		m.Title, filled = other.Title, true
+	}
+	if len(m.Authors) == 0 && len(other.Authors) > 0 {
+		m.Authors, filled = other.Authors, true
+	}
+	if m.Summary == "" && other.Summary != "" {
+		m.Summary, filled = other.Summary, true
+	}
+	return filled
+}
+
+// Provider 书目信息提供方
+type Provider interface {
+	Name() string
+	// Lookup 查询规范化后的 ISBN，没有该书时返回 ErrNotFound
+	Lookup(ctx context.Context, isbn string) (*Metadata, error)
+}
+
+// NewProvider 根据配置创建提供方，timeout 为单次请求的超时时间
+func NewProvider(kind, name, baseURL string, timeout time.Duration) (Provider, error) {
+	switch kind {
+	case "openlibrary":
+		return NewOpenLibrary(name, baseURL, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown metadata provider type %q", kind)
+	}
+}
+
+// Cache 查询结果缓存，未命中时 Get 返回 ErrNotFound
+type Cache interface {
+	Get(isbn string) (md *Metadata, fetchedAt time.Time, err error)
+	Put(md *Metadata) error
+}
+
+// Chain 按顺序查询提供方：前一个提供方缺少的字段由后一个补充，字段齐全后不再继续查询。
+// 缓存未过期时直接返回缓存结果
+type Chain struct {
+	Providers []Provider
+	Cache     Cache         // 可为空
+	CacheTTL  time.Duration // 缓存有效期
+}
+
+// Lookup 查询 ISBN（可带连字符），cached 表示结果来自缓存
+func (c *Chain) Lookup(ctx context.Context, isbn string) (md *Metadata, cached bool, err error) {
+	normalized, ok := NormalizeISBN(isbn)
+	if !ok {
+		return nil, false, ErrInvalidISBN
+	}
+
+	if c.Cache != nil {
+		md, fetchedAt, err := c.Cache.Get(normalized)
+		if err == nil && time.Since(fetchedAt) < c.CacheTTL {
+			return md, true, nil
+		}
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			log.Printf("读取书目缓存失败 (ISBN: %s): %v", normalized, err)
+		}
+	}
+
+	md = &Metadata{ISBN: normalized}
+	var failed error
+	for _, provider := range c.Providers {
+		found, err := provider.Lookup(ctx, normalized)
+		if err != nil {
+			if !errors.Is(err, ErrNotFound) {
+				log.Printf("书目查询失败 (提供方: %s, ISBN: %s): %v", provider.Name(), normalized, err)
+				failed = err
+			}
+			continue
+		}
+		if md.merge(found) {
+			md.Sources = append(md.Sources, provider.Name())
+		}
+		if md.complete() {
+			break
+		}
+	}
+
+	if len(md.Sources) == 0 {
+		if failed != nil {
+			return nil, false, fmt.Errorf("%w: %v", ErrUnavailable, failed)
+		}
+		return nil, false, ErrNotFound
+	}
+	if c.Cache != nil {
+		if err := c.Cache.Put(md); err != nil {
+			log.Printf("写入书目缓存失败 (ISBN: %s): %v", normalized, err)
+		}
+	}
+	return md, false, nil
+}
+
+// NormalizeISBN 去掉连字符和空格，校验 ISBN-10 或 ISBN-13 的校验位
+func NormalizeISBN(isbn string) (string, bool) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, r := range s {
+			var d int
+			switch {
+			case r >= '0' && r <= '9':
+				d = int(r - '0')
+			case r == 'X' && i == 9:
+				d = 10
+			default:
+				return "", false
+			}
+			sum += (10 - i) * d
+		}
+		return s, sum%11 == 0
+	case 13:
+		sum := 0
+		for i, r := range s {
+			if r < '0' || r > '9' {
+				return "", false
+			}
+			weight := 1
+			if i%2 == 1 {
+				weight = 3
+			}
+			sum += weight * int(r-'0')
+		}
+		return s, sum%10 == 0
+	}
+	return "", false
+}
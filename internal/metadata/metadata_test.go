@@ -0,0 +1,169 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOpenLibrary 本地模拟的 Open Library，books 为 ISBN -> 响应中的书目 JSON
+func fakeOpenLibrary(t *testing.T, books map[string]string) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/api/books", r.URL.Path)
+		assert.Equal(t, "details", r.URL.Query().Get("jscmd"))
+		key := r.URL.Query().Get("bibkeys")
+		w.Header().Set("Content-Type", "application/json")
+		if book, ok := books[key]; ok {
+			_, _ = w.Write([]byte(`{"` + key + `":` + book + `}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+// memoryCache 内存缓存
+type memoryCache struct {
+	entries   map[string]*Metadata
+	fetchedAt time.Time
+}
+
+func (m *memoryCache) Get(isbn string) (*Metadata, time.Time, error) {
+	if md, ok := m.entries[isbn]; ok {
+		return md, m.fetchedAt, nil
+	}
+	return nil, time.Time{}, ErrNotFound
+}
+
+func (m *memoryCache) Put(md *Metadata) error {
+	m.entries[md.ISBN], m.fetchedAt = md, time.Now()
+	return nil
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	for _, isbn := range []string{"978-7-111-54742-6", "9787111547426", "0-306-40615-2", "0 8044 2957 x"} {
+		_, ok := NormalizeISBN(isbn)
+		assert.True(t, ok, isbn)
+	}
+	isbn, _ := NormalizeISBN("0-8044-2957-x")
+	assert.Equal(t, "080442957X", isbn)
+
+	for _, isbn := range []string{"978-7-111-54742-7", "030640615X", "97871115474", "978711154742a", ""} {
+		_, ok := NormalizeISBN(isbn)
+		assert.False(t, ok, isbn)
+	}
+}
+
+func TestOpenLibrary(t *testing.T) {
+	server, _ := fakeOpenLibrary(t, map[string]string{
+		"ISBN:9787111547426": `{"details": {"title": " Go程序设计语言 ", "authors": [{"key": "/authors/OL1A", "name": "Alan A. A. Donovan"}, {"name": "Brian W. Kernighan"}], "description": {"type": "/type/text", "value": "Go 语言权威指南"}}}`,
+		"ISBN:0306406152":    `{"details": {"title": "Plain", "description": "字符串简介"}}`,
+	})
+	provider, err := NewProvider("openlibrary", "openlibrary", server.URL+"/", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "openlibrary", provider.Name())
+
+	md, err := provider.Lookup(context.Background(), "9787111547426")
+	require.NoError(t, err)
+	assert.Equal(t, "Go程序设计语言", md.Title)
+	assert.Equal(t, []string{"Alan A. A. Donovan", "Brian W. Kernighan"}, md.Authors)
+	assert.Equal(t, "Go 语言权威指南", md.Summary)
+
+	md, err = provider.Lookup(context.Background(), "0306406152")
+	require.NoError(t, err)
+	assert.Equal(t, "字符串简介", md.Summary)
+	assert.Empty(t, md.Authors)
+
+	_, err = provider.Lookup(context.Background(), "9780000000002")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	// 超出响应体上限时按解析失败处理，不会读入全部内容
+	huge, _ := fakeOpenLibrary(t, map[string]string{
+		"ISBN:9787111547426": `{"details": {"title": "Go", "description": "` + strings.Repeat("x", maxResponseSize) + `"}}`,
+	})
+	provider, err = NewProvider("openlibrary", "huge", huge.URL, time.Second)
+	require.NoError(t, err)
+	_, err = provider.Lookup(context.Background(), "9787111547426")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	_, err = NewProvider("unknown", "x", "", time.Second)
+	assert.Error(t, err)
+}
+
+func TestChain(t *testing.T) {
+	// 第一个提供方只有书名，第二个补充作者和简介
+	partial, partialRequests := fakeOpenLibrary(t, map[string]string{
+		"ISBN:9787111547426": `{"details": {"title": "Go程序设计语言"}}`,
+	})
+	full, fullRequests := fakeOpenLibrary(t, map[string]string{
+		"ISBN:9787111547426": `{"details": {"title": "The Go Programming Language", "authors": [{"name": "Donovan"}], "description": "简介"}}`,
+		"ISBN:0306406152":    `{"details": {"title": "Only Second"}}`,
+	})
+	// 超时的提供方
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	t.Cleanup(slow.Close)
+
+	cache := &memoryCache{entries: map[string]*Metadata{}}
+	chain := &Chain{
+		Providers: []Provider{
+			NewOpenLibrary("slow", slow.URL, 50*time.Millisecond),
+			NewOpenLibrary("local", partial.URL, time.Second),
+			NewOpenLibrary("openlibrary", full.URL, time.Second),
+		},
+		Cache:    cache,
+		CacheTTL: time.Hour,
+	}
+
+	md, cached, err := chain.Lookup(context.Background(), "978-7-111-54742-6")
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, "9787111547426", md.ISBN)
+	assert.Equal(t, "Go程序设计语言", md.Title)
+	assert.Equal(t, []string{"Donovan"}, md.Authors)
+	assert.Equal(t, "简介", md.Summary)
+	assert.Equal(t, []string{"local", "openlibrary"}, md.Sources)
+
+	// 第二次查询命中缓存，不再请求提供方
+	md, cached, err = chain.Lookup(context.Background(), "9787111547426")
+	require.NoError(t, err)
+	assert.True(t, cached)
+	assert.Equal(t, "Go程序设计语言", md.Title)
+	assert.Equal(t, 1, *partialRequests)
+	assert.Equal(t, 1, *fullRequests)
+
+	// 缓存过期后重新查询
+	cache.fetchedAt = time.Now().Add(-2 * time.Hour)
+	_, cached, err = chain.Lookup(context.Background(), "9787111547426")
+	require.NoError(t, err)
+	assert.False(t, cached)
+	assert.Equal(t, 2, *fullRequests)
+
+	// 前面的提供方没有结果时由后面的提供
+	md, _, err = chain.Lookup(context.Background(), "0-306-40615-2")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"openlibrary"}, md.Sources)
+
+	// 都没有结果：有提供方失败时为 ErrUnavailable，否则为 ErrNotFound
+	_, _, err = chain.Lookup(context.Background(), "9780000000002")
+	assert.ErrorIs(t, err, ErrUnavailable)
+	chain.Providers = chain.Providers[1:]
+	_, _, err = chain.Lookup(context.Background(), "9780000000002")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.False(t, errors.Is(err, ErrUnavailable))
+
+	_, _, err = chain.Lookup(context.Background(), "123")
+	assert.ErrorIs(t, err, ErrInvalidISBN)
+}
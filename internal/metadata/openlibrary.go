@@ -0,0 +1,114 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// maxResponseSize 响应体上限，单本书的详情远小于此，超出时按解析失败处理
+const maxResponseSize = 1 << 20
+
+// OpenLibrary Open Library Books API（/api/books?jscmd=details）及兼容的服务
+type OpenLibrary struct {
+	name    string
+	baseURL string
+	client  *http.Client
+}
+
+// NewOpenLibrary baseURL 为空时使用 https://openlibrary.org
+func NewOpenLibrary(name, baseURL string, timeout time.Duration) *OpenLibrary {
+	if baseURL == "" {
+		baseURL = "https://openlibrary.org"
+	}
+	return &OpenLibrary{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+func (o *OpenLibrary) Name() string {
+	return o.name
+}
+
+// openLibraryText 简介可能是字符串，也可能是 {"type": "/type/text", "value": "..."}
+type openLibraryText string
+
+func (t *openLibraryText) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = openLibraryText(s)
+		return nil
+	}
+	var obj struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*t = openLibraryText(obj.Value)
+	return nil
+}
+
+type openLibraryBook struct {
+	Details struct {
+		Title   string `json:"title"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+		Description openLibraryText `json:"description"`
+	} `json:"details"`
+}
+
+func (o *OpenLibrary) Lookup(ctx context.Context, isbn string) (*Metadata, error) {
+	key := "ISBN:" + isbn
+	q := url.Values{}
+	q.Set("bibkeys", key)
+	q.Set("format", "json")
+	q.Set("jscmd", "details")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+"/api/books?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	// 没有该书时返回 {}
+	var books map[string]openLibraryBook
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&books); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	book, ok := books[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	md := &Metadata{
+		ISBN:    isbn,
+		Title:   strings.TrimSpace(book.Details.Title),
+		Summary: strings.TrimSpace(string(book.Details.Description)),
+	}
+	for _, author := range book.Details.Authors {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			md.Authors = append(md.Authors, name)
+		}
+	}
+	return md, nil
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit 审计日志写入函数，测试中可替换
+var recordAudit = service.RecordAudit
+
+// Audit 记录当前接口的审计日志：操作者、来源、处理结果；
+// 操作对象和变更快照由处理器通过 audit 包补充
+func Audit(action, targetType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry := audit.Begin(c, action, targetType)
+
+		c.Next()
+
+		fillActor(c, entry)
+		entry.Outcome = model.AuditSuccess
+		if code, exists := c.Get(result.CodeKey); (exists && code != result.SuccessCode) || c.Writer.Status() >= http.StatusBadRequest {
+			entry.Outcome = model.AuditFailure
+			entry.Detail = c.GetString(result.MessageKey)
+		}
+
+		recordAudit(entry)
+	}
+}
+
+// AuditDenied 记录被认证中间件拒绝的请求，需放在 AuthMiddleware 之前
+func AuditDenied(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		if !c.IsAborted() || (status != http.StatusUnauthorized && status != http.StatusForbidden) {
+			return
+		}
+
+		entry := &model.AuditLog{
+			Action:     action,
+			TargetType: "route",
+			TargetID:   c.Request.Method + " " + c.FullPath(),
+			IP:         c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Outcome:    model.AuditFailure,
+			Detail:     http.StatusText(status),
+		}
+		fillActor(c, entry)
+		recordAudit(entry)
+	}
+}
+
+// fillActor 从认证中间件写入的上下文中取操作者
+func fillActor(c *gin.Context, entry *model.AuditLog) {
+	if userID := c.GetUint("user_id"); userID != 0 {
+		entry.ActorID = userID
+	}
+	if keyID := c.GetUint("api_key_id"); keyID != 0 {
+		entry.APIKeyID = keyID
+		entry.ActorType = model.ActorAPIKey
+		return
+	}
+	if entry.ActorID != 0 {
+		entry.ActorType = model.ActorUser
+	} else {
+		entry.ActorType = model.ActorAnonymous
+	}
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureAudit 替换审计写入函数，返回记录到的日志
+func captureAudit(t *testing.T) *[]*model.AuditLog {
+	var entries []*model.AuditLog
+	original := recordAudit
+	recordAudit = func(entry *model.AuditLog) { entries = append(entries, entry) }
+	t.Cleanup(func() { recordAudit = original })
+	return &entries
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	entries := captureAudit(t)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Next()
+	})
+	r.PUT("/books/:id", Audit("book.update", "book"), func(c *gin.Context) {
+		audit.SetChange(c, map[string]string{"title": "old"}, map[string]string{"title": "new"})
+		result.Success(c, nil)
+	})
+	r.DELETE("/books/:id", Audit("book.delete", "book"), func(c *gin.Context) {
+		result.Failed(c, result.FailedCode, "书籍删除失败")
+	})
+
+	req := httptest.NewRequest(http.MethodPut, "/books/9", nil)
+	req.Header.Set("User-Agent", "test-agent")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, 1, len(*entries))
+	e := (*entries)[0]
+	assert.Equal(t, "book.update", e.Action)
+	assert.Equal(t, uint(5), e.ActorID)
+	assert.Equal(t, model.ActorUser, e.ActorType)
+	assert.Equal(t, "book", e.TargetType)
+	assert.Equal(t, "9", e.TargetID)
+	assert.Equal(t, "test-agent", e.UserAgent)
+	assert.Equal(t, model.AuditSuccess, e.Outcome)
+	assert.JSONEq(t, `{"title":"old"}`, e.Before)
+	assert.JSONEq(t, `{"title":"new"}`, e.After)
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/books/9", nil))
+	assert.Equal(t, 2, len(*entries))
+	assert.Equal(t, model.AuditFailure, (*entries)[1].Outcome)
+	assert.Equal(t, "书籍删除失败", (*entries)[1].Detail)
+}
+
+func TestAuditAPIKeyActor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	entries := captureAudit(t)
+
+	r := gin.New()
+	r.POST("/books/add", func(c *gin.Context) {
+		c.Set("user_id", uint(5))
+		c.Set("api_key_id", uint(3))
+		c.Next()
+	}, Audit("book.create", "book"), func(c *gin.Context) {
+		result.Success(c, nil)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/books/add", nil))
+	assert.Equal(t, 1, len(*entries))
+	assert.Equal(t, model.ActorAPIKey, (*entries)[0].ActorType)
+	assert.Equal(t, uint(3), (*entries)[0].APIKeyID)
+	assert.Equal(t, uint(5), (*entries)[0].ActorID)
+}
+
+func TestAuditDenied(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	entries := captureAudit(t)
+
+	r := gin.New()
+	r.Use(AuditDenied("admin.access_denied"))
+	r.GET("/admin/denied", func(c *gin.Context) {
+		c.Set("user_id", uint(2))
+		c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
+		c.Abort()
+	})
+	r.GET("/admin/ok", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/ok", nil))
+	assert.Equal(t, 0, len(*entries))
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/denied", nil))
+	assert.Equal(t, 1, len(*entries))
+	e := (*entries)[0]
+	assert.Equal(t, "admin.access_denied", e.Action)
+	assert.Equal(t, "GET /admin/denied", e.TargetID)
+	assert.Equal(t, uint(2), e.ActorID)
+	assert.Equal(t, model.AuditFailure, e.Outcome)
+}
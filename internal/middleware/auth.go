@@ -1,45 +1,170 @@
 package middleware
 
 import (
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/service"
 	"LibraryManagement/internal/utils"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-// AuthMiddleware JWT 认证中间件
+// 认证失败的错误，响应格式与处理器的错误响应一致
+var (
+	errMissingToken  = apperr.Unauthorized("missing_token", "未提供认证令牌")
+	errInvalidToken  = apperr.Unauthorized("invalid_token", "无效或过期的令牌")
+	errTokenRevoked  = apperr.Unauthorized("token_revoked", "令牌已失效，请重新登录")
+	errForbidden     = apperr.Forbidden("forbidden", "权限不足")
+	errScopeRequired = apperr.Forbidden("scope_required", "API Key 权限不足")
+	errSessionOnly   = apperr.Forbidden("session_required", "该接口不支持使用API Key访问")
+	errMFARequired   = apperr.Forbidden("mfa_required", "管理员账号需先启用两步验证")
+)
+
+// AuthMiddleware 认证中间件，支持 Bearer JWT 和 API Key 两种凭证
 func AuthMiddleware(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr := c.GetHeader("Authorization")
-		if tokenStr == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "未提供认证令牌"})
-			c.Abort()
+		var user *model.User
+		if apiKey := extractAPIKey(c); apiKey != "" {
+			key, owner, err := service.AuthenticateAPIKey(apiKey)
+			if err != nil {
+				// 不区分 Key 无效和所属账号被禁用，避免泄露账号状态
+				result.Abort(c, service.ErrInvalidAPIKey)
+				return
+			}
+			user = owner
+			c.Set("api_key_id", key.ID)
+			c.Set("api_key_scopes", key.ScopeList())
+		} else {
+			var ok bool
+			if user, ok = authenticateToken(c, requiredRole); !ok {
+				return
+			}
+		}
+
+		// 将用户信息存入上下文（先于角色校验写入，审计日志可记录被拒绝的用户）
+		c.Set("user_id", user.ID)
+		c.Set("user_role", user.Role)
+
+		// 强制模式下，未启用两步验证的管理员不能访问管理接口（仍可通过 /api/me/mfa 完成注册）；
+		// 对 API Key 同样按所属用户检查，否则可以先申请 admin 权限的 Key 绕过两步验证
+		if requiredRole == "admin" && config.Config.Auth.MFA.EnforceAdmin && !user.TOTPEnabled {
+			result.Abort(c, errMFARequired)
 			return
 		}
 
-		// Bearer <token>
-		if len(tokenStr) > 7 && tokenStr[:7] == "Bearer " {
-			tokenStr = tokenStr[7:]
+		// 角色权限校验
+		if requiredRole != "" && user.Role != requiredRole {
+			result.Abort(c, errForbidden)
+			return
 		}
 
-		claims, err := utils.ParseToken(tokenStr)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "无效或过期的令牌"})
-			c.Abort()
+		c.Next()
+	}
+}
+
+// RequireScope 要求 API Key 具备指定权限范围；使用 JWT 登录的请求不受限制
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, exists := c.Get("api_key_scopes")
+		if !exists {
+			c.Next()
 			return
 		}
 
-		// 角色权限校验
-		if requiredRole != "" && claims.Role != requiredRole {
-			c.JSON(http.StatusForbidden, gin.H{"error": "权限不足"})
-			c.Abort()
+		for _, s := range value.([]string) {
+			if s == scope || s == model.ScopeAdmin {
+				c.Next()
+				return
+			}
+		}
+
+		result.Abort(c, errScopeRequired.WithMessage(errScopeRequired.Message+"，需要 "+scope))
+	}
+}
+
+// SessionOnly 只允许用户本人登录后访问（如修改密码、管理 API Key），拒绝 API Key
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, exists := c.Get("api_key_id"); exists {
+			result.Abort(c, errSessionOnly)
 			return
 		}
+		c.Next()
+	}
+}
 
-		// 将用户信息存入上下文
-		c.Set("user_id", claims.UserID)
-		c.Set("user_role", claims.Role)
+// authenticateToken 校验 Bearer JWT，失败时写入响应并返回 false
+func authenticateToken(c *gin.Context, requiredRole string) (*model.User, bool) {
+	tokenStr := c.GetHeader("Authorization")
+	if tokenStr == "" {
+		result.Abort(c, errMissingToken)
+		return nil, false
+	}
+
+	// Bearer <token>
+	if len(tokenStr) > 7 && tokenStr[:7] == "Bearer " {
+		tokenStr = tokenStr[7:]
+	}
+
+	claims, err := utils.ParseToken(tokenStr)
+	// 两步验证挑战令牌不能用于访问业务接口
+	if err != nil || claims.Purpose != "" {
+		result.Abort(c, errInvalidToken)
+		return nil, false
+	}
+
+	// 修改或重置密码后 token_version 递增，旧令牌随之失效
+	user, err := dao.ApiDao.GetUserByIdDAO(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion {
+		result.Abort(c, errTokenRevoked)
+		return nil, false
+	}
+
+	if user.Disabled {
+		result.Abort(c, service.ErrUserDisabled)
+		return nil, false
+	}
+
+	return user, true
+}
 
+// extractAPIKey 从 X-API-Key、Authorization: ApiKey <key> 或 Basic 认证的密码中读取 API Key。
+// 电子书阅读器等只支持 Basic 认证的客户端以任意用户名和 API Key 作为密码访问
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "ApiKey ") {
+		return strings.TrimPrefix(auth, "ApiKey ")
+	}
+	if _, password, ok := c.Request.BasicAuth(); ok {
+		return password
+	}
+	return ""
+}
+
+// BasicChallenge 认证失败返回 401 时附带 WWW-Authenticate: Basic，
+// 让只支持 Basic 认证的客户端提示用户输入凭证。需放在 AuthMiddleware 之前
+func BasicChallenge(realm string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &challengeWriter{ResponseWriter: c.Writer, challenge: `Basic realm="` + realm + `", charset="UTF-8"`}
 		c.Next()
 	}
 }
+
+type challengeWriter struct {
+	gin.ResponseWriter
+	challenge string
+}
+
+func (w *challengeWriter) WriteHeader(code int) {
+	if code == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", w.challenge)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
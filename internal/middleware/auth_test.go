@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"LibraryManagement/internal/api/result"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/utils"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withScopes 模拟 AuthMiddleware 对 API Key 请求写入的上下文
+func withScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes != nil {
+			c.Set("api_key_id", uint(1))
+			c.Set("api_key_scopes", scopes)
+		}
+		c.Next()
+	}
+}
+
+func serve(r http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+
+	r := gin.New()
+	r.GET("/jwt", withScopes(), RequireScope(model.ScopeBooksWrite), ok)
+	r.GET("/read", withScopes(model.ScopeBooksRead), RequireScope(model.ScopeBooksWrite), ok)
+	r.GET("/write", withScopes(model.ScopeBooksRead, model.ScopeBooksWrite), RequireScope(model.ScopeBooksWrite), ok)
+	r.GET("/admin", withScopes(model.ScopeAdmin), RequireScope(model.ScopeBooksWrite), ok)
+
+	assert.Equal(t, http.StatusOK, serve(r, "/jwt").Code)
+	denied := serve(r, "/read")
+	assert.Equal(t, http.StatusForbidden, denied.Code)
+	assert.Contains(t, denied.Body.String(), `"error_code":"scope_required"`)
+	assert.Equal(t, http.StatusOK, serve(r, "/write").Code)
+	assert.Equal(t, http.StatusOK, serve(r, "/admin").Code)
+}
+
+func TestSessionOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "ok") }
+
+	r := gin.New()
+	r.GET("/jwt", withScopes(), SessionOnly(), ok)
+	r.GET("/key", withScopes(model.ScopeAdmin), SessionOnly(), ok)
+
+	assert.Equal(t, http.StatusOK, serve(r, "/jwt").Code)
+	assert.Equal(t, http.StatusForbidden, serve(r, "/key").Code)
+}
+
+func TestExtractAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := map[string][2]string{
+		"lm_a_b": {"X-API-Key", "lm_a_b"},
+		"lm_c_d": {"Authorization", "ApiKey lm_c_d"},
+		"lm_e_f": {"Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte("reader:lm_e_f"))},
+		"":       {"Authorization", "Bearer token"},
+	}
+	for want, header := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		c.Request.Header.Set(header[0], header[1])
+		assert.Equal(t, want, extractAPIKey(c))
+	}
+}
+
+func TestBasicChallenge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.GET("/denied", BasicChallenge("Library"), func(c *gin.Context) { result.Abort(c, errMissingToken) })
+	r.GET("/ok", BasicChallenge("Library"), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	w := serve(r, "/denied")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, `Basic realm="Library", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+	assert.Contains(t, w.Body.String(), `"error_code":"missing_token"`)
+
+	w = serve(r, "/ok")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("WWW-Authenticate"))
+}
+
+// keyDAO 只实现 API Key 认证用到的查询，其余方法未实现
+type keyDAO struct {
+	dao.ApiDBDao
+	key  *model.APIKey
+	user *model.User
+}
+
+func (d *keyDAO) GetAPIKeyByPrefixDAO(prefix string) (*model.APIKey, error) { return d.key, nil }
+func (d *keyDAO) GetUserByIdDAO(id uint) (*model.User, error)               { return d.user, nil }
+func (d *keyDAO) TouchAPIKeyDAO(id uint, usedAt time.Time) error            { return nil }
+
+func TestAuthMiddlewareAPIKeyMFA(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	raw := "lm_abcd1234_secret"
+	owner := &model.User{Role: "admin"}
+	owner.ID = 1
+	fake := &keyDAO{
+		key:  &model.APIKey{UserID: 1, Prefix: "abcd1234", KeyHash: utils.HashToken(raw), Scopes: model.ScopeAdmin},
+		user: owner,
+	}
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("auth:\n  mfa:\n    enforce_admin: true\n"), 0o600))
+	originalDAO, originalConfig := dao.ApiDao, config.Config
+	defer func() { dao.ApiDao, config.Config = originalDAO, originalConfig }()
+	require.NoError(t, config.LoadConfig(path))
+	dao.ApiDao = fake
+
+	r := gin.New()
+	r.GET("/admin", AuthMiddleware("admin"), func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+	request := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("X-API-Key", raw)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 所属管理员未启用两步验证时，API Key 同样不能访问管理接口
+	w := request()
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Contains(t, w.Body.String(), `"error_code":"mfa_required"`)
+
+	owner.TOTPEnabled = true
+	assert.Equal(t, http.StatusOK, request().Code)
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// API Key 权限范围
+const (
+	ScopeBooksRead  = "books:read"  // 访问 /api 下的书籍查询接口
+	ScopeBooksWrite = "books:write" // 书籍增删改
+	ScopeAdmin      = "admin"       // 其余管理接口，包含以上全部权限
+)
+
+// API Key 类型
+const (
+	APIKeyPersonal = "personal" // 用户自行创建
+	APIKeyService  = "service"  // 管理员为服务账号创建
+)
+
+// APIKey 服务间调用凭证。明文只在创建时返回一次，库中保存哈希；
+// Prefix 为公开部分，用于识别和查找。软删除即吊销
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `gorm:"column:user_id;index;not null"`
+	Name       string     `gorm:"column:name;type:varchar(64);not null"`
+	Kind       string     `gorm:"column:kind;type:varchar(16);default:'personal';not null"`
+	Prefix     string     `gorm:"column:prefix;type:varchar(16);uniqueIndex;not null"`
+	KeyHash    string     `gorm:"column:key_hash;type:char(64);not null"`
+	Scopes     string     `gorm:"column:scopes;type:varchar(255);not null"` // 逗号分隔
+	ExpiresAt  *time.Time `gorm:"column:expires_at"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at"`
+}
+
+// ScopeList 返回权限范围列表
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(k.Scopes, ",")
+}
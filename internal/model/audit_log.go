@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// 审计结果
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// 操作者类型
+const (
+	ActorUser      = "user"
+	ActorAPIKey    = "api_key"
+	ActorSystem    = "system"
+	ActorAnonymous = "anonymous"
+)
+
+// AuditLog 安全审计日志，只追加、不修改，超过保留期后批量清理。
+// 不嵌入 gorm.Model：没有 updated_at，也不做软删除
+type AuditLog struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	ActorID    uint      `json:"actor_id" gorm:"column:actor_id;index"` // 0 表示匿名或系统
+	ActorType  string    `json:"actor_type" gorm:"column:actor_type;type:varchar(16);not null"`
+	APIKeyID   uint      `json:"api_key_id,omitempty" gorm:"column:api_key_id"` // 通过 API Key 调用时记录
+	Action     string    `json:"action" gorm:"column:action;type:varchar(64);index;not null"`
+	TargetType string    `json:"target_type" gorm:"column:target_type;type:varchar(32)"`
+	TargetID   string    `json:"target_id" gorm:"column:target_id;type:varchar(64)"`
+	IP         string    `json:"ip" gorm:"column:ip;type:varchar(45)"`
+	UserAgent  string    `json:"user_agent" gorm:"column:user_agent;type:varchar(255)"`
+	Before     string    `json:"-" gorm:"column:before_data;type:mediumtext"` // 变更前快照（JSON），书籍正文只记录长度和哈希
+	After      string    `json:"-" gorm:"column:after_data;type:mediumtext"`  // 变更后快照（JSON）
+	Outcome    string    `json:"outcome" gorm:"column:outcome;type:varchar(16);not null"`
+	Detail     string    `json:"detail" gorm:"column:detail;type:varchar(255)"` // 失败原因等补充信息
+}
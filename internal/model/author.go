@@ -0,0 +1,146 @@
+package model
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// MaxAuthorLength 与 books.author 的列宽一致
+const MaxAuthorLength = 100
+
+// 书籍贡献者的角色
+const (
+	ContributorAuthor      = "author"      // 著者
+	ContributorTranslator  = "translator"  // 译者
+	ContributorEditor      = "editor"      // 编者
+	ContributorIllustrator = "illustrator" // 绘者
+)
+
+// Author 作者（个人或机构），同名的不同作者用 Disambiguation 区分
+type Author struct {
+	gorm.Model
+	Name           string `gorm:"column:name;type:varchar(100);index:idx_author_name;not null" json:"name"`
+	Disambiguation string `gorm:"column:disambiguation;type:varchar(255)" json:"disambiguation"` // 如生卒年、国籍、所属机构
+	Bio            string `gorm:"column:bio;type:text" json:"bio"`
+}
+
+// BookContributor 书籍与作者的多对多关系，同一作者在一本书中可以担任多个角色
+type BookContributor struct {
+	ID       uint   `gorm:"primarykey"`
+	BookID   uint   `gorm:"column:book_id;uniqueIndex:idx_book_contributor,priority:1;not null"`
+	AuthorID uint   `gorm:"column:author_id;uniqueIndex:idx_book_contributor,priority:2;index:idx_contributor_author;not null"`
+	Role     string `gorm:"column:role;type:varchar(16);uniqueIndex:idx_book_contributor,priority:3;not null"`
+	Position int    `gorm:"column:position;not null;default:0"` // 在书籍贡献者中的顺序，从 0 开始
+}
+
+// Contributor 书籍的一位贡献者，AuthorID 为 0 表示尚未关联作者记录
+type Contributor struct {
+	AuthorID uint   `json:"author_id"`
+	Name     string `json:"name"`
+	Role     string `json:"role"`
+}
+
+// AuthorNames 角色为著者的贡献者姓名
+func AuthorNames(contributors []Contributor) []string {
+	var names []string
+	for _, c := range contributors {
+		if c.Role == ContributorAuthor {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// JoinAuthors 以逗号连接作者，超出 books.author 列宽的作者不再加入
+func JoinAuthors(authors []string) string {
+	joined := ""
+	for _, author := range authors {
+		next := author
+		if joined != "" {
+			next = joined + ", " + author
+		}
+		if utf8.RuneCountInString(next) > MaxAuthorLength {
+			break
+		}
+		joined = next
+	}
+	return joined
+}
+
+// contributorGroupSeparators 分隔不同贡献者组，如 "张三 著，李四 译"
+var contributorGroupSeparators = []string{",", "，", ";", "；", "/", "|"}
+
+// contributorRoleSuffixes 著录中常见的责任方式，较长的放在前面
+var contributorRoleSuffixes = []struct {
+	suffix string
+	role   string
+}{
+	{"编著", ContributorAuthor},
+	{"主编", ContributorEditor},
+	{"翻译", ContributorTranslator},
+	{"绘图", ContributorIllustrator},
+	{"著", ContributorAuthor},
+	{"编", ContributorEditor},
+	{"译", ContributorTranslator},
+	{"绘", ContributorIllustrator},
+	{"等", ""},
+}
+
+// ParseContributors 把书籍的作者字符串拆分为贡献者。
+// 逗号、分号、斜线分隔不同的组，组内用顿号分隔多人，组末的责任方式（著、译、编、绘等）
+// 适用于组内所有人，没有责任方式的视为著者；姓名前的国籍标记（如 [美]）去掉，重复的忽略
+func ParseContributors(author string) []Contributor {
+	groups := []string{author}
+	for _, sep := range contributorGroupSeparators {
+		var split []string
+		for _, g := range groups {
+			split = append(split, strings.Split(g, sep)...)
+		}
+		groups = split
+	}
+
+	var contributors []Contributor
+	seen := make(map[Contributor]bool)
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		role := ContributorAuthor
+		for _, s := range contributorRoleSuffixes {
+			rest := strings.TrimSpace(strings.TrimSuffix(group, s.suffix))
+			// 去掉后缀后至少保留两个字，避免误伤以这些字结尾的姓名；只有责任方式的组整体忽略
+			if rest != group && (rest == "" || utf8.RuneCountInString(rest) >= 2) {
+				group = rest
+				if s.role != "" {
+					role = s.role
+				}
+				break
+			}
+		}
+
+		for _, name := range strings.Split(group, "、") {
+			name = strings.TrimSpace(trimNationality(strings.TrimSpace(name)))
+			if name == "" || utf8.RuneCountInString(name) > MaxAuthorLength {
+				continue
+			}
+			c := Contributor{Name: name, Role: role}
+			if !seen[c] {
+				seen[c] = true
+				contributors = append(contributors, c)
+			}
+		}
+	}
+	return contributors
+}
+
+// trimNationality 去掉姓名前括号中的国籍或朝代，如 [美]、（清）
+func trimNationality(name string) string {
+	for _, pair := range [][2]string{{"[", "]"}, {"(", ")"}, {"（", "）"}, {"〔", "〕"}, {"【", "】"}} {
+		if strings.HasPrefix(name, pair[0]) {
+			if end := strings.Index(name, pair[1]); end > 0 && end+len(pair[1]) < len(name) {
+				return name[end+len(pair[1]):]
+			}
+		}
+	}
+	return name
+}
@@ -1,10 +1,20 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 //GORM 默认会将结构体名转换为复数形式并将其用作表名。如果你定义的 Go 结构体名称是 Book，
 //那么 GORM 会自动匹配到名为 books 的数据库表，因为它是根据结构体名 Book 转换成复数形式来决定表名的。
 
+// MaxTitleLength、MaxSummaryBytes 与 books.title（字符数）、books.summary（TEXT，字节数）的容量一致
+const (
+	MaxTitleLength  = 255
+	MaxSummaryBytes = 65535
+)
+
 type Book struct {
 	gorm.Model
 	Title   string `gorm:"column:title;type:varchar(255);comment:书名;NOT NULL" json:"title" json:"title,omitempty"`
@@ -15,6 +25,20 @@ type Book struct {
 	Summary string `gorm:"column:summary;type:text;comment:内容摘要" json:"summary"`
 
 	Version int `gorm:"column:version;default:1;comment:乐观锁版本号" json:"version"`
+
+	// Contributors 书籍的贡献者，不是表中的列，由 DAO 在需要时（如索引到 ES）一并读取
+	Contributors []Contributor `gorm:"-" json:"-"`
+	// Categories、Tags 书籍的分类和标签，与 Contributors 一样由 DAO 读取
+	Categories []Category `gorm:"-" json:"-"`
+	Tags       []string   `gorm:"-" json:"-"`
+}
+
+// Datestamp 书籍最后一次变化的时间：在回收站中为删除时间，否则为更新时间
+func (b *Book) Datestamp() time.Time {
+	if b.DeletedAt.Valid {
+		return b.DeletedAt.Time
+	}
+	return b.UpdatedAt
 }
 
 // ESBookDocument ES中的书籍文档结构
@@ -26,4 +50,151 @@ type ESBookDocument struct {
 	ISBN    string `json:"isbn"`
 	Content string `json:"content"`
 	Summary string `json:"summary"`
+
+	Contributors []Contributor `json:"contributors,omitempty"` // nested 类型，可按作者 ID 或角色检索
+
+	CategoryIDs   []uint   `json:"category_ids,omitempty"`   // 所属分类及其全部上级分类，按分类筛选时包含子孙分类
+	CategoryCodes []string `json:"category_codes,omitempty"` // 所属分类的分类号
+	Tags          []string `json:"tags,omitempty"`
+}
+
+// ESCategories 书籍分类在 ES 文档中的字段：所属分类及其上级分类的 ID（去重）和所属分类的分类号
+func ESCategories(categories []Category) ([]uint, []string) {
+	var ids []uint
+	var codes []string
+	seen := make(map[uint]bool)
+	for i := range categories {
+		for _, id := range categories[i].AncestorIDs() {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+		codes = append(codes, categories[i].Code)
+	}
+	return ids, codes
+}
+
+// 修订记录的来源
+const (
+	RevisionCreate   = "create"   // 新增书籍
+	RevisionUpdate   = "update"   // 全量或局部更新
+	RevisionRollback = "rollback" // 恢复到历史版本
+	RevisionBaseline = "baseline" // 启用修订记录前已存在的版本，首次更新时补记
+)
+
+// BookRevision 书籍修订记录，每个版本一条，保存该版本的完整快照。
+// 只追加、不修改，书籍彻底删除时一并清理
+type BookRevision struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time `json:"created_at"`
+	BookID       uint      `json:"book_id" gorm:"column:book_id;uniqueIndex:idx_book_revision,priority:1;not null"`
+	Version      int       `json:"version" gorm:"column:version;uniqueIndex:idx_book_revision,priority:2;not null"`
+	Action       string    `json:"action" gorm:"column:action;type:varchar(16);not null"`
+	ActorID      uint      `json:"actor_id" gorm:"column:actor_id"`                     // 0 表示系统或未知
+	APIKeyID     uint      `json:"api_key_id" gorm:"column:api_key_id"`                 // 通过 API Key 修改时记录
+	Fields       string    `json:"fields" gorm:"column:fields;type:varchar(255)"`       // 本次修改的字段，逗号分隔
+	RestoredFrom int       `json:"restored_from" gorm:"column:restored_from;default:0"` // 回滚时的来源版本
+	Snapshot     string    `json:"-" gorm:"column:snapshot;type:longtext;not null"`     // 该版本的完整内容（BookSnapshot JSON）
+}
+
+// BookSnapshot 修订记录中保存的书籍内容
+type BookSnapshot struct {
+	Title   string `json:"title"`
+	Count   uint   `json:"count"`
+	ISBN    string `json:"isbn"`
+	Author  string `json:"author"`
+	Content string `json:"content"`
+	Summary string `json:"summary"`
+}
+
+// BookFields 书籍可修改的字段（JSON 字段名），按展示顺序排列
+var BookFields = []string{"title", "count", "isbn", "author", "content", "summary"}
+
+// Snapshot 书籍当前内容的快照
+func (b *Book) Snapshot() BookSnapshot {
+	return BookSnapshot{
+		Title:   b.Title,
+		Count:   b.Count,
+		ISBN:    b.ISBN,
+		Author:  b.Author,
+		Content: b.Content,
+		Summary: b.Summary,
+	}
+}
+
+// Field 按 JSON 字段名取值
+func (s BookSnapshot) Field(name string) interface{} {
+	switch name {
+	case "title":
+		return s.Title
+	case "count":
+		return s.Count
+	case "isbn":
+		return s.ISBN
+	case "author":
+		return s.Author
+	case "content":
+		return s.Content
+	case "summary":
+		return s.Summary
+	}
+	return nil
+}
+
+// Merge 返回把 fields 中列出的字段替换为 changes 取值后的快照
+func (s BookSnapshot) Merge(changes BookSnapshot, fields []string) BookSnapshot {
+	merged := s
+	for _, field := range fields {
+		switch field {
+		case "title":
+			merged.Title = changes.Title
+		case "count":
+			merged.Count = changes.Count
+		case "isbn":
+			merged.ISBN = changes.ISBN
+		case "author":
+			merged.Author = changes.Author
+		case "content":
+			merged.Content = changes.Content
+		case "summary":
+			merged.Summary = changes.Summary
+		}
+	}
+	return merged
+}
+
+// ChangedFields 返回与 other 取值不同的字段
+func (s BookSnapshot) ChangedFields(other BookSnapshot) []string {
+	fields := make([]string, 0, len(BookFields))
+	for _, name := range BookFields {
+		if s.Field(name) != other.Field(name) {
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
+
+// BookMARC 通过 MARC 导入的书籍保存的原始记录（marc.Record JSON），
+// 导出时在原始记录上更新映射的字段，其余字段原样输出
+type BookMARC struct {
+	BookID    uint      `gorm:"column:book_id;primaryKey;autoIncrement:false"`
+	Record    string    `gorm:"column:record;type:longtext;not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (BookMARC) TableName() string {
+	return "book_marc_records"
+}
+
+// BookMetadataCache 按 ISBN 查询到的外部书目信息（metadata.Metadata JSON），
+// 有效期内的查询直接使用，不再请求外部服务
+type BookMetadataCache struct {
+	ISBN      string    `gorm:"column:isbn;type:varchar(13);primaryKey"` // 去掉连字符的 ISBN
+	Data      string    `gorm:"column:data;type:text;not null"`
+	FetchedAt time.Time `gorm:"column:fetched_at"`
+}
+
+func (BookMetadataCache) TableName() string {
+	return "book_metadata_cache"
 }
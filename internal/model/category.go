@@ -0,0 +1,71 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCategoryDepth 分类树的最大层数，受 categories.path 列宽限制
+const MaxCategoryDepth = 16
+
+// Category 分类树中的一个节点。Code 为《中国图书馆分类法》分类号（如 TP312）或自定义的分类号；
+// Path 为从根到本节点的 ID 路径（如 /1/5/12/），用于查询子孙分类
+type Category struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ParentID  uint      `gorm:"column:parent_id;index:idx_category_parent;not null;default:0" json:"parent_id"` // 0 表示顶级分类
+	Code      string    `gorm:"column:code;type:varchar(32);uniqueIndex:idx_category_code;not null" json:"code"`
+	Name      string    `gorm:"column:name;type:varchar(100);not null" json:"name"`
+	Path      string    `gorm:"column:path;type:varchar(255);not null" json:"path"`
+}
+
+// AncestorIDs 从顶级分类到本分类的 ID，包含本分类
+func (c *Category) AncestorIDs() []uint {
+	var ids []uint
+	for _, s := range strings.Split(strings.Trim(c.Path, "/"), "/") {
+		if id, err := strconv.ParseUint(s, 10, 32); err == nil {
+			ids = append(ids, uint(id))
+		}
+	}
+	return ids
+}
+
+// CategoryPath 子分类的 Path，parent 为 nil 表示顶级分类
+func CategoryPath(parent *Category, id uint) string {
+	prefix := "/"
+	if parent != nil {
+		prefix = parent.Path
+	}
+	return prefix + strconv.FormatUint(uint64(id), 10) + "/"
+}
+
+// CategorySubtreePattern 匹配该分类及其全部子孙分类 Path 的 LIKE 模式
+func CategorySubtreePattern(id uint) string {
+	return "%/" + strconv.FormatUint(uint64(id), 10) + "/%"
+}
+
+// BookCategory 书籍与分类的多对多关系
+type BookCategory struct {
+	ID         uint `gorm:"primarykey"`
+	BookID     uint `gorm:"column:book_id;uniqueIndex:idx_book_category,priority:1;not null"`
+	CategoryID uint `gorm:"column:category_id;uniqueIndex:idx_book_category,priority:2;index:idx_category_book;not null"`
+}
+
+// MaxTagLength 标签的最大长度
+const MaxTagLength = 50
+
+// Tag 自由标签，为书籍设置标签时自动创建
+type Tag struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Name      string    `gorm:"column:name;type:varchar(50);uniqueIndex:idx_tag_name;not null" json:"name"`
+}
+
+// BookTag 书籍与标签的多对多关系
+type BookTag struct {
+	ID     uint `gorm:"primarykey"`
+	BookID uint `gorm:"column:book_id;uniqueIndex:idx_book_tag,priority:1;not null"`
+	TagID  uint `gorm:"column:tag_id;uniqueIndex:idx_book_tag,priority:2;index:idx_tag_book;not null"`
+}
@@ -1,10 +1,69 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type User struct {
 	gorm.Model
 	Username     string `json:"username" gorm:"uniqueIndex;not null"`
 	PasswordHash string `json:"-" gorm:"column:password_hash"` // 不返回给前端
 	Role         string `json:"role" gorm:"type:enum('user','admin');default:'user'"`
+	Disabled     bool   `json:"disabled" gorm:"column:disabled;default:false;not null"` // 被禁用的账号无法登录
+
+	// 个人资料
+	DisplayName       string `json:"display_name" gorm:"column:display_name;type:varchar(64)"`
+	Email             string `json:"email" gorm:"column:email;type:varchar(128);index"`
+	EmailVerified     bool   `json:"email_verified" gorm:"column:email_verified;default:false;not null"` // 邮箱由身份提供方确认过，修改邮箱后清除
+	Phone             string `json:"phone" gorm:"column:phone;type:varchar(20)"`
+	PreferredLanguage string `json:"preferred_language" gorm:"column:preferred_language;type:varchar(8);default:'zh'"`
+
+	// 两步验证（TOTP），密钥在确认启用前即写入，TOTPEnabled 为 true 才生效
+	TOTPSecret   string `json:"-" gorm:"column:totp_secret;type:varchar(64)"`
+	TOTPEnabled  bool   `json:"totp_enabled" gorm:"column:totp_enabled;default:false;not null"`
+	TOTPLastStep int64  `json:"-" gorm:"column:totp_last_step;default:0;not null"` // 最近一次使用的时间步，防止验证码重放
+
+	// 登录第二步连续输错的次数，达到上限后锁定到 MFALockedUntil，防止暴力猜测验证码
+	MFAFailedAttempts int        `json:"-" gorm:"column:mfa_failed_attempts;default:0;not null"`
+	MFALockedUntil    *time.Time `json:"-" gorm:"column:mfa_locked_until"`
+
+	// 每次修改/重置密码时递增，用于使已签发的 JWT 失效
+	TokenVersion uint `json:"-" gorm:"column:token_version;default:0;not null"`
+}
+
+// PasswordResetToken 密码重置令牌，数据库中只保存令牌的哈希值
+type PasswordResetToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"column:user_id;index;not null"`
+	TokenHash string     `gorm:"column:token_hash;type:char(64);uniqueIndex;not null"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"` // 非空表示已使用
+}
+
+// RecoveryCode 两步验证恢复码，只保存哈希值，每个只能使用一次
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"column:user_id;index;not null"`
+	CodeHash string     `gorm:"column:code_hash;type:char(64);not null"`
+	UsedAt   *time.Time `gorm:"column:used_at"`
+}
+
+// UserIdentity 外部身份提供方（OIDC）账号与本地用户的绑定关系
+type UserIdentity struct {
+	gorm.Model
+	UserID   uint   `gorm:"column:user_id;index;not null"`
+	Provider string `gorm:"column:provider;type:varchar(255);uniqueIndex:idx_identity_subject;not null"` // 提供方 issuer
+	Subject  string `gorm:"column:subject;type:varchar(255);uniqueIndex:idx_identity_subject;not null"`  // ID Token 中的 sub
+	Email    string `gorm:"column:email;type:varchar(128)"`
+	// 按邮箱自动绑定的身份，同步角色时不会提升本地账号的权限
+	AutoLinked bool `gorm:"column:auto_linked;default:false;not null"`
+}
+
+// PasswordHistory 用户使用过的密码哈希，用于禁止重复使用近期密码
+type PasswordHistory struct {
+	gorm.Model
+	UserID       uint   `gorm:"column:user_id;index;not null"`
+	PasswordHash string `gorm:"column:password_hash;not null"`
 }
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Message 一条待发送的通知
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier 通知发送接口，可替换为邮件、短信等实现
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// NewNotifier 根据配置创建通知发送器，未知类型回退到日志输出
+func NewNotifier(kind, filePath string) Notifier {
+	switch kind {
+	case "file":
+		return &FileNotifier{Path: filePath}
+	default:
+		return &LogNotifier{}
+	}
+}
+
+// LogNotifier 将通知内容输出到日志，便于本地开发
+type LogNotifier struct{}
+
+func (l *LogNotifier) Send(msg Message) error {
+	log.Printf("[notify] to=%s subject=%s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileNotifier 将通知追加写入文件
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+func (f *FileNotifier) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	file, err := os.OpenFile(f.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("打开通知文件失败: %w", err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "time: %s\nto: %s\nsubject: %s\n\n%s\n----\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
@@ -0,0 +1,30 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNotifier(t *testing.T) {
+	assert.IsType(t, &LogNotifier{}, NewNotifier("log", ""))
+	assert.IsType(t, &LogNotifier{}, NewNotifier("unknown", ""))
+	assert.IsType(t, &FileNotifier{}, NewNotifier("file", "out.log"))
+}
+
+func TestFileNotifier_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notify.log")
+	n := NewNotifier("file", path)
+
+	err := n.Send(Message{To: "alice", Subject: "重置密码", Body: "token=abc"})
+	assert.NoError(t, err)
+	err = n.Send(Message{To: "bob", Subject: "重置密码", Body: "token=def"})
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "to: alice")
+	assert.Contains(t, string(data), "token=def")
+}
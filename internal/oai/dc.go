@@ -0,0 +1,32 @@
+package oai
+
+import (
+	"LibraryManagement/internal/dc"
+	"encoding/xml"
+)
+
+// oai_dc 元数据格式，所有仓储都必须支持
+const (
+	PrefixDC    = "oai_dc"
+	DCNamespace = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	DCSchema    = "http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+)
+
+// DC oai_dc 记录
+type DC struct {
+	XMLName        xml.Name `xml:"oai_dc:dc"`
+	XmlnsOAIDC     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsXsi       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	dc.Elements
+}
+
+// Marshal 编码为 <oai_dc:dc> 元素
+func (d *DC) Marshal() ([]byte, error) {
+	d.XmlnsOAIDC = DCNamespace
+	d.XmlnsDC = dc.Namespace
+	d.XmlnsXsi = xsiNamespace
+	d.SchemaLocation = DCNamespace + " " + DCSchema
+	return xml.Marshal(d)
+}
@@ -0,0 +1,285 @@
+// Package oai 实现 OAI-PMH 2.0 协议层：请求参数校验、错误码、日期格式、
+// 断点续传令牌和 XML 响应结构。记录内容由调用方提供
+package oai
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// 协议要求的命名空间
+const (
+	Namespace      = "http://www.openarchives.org/OAI/2.0/"
+	SchemaLocation = "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	xsiNamespace   = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// 请求动词
+const (
+	VerbIdentify            = "Identify"
+	VerbListMetadataFormats = "ListMetadataFormats"
+	VerbListSets            = "ListSets"
+	VerbListIdentifiers     = "ListIdentifiers"
+	VerbListRecords         = "ListRecords"
+	VerbGetRecord           = "GetRecord"
+)
+
+// 协议错误码
+const (
+	CodeBadArgument             = "badArgument"
+	CodeBadResumptionToken      = "badResumptionToken"
+	CodeBadVerb                 = "badVerb"
+	CodeCannotDisseminateFormat = "cannotDisseminateFormat"
+	CodeIDDoesNotExist          = "idDoesNotExist"
+	CodeNoRecordsMatch          = "noRecordsMatch"
+	CodeNoMetadataFormats       = "noMetadataFormats"
+	CodeNoSetHierarchy          = "noSetHierarchy"
+)
+
+// Error 协议错误，以 HTTP 200 在响应的 <error> 元素中返回
+type Error struct {
+	Code    string `xml:"code,attr"`
+	Message string `xml:",chardata"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+func NewError(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// arguments 各动词允许的参数，true 表示必填；resumptionToken 只能单独出现
+var arguments = map[string]map[string]bool{
+	VerbIdentify:            {},
+	VerbListMetadataFormats: {"identifier": false},
+	VerbListSets:            {"resumptionToken": false},
+	VerbListIdentifiers:     {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+	VerbListRecords:         {"metadataPrefix": true, "from": false, "until": false, "set": false, "resumptionToken": false},
+	VerbGetRecord:           {"identifier": true, "metadataPrefix": true},
+}
+
+// CheckArguments 校验动词和参数：未知动词返回 badVerb，
+// 多余、重复或缺少参数返回 badArgument
+func CheckArguments(args url.Values) *Error {
+	verbs := args["verb"]
+	if len(verbs) != 1 {
+		return NewError(CodeBadVerb, "verb 参数缺失或重复")
+	}
+	allowed, ok := arguments[verbs[0]]
+	if !ok {
+		return NewError(CodeBadVerb, "不支持的 verb：%s", verbs[0])
+	}
+
+	for name, values := range args {
+		if name == "verb" {
+			continue
+		}
+		if _, ok := allowed[name]; !ok {
+			return NewError(CodeBadArgument, "%s 不支持参数 %s", verbs[0], name)
+		}
+		if len(values) > 1 {
+			return NewError(CodeBadArgument, "参数 %s 重复", name)
+		}
+	}
+
+	if _, ok := args["resumptionToken"]; ok {
+		if len(args) > 2 {
+			return NewError(CodeBadArgument, "resumptionToken 不能与其他参数同时使用")
+		}
+		return nil
+	}
+	for name, required := range allowed {
+		if required && args.Get(name) == "" {
+			return NewError(CodeBadArgument, "缺少参数 %s", name)
+		}
+	}
+	return nil
+}
+
+// 日期格式，协议要求使用 UTC
+const (
+	dayLayout    = "2006-01-02"
+	secondLayout = "2006-01-02T15:04:05Z"
+	// Granularity Identify 中声明的时间粒度
+	Granularity = "YYYY-MM-DDThh:mm:ssZ"
+)
+
+// FormatDate 以秒级粒度输出 UTC 时间
+func FormatDate(t time.Time) string {
+	return t.UTC().Format(secondLayout)
+}
+
+// Range 选择性收割的时间范围，From 包含，Until 不包含；为零值表示不限
+type Range struct {
+	From  time.Time
+	Until time.Time
+}
+
+// ParseRange 解析 from 和 until，两者的粒度必须相同。
+// until 包含当天或当秒内的全部记录，转换为不包含的上界
+func ParseRange(from, until string) (Range, *Error) {
+	var r Range
+	var fromDay, untilDay bool
+	var err *Error
+	if from != "" {
+		if r.From, fromDay, err = parseDate("from", from); err != nil {
+			return r, err
+		}
+	}
+	if until != "" {
+		if r.Until, untilDay, err = parseDate("until", until); err != nil {
+			return r, err
+		}
+		if untilDay {
+			r.Until = r.Until.AddDate(0, 0, 1)
+		} else {
+			r.Until = r.Until.Add(time.Second)
+		}
+	}
+
+	if from != "" && until != "" {
+		if fromDay != untilDay {
+			return r, NewError(CodeBadArgument, "from 和 until 的粒度不同")
+		}
+		if !r.From.Before(r.Until) {
+			return r, NewError(CodeBadArgument, "from 晚于 until")
+		}
+	}
+	return r, nil
+}
+
+// parseDate time.Parse 会接受布局中没有的小数秒，先按长度区分粒度
+func parseDate(name, value string) (time.Time, bool, *Error) {
+	switch len(value) {
+	case len(secondLayout):
+		if t, err := time.Parse(secondLayout, value); err == nil {
+			return t, false, nil
+		}
+	case len(dayLayout):
+		if t, err := time.Parse(dayLayout, value); err == nil {
+			return t, true, nil
+		}
+	}
+	return time.Time{}, false, NewError(CodeBadArgument, "%s 的日期格式错误：%s", name, value)
+}
+
+// Response OAI-PMH 响应，按动词填写其中一项，出错时只填写 Errors
+type Response struct {
+	XMLName        xml.Name `xml:"OAI-PMH"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	XmlnsXsi       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	ResponseDate   string   `xml:"responseDate"`
+	Request        Request  `xml:"request"`
+
+	Errors              []*Error             `xml:"error,omitempty"`
+	Identify            *Identify            `xml:"Identify,omitempty"`
+	ListMetadataFormats *ListMetadataFormats `xml:"ListMetadataFormats,omitempty"`
+	ListIdentifiers     *ListIdentifiers     `xml:"ListIdentifiers,omitempty"`
+	ListRecords         *ListRecords         `xml:"ListRecords,omitempty"`
+	GetRecord           *GetRecord           `xml:"GetRecord,omitempty"`
+}
+
+// Request 回显请求的参数；badVerb 和 badArgument 时只包含地址
+type Request struct {
+	BaseURL         string `xml:",chardata"`
+	Verb            string `xml:"verb,attr,omitempty"`
+	Identifier      string `xml:"identifier,attr,omitempty"`
+	MetadataPrefix  string `xml:"metadataPrefix,attr,omitempty"`
+	From            string `xml:"from,attr,omitempty"`
+	Until           string `xml:"until,attr,omitempty"`
+	Set             string `xml:"set,attr,omitempty"`
+	ResumptionToken string `xml:"resumptionToken,attr,omitempty"`
+}
+
+// NewResponse 创建响应并回显请求参数
+func NewResponse(baseURL string, args url.Values, now time.Time) *Response {
+	return &Response{
+		Xmlns:          Namespace,
+		XmlnsXsi:       xsiNamespace,
+		SchemaLocation: SchemaLocation,
+		ResponseDate:   FormatDate(now),
+		Request: Request{
+			BaseURL:         baseURL,
+			Verb:            args.Get("verb"),
+			Identifier:      args.Get("identifier"),
+			MetadataPrefix:  args.Get("metadataPrefix"),
+			From:            args.Get("from"),
+			Until:           args.Get("until"),
+			Set:             args.Get("set"),
+			ResumptionToken: args.Get("resumptionToken"),
+		},
+	}
+}
+
+// Fail 设置错误；badVerb 和 badArgument 时请求回显中不包含参数
+func (r *Response) Fail(err *Error) {
+	r.Errors = append(r.Errors, err)
+	if err.Code == CodeBadVerb || err.Code == CodeBadArgument {
+		r.Request = Request{BaseURL: r.Request.BaseURL}
+	}
+}
+
+// Identify 仓储信息
+type Identify struct {
+	RepositoryName    string   `xml:"repositoryName"`
+	BaseURL           string   `xml:"baseURL"`
+	ProtocolVersion   string   `xml:"protocolVersion"`
+	AdminEmails       []string `xml:"adminEmail"`
+	EarliestDatestamp string   `xml:"earliestDatestamp"`
+	DeletedRecord     string   `xml:"deletedRecord"` // no | transient | persistent
+	Granularity       string   `xml:"granularity"`
+}
+
+// MetadataFormat 支持的元数据格式
+type MetadataFormat struct {
+	Prefix    string `xml:"metadataPrefix"`
+	Schema    string `xml:"schema"`
+	Namespace string `xml:"metadataNamespace"`
+}
+
+type ListMetadataFormats struct {
+	Formats []MetadataFormat `xml:"metadataFormat"`
+}
+
+// Header 记录头，已删除的记录 Status 为 deleted
+type Header struct {
+	Status     string `xml:"status,attr,omitempty"`
+	Identifier string `xml:"identifier"`
+	Datestamp  string `xml:"datestamp"`
+}
+
+// Record 一条记录，Metadata 为已编码的元数据 XML；已删除的记录没有元数据
+type Record struct {
+	Header   Header    `xml:"header"`
+	Metadata *Metadata `xml:"metadata,omitempty"`
+}
+
+type Metadata struct {
+	Content []byte `xml:",innerxml"`
+}
+
+// ResumptionToken 断点续传令牌；最后一页 Value 为空
+type ResumptionToken struct {
+	Value            string `xml:",chardata"`
+	CompleteListSize int64  `xml:"completeListSize,attr"`
+	Cursor           int    `xml:"cursor,attr"`
+}
+
+type ListIdentifiers struct {
+	Headers         []Header         `xml:"header"`
+	ResumptionToken *ResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type ListRecords struct {
+	Records         []Record         `xml:"record"`
+	ResumptionToken *ResumptionToken `xml:"resumptionToken,omitempty"`
+}
+
+type GetRecord struct {
+	Record Record `xml:"record"`
+}
@@ -0,0 +1,110 @@
+package oai
+
+import (
+	"LibraryManagement/internal/dc"
+	"encoding/xml"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckArguments(t *testing.T) {
+	cases := []struct {
+		query string
+		code  string
+	}{
+		{"verb=Identify", ""},
+		{"verb=ListRecords&metadataPrefix=oai_dc&from=2026-01-01", ""},
+		{"verb=ListRecords&resumptionToken=abc", ""},
+		{"verb=GetRecord&identifier=oai:x:book/1&metadataPrefix=oai_dc", ""},
+		{"", CodeBadVerb},
+		{"verb=Harvest", CodeBadVerb},
+		{"verb=Identify&verb=Identify", CodeBadVerb},
+		{"verb=Identify&metadataPrefix=oai_dc", CodeBadArgument},
+		{"verb=ListRecords", CodeBadArgument},
+		{"verb=ListRecords&metadataPrefix=oai_dc&metadataPrefix=marc21", CodeBadArgument},
+		{"verb=ListRecords&resumptionToken=abc&metadataPrefix=oai_dc", CodeBadArgument},
+		{"verb=GetRecord&identifier=oai:x:book/1", CodeBadArgument},
+	}
+	for _, tc := range cases {
+		args, _ := url.ParseQuery(tc.query)
+		err := CheckArguments(args)
+		if tc.code == "" {
+			assert.Nil(t, err, tc.query)
+		} else if assert.NotNil(t, err, tc.query) {
+			assert.Equal(t, tc.code, err.Code, tc.query)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := ParseRange("2026-01-01", "2026-01-31")
+	require.Nil(t, err)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), r.From)
+	// until 包含当天
+	assert.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), r.Until)
+
+	r, err = ParseRange("", "2026-01-01T10:00:00Z")
+	require.Nil(t, err)
+	assert.True(t, r.From.IsZero())
+	assert.Equal(t, time.Date(2026, 1, 1, 10, 0, 1, 0, time.UTC), r.Until)
+
+	for _, bad := range [][2]string{
+		{"2026-01-01", "2026-01-01T10:00:00Z"}, // 粒度不同
+		{"2026-02-01", "2026-01-01"},           // from 晚于 until
+		{"2026/01/01", ""},
+		{"2026-01-01T10:00:00.5Z", ""}, // 比声明的粒度更细
+	} {
+		_, err := ParseRange(bad[0], bad[1])
+		if assert.NotNil(t, err, bad) {
+			assert.Equal(t, CodeBadArgument, err.Code)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	token := &Token{MetadataPrefix: "oai_dc", From: "2026-01-01", AfterTime: time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC).UnixNano(), AfterID: 9, Cursor: 100}
+	decoded, err := DecodeToken(token.Encode())
+	require.Nil(t, err)
+	assert.Equal(t, token, decoded)
+	assert.True(t, decoded.After().Equal(time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)))
+
+	for _, bad := range []string{"!!", "e30", (&Token{MetadataPrefix: "oai_dc"}).Encode()} {
+		_, err := DecodeToken(bad)
+		if assert.NotNil(t, err, bad) {
+			assert.Equal(t, CodeBadResumptionToken, err.Code)
+		}
+	}
+}
+
+func TestResponse(t *testing.T) {
+	args := url.Values{"verb": {"GetRecord"}, "identifier": {"oai:x:book/1"}, "metadataPrefix": {"oai_dc"}}
+	now := time.Date(2026, 1, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+
+	dc, err := (&DC{Elements: dc.Elements{Title: []string{"Go <入门>"}, Creator: []string{"张三"}}}).Marshal()
+	require.NoError(t, err)
+	resp := NewResponse("http://example.org/oai", args, now)
+	resp.GetRecord = &GetRecord{Record: Record{
+		Header:   Header{Identifier: "oai:x:book/1", Datestamp: "2026-01-01T00:00:00Z"},
+		Metadata: &Metadata{Content: dc},
+	}}
+	data, err := xml.Marshal(resp)
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, `<OAI-PMH xmlns="http://www.openarchives.org/OAI/2.0/"`)
+	assert.Contains(t, out, `<responseDate>2026-01-01T00:00:00Z</responseDate>`)
+	assert.Contains(t, out, `<request verb="GetRecord" identifier="oai:x:book/1" metadataPrefix="oai_dc">http://example.org/oai</request>`)
+	assert.Contains(t, out, `<metadata><oai_dc:dc xmlns:oai_dc="http://www.openarchives.org/OAI/2.0/oai_dc/" xmlns:dc="http://purl.org/dc/elements/1.1/"`)
+	assert.Contains(t, out, `<dc:title>Go &lt;入门&gt;</dc:title><dc:creator>张三</dc:creator>`)
+	assert.NotContains(t, out, "<error")
+
+	// badArgument 时回显中不包含参数
+	resp = NewResponse("http://example.org/oai", args, now)
+	resp.Fail(NewError(CodeBadArgument, "缺少参数 %s", "identifier"))
+	data, _ = xml.Marshal(resp)
+	assert.Contains(t, string(data), `<request>http://example.org/oai</request><error code="badArgument">缺少参数 identifier</error></OAI-PMH>`)
+}
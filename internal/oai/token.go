@@ -0,0 +1,42 @@
+package oai
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
+
+// Token 断点续传令牌的内容：原始查询条件和上一页最后一条记录的位置。
+// 令牌不在服务端保存，按日期戳和 ID 继续读取，翻页期间修改的记录会移到列表末尾而不会被漏掉
+type Token struct {
+	MetadataPrefix string `json:"p"`
+	From           string `json:"f,omitempty"`
+	Until          string `json:"u,omitempty"`
+	AfterTime      int64  `json:"t"` // 上一页最后一条记录的日期戳（Unix 纳秒）
+	AfterID        uint   `json:"i"`
+	Cursor         int    `json:"c"` // 已返回的记录数
+}
+
+// After 上一页最后一条记录的日期戳
+func (t *Token) After() time.Time {
+	return time.Unix(0, t.AfterTime)
+}
+
+// Encode 编码为 URL 安全的字符串
+func (t *Token) Encode() string {
+	data, _ := json.Marshal(t)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeToken 解析令牌，格式错误时返回 badResumptionToken
+func DecodeToken(s string) (*Token, *Error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, NewError(CodeBadResumptionToken, "resumptionToken 无效")
+	}
+	t := &Token{}
+	if err := json.Unmarshal(data, t); err != nil || t.MetadataPrefix == "" || t.AfterID == 0 || t.Cursor <= 0 {
+		return nil, NewError(CodeBadResumptionToken, "resumptionToken 无效")
+	}
+	return t, nil
+}
@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// StringClaim 读取字符串声明，支持 a.b 形式的嵌套路径
+func StringClaim(claims jwt.MapClaims, path string) string {
+	value, ok := lookupClaim(claims, path)
+	if !ok {
+		return ""
+	}
+	s, _ := value.(string)
+	return s
+}
+
+// BoolClaim 读取布尔声明，部分提供方会把 email_verified 返回成字符串
+func BoolClaim(claims jwt.MapClaims, path string) bool {
+	value, ok := lookupClaim(claims, path)
+	if !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// MapRole 根据声明值映射本地角色。
+// 声明可以是字符串或字符串数组（如 groups），mapping 中匹配到 admin 优先，都未匹配时返回 defaultRole
+func MapRole(claims jwt.MapClaims, claim string, mapping map[string]string, defaultRole string) string {
+	value, ok := lookupClaim(claims, claim)
+	if !ok {
+		return defaultRole
+	}
+
+	var values []string
+	switch v := value.(type) {
+	case string:
+		values = strings.Fields(v)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+	}
+
+	role := ""
+	for _, v := range values {
+		mapped, ok := mapping[v]
+		if !ok {
+			continue
+		}
+		if mapped == "admin" {
+			return mapped
+		}
+		role = mapped
+	}
+
+	if role == "" {
+		return defaultRole
+	}
+	return role
+}
+
+func lookupClaim(claims jwt.MapClaims, path string) (interface{}, bool) {
+	if path == "" {
+		return nil, false
+	}
+
+	var current interface{} = map[string]interface{}(claims)
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[part]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
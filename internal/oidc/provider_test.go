@@ -0,0 +1,245 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockIdP 本地模拟的 OIDC 提供方
+type mockIdP struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	// 授权码 -> code_challenge / nonce
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{key: key, clientID: "library"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(Metadata{
+			Issuer:                idp.server.URL,
+			AuthorizationEndpoint: idp.server.URL + "/authorize",
+			TokenEndpoint:         idp.server.URL + "/token",
+			JWKSURI:               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "k1",
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Form.Get("code") != "good-code" || CodeChallenge(r.Form.Get("code_verifier")) != idp.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": idp.sign(t, idp.idClaims())})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (m *mockIdP) idClaims() jwt.MapClaims {
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   m.clientID,
+		"sub":   "staff-001",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"iat":   time.Now().Unix(),
+		"nonce": m.nonce,
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	return claims
+}
+
+func (m *mockIdP) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "k1"
+	raw, err := token.SignedString(m.key)
+	require.NoError(t, err)
+	return raw
+}
+
+func (m *mockIdP) provider(t *testing.T) *Provider {
+	p, err := Discover(context.Background(), Config{
+		Issuer:      m.server.URL,
+		ClientID:    m.clientID,
+		RedirectURL: "http://localhost/callback",
+	})
+	require.NoError(t, err)
+	return p
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider(t)
+
+	raw := p.AuthCodeURL("st", "nc", CodeChallenge("verifier"))
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+
+	q := u.Query()
+	assert.Equal(t, "/authorize", u.Path)
+	assert.Equal(t, "code", q.Get("response_type"))
+	assert.Equal(t, "library", q.Get("client_id"))
+	assert.Equal(t, "st", q.Get("state"))
+	assert.Equal(t, "nc", q.Get("nonce"))
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, CodeChallenge("verifier"), q.Get("code_challenge"))
+	assert.Equal(t, "openid profile email", q.Get("scope"))
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider(t)
+
+	idp.challenge = CodeChallenge("verifier")
+	idp.nonce = "nc"
+	idp.claims = jwt.MapClaims{"email": "alice@example.com"}
+
+	raw, err := p.Exchange(context.Background(), "good-code", "verifier")
+	require.NoError(t, err)
+
+	claims, err := p.VerifyIDToken(context.Background(), raw, "nc")
+	require.NoError(t, err)
+	assert.Equal(t, "staff-001", claims["sub"])
+	assert.Equal(t, "alice@example.com", StringClaim(claims, "email"))
+
+	t.Run("错误的 code_verifier", func(t *testing.T) {
+		_, err := p.Exchange(context.Background(), "good-code", "other")
+		assert.ErrorIs(t, err, ErrExchange)
+	})
+
+	t.Run("nonce 不匹配", func(t *testing.T) {
+		_, err := p.VerifyIDToken(context.Background(), raw, "other")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestVerifyIDTokenRejects(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider(t)
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+	}{
+		{"issuer 不匹配", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"audience 不匹配", func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{"已过期", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() }},
+		{"缺少 sub", func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := idp.idClaims()
+			tt.modify(claims)
+			_, err := p.VerifyIDToken(context.Background(), idp.sign(t, claims), "")
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+
+	t.Run("签名密钥不匹配", func(t *testing.T) {
+		other, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, idp.idClaims())
+		token.Header["kid"] = "k1"
+		raw, err := token.SignedString(other)
+		require.NoError(t, err)
+
+		_, err = p.VerifyIDToken(context.Background(), raw, "")
+		assert.ErrorIs(t, err, ErrInvalidToken)
+	})
+}
+
+func TestDiscoverIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+
+	_, err := Discover(context.Background(), Config{Issuer: idp.server.URL + "/other"})
+	assert.ErrorIs(t, err, ErrDiscovery)
+}
+
+func TestStateStore(t *testing.T) {
+	store := NewStateStore(time.Minute)
+	now := time.Now()
+	store.now = func() time.Time { return now }
+
+	store.Put("a", State{Nonce: "n1"})
+	state, ok := store.Take("a")
+	assert.True(t, ok)
+	assert.Equal(t, "n1", state.Nonce)
+
+	// 只能取出一次
+	_, ok = store.Take("a")
+	assert.False(t, ok)
+
+	// 回调必须来自发起授权的浏览器
+	bound := State{}
+	bound.BindBrowser("cookie-1")
+	assert.True(t, bound.BoundTo("cookie-1"))
+	assert.False(t, bound.BoundTo("cookie-2"))
+	assert.False(t, bound.BoundTo(""))
+	assert.False(t, State{}.BoundTo(""))
+
+	// 过期后不可用
+	store.Put("b", State{})
+	now = now.Add(2 * time.Minute)
+	_, ok = store.Take("b")
+	assert.False(t, ok)
+}
+
+func TestMapRole(t *testing.T) {
+	mapping := map[string]string{"library-admins": "admin", "library-staff": "user"}
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		claim  string
+		want   string
+	}{
+		{"数组声明命中管理员", jwt.MapClaims{"groups": []interface{}{"library-staff", "library-admins"}}, "groups", "admin"},
+		{"数组声明命中普通用户", jwt.MapClaims{"groups": []interface{}{"library-staff"}}, "groups", "user"},
+		{"字符串声明", jwt.MapClaims{"role": "library-admins"}, "role", "admin"},
+		{"嵌套声明", jwt.MapClaims{"realm_access": map[string]interface{}{"roles": []interface{}{"library-admins"}}}, "realm_access.roles", "admin"},
+		{"未命中使用默认角色", jwt.MapClaims{"groups": []interface{}{"others"}}, "groups", "user"},
+		{"缺少声明使用默认角色", jwt.MapClaims{}, "groups", "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MapRole(tt.claims, tt.claim, mapping, "user"))
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package oidc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"sync"
+	"time"
+)
+
+// State 一次授权请求需要在回调时取回的数据
+type State struct {
+	Nonce        string
+	CodeVerifier string
+	LinkUserID   uint // 非 0 表示把身份绑定到该本地用户，而不是登录
+	ExpiresAt    time.Time
+
+	// BrowserHash 发起授权的浏览器持有的随机值的摘要，随 Cookie 下发原值，
+	// 回调时必须一致，防止 state 被其他浏览器使用（登录 CSRF、把他人身份绑定到自己账号）
+	BrowserHash [sha256.Size]byte
+}
+
+// BindBrowser 记录浏览器随机值的摘要
+func (s *State) BindBrowser(binding string) {
+	s.BrowserHash = sha256.Sum256([]byte(binding))
+}
+
+// BoundTo 回调请求携带的随机值是否与发起授权的浏览器一致
+func (s State) BoundTo(binding string) bool {
+	if binding == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(binding))
+	return subtle.ConstantTimeCompare(sum[:], s.BrowserHash[:]) == 1
+}
+
+// StateStore 内存中的授权状态，每个 state 只能取出一次。
+// 状态只保存在当前进程中，部署多个实例时回调必须回到发起授权的实例（如按来源 IP 会话保持）
+type StateStore struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	states map[string]State
+	now    func() time.Time
+}
+
+func NewStateStore(ttl time.Duration) *StateStore {
+	return &StateStore{ttl: ttl, states: map[string]State{}, now: time.Now}
+}
+
+// Put 保存 state，同时清理已过期的记录
+func (s *StateStore) Put(key string, state State) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for k, v := range s.states {
+		if now.After(v.ExpiresAt) {
+			delete(s.states, k)
+		}
+	}
+
+	state.ExpiresAt = now.Add(s.ttl)
+	s.states[key] = state
+}
+
+// Take 取出并删除 state，不存在或已过期时返回 false
+func (s *StateStore) Take(key string) (State, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[key]
+	if !ok {
+		return State{}, false
+	}
+	delete(s.states, key)
+
+	if s.now().After(state.ExpiresAt) {
+		return State{}, false
+	}
+	return state, true
+}
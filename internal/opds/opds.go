@@ -0,0 +1,177 @@
+// Package opds 生成 OPDS 1.2 目录：Atom 导航和获取 feed、分页链接以及 OpenSearch 描述文档。
+// feed 中的书目由调用方填写
+package opds
+
+import (
+	"encoding/xml"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// 命名空间
+const (
+	atomNamespace       = "http://www.w3.org/2005/Atom"
+	dcTermsNamespace    = "http://purl.org/dc/terms/"
+	opdsNamespace       = "http://opds-spec.org/2010/catalog"
+	openSearchNamespace = "http://a9.com/-/spec/opensearch/1.1/"
+)
+
+// 媒体类型
+const (
+	NavigationType  = "application/atom+xml;profile=opds-catalog;kind=navigation"
+	AcquisitionType = "application/atom+xml;profile=opds-catalog;kind=acquisition"
+	OpenSearchType  = "application/opensearchdescription+xml"
+)
+
+// 链接关系
+const (
+	RelSelf        = "self"
+	RelStart       = "start"
+	RelUp          = "up"
+	RelSearch      = "search"
+	RelSubsection  = "subsection"
+	RelFirst       = "first"
+	RelPrevious    = "previous"
+	RelNext        = "next"
+	RelLast        = "last"
+	RelNew         = "http://opds-spec.org/sort/new"
+	RelAcquisition = "http://opds-spec.org/acquisition"
+)
+
+// Feed Atom feed。导航 feed 的条目指向其他 feed，获取 feed 的条目为书目
+type Feed struct {
+	XMLName         xml.Name `xml:"feed"`
+	Xmlns           string   `xml:"xmlns,attr"`
+	XmlnsDC         string   `xml:"xmlns:dc,attr"`
+	XmlnsOPDS       string   `xml:"xmlns:opds,attr"`
+	XmlnsOpenSearch string   `xml:"xmlns:opensearch,attr"`
+
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Links   []Link `xml:"link"`
+
+	// 分页信息，只在分页的 feed 中输出
+	TotalResults int64 `xml:"opensearch:totalResults,omitempty"`
+	ItemsPerPage int   `xml:"opensearch:itemsPerPage,omitempty"`
+	StartIndex   int   `xml:"opensearch:startIndex,omitempty"`
+
+	Entries []Entry `xml:"entry"`
+}
+
+type Link struct {
+	Rel   string `xml:"rel,attr,omitempty"`
+	Href  string `xml:"href,attr"`
+	Type  string `xml:"type,attr,omitempty"`
+	Title string `xml:"title,attr,omitempty"`
+}
+
+type Person struct {
+	Name string `xml:"name"`
+}
+
+// Text Atom 文本，Type 为 text 或 html
+type Text struct {
+	Type  string `xml:"type,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// Entry feed 条目。导航条目必须有 Content
+type Entry struct {
+	Title      string   `xml:"title"`
+	ID         string   `xml:"id"`
+	Updated    string   `xml:"updated"`
+	Authors    []Person `xml:"author"`
+	Identifier string   `xml:"dc:identifier,omitempty"`
+	Summary    *Text    `xml:"summary,omitempty"`
+	Content    *Text    `xml:"content,omitempty"`
+	Links      []Link   `xml:"link"`
+}
+
+// FormatTime Atom 时间格式（RFC 3339，UTC）
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// NewFeed 创建 feed，self 和 start 链接指向 self 和 root
+func NewFeed(id, title, self, kind, root string, updated time.Time) *Feed {
+	return &Feed{
+		Xmlns:           atomNamespace,
+		XmlnsDC:         dcTermsNamespace,
+		XmlnsOPDS:       opdsNamespace,
+		XmlnsOpenSearch: openSearchNamespace,
+		ID:              id,
+		Title:           title,
+		Updated:         FormatTime(updated),
+		Links: []Link{
+			{Rel: RelSelf, Href: self, Type: kind},
+			{Rel: RelStart, Href: root, Type: NavigationType},
+		},
+	}
+}
+
+// AddLink 添加 feed 级链接
+func (f *Feed) AddLink(rel, href, kind, title string) {
+	f.Links = append(f.Links, Link{Rel: rel, Href: href, Type: kind, Title: title})
+}
+
+// Paginate 写入分页信息并添加 first、previous、next、last 链接，
+// 链接为 path 加上 query 和 page 参数；page 从 1 开始
+func (f *Feed) Paginate(path string, query url.Values, kind string, page, pageSize int, total int64) {
+	f.TotalResults, f.ItemsPerPage, f.StartIndex = total, pageSize, (page-1)*pageSize+1
+	last := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if last < 1 {
+		last = 1
+	}
+
+	href := func(n int) string {
+		q := url.Values{}
+		for k, v := range query {
+			q[k] = v
+		}
+		if n > 1 {
+			q.Set("page", strconv.Itoa(n))
+		}
+		if len(q) == 0 {
+			return path
+		}
+		return path + "?" + q.Encode()
+	}
+	f.AddLink(RelFirst, href(1), kind, "")
+	if page > 1 {
+		f.AddLink(RelPrevious, href(min(page-1, last)), kind, "")
+	}
+	if page < last {
+		f.AddLink(RelNext, href(page+1), kind, "")
+	}
+	f.AddLink(RelLast, href(last), kind, "")
+}
+
+// OpenSearchDescription 搜索描述文档，Template 中的 {searchTerms} 由客户端替换为关键词
+type OpenSearchDescription struct {
+	XMLName        xml.Name      `xml:"OpenSearchDescription"`
+	Xmlns          string        `xml:"xmlns,attr"`
+	ShortName      string        `xml:"ShortName"`
+	Description    string        `xml:"Description"`
+	InputEncoding  string        `xml:"InputEncoding"`
+	OutputEncoding string        `xml:"OutputEncoding"`
+	URL            OpenSearchURL `xml:"Url"`
+}
+
+type OpenSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// NewOpenSearchDescription 搜索结果为获取 feed
+func NewOpenSearchDescription(shortName, description, template string) *OpenSearchDescription {
+	return &OpenSearchDescription{
+		Xmlns:          openSearchNamespace,
+		ShortName:      shortName,
+		Description:    description,
+		InputEncoding:  "UTF-8",
+		OutputEncoding: "UTF-8",
+		URL:            OpenSearchURL{Type: AcquisitionType, Template: template},
+	}
+}
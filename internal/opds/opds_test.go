@@ -0,0 +1,68 @@
+package opds
+
+import (
+	"encoding/xml"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeed(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 11, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	feed := NewFeed("urn:x:new", "新书", "/opds/new?page=2", AcquisitionType, "/opds", updated)
+	feed.Entries = append(feed.Entries, Entry{
+		Title:      "Go <入门>",
+		ID:         "urn:x:book:1",
+		Updated:    FormatTime(updated),
+		Authors:    []Person{{Name: "张三"}},
+		Identifier: "urn:isbn:9787111111111",
+		Links:      []Link{{Rel: RelAcquisition, Href: "/opds/books/1/content", Type: "text/plain"}},
+	})
+	data, err := xml.Marshal(feed)
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:dc="http://purl.org/dc/terms/" xmlns:opds="http://opds-spec.org/2010/catalog" xmlns:opensearch="http://a9.com/-/spec/opensearch/1.1/"><id>urn:x:new</id><title>新书</title><updated>2026-01-02T03:00:00Z</updated>`)
+	assert.Contains(t, out, `<link rel="self" href="/opds/new?page=2" type="application/atom+xml;profile=opds-catalog;kind=acquisition"></link><link rel="start" href="/opds" type="application/atom+xml;profile=opds-catalog;kind=navigation"></link>`)
+	assert.Contains(t, out, `<entry><title>Go &lt;入门&gt;</title><id>urn:x:book:1</id><updated>2026-01-02T03:00:00Z</updated><author><name>张三</name></author><dc:identifier>urn:isbn:9787111111111</dc:identifier><link rel="http://opds-spec.org/acquisition" href="/opds/books/1/content" type="text/plain"></link></entry>`)
+	// 未分页的 feed 不输出 opensearch 元素
+	assert.NotContains(t, out, "opensearch:totalResults")
+}
+
+func TestPaginate(t *testing.T) {
+	links := func(feed *Feed) map[string]string {
+		m := make(map[string]string)
+		for _, link := range feed.Links {
+			m[link.Rel] = link.Href
+		}
+		return m
+	}
+
+	feed := NewFeed("id", "title", "/opds/search", AcquisitionType, "/opds", time.Now())
+	feed.Paginate("/opds/search", url.Values{"q": {"go 编程"}}, AcquisitionType, 2, 20, 45)
+	assert.Equal(t, int64(45), feed.TotalResults)
+	assert.Equal(t, 20, feed.ItemsPerPage)
+	assert.Equal(t, 21, feed.StartIndex)
+	m := links(feed)
+	assert.Equal(t, "/opds/search?q=go+%E7%BC%96%E7%A8%8B", m[RelFirst])
+	assert.Equal(t, "/opds/search?q=go+%E7%BC%96%E7%A8%8B", m[RelPrevious])
+	assert.Equal(t, "/opds/search?page=3&q=go+%E7%BC%96%E7%A8%8B", m[RelNext])
+	assert.Equal(t, "/opds/search?page=3&q=go+%E7%BC%96%E7%A8%8B", m[RelLast])
+
+	// 只有一页时没有 previous 和 next
+	feed = NewFeed("id", "title", "/opds/new", AcquisitionType, "/opds", time.Now())
+	feed.Paginate("/opds/new", nil, AcquisitionType, 1, 20, 0)
+	m = links(feed)
+	assert.Equal(t, "/opds/new", m[RelLast])
+	assert.NotContains(t, m, RelPrevious)
+	assert.NotContains(t, m, RelNext)
+}
+
+func TestOpenSearchDescription(t *testing.T) {
+	data, err := xml.Marshal(NewOpenSearchDescription("图书馆", "搜索书目", "/opds/search?q={searchTerms}"))
+	require.NoError(t, err)
+	assert.Equal(t, `<OpenSearchDescription xmlns="http://a9.com/-/spec/opensearch/1.1/"><ShortName>图书馆</ShortName><Description>搜索书目</Description><InputEncoding>UTF-8</InputEncoding><OutputEncoding>UTF-8</OutputEncoding><Url type="application/atom+xml;profile=opds-catalog;kind=acquisition" template="/opds/search?q={searchTerms}"></Url></OpenSearchDescription>`, string(data))
+}
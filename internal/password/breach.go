@@ -0,0 +1,88 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prefixLen k-anonymity 前缀长度，与 Pwned Passwords range 接口一致
+const prefixLen = 5
+
+// BreachList 本地泄露密码库。
+// 按 SHA-1 前 5 位分桶保存后缀，查询时只按前缀取出候选集合再比较，
+// 与 Pwned Passwords 的 range 接口（k-anonymity）方式一致
+type BreachList struct {
+	buckets map[string]map[string]struct{}
+}
+
+// LoadBreachList 读取泄露密码哈希文件。
+// 每行一个大写或小写的 40 位 SHA-1，可带 ":次数" 后缀（Pwned Passwords 下载格式），
+// 空行和 # 开头的注释行会被忽略
+func LoadBreachList(path string) (*BreachList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	list := &BreachList{buckets: map[string]map[string]struct{}{}}
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash := strings.ToUpper(strings.SplitN(text, ":", 2)[0])
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("%s:%d: invalid sha1 hash", path, line)
+		}
+		list.add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// Contains 判断密码是否在泄露库中
+func (b *BreachList) Contains(password string) bool {
+	if b == nil {
+		return false
+	}
+
+	hash := SHA1Hex(password)
+	_, ok := b.buckets[hash[:prefixLen]][hash[prefixLen:]]
+	return ok
+}
+
+// Len 泄露库中的哈希数量
+func (b *BreachList) Len() int {
+	n := 0
+	for _, bucket := range b.buckets {
+		n += len(bucket)
+	}
+	return n
+}
+
+func (b *BreachList) add(hash string) {
+	prefix, suffix := hash[:prefixLen], hash[prefixLen:]
+	bucket, ok := b.buckets[prefix]
+	if !ok {
+		bucket = map[string]struct{}{}
+		b.buckets[prefix] = bucket
+	}
+	bucket[suffix] = struct{}{}
+}
+
+// SHA1Hex 计算大写十六进制 SHA-1
+func SHA1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
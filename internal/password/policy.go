@@ -0,0 +1,120 @@
+package password
+
+import (
+	"LibraryManagement/internal/apperr"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword 密码不符合策略，具体原因见 PolicyError
+var ErrWeakPassword = apperr.Validation("weak_password", "密码不符合安全要求")
+
+// PolicyError 密码策略校验失败，Violations 为逐条可读的原因
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return strings.Join(e.Violations, "；")
+}
+
+// Unwrap 返回携带具体原因的 ErrWeakPassword，处理器可直接展示给用户
+func (e *PolicyError) Unwrap() error {
+	return ErrWeakPassword.WithMessage(e.Error())
+}
+
+// Policy 密码策略
+type Policy struct {
+	MinLength        int  // 最小长度（按字符计）
+	RequireUpper     bool // 必须包含大写字母
+	RequireLower     bool // 必须包含小写字母
+	RequireDigit     bool // 必须包含数字
+	RequireSymbol    bool // 必须包含特殊字符
+	MinClasses       int  // 至少包含几类字符（大写、小写、数字、特殊字符）
+	DisallowUsername bool // 不能包含用户名或与用户名过于相似
+}
+
+// Check 校验密码是否符合策略，不符合时返回 *PolicyError
+func (p Policy) Check(password, username string) error {
+	var violations []string
+
+	if p.MinLength > 0 && utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("密码长度至少为 %d 位", p.MinLength))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	if p.RequireUpper && !upper {
+		violations = append(violations, "密码必须包含大写字母")
+	}
+	if p.RequireLower && !lower {
+		violations = append(violations, "密码必须包含小写字母")
+	}
+	if p.RequireDigit && !digit {
+		violations = append(violations, "密码必须包含数字")
+	}
+	if p.RequireSymbol && !symbol {
+		violations = append(violations, "密码必须包含特殊字符")
+	}
+	if p.MinClasses > 0 && countTrue(upper, lower, digit, symbol) < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("密码至少需要包含大写字母、小写字母、数字、特殊字符中的 %d 类", p.MinClasses))
+	}
+
+	if p.DisallowUsername && similarToUsername(password, username) {
+		violations = append(violations, "密码不能包含用户名或与用户名过于相似")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// NewPolicyError 构造单条原因的策略错误，供历史密码、泄露密码等检查使用
+func NewPolicyError(violation string) error {
+	return &PolicyError{Violations: []string{violation}}
+}
+
+// similarToUsername 忽略大小写后，密码包含用户名、用户名包含密码、
+// 或密码反转后包含用户名都视为相似
+func similarToUsername(password, username string) bool {
+	u := strings.ToLower(strings.TrimSpace(username))
+	if utf8.RuneCountInString(u) < 3 {
+		return false
+	}
+
+	p := strings.ToLower(password)
+	return strings.Contains(p, u) || strings.Contains(u, p) || strings.Contains(reverse(p), u)
+}
+
+func reverse(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+func countTrue(values ...bool) int {
+	n := 0
+	for _, v := range values {
+		if v {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,80 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinLength:        8,
+		RequireUpper:     true,
+		RequireLower:     true,
+		RequireDigit:     true,
+		DisallowUsername: true,
+	}
+
+	tests := []struct {
+		name       string
+		password   string
+		username   string
+		violations []string
+	}{
+		{"符合策略", "Library2025", "alice", nil},
+		{"过短", "Ab1", "alice", []string{"密码长度至少为 8 位"}},
+		{"缺少大写和数字", "libraryonly", "alice", []string{"密码必须包含大写字母", "密码必须包含数字"}},
+		{"包含用户名", "Alice12345", "alice", []string{"密码不能包含用户名或与用户名过于相似"}},
+		{"反转包含用户名", "X1ecilaAb", "alice", []string{"密码不能包含用户名或与用户名过于相似"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(tt.password, tt.username)
+			if tt.violations == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var pe *PolicyError
+			assert.True(t, errors.As(err, &pe))
+			assert.Equal(t, tt.violations, pe.Violations)
+			assert.ErrorIs(t, err, ErrWeakPassword)
+		})
+	}
+}
+
+func TestPolicyMinClasses(t *testing.T) {
+	policy := Policy{MinClasses: 3}
+
+	assert.NoError(t, policy.Check("abcDEF123", ""))
+	assert.NoError(t, policy.Check("abc-def-123", ""))
+	assert.Error(t, policy.Check("abcdef123", ""))
+}
+
+func TestBreachList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	content := "# 泄露密码\n" +
+		SHA1Hex("password") + ":3861493\n" +
+		"\n" +
+		SHA1Hex("123456") + "\n"
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := LoadBreachList(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, list.Len())
+	assert.True(t, list.Contains("password"))
+	assert.True(t, list.Contains("123456"))
+	assert.False(t, list.Contains("Library2025"))
+
+	// 未加载泄露库时不拦截
+	var empty *BreachList
+	assert.False(t, empty.Contains("password"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("not-a-hash\n"), 0o600))
+	_, err = LoadBreachList(path)
+	assert.Error(t, err)
+}
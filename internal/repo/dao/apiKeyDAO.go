@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"LibraryManagement/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyDAO interface {
+	CreateAPIKeyDAO(key *model.APIKey) error
+	ListAPIKeysDAO(userID uint) ([]model.APIKey, error)
+	GetAPIKeyByPrefixDAO(prefix string) (*model.APIKey, error)
+	RevokeAPIKeyDAO(id uint, userID uint) error
+	TouchAPIKeyDAO(id uint, usedAt time.Time) error
+}
+
+// CreateAPIKeyDAO 保存 API Key
+func (d *dbService) CreateAPIKeyDAO(key *model.APIKey) error {
+	return d.db.Create(key).Error
+}
+
+// ListAPIKeysDAO 查询未吊销的 API Key，userID 为 0 时查询全部
+func (d *dbService) ListAPIKeysDAO(userID uint) ([]model.APIKey, error) {
+	dbSql := d.db.Model(&model.APIKey{})
+	if userID != 0 {
+		dbSql = dbSql.Where("user_id = ?", userID)
+	}
+
+	var keys []model.APIKey
+	err := dbSql.Order("id DESC").Find(&keys).Error
+	return keys, err
+}
+
+// GetAPIKeyByPrefixDAO 根据公开前缀查找 API Key
+func (d *dbService) GetAPIKeyByPrefixDAO(prefix string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := d.db.Where("prefix = ?", prefix).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RevokeAPIKeyDAO 吊销 API Key，userID 不为 0 时只能吊销该用户自己的 Key
+func (d *dbService) RevokeAPIKeyDAO(id uint, userID uint) error {
+	dbSql := d.db.Where("id = ?", id)
+	if userID != 0 {
+		dbSql = dbSql.Where("user_id = ?", userID)
+	}
+
+	result := dbSql.Delete(&model.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TouchAPIKeyDAO 更新最近使用时间
+func (d *dbService) TouchAPIKeyDAO(id uint, usedAt time.Time) error {
+	return d.db.Model(&model.APIKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
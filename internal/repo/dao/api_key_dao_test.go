@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"LibraryManagement/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	k1 := &model.APIKey{UserID: 1, Name: "ingest", Prefix: "aaaa1111", KeyHash: "h1", Scopes: "books:read,books:write"}
+	k2 := &model.APIKey{UserID: 2, Name: "report", Prefix: "bbbb2222", KeyHash: "h2", Scopes: "books:read"}
+	assert.NoError(t, dao.CreateAPIKeyDAO(k1))
+	assert.NoError(t, dao.CreateAPIKeyDAO(k2))
+
+	// 前缀唯一
+	assert.Error(t, dao.CreateAPIKeyDAO(&model.APIKey{UserID: 1, Name: "dup", Prefix: "aaaa1111", KeyHash: "h3", Scopes: "books:read"}))
+
+	found, err := dao.GetAPIKeyByPrefixDAO("aaaa1111")
+	assert.NoError(t, err)
+	assert.Equal(t, k1.ID, found.ID)
+	assert.Equal(t, []string{"books:read", "books:write"}, found.ScopeList())
+
+	keys, err := dao.ListAPIKeysDAO(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(keys))
+	keys, err = dao.ListAPIKeysDAO(0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(keys))
+
+	now := time.Now()
+	assert.NoError(t, dao.TouchAPIKeyDAO(k1.ID, now))
+	found, _ = dao.GetAPIKeyByPrefixDAO("aaaa1111")
+	assert.NotNil(t, found.LastUsedAt)
+
+	// 只能吊销自己的 Key
+	assert.Error(t, dao.RevokeAPIKeyDAO(k2.ID, 1))
+	assert.NoError(t, dao.RevokeAPIKeyDAO(k1.ID, 1))
+	_, err = dao.GetAPIKeyByPrefixDAO("aaaa1111")
+	assert.Error(t, err)
+
+	// 管理员可吊销任意 Key
+	assert.NoError(t, dao.RevokeAPIKeyDAO(k2.ID, 0))
+	keys, _ = dao.ListAPIKeysDAO(0)
+	assert.Equal(t, 0, len(keys))
+}
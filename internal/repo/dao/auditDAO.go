@@ -0,0 +1,114 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// 审计日志只提供写入、查询和按保留期清理，不提供修改
+type auditDAO interface {
+	CreateAuditLogDAO(entry *model.AuditLog) error
+	ListAuditLogsDAO(req *api.AuditListReq) (*api.AuditListResp, error)
+	PurgeAuditLogsDAO(before time.Time) (int64, error)
+}
+
+// CreateAuditLogDAO 写入一条审计日志
+func (d *dbService) CreateAuditLogDAO(entry *model.AuditLog) error {
+	return d.db.Create(entry).Error
+}
+
+// ListAuditLogsDAO 按条件分页查询审计日志，按时间倒序
+func (d *dbService) ListAuditLogsDAO(req *api.AuditListReq) (*api.AuditListResp, error) {
+	dbSql := d.db.Model(&model.AuditLog{})
+	if req.ActorID != 0 {
+		dbSql = dbSql.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		// 支持按前缀查询，如 book. 匹配全部书籍操作
+		dbSql = dbSql.Where("action LIKE ?", req.Action+"%")
+	}
+	if req.TargetType != "" {
+		dbSql = dbSql.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID != "" {
+		dbSql = dbSql.Where("target_id = ?", req.TargetID)
+	}
+	if req.Outcome != "" {
+		dbSql = dbSql.Where("outcome = ?", req.Outcome)
+	}
+	if req.IP != "" {
+		dbSql = dbSql.Where("ip = ?", req.IP)
+	}
+	if req.From != nil {
+		dbSql = dbSql.Where("created_at >= ?", *req.From)
+	}
+	if req.To != nil {
+		dbSql = dbSql.Where("created_at < ?", *req.To)
+	}
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count audit logs: %w", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 20
+	}
+	offset := (page - 1) * pageSize
+
+	var logs []model.AuditLog
+	if err := dbSql.Order("id DESC").Offset(offset).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+
+	items := make([]api.AuditLogResp, 0, len(logs))
+	for i := range logs {
+		items = append(items, toAuditLogResp(&logs[i]))
+	}
+
+	return &api.AuditListResp{
+		Logs:       items,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// PurgeAuditLogsDAO 删除早于指定时间的审计日志，返回删除条数
+func (d *dbService) PurgeAuditLogsDAO(before time.Time) (int64, error) {
+	result := d.db.Where("created_at < ?", before).Delete(&model.AuditLog{})
+	return result.RowsAffected, result.Error
+}
+
+func toAuditLogResp(entry *model.AuditLog) api.AuditLogResp {
+	resp := api.AuditLogResp{
+		ID:         entry.ID,
+		CreatedAt:  entry.CreatedAt,
+		ActorID:    entry.ActorID,
+		ActorType:  entry.ActorType,
+		APIKeyID:   entry.APIKeyID,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+		IP:         entry.IP,
+		UserAgent:  entry.UserAgent,
+		Outcome:    entry.Outcome,
+		Detail:     entry.Detail,
+	}
+	if entry.Before != "" {
+		resp.Before = json.RawMessage(entry.Before)
+	}
+	if entry.After != "" {
+		resp.After = json.RawMessage(entry.After)
+	}
+	return resp
+}
@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditLogDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	old := &model.AuditLog{ActorID: 1, ActorType: model.ActorUser, Action: "auth.login", Outcome: model.AuditFailure, CreatedAt: time.Now().Add(-48 * time.Hour)}
+	entries := []*model.AuditLog{
+		old,
+		{ActorID: 1, ActorType: model.ActorUser, Action: "auth.login", Outcome: model.AuditSuccess, IP: "10.0.0.1"},
+		{ActorID: 2, ActorType: model.ActorUser, Action: "book.delete", TargetType: "book", TargetID: "7", Before: `[{"id":7}]`, Outcome: model.AuditSuccess},
+		{ActorID: 2, ActorType: model.ActorAPIKey, Action: "book.update", TargetType: "book", TargetID: "8", Outcome: model.AuditSuccess},
+	}
+	for _, e := range entries {
+		assert.NoError(t, dao.CreateAuditLogDAO(e))
+	}
+
+	// 按操作前缀查询，结果按时间倒序
+	resp, err := dao.ListAuditLogsDAO(&api.AuditListReq{Action: "book."})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Total)
+	assert.Equal(t, "book.update", resp.Logs[0].Action)
+	assert.JSONEq(t, `[{"id":7}]`, string(resp.Logs[1].Before))
+	assert.Nil(t, resp.Logs[1].After)
+
+	resp, err = dao.ListAuditLogsDAO(&api.AuditListReq{ActorID: 1, Outcome: model.AuditFailure})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+
+	from := time.Now().Add(-time.Hour)
+	resp, err = dao.ListAuditLogsDAO(&api.AuditListReq{From: &from, TargetType: "book", TargetID: "7"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+
+	// 分页
+	resp, err = dao.ListAuditLogsDAO(&api.AuditListReq{Page: 2, PageSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), resp.Total)
+	assert.Equal(t, 2, resp.TotalPages)
+	assert.Equal(t, 1, len(resp.Logs))
+
+	// 清理超过保留期的日志
+	n, err := dao.PurgeAuditLogsDAO(time.Now().Add(-24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	resp, err = dao.ListAuditLogsDAO(&api.AuditListReq{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Total)
+}
@@ -0,0 +1,385 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrContributorAuthorNotFound 书籍的贡献者引用了不存在的作者
+var ErrContributorAuthorNotFound = errors.New("贡献者引用的作者不存在")
+
+// ErrAuthorInUse 删除作者时仍有书籍关联该作者（包括回收站中的书籍）
+var ErrAuthorInUse = errors.New("作者仍关联书籍")
+
+type authorDAO interface {
+	AuthorCreateDAO(req *api.AuthorInfoReq) (*model.Author, error)
+	AuthorGetDAO(id uint) (*api.AuthorInfoResp, error)
+	AuthorListDAO(req *api.AuthorSearchReq) (*api.AuthorSearchResp, error)
+	AuthorUpdateDAO(id uint, req *api.AuthorInfoReq) ([]model.Book, error)
+	AuthorDeleteDAO(id uint) error
+
+	// 书籍贡献者
+	BookContributorListDAO(bookIDs []uint) (map[uint][]model.Contributor, error)
+	BookContributorMigrateDAO(afterID uint, limit int) ([]model.Book, int, error)
+}
+
+// authorBooks 作者参与的书籍（不区分角色），参数为作者 ID
+const authorBooks = "SELECT book_contributors.book_id FROM book_contributors WHERE book_contributors.author_id = ?"
+
+// AuthorCreateDAO 新增作者，不检查重名：同名的不同作者用 disambiguation 区分
+func (d *dbService) AuthorCreateDAO(req *api.AuthorInfoReq) (*model.Author, error) {
+	author := &model.Author{
+		Name:           req.Name,
+		Disambiguation: req.Disambiguation,
+		Bio:            req.Bio,
+	}
+	if err := d.db.Create(author).Error; err != nil {
+		return nil, err
+	}
+	return author, nil
+}
+
+// authorQuery 作者及其书籍数量，回收站中的书籍不计入
+func authorQuery(db *gorm.DB) *gorm.DB {
+	return db.Table("authors").
+		Select("authors.id, authors.name, authors.disambiguation, authors.bio, authors.created_at, authors.updated_at, " +
+			"COUNT(DISTINCT books.id) AS book_count").
+		Joins("LEFT JOIN book_contributors ON book_contributors.author_id = authors.id").
+		Joins("LEFT JOIN books ON books.id = book_contributors.book_id AND books.deleted_at IS NULL").
+		Where("authors.deleted_at IS NULL").
+		Group("authors.id")
+}
+
+// AuthorGetDAO 作者详情
+func (d *dbService) AuthorGetDAO(id uint) (*api.AuthorInfoResp, error) {
+	var authors []api.AuthorInfoResp
+	if err := authorQuery(d.db).Where("authors.id = ?", id).Scan(&authors).Error; err != nil {
+		return nil, err
+	}
+	if len(authors) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &authors[0], nil
+}
+
+// AuthorListDAO 分页查询作者，按姓名排序
+func (d *dbService) AuthorListDAO(req *api.AuthorSearchReq) (*api.AuthorSearchResp, error) {
+	filter := func(dbSql *gorm.DB) *gorm.DB {
+		if req.Name != "" {
+			dbSql = dbSql.Where("authors.name LIKE ?", "%"+req.Name+"%")
+		}
+		return dbSql
+	}
+
+	var total int64
+	if err := filter(d.db.Model(&model.Author{})).Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count authors: %w", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	authors := make([]api.AuthorInfoResp, 0, pageSize)
+	err := filter(authorQuery(d.db)).Order("authors.name, authors.id").
+		Offset((page - 1) * pageSize).Limit(pageSize).Scan(&authors).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query authors: %w", err)
+	}
+
+	return &api.AuthorSearchResp{
+		Authors:    authors,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// AuthorUpdateDAO 修改作者。改名后该作者担任著者的书籍重新生成 author（版本号加一并追加修订记录），
+// 返回关联该作者的全部书籍（含回收站中的书籍，已读取贡献者、分类和标签），供调用方同步到 ES
+func (d *dbService) AuthorUpdateDAO(id uint, req *api.AuthorInfoReq) ([]model.Book, error) {
+	var books []model.Book
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		var author model.Author
+		if err := tx.First(&author, id).Error; err != nil {
+			return err
+		}
+		renamed := author.Name != req.Name
+		err := tx.Model(&author).Updates(map[string]interface{}{
+			"name":           req.Name,
+			"disambiguation": req.Disambiguation,
+			"bio":            req.Bio,
+		}).Error
+		if err != nil || !renamed {
+			return err
+		}
+
+		var ids []uint
+		err = tx.Model(&model.BookContributor{}).Where("author_id = ?", id).Distinct("book_id").Pluck("book_id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", ids).Order("id").Find(&books).Error; err != nil {
+			return err
+		}
+		ptrs := make([]*model.Book, 0, len(books))
+		for i := range books {
+			ptrs = append(ptrs, &books[i])
+		}
+		if err := loadBookRelations(tx, ptrs...); err != nil {
+			return err
+		}
+
+		for i := range books {
+			book := &books[i]
+			joined := model.JoinAuthors(model.AuthorNames(book.Contributors))
+			if !hasContributor(book.Contributors, id, model.ContributorAuthor) || joined == book.Author {
+				continue
+			}
+			if err := setBookAuthor(tx, book, joined, req.Operator); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// setBookAuthor 由系统改写书籍的 author，按普通更新处理：版本号加一并追加修订记录
+func setBookAuthor(tx *gorm.DB, book *model.Book, author string, operator api.Operator) error {
+	if err := ensureBaselineRevision(tx, book); err != nil {
+		return err
+	}
+	before := book.Snapshot()
+
+	result := tx.Unscoped().Model(&model.Book{}).
+		Where("id = ? AND version = ?", book.ID, book.Version).
+		Updates(map[string]interface{}{"author": author, "version": book.Version + 1})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrVersionConflict
+	}
+
+	if err := tx.Unscoped().Where("id = ?", book.ID).First(book).Error; err != nil {
+		return err
+	}
+	return createRevision(tx, book, model.RevisionUpdate, operator, before.ChangedFields(book.Snapshot()), 0)
+}
+
+func hasContributor(contributors []model.Contributor, authorID uint, role string) bool {
+	for _, c := range contributors {
+		if c.AuthorID == authorID && c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthorDeleteDAO 删除作者，仍关联书籍时返回 ErrAuthorInUse
+func (d *dbService) AuthorDeleteDAO(id uint) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.BookContributor{}).Where("author_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrAuthorInUse
+		}
+		result := tx.Delete(&model.Author{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// BookContributorListDAO 批量查询书籍的贡献者，按书籍内的顺序排列；没有贡献者的书籍不在结果中
+func (d *dbService) BookContributorListDAO(bookIDs []uint) (map[uint][]model.Contributor, error) {
+	return listContributors(d.db, bookIDs)
+}
+
+func listContributors(db *gorm.DB, bookIDs []uint) (map[uint][]model.Contributor, error) {
+	contributors := make(map[uint][]model.Contributor)
+	if len(bookIDs) == 0 {
+		return contributors, nil
+	}
+
+	var rows []struct {
+		BookID   uint
+		AuthorID uint
+		Name     string
+		Role     string
+	}
+	err := db.Table("book_contributors").
+		Select("book_contributors.book_id, book_contributors.author_id, authors.name, book_contributors.role").
+		Joins("JOIN authors ON authors.id = book_contributors.author_id").
+		Where("book_contributors.book_id IN ?", bookIDs).
+		Order("book_contributors.book_id, book_contributors.position").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		contributors[row.BookID] = append(contributors[row.BookID], model.Contributor{
+			AuthorID: row.AuthorID, Name: row.Name, Role: row.Role,
+		})
+	}
+	return contributors, nil
+}
+
+// BookContributorMigrateDAO 为 ID 大于 afterID、有作者字符串但还没有贡献者的书籍（含回收站）
+// 拆分作者字符串并建立贡献者，每次最多 limit 本。返回本批检查过的书籍（已填写 Contributors，
+// 拆不出姓名的为空）和新建的作者数量；返回的书籍少于 limit 时表示已全部处理
+func (d *dbService) BookContributorMigrateDAO(afterID uint, limit int) ([]model.Book, int, error) {
+	var books []model.Book
+	created := 0
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().
+			Where("id > ? AND author <> ''", afterID).
+			Where("NOT EXISTS (SELECT 1 FROM book_contributors WHERE book_contributors.book_id = books.id)").
+			Order("id").Limit(limit).Find(&books).Error
+		if err != nil {
+			return err
+		}
+
+		r := newContributorResolver(tx)
+		for i := range books {
+			contributors, err := r.resolve(model.ParseContributors(books[i].Author))
+			if err != nil {
+				return err
+			}
+			if err := replaceContributors(tx, books[i].ID, contributors); err != nil {
+				return err
+			}
+		}
+		created = r.created
+
+		// 已有的分类和标签一并读取，供调用方整体写入 ES
+		ptrs := make([]*model.Book, 0, len(books))
+		for i := range books {
+			ptrs = append(ptrs, &books[i])
+		}
+		return loadBookRelations(tx, ptrs...)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return books, created, nil
+}
+
+// requestedContributors 请求中的贡献者，未给出时按作者字符串拆分
+func requestedContributors(req *api.BookInfoReq) []model.Contributor {
+	if req.Contributors == nil {
+		return model.ParseContributors(req.Author)
+	}
+	contributors := make([]model.Contributor, 0, len(req.Contributors))
+	for _, c := range req.Contributors {
+		contributors = append(contributors, model.Contributor{AuthorID: c.AuthorID, Name: c.Name, Role: c.Role})
+	}
+	return contributors
+}
+
+// contributorResolver 在事务中把贡献者关联到作者记录，按姓名查到或新建的作者在事务内缓存
+type contributorResolver struct {
+	tx      *gorm.DB
+	byName  map[string]uint
+	created int // 新建的作者数量
+}
+
+func newContributorResolver(tx *gorm.DB) *contributorResolver {
+	return &contributorResolver{tx: tx, byName: make(map[string]uint)}
+}
+
+// resolve 填写贡献者的作者 ID 和姓名：有 AuthorID 时使用该作者的姓名，否则按姓名查找最早创建的同名作者，
+// 没有时新建。角色为空的视为著者，同一作者的同一角色只保留第一个
+func (r *contributorResolver) resolve(contributors []model.Contributor) ([]model.Contributor, error) {
+	resolved := make([]model.Contributor, 0, len(contributors))
+	seen := make(map[model.Contributor]bool)
+	for _, c := range contributors {
+		if c.Role == "" {
+			c.Role = model.ContributorAuthor
+		}
+		if c.AuthorID != 0 {
+			var author model.Author
+			err := r.tx.Select("id", "name").First(&author, c.AuthorID).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrContributorAuthorNotFound
+			}
+			if err != nil {
+				return nil, err
+			}
+			c.Name = author.Name
+		} else {
+			c.Name = strings.TrimSpace(c.Name)
+			if c.Name == "" {
+				continue
+			}
+			id, err := r.authorID(c.Name)
+			if err != nil {
+				return nil, err
+			}
+			c.AuthorID = id
+		}
+
+		key := model.Contributor{AuthorID: c.AuthorID, Role: c.Role}
+		if !seen[key] {
+			seen[key] = true
+			resolved = append(resolved, c)
+		}
+	}
+	return resolved, nil
+}
+
+func (r *contributorResolver) authorID(name string) (uint, error) {
+	if id, ok := r.byName[name]; ok {
+		return id, nil
+	}
+	var ids []uint
+	if err := r.tx.Model(&model.Author{}).Where("name = ?", name).Order("id").Limit(1).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		author := &model.Author{Name: name}
+		if err := r.tx.Create(author).Error; err != nil {
+			return 0, err
+		}
+		r.created++
+		ids = append(ids, author.ID)
+	}
+	r.byName[name] = ids[0]
+	return ids[0], nil
+}
+
+// replaceContributors 用 contributors（已关联作者）替换书籍现有的贡献者
+func replaceContributors(tx *gorm.DB, bookID uint, contributors []model.Contributor) error {
+	if err := tx.Where("book_id = ?", bookID).Delete(&model.BookContributor{}).Error; err != nil {
+		return err
+	}
+	if len(contributors) == 0 {
+		return nil
+	}
+	rows := make([]model.BookContributor, 0, len(contributors))
+	for i, c := range contributors {
+		rows = append(rows, model.BookContributor{BookID: bookID, AuthorID: c.AuthorID, Role: c.Role, Position: i})
+	}
+	return tx.Create(&rows).Error
+}
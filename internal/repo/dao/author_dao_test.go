@@ -0,0 +1,214 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestBookContributorsDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	// 未给出贡献者时按作者字符串拆分，同名作者复用
+	book, err := dao.BookAddDAO(&api.BookInfoReq{Title: "A", Count: 1, ISBN: "978-0000000201", Author: "[美] 张三 著，李四、王五 译"})
+	require.NoError(t, err)
+	assert.Equal(t, "[美] 张三 著，李四、王五 译", book.Author)
+	assert.Equal(t, []string{"张三", "李四", "王五"}, contributorNames(book.Contributors))
+	assert.Equal(t, []string{"author", "translator", "translator"}, contributorRoles(book.Contributors))
+
+	other, err := dao.BookAddDAO(&api.BookInfoReq{Title: "B", Count: 1, ISBN: "978-0000000202", Author: "张三"})
+	require.NoError(t, err)
+	zhang := book.Contributors[0].AuthorID
+	assert.Equal(t, zhang, other.Contributors[0].AuthorID)
+
+	loaded, err := dao.BookGetByIDDAO(book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, book.Contributors, loaded.Contributors)
+
+	// 给出贡献者时 author 由著者生成，可引用已有作者
+	third, err := dao.BookAddDAO(&api.BookInfoReq{Title: "C", Count: 1, ISBN: "978-0000000203", Author: "忽略",
+		Contributors: []api.BookContributor{
+			{AuthorID: zhang},
+			{Name: " 赵六 ", Role: "author"},
+			{Name: "钱七", Role: "illustrator"},
+			{AuthorID: zhang, Role: "author"}, // 重复的忽略
+		}})
+	require.NoError(t, err)
+	assert.Equal(t, "张三, 赵六", third.Author)
+	assert.Equal(t, []string{"张三", "赵六", "钱七"}, contributorNames(third.Contributors))
+
+	_, err = dao.BookAddDAO(&api.BookInfoReq{Title: "D", Count: 1, ISBN: "978-0000000204",
+		Contributors: []api.BookContributor{{AuthorID: 9999}}})
+	assert.ErrorIs(t, err, ErrContributorAuthorNotFound)
+	_, err = dao.BookGetByISBNDAO("978-0000000204")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 修改其他字段不影响贡献者，修改作者字符串后重新拆分
+	version := third.Version
+	updated, err := dao.BookUpdateDAO(&api.BookUpdateReq{ID: third.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{Title: "C2"}, Fields: []string{"title"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"张三", "赵六", "钱七"}, contributorNames(updated.Contributors))
+
+	version = updated.Version
+	updated, err = dao.BookUpdateDAO(&api.BookUpdateReq{ID: third.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{Author: "孙八 编"}, Fields: []string{"author"}})
+	require.NoError(t, err)
+	assert.Equal(t, []model.Contributor{{AuthorID: updated.Contributors[0].AuthorID, Name: "孙八", Role: "editor"}}, updated.Contributors)
+
+	// 局部更新贡献者时 author 一并更新，修订记录中记为修改了 contributors
+	version = updated.Version
+	updated, err = dao.BookUpdateDAO(&api.BookUpdateReq{ID: third.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{Contributors: []api.BookContributor{{Name: "李四"}}}, Fields: []string{"contributors"}})
+	require.NoError(t, err)
+	assert.Equal(t, "李四", updated.Author)
+	assert.Equal(t, "C2", updated.Title)
+	revision, err := dao.BookRevisionGetDAO(third.ID, updated.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "author,contributors", revision.Fields)
+
+	// 按作者记录筛选，包含担任其他角色的书籍
+	li := updated.Contributors[0].AuthorID
+	list, err := dao.BookListDAO(&api.BookSearchReq{AuthorID: li})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.Total)
+
+	contributors, err := dao.BookContributorListDAO([]uint{book.ID, other.ID, third.ID})
+	require.NoError(t, err)
+	assert.Len(t, contributors, 3)
+
+	// 彻底删除书籍时一并删除贡献者
+	dao.db.Delete(&model.Book{}, other.ID)
+	_, err = dao.BookPurgeDAO([]uint{other.ID})
+	require.NoError(t, err)
+	contributors, err = dao.BookContributorListDAO([]uint{other.ID})
+	require.NoError(t, err)
+	assert.Empty(t, contributors)
+}
+
+func TestAuthorDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	book, err := dao.BookAddDAO(&api.BookInfoReq{Title: "A", Count: 1, ISBN: "978-0000000301", Author: "张三、李四 著，王五 译"})
+	require.NoError(t, err)
+	trashed, err := dao.BookAddDAO(&api.BookInfoReq{Title: "B", Count: 1, ISBN: "978-0000000302", Author: "张三"})
+	require.NoError(t, err)
+	dao.db.Delete(&model.Book{}, trashed.ID)
+	zhang := book.Contributors[0].AuthorID
+
+	// 同名作者可以并存
+	namesake, err := dao.AuthorCreateDAO(&api.AuthorInfoReq{Name: "张三", Disambiguation: "1950-"})
+	require.NoError(t, err)
+
+	author, err := dao.AuthorGetDAO(zhang)
+	require.NoError(t, err)
+	assert.Equal(t, "张三", author.Name)
+	assert.Equal(t, int64(1), author.BookCount) // 回收站中的书籍不计入
+	assert.False(t, author.CreatedAt.IsZero())
+
+	list, err := dao.AuthorListDAO(&api.AuthorSearchReq{Name: "张", PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.Total)
+	assert.Equal(t, []uint{zhang, namesake.ID}, []uint{list.Authors[0].ID, list.Authors[1].ID})
+	assert.Equal(t, int64(0), list.Authors[1].BookCount)
+
+	// 改名后重新生成担任著者的书籍的 author，返回全部关联的书籍
+	books, err := dao.AuthorUpdateDAO(zhang, &api.AuthorInfoReq{Name: "张三丰", Bio: "简介", Operator: api.Operator{UserID: 7}})
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, "张三丰, 李四", books[0].Author)
+	assert.Equal(t, 2, books[0].Version)
+	assert.Equal(t, "张三丰", books[1].Author)
+	assert.True(t, books[1].DeletedAt.Valid)
+	revision, err := dao.BookRevisionGetDAO(book.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), revision.ActorID)
+	assert.Equal(t, "author", revision.Fields)
+
+	// 译者改名不改变作者字符串
+	wang := book.Contributors[2].AuthorID
+	books, err = dao.AuthorUpdateDAO(wang, &api.AuthorInfoReq{Name: "王五五"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, books[0].Version)
+	assert.Equal(t, "王五五", books[0].Contributors[2].Name)
+
+	// 姓名不变时不改动书籍
+	books, err = dao.AuthorUpdateDAO(zhang, &api.AuthorInfoReq{Name: "张三丰"})
+	require.NoError(t, err)
+	assert.Empty(t, books)
+	author, err = dao.AuthorGetDAO(zhang)
+	require.NoError(t, err)
+	assert.Equal(t, "", author.Bio)
+
+	_, err = dao.AuthorUpdateDAO(9999, &api.AuthorInfoReq{Name: "x"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 仍关联书籍（包括回收站中的书籍）时不能删除
+	assert.ErrorIs(t, dao.AuthorDeleteDAO(zhang), ErrAuthorInUse)
+	assert.NoError(t, dao.AuthorDeleteDAO(namesake.ID))
+	assert.ErrorIs(t, dao.AuthorDeleteDAO(namesake.ID), gorm.ErrRecordNotFound)
+	_, err = dao.AuthorGetDAO(namesake.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestBookContributorMigrateDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	// 启用贡献者前写入的书籍只有作者字符串
+	legacy := []model.Book{
+		{Title: "A", Count: 1, ISBN: "978-0000000401", Author: "（清）曹雪芹 著"},
+		{Title: "B", Count: 1, ISBN: "978-0000000402", Author: "Alan Donovan, Brian Kernighan"},
+		{Title: "C", Count: 1, ISBN: "978-0000000403", Author: ""},
+		{Title: "D", Count: 1, ISBN: "978-0000000404", Author: "等"},
+		{Title: "E", Count: 1, ISBN: "978-0000000405", Author: "曹雪芹 等 / 张三 主编"},
+	}
+	require.NoError(t, dao.db.Create(&legacy).Error)
+	dao.db.Delete(&legacy[4])
+
+	books, created, err := dao.BookContributorMigrateDAO(0, 2)
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, 3, created)
+	assert.Equal(t, []string{"曹雪芹"}, contributorNames(books[0].Contributors))
+	cao := books[0].Contributors[0].AuthorID
+	assert.Equal(t, []string{"Alan Donovan", "Brian Kernighan"}, contributorNames(books[1].Contributors))
+
+	// 没有作者的书籍跳过，拆不出姓名的也在结果中，回收站中的书籍同样迁移
+	books, created, err = dao.BookContributorMigrateDAO(books[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, books, 2)
+	assert.Equal(t, 1, created)
+	assert.Empty(t, books[0].Contributors)
+	assert.Equal(t, []string{"曹雪芹", "张三"}, contributorNames(books[1].Contributors))
+	assert.Equal(t, []string{"author", "editor"}, contributorRoles(books[1].Contributors))
+	assert.Equal(t, cao, books[1].Contributors[0].AuthorID)
+
+	// 已迁移的书籍不再处理
+	books, _, err = dao.BookContributorMigrateDAO(0, 10)
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	assert.Equal(t, "等", books[0].Author)
+}
+
+func contributorNames(contributors []model.Contributor) []string {
+	names := make([]string, 0, len(contributors))
+	for _, c := range contributors {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+func contributorRoles(contributors []model.Contributor) []string {
+	roles := make([]string, 0, len(contributors))
+	for _, c := range contributors {
+		roles = append(roles, c.Role)
+	}
+	return roles
+}
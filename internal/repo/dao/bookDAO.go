@@ -5,17 +5,60 @@ import (
 	"LibraryManagement/internal/model"
 	"errors"
 	"fmt"
-	"strconv"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict 乐观锁更新时版本号已变化
+var ErrVersionConflict = errors.New("更新失败：数据已被其他用户修改，请刷新后重试")
+
+// ErrBooksNotFound 事务模式批量删除时部分书籍不存在，整批回滚
+var ErrBooksNotFound = errors.New("部分书籍不存在")
+
 type bookDAO interface {
 	BookAddDAO(req *api.BookInfoReq) (*model.Book, error)
-	BookDeleteDAO(idStr []string) error
+	BookDeleteDAO(ids []uint, atomic bool) ([]uint, error)
 	BookUpdateDAO(req *api.BookUpdateReq) (*model.Book, error)
 	BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, error)
+	BookExportDAO(req *api.BookSearchReq, fn func(book *model.Book) error) error
 
 	BookGetByIDDAO(id uint) (*model.Book, error)
+	BookListByIDsDAO(ids []uint) ([]model.Book, error)
 	BookGetByISBNDAO(isbn string) (*model.Book, error)
+	BookGetByISBNWithDeletedDAO(isbn string) (*model.Book, error)
+
+	// 批量导入
+	BookBatchAddDAO(reqs []*api.BookInfoReq) ([]*model.Book, error)
+	BookListByISBNsWithDeletedDAO(isbns []string) ([]model.Book, error)
+
+	// MARC 原始记录
+	BookMARCSaveDAO(records []model.BookMARC) error
+	BookMARCExportDAO(req *api.BookSearchReq, fn func(book *model.Book, record string) error) error
+	BookMARCListDAO(ids []uint) ([]model.BookMARC, error)
+
+	// 外部书目信息缓存
+	BookMetadataCacheGetDAO(isbn string) (*model.BookMetadataCache, error)
+	BookMetadataCacheSaveDAO(entry *model.BookMetadataCache) error
+
+	// OAI-PMH 收割
+	BookHarvestDAO(req *api.BookHarvestReq) ([]model.Book, int64, error)
+	BookGetByIDWithDeletedDAO(id uint) (*model.Book, error)
+	BookEarliestDatestampDAO() (time.Time, error)
+
+	// OPDS 目录和订阅 feed
+	BookFeedDAO(req *api.BookFeedReq) ([]api.BookFeedItem, int64, error)
+	BookFeedByIDsDAO(ids []uint) ([]api.BookFeedItem, error)
+	BookAuthorListDAO(page, pageSize int) ([]api.AuthorCount, int64, error)
+	BookLastModifiedDAO(authorID, categoryID uint) (time.Time, error)
+
+	// 回收站
+	BookTrashListDAO(req *api.BookTrashListReq) (*api.BookSearchResp, error)
+	BookRestoreDAO(id uint) (*model.Book, error)
+	BookPurgeDAO(ids []uint) (int64, error)
+	BookPurgeDeletedBeforeDAO(before time.Time) (int64, error)
 }
 
 func (d *dbService) BookAddDAO(req *api.BookInfoReq) (*model.Book, error) {
@@ -29,7 +72,42 @@ func (d *dbService) BookAddDAO(req *api.BookInfoReq) (*model.Book, error) {
 		Summary: req.Summary,
 	}
 
-	err := d.db.Create(book).Error
+	// 书籍与贡献者、分类、标签、第一个版本的修订记录一起写入
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		contributors, err := newContributorResolver(tx).resolve(requestedContributors(req))
+		if err != nil {
+			return err
+		}
+		categories, err := resolveCategories(tx, req.CategoryIDs)
+		if err != nil {
+			return err
+		}
+		tags, err := resolveTags(tx, req.Tags)
+		if err != nil {
+			return err
+		}
+		if req.Contributors != nil {
+			book.Author = model.JoinAuthors(model.AuthorNames(contributors))
+		}
+		if err := tx.Create(book).Error; err != nil {
+			return err
+		}
+		if err := replaceContributors(tx, book.ID, contributors); err != nil {
+			return err
+		}
+		book.Contributors = contributors
+		if err := replaceCategories(tx, book.ID, categories); err != nil {
+			return err
+		}
+		book.Categories = categories
+		if err := replaceTags(tx, book.ID, tags); err != nil {
+			return err
+		}
+		book.Tags = tagNames(tags)
+
+		changed := model.BookSnapshot{}.ChangedFields(book.Snapshot())
+		return createRevision(tx, book, model.RevisionCreate, req.Operator, changed, 0)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -37,58 +115,215 @@ func (d *dbService) BookAddDAO(req *api.BookInfoReq) (*model.Book, error) {
 	return book, nil
 }
 
-func (d *dbService) BookDeleteDAO(idStr []string) error {
-	if len(idStr) == 0 {
-		return nil // 没有 ID，无需删除
+// BookBatchAddDAO 在一个事务中批量新增书籍及其第一个版本的修订记录，任一失败则整批回滚
+func (d *dbService) BookBatchAddDAO(reqs []*api.BookInfoReq) ([]*model.Book, error) {
+	if len(reqs) == 0 {
+		return nil, nil
 	}
 
-	ids := make([]uint, 0, len(idStr))
-	for _, id := range idStr {
-		uid, _ := strconv.ParseUint(id, 10, 64)
-		ids = append(ids, uint(uid))
+	books := make([]*model.Book, 0, len(reqs))
+	for _, req := range reqs {
+		books = append(books, &model.Book{
+			Title: req.Title,
+			Count: req.Count,
+			ISBN:  req.ISBN,
+
+			Author:  req.Author,
+			Content: req.Content,
+			Summary: req.Summary,
+		})
 	}
 
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		r := newContributorResolver(tx)
+		for i, book := range books {
+			contributors, err := r.resolve(requestedContributors(reqs[i]))
+			if err != nil {
+				return err
+			}
+			if reqs[i].Contributors != nil {
+				book.Author = model.JoinAuthors(model.AuthorNames(contributors))
+			}
+			book.Contributors = contributors
+		}
+
+		if err := tx.CreateInBatches(books, len(books)).Error; err != nil {
+			return err
+		}
+		for i, book := range books {
+			if err := replaceContributors(tx, book.ID, book.Contributors); err != nil {
+				return err
+			}
+			changed := model.BookSnapshot{}.ChangedFields(book.Snapshot())
+			if err := createRevision(tx, book, model.RevisionCreate, reqs[i].Operator, changed, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return books, nil
+}
+
+// BookDeleteDAO 批量软删除书籍，返回实际删除的 ID。
+// atomic 为 true 时只要有一本书不存在就整批回滚，返回已找到的 ID 和 ErrBooksNotFound
+func (d *dbService) BookDeleteDAO(ids []uint, atomic bool) ([]uint, error) {
 	if len(ids) == 0 {
-		return nil // 所有 ID 都非法，不执行删除
+		return nil, nil // 没有 ID，无需删除
 	}
-	return d.db.Delete(&model.Book{}, ids).Error
+
+	var deleted []uint
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Book{}).Where("id IN ?", ids).Pluck("id", &deleted).Error; err != nil {
+			return err
+		}
+		if atomic && len(deleted) != len(ids) {
+			return ErrBooksNotFound
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+		return tx.Delete(&model.Book{}, deleted).Error
+	})
+	return deleted, err
 }
 
-// 数据库乐观锁更新
+// 数据库乐观锁更新：req.Version 为客户端读取时的版本号，为空时以当前版本为准（仅供服务端内部调用）；
+// req.Fields 不为 nil 时只更新其中列出的字段。每次更新都会追加一条修订记录
 func (d *dbService) BookUpdateDAO(req *api.BookUpdateReq) (*model.Book, error) {
 	var book model.Book
-	err := d.db.Where("id = ?", req.ID).First(&book).Error
-	if err != nil {
-		return nil, err
-	}
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", req.ID).First(&book).Error; err != nil {
+			return err
+		}
 
-	// 准备更新字段
-	updates := map[string]interface{}{
-		"title": req.Title,
-		"count": req.Count,
-		"isbn":  req.ISBN,
+		expected := book.Version
+		if req.Version != nil {
+			expected = *req.Version
+		}
 
-		"author":  req.Author,
-		"content": req.Content,
-		"summary": req.Summary,
+		// 给出贡献者时 author 由其中的著者生成，局部更新也一并写入
+		fields := req.Fields
+		var contributors []model.Contributor
+		if req.Contributors != nil {
+			var err error
+			if contributors, err = newContributorResolver(tx).resolve(requestedContributors(&req.BookInfoReq)); err != nil {
+				return err
+			}
+			req.Author = model.JoinAuthors(model.AuthorNames(contributors))
+			if fields != nil {
+				fields = append(fields[:len(fields):len(fields)], "author")
+			}
+		}
 
-		"version": book.Version + 1,
-	}
+		// 准备更新字段
+		updates := map[string]interface{}{
+			"title": req.Title,
+			"count": req.Count,
+			"isbn":  req.ISBN,
 
-	// 使用乐观锁更新
-	result := d.db.Model(&model.Book{}).
-		Where("id = ? AND version = ?", req.ID, book.Version).
-		Updates(updates)
+			"author":  req.Author,
+			"content": req.Content,
+			"summary": req.Summary,
+		}
 
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	if result.RowsAffected == 0 {
-		return nil, errors.New("更新失败：数据已被其他用户修改，请刷新后重试")
-	}
+		// 局部更新只写入指定的字段，未列出的字段保持原值
+		before := book.Snapshot()
+		after := model.BookSnapshot{
+			Title: req.Title, Count: req.Count, ISBN: req.ISBN,
+			Author: req.Author, Content: req.Content, Summary: req.Summary,
+		}
+		if fields != nil {
+			partial := make(map[string]interface{}, len(fields)+1)
+			for _, field := range fields {
+				if value, ok := updates[field]; ok {
+					partial[field] = value
+				}
+			}
+			updates = partial
+			after = before.Merge(after, fields)
+		}
+		updates["version"] = expected + 1
+
+		// 使用乐观锁更新
+		result := tx.Model(&model.Book{}).
+			Where("id = ? AND version = ?", req.ID, expected).
+			Updates(updates)
+
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		// 启用修订记录前的旧版本先补记，保证可以回滚到更新前的内容
+		if err := ensureBaselineRevision(tx, &book); err != nil {
+			return err
+		}
+
+		// 重新查询更新后的数据
+		if err := tx.Where("id = ?", req.ID).First(&book).Error; err != nil {
+			return err
+		}
+
+		// 没有给出贡献者时，作者字符串变化后重新拆分
+		changed := before.ChangedFields(after)
+		if req.Contributors == nil && after.Author != before.Author {
+			var err error
+			if contributors, err = newContributorResolver(tx).resolve(model.ParseContributors(after.Author)); err != nil {
+				return err
+			}
+		}
+		if req.Contributors != nil || after.Author != before.Author {
+			if err := replaceContributors(tx, book.ID, contributors); err != nil {
+				return err
+			}
+			if req.Contributors != nil {
+				changed = append(changed, "contributors")
+			}
+		}
 
-	// 重新查询更新后的数据
-	err = d.db.Where("id = ?", req.ID).First(&book).Error
+		// 分类和标签只在给出且与原来不同时替换
+		if err := loadBookRelations(tx, &book); err != nil {
+			return err
+		}
+		if req.CategoryIDs != nil {
+			categories, err := resolveCategories(tx, req.CategoryIDs)
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(categoryIDs(categories), categoryIDs(book.Categories)) {
+				if err := replaceCategories(tx, book.ID, categories); err != nil {
+					return err
+				}
+				book.Categories = categories
+				changed = append(changed, "category_ids")
+			}
+		}
+		if req.Tags != nil {
+			tags, err := resolveTags(tx, req.Tags)
+			if err != nil {
+				return err
+			}
+			if names := tagNames(tags); !sameTags(names, book.Tags) {
+				if err := replaceTags(tx, book.ID, tags); err != nil {
+					return err
+				}
+				book.Tags = names
+				changed = append(changed, "tags")
+			}
+		}
+
+		action := model.RevisionUpdate
+		if req.RestoredFrom > 0 {
+			action = model.RevisionRollback
+		}
+		return createRevision(tx, &book, action, req.Operator, changed, req.RestoredFrom)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -96,10 +331,8 @@ func (d *dbService) BookUpdateDAO(req *api.BookUpdateReq) (*model.Book, error) {
 	return &book, nil
 }
 
-// BookListDAO 支持分页的书籍列表查询
-// TODO 深分页问题
-func (d *dbService) BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, error) {
-	dbSql := d.db.Model(&model.Book{})
+// bookFilter 书籍查询条件，列表和导出共用
+func bookFilter(dbSql *gorm.DB, req *api.BookSearchReq) *gorm.DB {
 	if req.Title != "" {
 		dbSql = dbSql.Where("title LIKE ?", "%"+req.Title+"%")
 	}
@@ -110,9 +343,25 @@ func (d *dbService) BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, er
 	if req.Author != "" {
 		dbSql = dbSql.Where("author LIKE ?", "%"+req.Author+"%")
 	}
+	if req.AuthorID != 0 {
+		dbSql = dbSql.Where("id IN ("+authorBooks+")", req.AuthorID)
+	}
+	if req.CategoryID != 0 {
+		dbSql = dbSql.Where("id IN ("+subtreeBooks+")", model.CategorySubtreePattern(req.CategoryID))
+	}
+	if req.Tag != "" {
+		dbSql = dbSql.Where("id IN (SELECT book_tags.book_id FROM book_tags JOIN tags ON tags.id = book_tags.tag_id WHERE tags.name = ?)", req.Tag)
+	}
 	if req.Content != "" {
 		dbSql = dbSql.Where("content LIKE ?", "%"+req.Content+"%")
 	}
+	return dbSql
+}
+
+// BookListDAO 支持分页的书籍列表查询
+// TODO 深分页问题
+func (d *dbService) BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, error) {
+	dbSql := bookFilter(d.db.Model(&model.Book{}), req)
 
 	// 获取总数（用于分页）
 	var total int64
@@ -147,6 +396,7 @@ func (d *dbService) BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, er
 			Count:   book.Count,
 			ISBN:    book.ISBN,
 			Summary: book.Summary, // 假设 model.Book 有 Summary 字段
+			Version: book.Version,
 		})
 	}
 
@@ -162,16 +412,29 @@ func (d *dbService) BookListDAO(req *api.BookSearchReq) (*api.BookSearchResp, er
 	}, nil
 }
 
-// BookGetByIDDAO 根据ID获取书籍详情
+// BookGetByIDDAO 根据ID获取书籍详情，包含贡献者、分类和标签
 func (d *dbService) BookGetByIDDAO(id uint) (*model.Book, error) {
 	var book model.Book
 	err := d.db.Where("id = ?", id).First(&book).Error
 	if err != nil {
 		return nil, err
 	}
+	if err := loadBookRelations(d.db, &book); err != nil {
+		return nil, err
+	}
 	return &book, nil
 }
 
+// BookListByIDsDAO 批量查询书籍（不含回收站），不保证顺序，不存在的 ID 忽略
+func (d *dbService) BookListByIDsDAO(ids []uint) ([]model.Book, error) {
+	var books []model.Book
+	if len(ids) == 0 {
+		return books, nil
+	}
+	err := d.db.Where("id IN ?", ids).Find(&books).Error
+	return books, err
+}
+
 // BookGetByISBNDAO 根据ISBN获取书籍
 func (d *dbService) BookGetByISBNDAO(isbn string) (*model.Book, error) {
 	var book model.Book
@@ -181,3 +444,390 @@ func (d *dbService) BookGetByISBNDAO(isbn string) (*model.Book, error) {
 	}
 	return &book, nil
 }
+
+// BookExportDAO 按条件逐行读取书籍（按 ID 升序），不把结果整体加载到内存；fn 返回错误时停止读取
+func (d *dbService) BookExportDAO(req *api.BookSearchReq, fn func(book *model.Book) error) error {
+	rows, err := bookFilter(d.db.Model(&model.Book{}), req).Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var book model.Book
+		if err := d.db.ScanRows(rows, &book); err != nil {
+			return err
+		}
+		if err := fn(&book); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// BookMARCSaveDAO 保存书籍的 MARC 原始记录，已存在时覆盖
+func (d *dbService) BookMARCSaveDAO(records []model.BookMARC) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "book_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"record", "updated_at"}),
+	}).Create(&records).Error
+}
+
+// BookMetadataCacheGetDAO 读取 ISBN 的书目信息缓存
+func (d *dbService) BookMetadataCacheGetDAO(isbn string) (*model.BookMetadataCache, error) {
+	var entry model.BookMetadataCache
+	if err := d.db.Where("isbn = ?", isbn).First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// BookMetadataCacheSaveDAO 保存书目信息缓存，已存在时覆盖
+func (d *dbService) BookMetadataCacheSaveDAO(entry *model.BookMetadataCache) error {
+	return d.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "isbn"}},
+		DoUpdates: clause.AssignmentColumns([]string{"data", "fetched_at"}),
+	}).Create(entry).Error
+}
+
+// BookMARCExportDAO 与 BookExportDAO 相同，同时读出书籍的 MARC 原始记录（没有时为空字符串）
+func (d *dbService) BookMARCExportDAO(req *api.BookSearchReq, fn func(book *model.Book, record string) error) error {
+	dbSql := d.db.Model(&model.Book{}).
+		Select("books.*, COALESCE(book_marc_records.record, '') AS marc_record").
+		Joins("LEFT JOIN book_marc_records ON book_marc_records.book_id = books.id")
+	rows, err := bookFilter(dbSql, req).Order("books.id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row struct {
+			model.Book
+			MARCRecord string `gorm:"column:marc_record"`
+		}
+		if err := d.db.ScanRows(rows, &row); err != nil {
+			return err
+		}
+		if err := fn(&row.Book, row.MARCRecord); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// BookMARCListDAO 批量读取书籍的 MARC 原始记录，没有原始记录的书籍不在结果中
+func (d *dbService) BookMARCListDAO(ids []uint) ([]model.BookMARC, error) {
+	var records []model.BookMARC
+	if len(ids) == 0 {
+		return records, nil
+	}
+	err := d.db.Where("book_id IN ?", ids).Find(&records).Error
+	return records, err
+}
+
+// datestampExpr 书籍的日期戳，与 model.Book.Datestamp 一致
+const datestampExpr = "COALESCE(deleted_at, updated_at)"
+
+// BookHarvestDAO 按日期戳和 ID 升序读取一页书籍（包含回收站中的书籍），
+// 同时返回满足 From/Until 条件的总数
+func (d *dbService) BookHarvestDAO(req *api.BookHarvestReq) ([]model.Book, int64, error) {
+	dbSql := d.db.Unscoped().Model(&model.Book{})
+	if !req.From.IsZero() {
+		dbSql = dbSql.Where(datestampExpr+" >= ?", req.From)
+	}
+	if !req.Until.IsZero() {
+		dbSql = dbSql.Where(datestampExpr+" < ?", req.Until)
+	}
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if req.AfterID != 0 {
+		dbSql = dbSql.Where("("+datestampExpr+" > ? OR ("+datestampExpr+" = ? AND id > ?))", req.AfterTime, req.AfterTime, req.AfterID)
+	}
+	var books []model.Book
+	err := dbSql.Order(datestampExpr).Order("id").Limit(req.Limit).Find(&books).Error
+	return books, total, err
+}
+
+// BookGetByIDWithDeletedDAO 根据ID获取书籍（包含回收站中的书籍）
+func (d *dbService) BookGetByIDWithDeletedDAO(id uint) (*model.Book, error) {
+	var book model.Book
+	err := d.db.Unscoped().Where("id = ?", id).First(&book).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// BookEarliestDatestampDAO 最早的日期戳，没有书籍时返回零值
+func (d *dbService) BookEarliestDatestampDAO() (time.Time, error) {
+	var book model.Book
+	err := d.db.Unscoped().Order(datestampExpr).Order("id").Limit(1).Find(&book).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	if book.ID == 0 {
+		return time.Time{}, nil
+	}
+	return book.Datestamp(), nil
+}
+
+// feedColumns feed 需要的列，不读取正文，只判断是否有正文
+const feedColumns = "id, title, isbn, author, summary, created_at, updated_at, COALESCE(content, '') <> '' AS has_content"
+
+// BookFeedDAO 分页查询 feed 书目，新上架或最近更新的在前
+func (d *dbService) BookFeedDAO(req *api.BookFeedReq) ([]api.BookFeedItem, int64, error) {
+	dbSql := d.db.Model(&model.Book{})
+	if req.AuthorID != 0 {
+		dbSql = dbSql.Where("id IN ("+authorBooks+")", req.AuthorID)
+	}
+	if req.CategoryID != 0 {
+		dbSql = dbSql.Where("id IN ("+subtreeBooks+")", model.CategorySubtreePattern(req.CategoryID))
+	}
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	order := "created_at DESC"
+	if req.ByUpdated {
+		order = "updated_at DESC"
+	}
+	var items []api.BookFeedItem
+	err := dbSql.Select(feedColumns).Order(order).Order("id DESC").
+		Offset((req.Page - 1) * req.PageSize).Limit(req.PageSize).Scan(&items).Error
+	return items, total, err
+}
+
+// BookFeedByIDsDAO 批量查询 OPDS 书目（不含回收站），不保证顺序
+func (d *dbService) BookFeedByIDsDAO(ids []uint) ([]api.BookFeedItem, error) {
+	var items []api.BookFeedItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	err := d.db.Model(&model.Book{}).Select(feedColumns).Where("id IN ?", ids).Scan(&items).Error
+	return items, err
+}
+
+// BookAuthorListDAO 分页查询作者及其书籍数量（不区分贡献者角色，回收站中的书籍不计入），
+// 按作者名排序，没有书籍的作者不返回
+func (d *dbService) BookAuthorListDAO(page, pageSize int) ([]api.AuthorCount, int64, error) {
+	scope := func(dbSql *gorm.DB) *gorm.DB {
+		return dbSql.Table("authors").
+			Joins("JOIN book_contributors ON book_contributors.author_id = authors.id").
+			Joins("JOIN books ON books.id = book_contributors.book_id AND books.deleted_at IS NULL").
+			Where("authors.deleted_at IS NULL")
+	}
+
+	var total int64
+	if err := scope(d.db).Distinct("authors.id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var authors []api.AuthorCount
+	err := scope(d.db).
+		Select("authors.id AS author_id, authors.name AS author, authors.disambiguation, COUNT(DISTINCT books.id) AS count").
+		Group("authors.id").Order("authors.name, authors.id").
+		Offset((page - 1) * pageSize).Limit(pageSize).Scan(&authors).Error
+	return authors, total, err
+}
+
+// BookLastModifiedDAO 书籍最后一次变化的时间：新增、修改、恢复或移入回收站。
+// authorID 不为 0 时只看该作者的书籍，categoryID 不为 0 时只看该分类及其子孙分类中的书籍；没有书籍时返回零值
+func (d *dbService) BookLastModifiedDAO(authorID, categoryID uint) (time.Time, error) {
+	scope := func(dbSql *gorm.DB) *gorm.DB {
+		dbSql = dbSql.Unscoped().Model(&model.Book{})
+		if authorID != 0 {
+			dbSql = dbSql.Where("id IN ("+authorBooks+")", authorID)
+		}
+		if categoryID != 0 {
+			dbSql = dbSql.Where("id IN ("+subtreeBooks+")", model.CategorySubtreePattern(categoryID))
+		}
+		return dbSql
+	}
+
+	// 软删除只写 deleted_at，不更新 updated_at
+	var updated, deleted model.Book
+	if err := scope(d.db).Select("updated_at").Order("updated_at DESC").Limit(1).Find(&updated).Error; err != nil {
+		return time.Time{}, err
+	}
+	err := scope(d.db).Select("deleted_at").Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Limit(1).Find(&deleted).Error
+	if err != nil {
+		return time.Time{}, err
+	}
+	if deleted.DeletedAt.Valid && deleted.DeletedAt.Time.After(updated.UpdatedAt) {
+		return deleted.DeletedAt.Time, nil
+	}
+	return updated.UpdatedAt, nil
+}
+
+// BookGetByISBNWithDeletedDAO 根据ISBN获取书籍（包含回收站中的书籍）
+func (d *dbService) BookGetByISBNWithDeletedDAO(isbn string) (*model.Book, error) {
+	var book model.Book
+	err := d.db.Unscoped().Where("isbn = ?", isbn).First(&book).Error
+	if err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// BookListByISBNsWithDeletedDAO 根据ISBN批量获取书籍（包含回收站中的书籍）
+func (d *dbService) BookListByISBNsWithDeletedDAO(isbns []string) ([]model.Book, error) {
+	var books []model.Book
+	if len(isbns) == 0 {
+		return books, nil
+	}
+	err := d.db.Unscoped().Where("isbn IN ?", isbns).Find(&books).Error
+	return books, err
+}
+
+// BookTrashListDAO 分页查询回收站中的书籍，最近删除的在前
+func (d *dbService) BookTrashListDAO(req *api.BookTrashListReq) (*api.BookSearchResp, error) {
+	dbSql := d.db.Unscoped().Model(&model.Book{}).Where("deleted_at IS NOT NULL")
+	if req.Title != "" {
+		dbSql = dbSql.Where("title LIKE ?", "%"+req.Title+"%")
+	}
+	if req.ISBN != "" {
+		dbSql = dbSql.Where("isbn = ?", req.ISBN)
+	}
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count trashed books: %w", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	var books []model.Book
+	if err := dbSql.Order("deleted_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&books).Error; err != nil {
+		return nil, fmt.Errorf("failed to query trashed books: %w", err)
+	}
+
+	bookResps := make([]api.BookInfoResp, 0, len(books))
+	for _, book := range books {
+		deletedAt := book.DeletedAt.Time
+		bookResps = append(bookResps, api.BookInfoResp{
+			ID:        book.ID,
+			Title:     book.Title,
+			Author:    book.Author,
+			Count:     book.Count,
+			ISBN:      book.ISBN,
+			Summary:   book.Summary,
+			Version:   book.Version,
+			DeletedAt: &deletedAt,
+		})
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &api.BookSearchResp{
+		Books:      bookResps,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// BookRestoreDAO 从回收站恢复书籍，书籍不在回收站中时返回 gorm.ErrRecordNotFound
+func (d *dbService) BookRestoreDAO(id uint) (*model.Book, error) {
+	result := d.db.Unscoped().Model(&model.Book{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return d.BookGetByIDDAO(id)
+}
+
+// BookPurgeDAO 彻底删除回收站中的书籍，未删除的书籍不受影响，返回删除条数
+func (d *dbService) BookPurgeDAO(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var trashed []uint
+	err := d.db.Unscoped().Model(&model.Book{}).
+		Where("id IN ? AND deleted_at IS NOT NULL", ids).
+		Pluck("id", &trashed).Error
+	if err != nil {
+		return 0, err
+	}
+	return d.purgeBooks(trashed)
+}
+
+// BookPurgeDeletedBeforeDAO 彻底删除 before 之前进入回收站的书籍，返回删除条数
+func (d *dbService) BookPurgeDeletedBeforeDAO(before time.Time) (int64, error) {
+	var expired []uint
+	err := d.db.Unscoped().Model(&model.Book{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Pluck("id", &expired).Error
+	if err != nil {
+		return 0, err
+	}
+	return d.purgeBooks(expired)
+}
+
+// purgeBooks 彻底删除回收站中的书籍及其修订记录、贡献者、分类、标签、MARC 原始记录
+func (d *dbService) purgeBooks(ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var n int64
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		// 调用方查出 ids 之后书籍可能已被恢复，在事务内锁定仍在回收站中的书籍，
+		// 后续只删除这些书籍及其关联数据，避免误删已恢复书籍的修订记录等
+		var trashed []uint
+		err := tx.Unscoped().Model(&model.Book{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ? AND deleted_at IS NOT NULL", ids).
+			Pluck("id", &trashed).Error
+		if err != nil {
+			return err
+		}
+		if len(trashed) == 0 {
+			return nil
+		}
+		ids = trashed
+
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&model.Book{})
+		if result.Error != nil {
+			return result.Error
+		}
+		n = result.RowsAffected
+
+		if err := tx.Where("book_id IN ?", ids).Delete(&model.BookRevision{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN ?", ids).Delete(&model.BookContributor{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN ?", ids).Delete(&model.BookCategory{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("book_id IN ?", ids).Delete(&model.BookTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("book_id IN ?", ids).Delete(&model.BookMARC{}).Error
+	})
+	return n, err
+}
@@ -0,0 +1,84 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"encoding/json"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 修订记录由 BookAddDAO / BookUpdateDAO 在同一事务中写入，这里只提供查询
+type bookRevisionDAO interface {
+	BookRevisionListDAO(bookID uint, offset, limit int) ([]model.BookRevision, int64, error)
+	BookRevisionGetDAO(bookID uint, version int) (*model.BookRevision, error)
+}
+
+// BookRevisionListDAO 分页查询书籍的修订记录，按版本号倒序
+func (d *dbService) BookRevisionListDAO(bookID uint, offset, limit int) ([]model.BookRevision, int64, error) {
+	dbSql := d.db.Model(&model.BookRevision{}).Where("book_id = ?", bookID)
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var revisions []model.BookRevision
+	if err := dbSql.Order("version DESC").Offset(offset).Limit(limit).Find(&revisions).Error; err != nil {
+		return nil, 0, err
+	}
+	return revisions, total, nil
+}
+
+// BookRevisionGetDAO 查询书籍的指定版本
+func (d *dbService) BookRevisionGetDAO(bookID uint, version int) (*model.BookRevision, error) {
+	var revision model.BookRevision
+	err := d.db.Where("book_id = ? AND version = ?", bookID, version).First(&revision).Error
+	if err != nil {
+		return nil, err
+	}
+	return &revision, nil
+}
+
+// createRevision 为书籍的当前版本追加一条修订记录
+func createRevision(tx *gorm.DB, book *model.Book, action string, operator api.Operator, fields []string, restoredFrom int) error {
+	snapshot, err := json.Marshal(book.Snapshot())
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&model.BookRevision{
+		BookID:       book.ID,
+		Version:      book.Version,
+		Action:       action,
+		ActorID:      operator.UserID,
+		APIKeyID:     operator.APIKeyID,
+		Fields:       strings.Join(fields, ","),
+		RestoredFrom: restoredFrom,
+		Snapshot:     string(snapshot),
+	}).Error
+}
+
+// ensureBaselineRevision 书籍当前版本还没有修订记录时（启用修订记录前创建或修改的书籍）补记一条
+func ensureBaselineRevision(tx *gorm.DB, book *model.Book) error {
+	var count int64
+	err := tx.Model(&model.BookRevision{}).
+		Where("book_id = ? AND version = ?", book.ID, book.Version).
+		Count(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	snapshot, err := json.Marshal(book.Snapshot())
+	if err != nil {
+		return err
+	}
+	return tx.Create(&model.BookRevision{
+		CreatedAt: book.UpdatedAt,
+		BookID:    book.ID,
+		Version:   book.Version,
+		Action:    model.RevisionBaseline,
+		Snapshot:  string(snapshot),
+	}).Error
+}
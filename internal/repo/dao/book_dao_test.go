@@ -3,9 +3,12 @@ package dao
 import (
 	"LibraryManagement/internal/api"
 	"LibraryManagement/internal/model"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
@@ -16,17 +19,33 @@ type user struct {
 	Username     string `gorm:"uniqueIndex;not null"`
 	PasswordHash string `gorm:"not null"`
 	Role         string `gorm:"type:text;default:user;not null"` // 测试专用
+	TokenVersion uint   `gorm:"default:0;not null"`
+	Disabled     bool   `gorm:"default:false;not null"`
+
+	DisplayName       string
+	Email             string
+	EmailVerified     bool `gorm:"default:false;not null"`
+	Phone             string
+	PreferredLanguage string `gorm:"default:zh"`
+
+	TOTPSecret   string
+	TOTPEnabled  bool  `gorm:"default:false;not null"`
+	TOTPLastStep int64 `gorm:"default:0;not null"`
+
+	MFAFailedAttempts int `gorm:"default:0;not null"`
+	MFALockedUntil    *time.Time
 }
 
 // setupTestDB 初始化内存数据库并自动迁移表
 func setupTestDB() (*dbService, error) {
-	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
 
 	// 自动迁移模型
-	err = db.AutoMigrate(&model.Book{})
+	err = db.AutoMigrate(&model.Book{}, &model.BookRevision{}, &model.BookMARC{}, &model.BookMetadataCache{}, &model.Author{}, &model.BookContributor{},
+		&model.Category{}, &model.BookCategory{}, &model.Tag{}, &model.BookTag{})
 	if err != nil {
 		return nil, err
 	}
@@ -37,6 +56,11 @@ func setupTestDB() (*dbService, error) {
 		return nil, err
 	}
 
+	err = db.AutoMigrate(&model.PasswordResetToken{}, &model.RecoveryCode{}, &model.APIKey{}, &model.UserIdentity{}, &model.AuditLog{}, &model.PasswordHistory{})
+	if err != nil {
+		return nil, err
+	}
+
 	return &dbService{db: db}, nil
 }
 
@@ -66,24 +90,41 @@ func TestBookDeleteDAO(t *testing.T) {
 	dao, err := setupTestDB()
 	assert.NoError(t, err)
 
-	// 先添加一本书
-	_ = dao.db.Create(&model.Book{
-		Title: "To Delete",
-		ISBN:  "978-1111111111",
-		Count: 1,
-	})
+	books := []model.Book{
+		{Title: "To Delete", ISBN: "978-1111111111", Count: 1},
+		{Title: "To Keep", ISBN: "978-1111111112", Count: 1},
+	}
+	dao.db.Create(&books)
 
-	// 删除 ID 为 1 的书
-	err = dao.BookDeleteDAO([]string{"1"})
+	// 不存在的 ID 被忽略，只返回实际删除的 ID
+	deleted, err := dao.BookDeleteDAO([]uint{books[0].ID, 999}, false)
 	assert.NoError(t, err)
+	assert.Equal(t, []uint{books[0].ID}, deleted)
 
 	var count int64
 	dao.db.Model(&model.Book{}).Count(&count)
-	assert.Equal(t, int64(0), count)
+	assert.Equal(t, int64(1), count)
+
+	// 已删除的书籍再次删除视为不存在
+	deleted, err = dao.BookDeleteDAO([]uint{books[0].ID}, false)
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	// 事务模式：有不存在的 ID 时整批回滚
+	deleted, err = dao.BookDeleteDAO([]uint{books[1].ID, 999}, true)
+	assert.ErrorIs(t, err, ErrBooksNotFound)
+	assert.Equal(t, []uint{books[1].ID}, deleted)
+	dao.db.Model(&model.Book{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	deleted, err = dao.BookDeleteDAO([]uint{books[1].ID}, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{books[1].ID}, deleted)
 
 	// 测试删除空列表
-	err = dao.BookDeleteDAO([]string{})
+	deleted, err = dao.BookDeleteDAO([]uint{}, false)
 	assert.NoError(t, err)
+	assert.Empty(t, deleted)
 }
 
 func TestBookUpdateDAO_OptimisticLock(t *testing.T) {
@@ -123,17 +164,71 @@ func TestBookUpdateDAO_OptimisticLock(t *testing.T) {
 	assert.Equal(t, "New Title", updatedBook.Title)
 	assert.Equal(t, uint(2), uint(updatedBook.Version))
 
-	// TODO 模拟并发更新：使用旧 version (1) 尝试更新
-	//oldReq := &api.BookUpdateReq{
-	//	ID: book.ID,
-	//	BookInfoReq: api.BookInfoReq{
-	//		Title: "Conflict Title",
-	//	},
-	//}
-	//
-	//_, err = dao.BookUpdateDAO(oldReq)
-	//assert.Error(t, err)
-	//assert.Contains(t, err.Error(), "更新失败：数据已被其他用户修改")
+	// 模拟并发更新：客户端仍持有旧 version (1)
+	stale := 1
+	oldReq := &api.BookUpdateReq{
+		ID:      book.ID,
+		Version: &stale,
+		BookInfoReq: api.BookInfoReq{
+			Title: "Conflict Title",
+			ISBN:  "978-1234567890",
+			Count: 1,
+		},
+	}
+
+	_, err = dao.BookUpdateDAO(oldReq)
+	assert.ErrorIs(t, err, ErrVersionConflict)
+
+	dao.db.First(&current, book.ID)
+	assert.Equal(t, "New Title", current.Title)
+	assert.Equal(t, 2, current.Version)
+
+	// 携带最新 version 可以更新
+	latest := 2
+	oldReq.Version = &latest
+	updatedBook, err = dao.BookUpdateDAO(oldReq)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, updatedBook.Version)
+}
+
+func TestBookUpdateDAO_PartialFields(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	book := model.Book{
+		Title:   "Go",
+		Author:  "张三",
+		ISBN:    "978-0000000001",
+		Count:   3,
+		Content: "正文",
+		Summary: "摘要",
+		Version: 1,
+	}
+	dao.db.Create(&book)
+
+	// 只更新 count，请求中其余字段为空也不会覆盖原值
+	version := 1
+	updated, err := dao.BookUpdateDAO(&api.BookUpdateReq{
+		ID:          book.ID,
+		Version:     &version,
+		BookInfoReq: api.BookInfoReq{Count: 8},
+		Fields:      []string{"count", "unknown"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(8), updated.Count)
+	assert.Equal(t, "Go", updated.Title)
+	assert.Equal(t, "正文", updated.Content)
+	assert.Equal(t, "摘要", updated.Summary)
+	assert.Equal(t, 2, updated.Version)
+
+	// 局部更新同样受乐观锁保护
+	_, err = dao.BookUpdateDAO(&api.BookUpdateReq{
+		ID:          book.ID,
+		Version:     &version,
+		BookInfoReq: api.BookInfoReq{Author: "李四"},
+		Fields:      []string{"author"},
+	})
+	assert.ErrorIs(t, err, ErrVersionConflict)
 }
 
 func TestBookListDAO_PaginationAndSearch(t *testing.T) {
@@ -229,3 +324,431 @@ func TestBookGetByISBNDAO(t *testing.T) {
 	_, err = dao.BookGetByISBNDAO("nonexistent")
 	assert.Error(t, err)
 }
+
+func TestBookTrash(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	books := []model.Book{
+		{Title: "Go 入门", ISBN: "978-0000000011", Count: 1},
+		{Title: "Go 进阶", ISBN: "978-0000000012", Count: 1},
+		{Title: "Rust", ISBN: "978-0000000013", Count: 1},
+	}
+	dao.db.Create(&books)
+	_, err = dao.BookDeleteDAO([]uint{books[0].ID, books[1].ID}, false)
+	assert.NoError(t, err)
+
+	// 回收站只包含已删除的书籍
+	trash, err := dao.BookTrashListDAO(&api.BookTrashListReq{Title: "Go"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), trash.Total)
+	for _, b := range trash.Books {
+		assert.NotNil(t, b.DeletedAt)
+	}
+
+	// 删除的书籍仍占用 ISBN
+	_, err = dao.BookGetByISBNDAO(books[0].ISBN)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	trashed, err := dao.BookGetByISBNWithDeletedDAO(books[0].ISBN)
+	assert.NoError(t, err)
+	assert.True(t, trashed.DeletedAt.Valid)
+	_, err = dao.BookAddDAO(&api.BookInfoReq{Title: "重复", ISBN: books[0].ISBN, Count: 1})
+	assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+
+	// 恢复
+	restored, err := dao.BookRestoreDAO(books[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Go 入门", restored.Title)
+	assert.False(t, restored.DeletedAt.Valid)
+
+	// 不在回收站中的书籍不能恢复
+	_, err = dao.BookRestoreDAO(books[2].ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 彻底删除只作用于回收站中的书籍
+	n, err := dao.BookPurgeDAO([]uint{books[1].ID, books[2].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	var count int64
+	dao.db.Unscoped().Model(&model.Book{}).Count(&count)
+	assert.Equal(t, int64(2), count)
+
+	// 彻底删除后 ISBN 可以重新使用
+	_, err = dao.BookAddDAO(&api.BookInfoReq{Title: "Go 进阶（第二版）", ISBN: books[1].ISBN, Count: 1})
+	assert.NoError(t, err)
+}
+
+func TestBookPurgeDeletedBeforeDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	old := model.Book{Title: "Old", ISBN: "978-0000000021", Count: 1}
+	recent := model.Book{Title: "Recent", ISBN: "978-0000000022", Count: 1}
+	live := model.Book{Title: "Live", ISBN: "978-0000000023", Count: 1}
+	dao.db.Create(&old)
+	dao.db.Create(&recent)
+	dao.db.Create(&live)
+	dao.db.Unscoped().Model(&old).Update("deleted_at", now.Add(-48*time.Hour))
+	dao.db.Unscoped().Model(&recent).Update("deleted_at", now.Add(-time.Hour))
+
+	n, err := dao.BookPurgeDeletedBeforeDAO(now.Add(-24 * time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	var remaining []model.Book
+	dao.db.Unscoped().Order("id").Find(&remaining)
+	assert.Len(t, remaining, 2)
+	assert.Equal(t, "Recent", remaining[0].Title)
+	assert.Equal(t, "Live", remaining[1].Title)
+}
+
+func TestBookBatchAddDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	books, err := dao.BookBatchAddDAO([]*api.BookInfoReq{
+		{Title: "Go", Count: 1, ISBN: "978-0000000041", Operator: api.Operator{UserID: 7}},
+		{Title: "Rust", Count: 2, ISBN: "978-0000000042", Author: "张三"},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, books, 2)
+	assert.NotZero(t, books[0].ID)
+	assert.Equal(t, 1, books[1].Version)
+
+	// 每本书都记录第一个版本
+	revisions, total, err := dao.BookRevisionListDAO(books[1].ID, 0, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "title,count,isbn,author", revisions[0].Fields)
+
+	// 任一 ISBN 重复则整批回滚
+	_, err = dao.BookBatchAddDAO([]*api.BookInfoReq{
+		{Title: "C", Count: 1, ISBN: "978-0000000043"},
+		{Title: "Go 2", Count: 1, ISBN: "978-0000000041"},
+	})
+	assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	_, err = dao.BookGetByISBNDAO("978-0000000043")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 按 ISBN 批量查询时包含回收站中的书籍
+	_, err = dao.BookDeleteDAO([]uint{books[0].ID}, false)
+	assert.NoError(t, err)
+	found, err := dao.BookListByISBNsWithDeletedDAO([]string{"978-0000000041", "978-0000000042", "978-0000000049"})
+	assert.NoError(t, err)
+	assert.Len(t, found, 2)
+	for _, book := range found {
+		assert.Equal(t, book.ID == books[0].ID, book.DeletedAt.Valid)
+	}
+}
+
+func TestBookExportDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	books := []model.Book{
+		{Title: "Go 入门", Count: 1, ISBN: "978-0000000051", Author: "张三"},
+		{Title: "Rust", Count: 2, ISBN: "978-0000000052"},
+		{Title: "Go 进阶", Count: 3, ISBN: "978-0000000053", Author: "张三"},
+		{Title: "Go 已删除", Count: 4, ISBN: "978-0000000054"},
+	}
+	dao.db.Create(&books)
+	dao.db.Delete(&books[3])
+
+	collect := func(req *api.BookSearchReq) []string {
+		var isbns []string
+		err := dao.BookExportDAO(req, func(book *model.Book) error {
+			isbns = append(isbns, book.ISBN)
+			return nil
+		})
+		assert.NoError(t, err)
+		return isbns
+	}
+
+	// 按 ID 顺序逐行读取，不包含回收站中的书籍
+	assert.Equal(t, []string{"978-0000000051", "978-0000000052", "978-0000000053"}, collect(&api.BookSearchReq{}))
+	// 过滤条件与列表查询相同
+	assert.Equal(t, []string{"978-0000000051", "978-0000000053"}, collect(&api.BookSearchReq{Title: "Go", Author: "张三"}))
+
+	// 回调出错时停止读取
+	stop := errors.New("stop")
+	n := 0
+	err = dao.BookExportDAO(&api.BookSearchReq{}, func(book *model.Book) error {
+		n++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, n)
+}
+
+func TestBookMARCDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	books := []model.Book{
+		{Title: "Go", Count: 1, ISBN: "978-0000000061"},
+		{Title: "Rust", Count: 2, ISBN: "978-0000000062"},
+		{Title: "Go 已删除", Count: 3, ISBN: "978-0000000063"},
+	}
+	dao.db.Create(&books)
+
+	assert.NoError(t, dao.BookMARCSaveDAO([]model.BookMARC{
+		{BookID: books[0].ID, Record: `{"leader":"old"}`},
+		{BookID: books[2].ID, Record: `{"leader":"deleted"}`},
+	}))
+	// 再次保存时覆盖
+	assert.NoError(t, dao.BookMARCSaveDAO([]model.BookMARC{{BookID: books[0].ID, Record: `{"leader":"new"}`}}))
+	assert.NoError(t, dao.BookMARCSaveDAO(nil))
+
+	collect := func(req *api.BookSearchReq) map[string]string {
+		records := make(map[string]string)
+		err := dao.BookMARCExportDAO(req, func(book *model.Book, record string) error {
+			records[book.ISBN] = record
+			return nil
+		})
+		assert.NoError(t, err)
+		return records
+	}
+	assert.Equal(t, map[string]string{
+		"978-0000000061": `{"leader":"new"}`,
+		"978-0000000062": "",
+		"978-0000000063": `{"leader":"deleted"}`,
+	}, collect(&api.BookSearchReq{}))
+	assert.Len(t, collect(&api.BookSearchReq{Title: "Go", ISBN: "978-0000000061"}), 1)
+
+	// 彻底删除书籍时一并删除原始记录
+	dao.db.Delete(&books[2])
+	_, err = dao.BookPurgeDAO([]uint{books[2].ID})
+	assert.NoError(t, err)
+	assert.Len(t, collect(&api.BookSearchReq{}), 2)
+	var n int64
+	dao.db.Model(&model.BookMARC{}).Count(&n)
+	assert.Equal(t, int64(1), n)
+}
+
+func TestBookHarvestDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	books := []model.Book{
+		{Title: "A", Count: 1, ISBN: "978-0000000071"},
+		{Title: "B", Count: 1, ISBN: "978-0000000072"},
+		{Title: "C", Count: 1, ISBN: "978-0000000073"},
+		{Title: "D", Count: 1, ISBN: "978-0000000074"},
+	}
+	dao.db.Create(&books)
+	// A、B 日期戳相同，按 ID 排序；C 在回收站中，日期戳为删除时间
+	dao.db.Model(&books[0]).UpdateColumn("updated_at", base)
+	dao.db.Model(&books[1]).UpdateColumn("updated_at", base)
+	dao.db.Model(&books[2]).UpdateColumn("updated_at", base.Add(-time.Minute))
+	dao.db.Model(&books[3]).UpdateColumn("updated_at", base.Add(2*time.Minute))
+	dao.db.Model(&books[2]).UpdateColumn("deleted_at", base.Add(time.Minute))
+
+	earliest, err := dao.BookEarliestDatestampDAO()
+	assert.NoError(t, err)
+	assert.True(t, earliest.Equal(base), earliest)
+
+	isbns := func(books []model.Book) []string {
+		var list []string
+		for _, book := range books {
+			list = append(list, book.ISBN)
+		}
+		return list
+	}
+
+	page, total, err := dao.BookHarvestDAO(&api.BookHarvestReq{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, []string{"978-0000000071", "978-0000000072"}, isbns(page))
+
+	// 从上一页最后一条之后继续
+	last := page[1]
+	page, _, err = dao.BookHarvestDAO(&api.BookHarvestReq{AfterTime: last.Datestamp(), AfterID: last.ID, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"978-0000000073", "978-0000000074"}, isbns(page))
+	assert.True(t, page[0].DeletedAt.Valid)
+
+	// 按日期戳过滤，From 包含、Until 不包含
+	page, total, err = dao.BookHarvestDAO(&api.BookHarvestReq{From: base.Add(time.Minute), Until: base.Add(2 * time.Minute), Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, []string{"978-0000000073"}, isbns(page))
+
+	book, err := dao.BookGetByIDWithDeletedDAO(books[2].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "C", book.Title)
+	_, err = dao.BookGetByIDWithDeletedDAO(999)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestBookListByIDsDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	books := []model.Book{
+		{Title: "A", Count: 1, ISBN: "978-0000000081"},
+		{Title: "B", Count: 1, ISBN: "978-0000000082"},
+	}
+	dao.db.Create(&books)
+	dao.db.Delete(&books[1])
+
+	// 回收站中的书籍和不存在的 ID 不返回
+	found, err := dao.BookListByIDsDAO([]uint{books[0].ID, books[1].ID, 999})
+	assert.NoError(t, err)
+	if assert.Len(t, found, 1) {
+		assert.Equal(t, "A", found[0].Title)
+	}
+
+	found, err = dao.BookListByIDsDAO(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestBookFeedDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	books := []model.Book{
+		{Title: "A", Count: 1, ISBN: "978-0000000091", Author: "张三", Content: "正文"},
+		{Title: "B", Count: 1, ISBN: "978-0000000092", Author: "李四"},
+		{Title: "C", Count: 1, ISBN: "978-0000000093", Author: "张三"},
+		{Title: "D", Count: 1, ISBN: "978-0000000094"},
+		{Title: "E", Count: 1, ISBN: "978-0000000095", Author: "张三"},
+	}
+	dao.db.Create(&books)
+	for i := range books {
+		dao.db.Model(&books[i]).UpdateColumn("created_at", base.Add(time.Duration(i)*time.Minute))
+	}
+	zhang := addContributor(t, dao, "张三", model.ContributorAuthor, books[0].ID, books[2].ID, books[4].ID)
+	li := addContributor(t, dao, "李四", model.ContributorAuthor, books[1].ID)
+	wang := addContributor(t, dao, "王五", model.ContributorTranslator, books[0].ID)
+	dao.db.Delete(&books[4])
+
+	titles := func(items []api.BookFeedItem) []string {
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			list = append(list, item.Title)
+		}
+		return list
+	}
+
+	// 新上架的在前，不含回收站
+	items, total, err := dao.BookFeedDAO(&api.BookFeedReq{Page: 1, PageSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, []string{"D", "C", "B"}, titles(items))
+	items, _, err = dao.BookFeedDAO(&api.BookFeedReq{Page: 2, PageSize: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, titles(items))
+	assert.True(t, items[0].HasContent)
+	assert.Equal(t, "978-0000000091", items[0].ISBN)
+	assert.False(t, items[0].UpdatedAt.IsZero())
+
+	items, total, err = dao.BookFeedDAO(&api.BookFeedReq{AuthorID: zhang, Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, []string{"C", "A"}, titles(items))
+	assert.False(t, items[0].HasContent)
+
+	// 按作者 ID 筛选，不区分贡献者角色
+	items, _, err = dao.BookFeedDAO(&api.BookFeedReq{AuthorID: wang, Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A"}, titles(items))
+
+	items, err = dao.BookFeedByIDsDAO([]uint{books[1].ID, books[4].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"B"}, titles(items))
+
+	// 作者来自贡献者记录，数量不含回收站中的书籍；没有书籍的作者不列出
+	dao.db.Create(&model.Author{Name: "赵六"})
+	authors, total, err := dao.BookAuthorListDAO(1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.ElementsMatch(t, []api.AuthorCount{
+		{AuthorID: zhang, Author: "张三", Count: 2},
+		{AuthorID: li, Author: "李四", Count: 1},
+		{AuthorID: wang, Author: "王五", Count: 1},
+	}, authors)
+	authors, _, err = dao.BookAuthorListDAO(2, 1)
+	assert.NoError(t, err)
+	assert.Len(t, authors, 1)
+}
+
+// addContributor 新建作者并以 role 关联到书籍，返回作者 ID
+func addContributor(t *testing.T, dao *dbService, name, role string, bookIDs ...uint) uint {
+	author := model.Author{Name: name}
+	require.NoError(t, dao.db.Create(&author).Error)
+	for _, id := range bookIDs {
+		require.NoError(t, dao.db.Create(&model.BookContributor{BookID: id, AuthorID: author.ID, Role: role}).Error)
+	}
+	return author.ID
+}
+
+func TestBookFeedByUpdatedDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	base := time.Now().Add(-time.Hour)
+	books := []model.Book{
+		{Title: "A", Count: 1, ISBN: "978-0000000101", Author: "张三"},
+		{Title: "B", Count: 1, ISBN: "978-0000000102", Author: "李四"},
+		{Title: "C", Count: 1, ISBN: "978-0000000103", Author: "张三"},
+	}
+	dao.db.Create(&books)
+	for i := range books {
+		dao.db.Model(&books[i]).UpdateColumns(map[string]interface{}{
+			"created_at": base.Add(time.Duration(i) * time.Minute),
+			"updated_at": base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	// 最早上架的 A 最近被修改
+	dao.db.Model(&books[0]).UpdateColumn("updated_at", base.Add(10*time.Minute))
+	li := addContributor(t, dao, "李四", model.ContributorAuthor, books[1].ID)
+	wang := addContributor(t, dao, "王五", model.ContributorAuthor)
+
+	items, _, err := dao.BookFeedDAO(&api.BookFeedReq{ByUpdated: true, Page: 1, PageSize: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "A", items[0].Title)
+	assert.Equal(t, "C", items[1].Title)
+	assert.True(t, items[0].UpdatedAt.After(items[0].CreatedAt))
+
+	// 最后变化时间：最近的修改
+	modified, err := dao.BookLastModifiedDAO(0, 0)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, base.Add(10*time.Minute), modified, time.Second)
+	modified, err = dao.BookLastModifiedDAO(li, 0)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, base.Add(time.Minute), modified, time.Second)
+
+	// 移入回收站也是一次变化
+	dao.db.Delete(&books[1])
+	modified, err = dao.BookLastModifiedDAO(li, 0)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), modified, 5*time.Second)
+
+	// 没有书籍时为零值
+	modified, err = dao.BookLastModifiedDAO(wang, 0)
+	assert.NoError(t, err)
+	assert.True(t, modified.IsZero())
+}
+
+func TestBookMetadataCacheDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	_, err = dao.BookMetadataCacheGetDAO("9787111547426")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	fetched := time.Now().Add(-time.Hour)
+	assert.NoError(t, dao.BookMetadataCacheSaveDAO(&model.BookMetadataCache{ISBN: "9787111547426", Data: `{"title":"旧"}`, FetchedAt: fetched}))
+	// 再次保存时覆盖
+	assert.NoError(t, dao.BookMetadataCacheSaveDAO(&model.BookMetadataCache{ISBN: "9787111547426", Data: `{"title":"新"}`, FetchedAt: time.Now()}))
+
+	entry, err := dao.BookMetadataCacheGetDAO("9787111547426")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"title":"新"}`, entry.Data)
+	assert.True(t, entry.FetchedAt.After(fetched))
+}
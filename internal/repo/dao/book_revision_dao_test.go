@@ -0,0 +1,132 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookRevisions(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	// 新增书籍时记录第一个版本
+	book, err := dao.BookAddDAO(&api.BookInfoReq{
+		Title: "Go", Count: 1, ISBN: "978-0000000031",
+		Operator: api.Operator{UserID: 7},
+	})
+	require.NoError(t, err)
+
+	revisions, total, err := dao.BookRevisionListDAO(book.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, model.RevisionCreate, revisions[0].Action)
+	assert.Equal(t, uint(7), revisions[0].ActorID)
+	assert.Equal(t, "title,count,isbn", revisions[0].Fields)
+
+	// 局部更新只记录实际修改的字段
+	_, err = dao.BookUpdateDAO(&api.BookUpdateReq{
+		ID:          book.ID,
+		BookInfoReq: api.BookInfoReq{Count: 5, Operator: api.Operator{UserID: 8, APIKeyID: 3}},
+		Fields:      []string{"count"},
+	})
+	require.NoError(t, err)
+
+	// 回滚到版本 1 记为 rollback
+	_, err = dao.BookUpdateDAO(&api.BookUpdateReq{
+		ID:           book.ID,
+		BookInfoReq:  api.BookInfoReq{Count: 1},
+		Fields:       []string{"count"},
+		RestoredFrom: 1,
+	})
+	require.NoError(t, err)
+
+	revisions, total, err = dao.BookRevisionListDAO(book.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Equal(t, []int{3, 2, 1}, []int{revisions[0].Version, revisions[1].Version, revisions[2].Version})
+
+	assert.Equal(t, model.RevisionRollback, revisions[0].Action)
+	assert.Equal(t, 1, revisions[0].RestoredFrom)
+
+	assert.Equal(t, model.RevisionUpdate, revisions[1].Action)
+	assert.Equal(t, "count", revisions[1].Fields)
+	assert.Equal(t, uint(3), revisions[1].APIKeyID)
+
+	// 快照保存完整内容
+	v2, err := dao.BookRevisionGetDAO(book.ID, 2)
+	require.NoError(t, err)
+	var snapshot model.BookSnapshot
+	require.NoError(t, json.Unmarshal([]byte(v2.Snapshot), &snapshot))
+	assert.Equal(t, model.BookSnapshot{Title: "Go", Count: 5, ISBN: "978-0000000031"}, snapshot)
+
+	// 乐观锁冲突时不产生修订记录
+	stale := 1
+	_, err = dao.BookUpdateDAO(&api.BookUpdateReq{ID: book.ID, Version: &stale, BookInfoReq: api.BookInfoReq{Title: "x", Count: 1, ISBN: "x"}})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	_, total, _ = dao.BookRevisionListDAO(book.ID, 0, 10)
+	assert.Equal(t, int64(3), total)
+
+	// 彻底删除书籍时一并清理修订记录
+	_, err = dao.BookDeleteDAO([]uint{book.ID}, false)
+	require.NoError(t, err)
+	n, err := dao.BookPurgeDAO([]uint{book.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	_, total, _ = dao.BookRevisionListDAO(book.ID, 0, 10)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestBookRevisionBaseline(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	// 启用修订记录前创建的书籍没有任何修订记录
+	legacy := model.Book{Title: "Legacy", Count: 2, ISBN: "978-0000000032", Version: 4}
+	dao.db.Create(&legacy)
+
+	_, err = dao.BookUpdateDAO(&api.BookUpdateReq{
+		ID:          legacy.ID,
+		BookInfoReq: api.BookInfoReq{Title: "Legacy 2nd", Count: 2, ISBN: "978-0000000032"},
+	})
+	require.NoError(t, err)
+
+	// 首次更新时补记旧版本，便于回滚
+	revisions, total, err := dao.BookRevisionListDAO(legacy.ID, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, 5, revisions[0].Version)
+	assert.Equal(t, "title", revisions[0].Fields)
+	assert.Equal(t, 4, revisions[1].Version)
+	assert.Equal(t, model.RevisionBaseline, revisions[1].Action)
+	assert.Contains(t, revisions[1].Snapshot, `"title":"Legacy"`)
+
+	_, err = dao.BookRevisionGetDAO(legacy.ID, 1)
+	assert.Error(t, err)
+}
+
+func TestPurgeBooksSkipsRestored(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	book, err := dao.BookAddDAO(&api.BookInfoReq{Title: "Go", Count: 1, ISBN: "978-0000000035"})
+	require.NoError(t, err)
+	_, err = dao.BookDeleteDAO([]uint{book.ID}, false)
+	require.NoError(t, err)
+
+	// 清理任务查出回收站中的书籍后，书籍在彻底删除前被恢复
+	_, err = dao.BookRestoreDAO(book.ID)
+	require.NoError(t, err)
+	n, err := dao.purgeBooks([]uint{book.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	// 已恢复书籍的修订记录不受影响
+	_, total, err := dao.BookRevisionListDAO(book.ID, 0, 10)
+	require.NoError(t, err)
+	assert.NotZero(t, total)
+}
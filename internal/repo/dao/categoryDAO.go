@@ -0,0 +1,450 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrBookCategoryNotFound 书籍引用了不存在的分类
+var ErrBookCategoryNotFound = errors.New("书籍引用的分类不存在")
+
+// 分类树维护的错误
+var (
+	ErrCategoryParentNotFound = errors.New("上级分类不存在")
+	ErrCategoryCycle          = errors.New("不能把分类移动到自身或其子孙分类下")
+	ErrCategoryTooDeep        = errors.New("分类层级超过上限")
+	ErrCategoryHasChildren    = errors.New("分类仍有子分类")
+	ErrCategoryInUse          = errors.New("分类仍关联书籍")
+)
+
+// subtreeBooks 属于某个分类或其子孙分类的书籍 ID，参数为 model.CategorySubtreePattern
+const subtreeBooks = "SELECT book_categories.book_id FROM book_categories " +
+	"JOIN categories ON categories.id = book_categories.category_id WHERE categories.path LIKE ?"
+
+type categoryDAO interface {
+	CategoryCreateDAO(req *api.CategoryInfoReq) (*model.Category, error)
+	CategoryGetDAO(id uint) (*model.Category, error)
+	// CategoryListDAO 全部分类，按分类号排序
+	CategoryListDAO() ([]model.Category, error)
+	CategoryListByIDsDAO(ids []uint) ([]model.Category, error)
+	CategoryChildrenDAO(id uint) ([]model.Category, error)
+	CategoryBookCountDAO(root *model.Category) (map[uint]int64, error)
+	CategoryUpdateDAO(id uint, req *api.CategoryInfoReq) ([]model.Book, error)
+	CategoryDeleteDAO(id uint) error
+
+	// 标签
+	TagListDAO(req *api.TagSearchReq) (*api.TagListResp, error)
+}
+
+// CategoryCreateDAO 新增分类，分类号重复时返回 gorm.ErrDuplicatedKey
+func (d *dbService) CategoryCreateDAO(req *api.CategoryInfoReq) (*model.Category, error) {
+	category := &model.Category{ParentID: req.ParentID, Code: req.Code, Name: req.Name}
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		parent, err := categoryParent(tx, req.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent != nil && len(parent.AncestorIDs()) >= model.MaxCategoryDepth {
+			return ErrCategoryTooDeep
+		}
+
+		// Path 包含自身的 ID，写入后再补上
+		if err := tx.Create(category).Error; err != nil {
+			return err
+		}
+		category.Path = model.CategoryPath(parent, category.ID)
+		return tx.Model(category).Update("path", category.Path).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// categoryParent 查询上级分类，parentID 为 0 时返回 nil
+func categoryParent(tx *gorm.DB, parentID uint) (*model.Category, error) {
+	if parentID == 0 {
+		return nil, nil
+	}
+	var parent model.Category
+	err := tx.First(&parent, parentID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrCategoryParentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &parent, nil
+}
+
+func (d *dbService) CategoryGetDAO(id uint) (*model.Category, error) {
+	var category model.Category
+	if err := d.db.First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (d *dbService) CategoryListDAO() ([]model.Category, error) {
+	var categories []model.Category
+	err := d.db.Order("code").Find(&categories).Error
+	return categories, err
+}
+
+// CategoryListByIDsDAO 批量查询分类，不保证顺序，不存在的 ID 忽略
+func (d *dbService) CategoryListByIDsDAO(ids []uint) ([]model.Category, error) {
+	var categories []model.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	err := d.db.Where("id IN ?", ids).Find(&categories).Error
+	return categories, err
+}
+
+// CategoryChildrenDAO 直接子分类，按分类号排序
+func (d *dbService) CategoryChildrenDAO(id uint) ([]model.Category, error) {
+	var categories []model.Category
+	err := d.db.Where("parent_id = ?", id).Order("code").Find(&categories).Error
+	return categories, err
+}
+
+// CategoryBookCountDAO 每个分类及其子孙分类中的书籍数量，不含回收站，同一本书只计一次。
+// root 不为 nil 时只统计 root 及其子孙分类；没有书籍的分类不在结果中
+func (d *dbService) CategoryBookCountDAO(root *model.Category) (map[uint]int64, error) {
+	dbSql := d.db.Table("book_categories").
+		Select("book_categories.book_id, categories.path").
+		Joins("JOIN categories ON categories.id = book_categories.category_id").
+		Joins("JOIN books ON books.id = book_categories.book_id AND books.deleted_at IS NULL")
+	if root != nil {
+		dbSql = dbSql.Where("categories.path LIKE ?", root.Path+"%")
+	}
+	var rows []struct {
+		BookID uint
+		Path   string
+	}
+	if err := dbSql.Order("book_categories.book_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// 一本书属于同一分支的多个分类时，共同的上级分类只计一次
+	counts := make(map[uint]int64)
+	var seen map[uint]bool
+	for i, row := range rows {
+		if i == 0 || row.BookID != rows[i-1].BookID {
+			seen = make(map[uint]bool)
+		}
+		category := model.Category{Path: row.Path}
+		for _, id := range category.AncestorIDs() {
+			if !seen[id] {
+				seen[id] = true
+				counts[id]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+// CategoryUpdateDAO 修改分类，ParentID 变化时连同子孙分类一起移动。
+// 分类号或位置变化时返回该分类及其子孙分类中的书籍（不含回收站，已读取分类等关联），供调用方同步到 ES
+func (d *dbService) CategoryUpdateDAO(id uint, req *api.CategoryInfoReq) ([]model.Book, error) {
+	var books []model.Book
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		var category model.Category
+		if err := tx.First(&category, id).Error; err != nil {
+			return err
+		}
+		moved := category.ParentID != req.ParentID
+		recoded := category.Code != req.Code
+
+		updates := map[string]interface{}{"code": req.Code, "name": req.Name}
+		if moved {
+			path, err := movedCategoryPath(tx, &category, req.ParentID)
+			if err != nil {
+				return err
+			}
+			// Path 中每个 ID 只出现一次，替换前缀即可
+			err = tx.Model(&model.Category{}).Where("path LIKE ? AND id <> ?", category.Path+"%", id).
+				Update("path", gorm.Expr("REPLACE(path, ?, ?)", category.Path, path)).Error
+			if err != nil {
+				return err
+			}
+			updates["parent_id"] = req.ParentID
+			updates["path"] = path
+		}
+		if err := tx.Model(&category).Updates(updates).Error; err != nil {
+			return err
+		}
+		if !moved && !recoded {
+			return nil
+		}
+
+		if err := tx.Where("id IN ("+subtreeBooks+")", model.CategorySubtreePattern(id)).Order("id").Find(&books).Error; err != nil {
+			return err
+		}
+		ptrs := make([]*model.Book, 0, len(books))
+		for i := range books {
+			ptrs = append(ptrs, &books[i])
+		}
+		return loadBookRelations(tx, ptrs...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return books, nil
+}
+
+// movedCategoryPath 把分类移动到 parentID 下后的 Path，不能移动到自身或子孙分类下，移动后的层级不能超过上限
+func movedCategoryPath(tx *gorm.DB, category *model.Category, parentID uint) (string, error) {
+	parent, err := categoryParent(tx, parentID)
+	if err != nil {
+		return "", err
+	}
+	if parent != nil && strings.HasPrefix(parent.Path, category.Path) {
+		return "", ErrCategoryCycle
+	}
+	path := model.CategoryPath(parent, category.ID)
+
+	var paths []string
+	if err := tx.Model(&model.Category{}).Where("path LIKE ?", category.Path+"%").Pluck("path", &paths).Error; err != nil {
+		return "", err
+	}
+	depth := len((&model.Category{Path: path}).AncestorIDs())
+	for _, p := range paths {
+		below := len((&model.Category{Path: p}).AncestorIDs()) - len(category.AncestorIDs())
+		if depth+below > model.MaxCategoryDepth {
+			return "", ErrCategoryTooDeep
+		}
+	}
+	return path, nil
+}
+
+// CategoryDeleteDAO 删除分类，仍有子分类或关联书籍（包括回收站中的书籍）时拒绝
+func (d *dbService) CategoryDeleteDAO(id uint) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryHasChildren
+		}
+		if err := tx.Model(&model.BookCategory{}).Where("category_id = ?", id).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrCategoryInUse
+		}
+		result := tx.Delete(&model.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// TagListDAO 分页查询有书籍的标签，书籍多的在前
+func (d *dbService) TagListDAO(req *api.TagSearchReq) (*api.TagListResp, error) {
+	query := func() *gorm.DB {
+		dbSql := d.db.Table("tags").
+			Joins("JOIN book_tags ON book_tags.tag_id = tags.id").
+			Joins("JOIN books ON books.id = book_tags.book_id AND books.deleted_at IS NULL")
+		if req.Name != "" {
+			dbSql = dbSql.Where("tags.name LIKE ?", req.Name+"%")
+		}
+		return dbSql
+	}
+
+	var total int64
+	if err := query().Distinct("tags.id").Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count tags: %w", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	tags := make([]api.TagCount, 0, pageSize)
+	err := query().Select("tags.name, COUNT(DISTINCT books.id) AS book_count").Group("tags.id, tags.name").
+		Order("book_count DESC, tags.name").Offset((page - 1) * pageSize).Limit(pageSize).Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tags: %w", err)
+	}
+
+	return &api.TagListResp{
+		Tags:       tags,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// resolveCategories 查询书籍引用的分类（按分类号排序），有不存在的分类时返回 ErrBookCategoryNotFound
+func resolveCategories(tx *gorm.DB, ids []uint) ([]model.Category, error) {
+	unique := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		unique[id] = true
+	}
+	var categories []model.Category
+	if len(unique) == 0 {
+		return categories, nil
+	}
+	if err := tx.Where("id IN ?", ids).Order("code").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	if len(categories) != len(unique) {
+		return nil, ErrBookCategoryNotFound
+	}
+	return categories, nil
+}
+
+// replaceCategories 用 categories 替换书籍现有的分类
+func replaceCategories(tx *gorm.DB, bookID uint, categories []model.Category) error {
+	if err := tx.Where("book_id = ?", bookID).Delete(&model.BookCategory{}).Error; err != nil {
+		return err
+	}
+	if len(categories) == 0 {
+		return nil
+	}
+	rows := make([]model.BookCategory, 0, len(categories))
+	for _, c := range categories {
+		rows = append(rows, model.BookCategory{BookID: bookID, CategoryID: c.ID})
+	}
+	return tx.Create(&rows).Error
+}
+
+// resolveTags 查询或新建标签，返回去重后的标签（按名称排序）。名称去掉首尾空白，空的忽略
+func resolveTags(tx *gorm.DB, names []string) ([]model.Tag, error) {
+	var tags []model.Tag
+	seen := make(map[uint]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || utf8.RuneCountInString(name) > model.MaxTagLength {
+			continue
+		}
+		var found []model.Tag
+		if err := tx.Where("name = ?", name).Limit(1).Find(&found).Error; err != nil {
+			return nil, err
+		}
+		if len(found) == 0 {
+			// 其他请求可能同时新建了同名标签
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.Tag{Name: name}).Error; err != nil {
+				return nil, err
+			}
+			if err := tx.Where("name = ?", name).Limit(1).Find(&found).Error; err != nil {
+				return nil, err
+			}
+		}
+		if len(found) > 0 && !seen[found[0].ID] {
+			seen[found[0].ID] = true
+			tags = append(tags, found[0])
+		}
+	}
+	slices.SortFunc(tags, func(a, b model.Tag) int { return strings.Compare(a.Name, b.Name) })
+	return tags, nil
+}
+
+// replaceTags 用 tags 替换书籍现有的标签
+func replaceTags(tx *gorm.DB, bookID uint, tags []model.Tag) error {
+	if err := tx.Where("book_id = ?", bookID).Delete(&model.BookTag{}).Error; err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	rows := make([]model.BookTag, 0, len(tags))
+	for _, t := range tags {
+		rows = append(rows, model.BookTag{BookID: bookID, TagID: t.ID})
+	}
+	return tx.Create(&rows).Error
+}
+
+func tagNames(tags []model.Tag) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(tags))
+	for _, t := range tags {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+func categoryIDs(categories []model.Category) []uint {
+	ids := make([]uint, 0, len(categories))
+	for _, c := range categories {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// sameTags 不计顺序比较两组标签，数据库的排序规则可能与 Go 不同
+func sameTags(a, b []string) bool {
+	return slices.Equal(slices.Sorted(slices.Values(a)), slices.Sorted(slices.Values(b)))
+}
+
+// loadBookRelations 读取书籍的贡献者、分类和标签
+func loadBookRelations(db *gorm.DB, books ...*model.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.ID)
+	}
+
+	contributors, err := listContributors(db, ids)
+	if err != nil {
+		return err
+	}
+	var categories []struct {
+		BookID uint
+		model.Category
+	}
+	err = db.Table("book_categories").Select("book_categories.book_id, categories.*").
+		Joins("JOIN categories ON categories.id = book_categories.category_id").
+		Where("book_categories.book_id IN ?", ids).Order("categories.code").Scan(&categories).Error
+	if err != nil {
+		return err
+	}
+	var tags []struct {
+		BookID uint
+		Name   string
+	}
+	err = db.Table("book_tags").Select("book_tags.book_id, tags.name").
+		Joins("JOIN tags ON tags.id = book_tags.tag_id").
+		Where("book_tags.book_id IN ?", ids).Order("tags.name").Scan(&tags).Error
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[uint]*model.Book, len(books))
+	for _, book := range books {
+		book.Contributors = contributors[book.ID]
+		book.Categories, book.Tags = nil, nil
+		byID[book.ID] = book
+	}
+	for _, row := range categories {
+		byID[row.BookID].Categories = append(byID[row.BookID].Categories, row.Category)
+	}
+	for _, row := range tags {
+		byID[row.BookID].Tags = append(byID[row.BookID].Tags, row.Name)
+	}
+	return nil
+}
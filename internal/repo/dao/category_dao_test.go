@@ -0,0 +1,245 @@
+package dao
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCategoryTreeDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	t1, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "T", Name: "工业技术"})
+	require.NoError(t, err)
+	tp, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP", Name: "自动化技术、计算机技术", ParentID: t1.ID})
+	require.NoError(t, err)
+	tp3, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP3", Name: "计算技术、计算机技术", ParentID: tp.ID})
+	require.NoError(t, err)
+	i, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "I", Name: "文学"})
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", t1.ID, tp.ID, tp3.ID), tp3.Path)
+	assert.Equal(t, []uint{t1.ID, tp.ID, tp3.ID}, tp3.AncestorIDs())
+
+	_, err = dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP", Name: "重复"})
+	assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+	_, err = dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "X", Name: "x", ParentID: 9999})
+	assert.ErrorIs(t, err, ErrCategoryParentNotFound)
+
+	list, err := dao.CategoryListDAO()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I", "T", "TP", "TP3"}, categoryCodes(list))
+	children, err := dao.CategoryChildrenDAO(t1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"TP"}, categoryCodes(children))
+
+	// 书籍计入所属分类及其全部上级分类，同一本书只计一次，回收站中的不计
+	a, err := dao.BookAddDAO(&api.BookInfoReq{Title: "A", Count: 1, ISBN: "978-0000000501", CategoryIDs: []uint{tp3.ID, tp.ID}})
+	require.NoError(t, err)
+	_, err = dao.BookAddDAO(&api.BookInfoReq{Title: "B", Count: 1, ISBN: "978-0000000502", CategoryIDs: []uint{i.ID}})
+	require.NoError(t, err)
+	trashed, err := dao.BookAddDAO(&api.BookInfoReq{Title: "C", Count: 1, ISBN: "978-0000000503", CategoryIDs: []uint{tp3.ID}})
+	require.NoError(t, err)
+	dao.db.Delete(&model.Book{}, trashed.ID)
+
+	counts, err := dao.CategoryBookCountDAO(nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[uint]int64{t1.ID: 1, tp.ID: 1, tp3.ID: 1, i.ID: 1}, counts)
+	counts, err = dao.CategoryBookCountDAO(tp)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), counts[tp.ID])
+	assert.Zero(t, counts[i.ID])
+
+	// 不能移动到自身或子孙分类下
+	_, err = dao.CategoryUpdateDAO(tp.ID, &api.CategoryInfoReq{Code: "TP", Name: "x", ParentID: tp3.ID})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = dao.CategoryUpdateDAO(tp.ID, &api.CategoryInfoReq{Code: "TP", Name: "x", ParentID: tp.ID})
+	assert.ErrorIs(t, err, ErrCategoryCycle)
+	_, err = dao.CategoryUpdateDAO(9999, &api.CategoryInfoReq{Code: "Y", Name: "y"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 只改名称时不影响书籍
+	books, err := dao.CategoryUpdateDAO(tp.ID, &api.CategoryInfoReq{Code: "TP", Name: "计算机技术", ParentID: t1.ID})
+	require.NoError(t, err)
+	assert.Empty(t, books)
+
+	// 移动后子孙分类的路径随之改变，返回子树中的书籍（含分类）供同步到 ES
+	books, err = dao.CategoryUpdateDAO(tp.ID, &api.CategoryInfoReq{Code: "TP", Name: "计算机技术", ParentID: i.ID})
+	require.NoError(t, err)
+	require.Len(t, books, 1)
+	assert.Equal(t, a.ID, books[0].ID)
+	assert.Equal(t, []string{"TP", "TP3"}, categoryCodes(books[0].Categories))
+	moved, err := dao.CategoryGetDAO(tp3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("/%d/%d/%d/", i.ID, tp.ID, tp3.ID), moved.Path)
+	ids, _ := model.ESCategories(books[0].Categories)
+	assert.ElementsMatch(t, []uint{i.ID, tp.ID, tp3.ID}, ids)
+
+	// 按分类筛选包含子孙分类
+	filtered, err := dao.BookListDAO(&api.BookSearchReq{CategoryID: i.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), filtered.Total)
+	filtered, err = dao.BookListDAO(&api.BookSearchReq{CategoryID: t1.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), filtered.Total)
+
+	// 有子分类或关联书籍（包括回收站中的书籍）时不能删除
+	assert.ErrorIs(t, dao.CategoryDeleteDAO(tp.ID), ErrCategoryHasChildren)
+	assert.ErrorIs(t, dao.CategoryDeleteDAO(tp3.ID), ErrCategoryInUse)
+	assert.NoError(t, dao.CategoryDeleteDAO(t1.ID))
+	assert.ErrorIs(t, dao.CategoryDeleteDAO(t1.ID), gorm.ErrRecordNotFound)
+
+	// 彻底删除书籍时一并删除分类关系
+	_, err = dao.BookPurgeDAO([]uint{trashed.ID})
+	require.NoError(t, err)
+	var remaining int64
+	dao.db.Model(&model.BookCategory{}).Where("book_id = ?", trashed.ID).Count(&remaining)
+	assert.Zero(t, remaining)
+}
+
+func TestCategoryDepthDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	var parent uint
+	var chain []*model.Category
+	for n := 0; n < model.MaxCategoryDepth; n++ {
+		c, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: fmt.Sprintf("C%d", n), Name: "c", ParentID: parent})
+		require.NoError(t, err)
+		chain = append(chain, c)
+		parent = c.ID
+	}
+	_, err = dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "DEEP", Name: "c", ParentID: parent})
+	assert.ErrorIs(t, err, ErrCategoryTooDeep)
+
+	// 把有两层的子树移动到最深处也会超过上限
+	other, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "O", Name: "o"})
+	require.NoError(t, err)
+	_, err = dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "O1", Name: "o", ParentID: other.ID})
+	require.NoError(t, err)
+	_, err = dao.CategoryUpdateDAO(other.ID, &api.CategoryInfoReq{Code: "O", Name: "o", ParentID: chain[model.MaxCategoryDepth-2].ID})
+	assert.ErrorIs(t, err, ErrCategoryTooDeep)
+	_, err = dao.CategoryUpdateDAO(other.ID, &api.CategoryInfoReq{Code: "O", Name: "o", ParentID: chain[model.MaxCategoryDepth-3].ID})
+	assert.NoError(t, err)
+}
+
+func TestBookCategoriesAndTagsDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	tp, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP", Name: "计算机技术"})
+	require.NoError(t, err)
+	i, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "I", Name: "文学"})
+	require.NoError(t, err)
+
+	// 标签去掉首尾空白后去重，按名称排序
+	book, err := dao.BookAddDAO(&api.BookInfoReq{Title: "A", Count: 1, ISBN: "978-0000000601",
+		CategoryIDs: []uint{tp.ID, i.ID, tp.ID}, Tags: []string{" 入门 ", "Go", "入门", " "}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I", "TP"}, categoryCodes(book.Categories))
+	assert.Equal(t, []string{"Go", "入门"}, book.Tags)
+
+	loaded, err := dao.BookGetByIDDAO(book.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I", "TP"}, categoryCodes(loaded.Categories))
+	assert.Equal(t, []string{"Go", "入门"}, loaded.Tags)
+
+	_, err = dao.BookAddDAO(&api.BookInfoReq{Title: "B", Count: 1, ISBN: "978-0000000602", CategoryIDs: []uint{9999}})
+	assert.ErrorIs(t, err, ErrBookCategoryNotFound)
+	_, err = dao.BookGetByISBNDAO("978-0000000602")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 不给出分类和标签时保持原样
+	version := book.Version
+	updated, err := dao.BookUpdateDAO(&api.BookUpdateReq{ID: book.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{Title: "A2"}, Fields: []string{"title"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"I", "TP"}, categoryCodes(updated.Categories))
+	assert.Equal(t, []string{"Go", "入门"}, updated.Tags)
+
+	// 与原来相同的不记为修改
+	version = updated.Version
+	updated, err = dao.BookUpdateDAO(&api.BookUpdateReq{ID: book.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{Title: "A2", Count: 1, ISBN: "978-0000000601",
+			CategoryIDs: []uint{i.ID, tp.ID}, Tags: []string{"入门", "Go", "并发"}}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Go", "入门", "并发"}, updated.Tags)
+	revision, err := dao.BookRevisionGetDAO(book.ID, updated.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "tags", revision.Fields)
+
+	// 空数组表示清空
+	version = updated.Version
+	updated, err = dao.BookUpdateDAO(&api.BookUpdateReq{ID: book.ID, Version: &version,
+		BookInfoReq: api.BookInfoReq{CategoryIDs: []uint{}, Tags: []string{"Go"}}, Fields: []string{"category_ids", "tags"}})
+	require.NoError(t, err)
+	assert.Empty(t, updated.Categories)
+	assert.Equal(t, []string{"Go"}, updated.Tags)
+	revision, err = dao.BookRevisionGetDAO(book.ID, updated.Version)
+	require.NoError(t, err)
+	assert.Equal(t, "category_ids,tags", revision.Fields)
+
+	// 标签列表只包含有书籍（不含回收站）的标签，书籍多的在前
+	other, err := dao.BookAddDAO(&api.BookInfoReq{Title: "C", Count: 1, ISBN: "978-0000000603", Tags: []string{"Go", "Rust"}})
+	require.NoError(t, err)
+	trashed, err := dao.BookAddDAO(&api.BookInfoReq{Title: "D", Count: 1, ISBN: "978-0000000604", Tags: []string{"Java"}})
+	require.NoError(t, err)
+	dao.db.Delete(&model.Book{}, trashed.ID)
+
+	tags, err := dao.TagListDAO(&api.TagSearchReq{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), tags.Total)
+	assert.Equal(t, []api.TagCount{{Name: "Go", BookCount: 2}, {Name: "Rust", BookCount: 1}}, tags.Tags)
+	tags, err = dao.TagListDAO(&api.TagSearchReq{Name: "R"})
+	require.NoError(t, err)
+	assert.Equal(t, []api.TagCount{{Name: "Rust", BookCount: 1}}, tags.Tags)
+
+	// 按标签筛选
+	list, err := dao.BookListDAO(&api.BookSearchReq{Tag: "Go"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), list.Total)
+	list, err = dao.BookListDAO(&api.BookSearchReq{Tag: "Rust"})
+	require.NoError(t, err)
+	assert.Equal(t, other.ID, list.Books[0].ID)
+}
+
+func TestBookFeedByCategoryDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	require.NoError(t, err)
+
+	tp, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP", Name: "计算机技术"})
+	require.NoError(t, err)
+	tp3, err := dao.CategoryCreateDAO(&api.CategoryInfoReq{Code: "TP3", Name: "计算技术", ParentID: tp.ID})
+	require.NoError(t, err)
+
+	in, err := dao.BookAddDAO(&api.BookInfoReq{Title: "A", Count: 1, ISBN: "978-0000000701", CategoryIDs: []uint{tp3.ID}})
+	require.NoError(t, err)
+	out, err := dao.BookAddDAO(&api.BookInfoReq{Title: "B", Count: 1, ISBN: "978-0000000702"})
+	require.NoError(t, err)
+	dao.db.Model(&model.Book{}).Where("id = ?", out.ID).Update("updated_at", time.Now().Add(time.Hour))
+
+	items, total, err := dao.BookFeedDAO(&api.BookFeedReq{CategoryID: tp.ID, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, in.ID, items[0].ID)
+
+	all, err := dao.BookLastModifiedDAO(0, 0)
+	require.NoError(t, err)
+	modified, err := dao.BookLastModifiedDAO(0, tp.ID)
+	require.NoError(t, err)
+	assert.True(t, modified.Before(all))
+}
+
+func categoryCodes(categories []model.Category) []string {
+	codes := make([]string, 0, len(categories))
+	for _, c := range categories {
+		codes = append(codes, c.Code)
+	}
+	return codes
+}
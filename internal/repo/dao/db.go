@@ -24,7 +24,13 @@ func GetDB() *gorm.DB {
 
 type ApiDBDao interface {
 	bookDAO
+	bookRevisionDAO
 	userDAO
+	apiKeyDAO
+	identityDAO
+	auditDAO
+	authorDAO
+	categoryDAO
 }
 
 func SetupDBLink() error {
@@ -39,7 +45,10 @@ func SetupDBLink() error {
 
 	var err error
 	s := &dbService{}
-	s.db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	s.db, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
+		// 把驱动的唯一键冲突等错误转换为 gorm.ErrDuplicatedKey，便于服务层映射业务错误
+		TranslateError: true,
+	})
 
 	if err != nil {
 		return fmt.Errorf("failed to connect database: %w", err)
@@ -0,0 +1,97 @@
+package dao
+
+import (
+	"LibraryManagement/internal/model"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrIdentityLinked 外部身份已绑定到其他用户
+var ErrIdentityLinked = errors.New("外部身份已绑定其他账号")
+
+type identityDAO interface {
+	GetIdentityDAO(provider, subject string) (*model.UserIdentity, error)
+	ListIdentitiesDAO(userID uint) ([]model.UserIdentity, error)
+	LinkIdentityDAO(identity *model.UserIdentity) error
+	UnlinkIdentityDAO(id, userID uint) error
+	ListUsersByEmailDAO(email string, limit int) ([]model.User, error)
+	CreateSSOUserDAO(user *model.User, identity *model.UserIdentity) error
+}
+
+// GetIdentityDAO 根据提供方和 sub 查找绑定关系
+func (d *dbService) GetIdentityDAO(provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := d.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// ListIdentitiesDAO 查询用户绑定的全部外部身份
+func (d *dbService) ListIdentitiesDAO(userID uint) ([]model.UserIdentity, error) {
+	var identities []model.UserIdentity
+	err := d.db.Where("user_id = ?", userID).Order("id").Find(&identities).Error
+	return identities, err
+}
+
+// LinkIdentityDAO 绑定外部身份，同一身份只能绑定一个用户
+func (d *dbService) LinkIdentityDAO(identity *model.UserIdentity) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return linkIdentity(tx, identity)
+	})
+}
+
+// UnlinkIdentityDAO 解除绑定。物理删除，以便之后重新绑定
+func (d *dbService) UnlinkIdentityDAO(id, userID uint) error {
+	result := d.db.Unscoped().Where("id = ? AND user_id = ?", id, userID).Delete(&model.UserIdentity{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListUsersByEmailDAO 根据邮箱查找用户，最多返回 limit 个。邮箱不唯一，调用方据此判断是否有重复
+func (d *dbService) ListUsersByEmailDAO(email string, limit int) ([]model.User, error) {
+	var users []model.User
+	err := d.db.Where("email = ?", email).Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// CreateSSOUserDAO 单点登录首次登录时创建本地用户并绑定外部身份
+func (d *dbService) CreateSSOUserDAO(user *model.User, identity *model.UserIdentity) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		identity.UserID = user.ID
+		return linkIdentity(tx, identity)
+	})
+}
+
+func linkIdentity(tx *gorm.DB, identity *model.UserIdentity) error {
+	var existing model.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", identity.Provider, identity.Subject).First(&existing).Error
+	if err == nil {
+		if existing.UserID != identity.UserID {
+			return ErrIdentityLinked
+		}
+		// 用户显式绑定了原先自动绑定的身份，此后按显式绑定对待
+		if existing.AutoLinked && !identity.AutoLinked {
+			if err := tx.Model(&existing).Update("auto_linked", false).Error; err != nil {
+				return err
+			}
+			existing.AutoLinked = false
+		}
+		*identity = existing
+		return nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return tx.Create(identity).Error
+}
@@ -0,0 +1,92 @@
+package dao
+
+import (
+	"LibraryManagement/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCreateSSOUserDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	u := &model.User{Username: "alice", Role: "admin", Email: "alice@example.com"}
+	identity := &model.UserIdentity{Provider: "https://sso", Subject: "sub-1", Email: "alice@example.com"}
+	assert.NoError(t, dao.CreateSSOUserDAO(u, identity))
+	assert.NotZero(t, u.ID)
+	assert.Equal(t, u.ID, identity.UserID)
+
+	found, err := dao.GetIdentityDAO("https://sso", "sub-1")
+	assert.NoError(t, err)
+	assert.Equal(t, u.ID, found.UserID)
+
+	byEmail, err := dao.ListUsersByEmailDAO("alice@example.com", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(byEmail))
+	assert.Equal(t, u.ID, byEmail[0].ID)
+
+	// 同一身份不能再创建另一个用户，事务回滚后用户也不存在
+	err = dao.CreateSSOUserDAO(&model.User{Username: "alice2"}, &model.UserIdentity{Provider: "https://sso", Subject: "sub-1"})
+	assert.ErrorIs(t, err, ErrIdentityLinked)
+	_, err = dao.GetUserByUsernameDAO("alice2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestLinkAndUnlinkIdentityDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.LinkIdentityDAO(&model.UserIdentity{UserID: 1, Provider: "p", Subject: "s"}))
+	// 重复绑定到同一用户是幂等的
+	assert.NoError(t, dao.LinkIdentityDAO(&model.UserIdentity{UserID: 1, Provider: "p", Subject: "s"}))
+	// 绑定到其他用户失败
+	assert.ErrorIs(t, dao.LinkIdentityDAO(&model.UserIdentity{UserID: 2, Provider: "p", Subject: "s"}), ErrIdentityLinked)
+
+	identities, err := dao.ListIdentitiesDAO(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(identities))
+
+	// 只能解绑自己的身份
+	assert.ErrorIs(t, dao.UnlinkIdentityDAO(identities[0].ID, 2), gorm.ErrRecordNotFound)
+	assert.NoError(t, dao.UnlinkIdentityDAO(identities[0].ID, 1))
+
+	// 解绑后可以重新绑定到其他用户
+	assert.NoError(t, dao.LinkIdentityDAO(&model.UserIdentity{UserID: 2, Provider: "p", Subject: "s"}))
+}
+
+func TestListUsersByEmailDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.db.Create(&model.User{Username: "alice", Email: "shared@example.com", EmailVerified: true}).Error)
+	assert.NoError(t, dao.db.Create(&model.User{Username: "mallory", Email: "shared@example.com"}).Error)
+
+	// 邮箱不唯一，调用方需要看到全部匹配才能拒绝自动绑定
+	users, err := dao.ListUsersByEmailDAO("shared@example.com", 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(users))
+
+	users, err = dao.ListUsersByEmailDAO("nobody@example.com", 2)
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+}
+
+func TestLinkIdentityDAOExplicitClearsAutoLinked(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.LinkIdentityDAO(&model.UserIdentity{UserID: 1, Provider: "p", Subject: "s", AutoLinked: true}))
+	found, err := dao.GetIdentityDAO("p", "s")
+	assert.NoError(t, err)
+	assert.True(t, found.AutoLinked)
+
+	// 用户随后显式绑定同一身份
+	identity := &model.UserIdentity{UserID: 1, Provider: "p", Subject: "s"}
+	assert.NoError(t, dao.LinkIdentityDAO(identity))
+	assert.False(t, identity.AutoLinked)
+	found, err = dao.GetIdentityDAO("p", "s")
+	assert.NoError(t, err)
+	assert.False(t, found.AutoLinked)
+}
@@ -4,14 +4,52 @@ import (
 	"LibraryManagement/internal/api"
 	"LibraryManagement/internal/model"
 	"errors"
+	"fmt"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// maxPasswordHistory 每个用户最多保留的历史密码数量
+const maxPasswordHistory = 24
+
+var (
+	// ErrResetTokenUsed 重置令牌已被使用（并发请求时只有一个能成功）
+	ErrResetTokenUsed = errors.New("重置令牌已被使用")
+	// ErrRecoveryCodeInvalid 恢复码不存在或已被使用
+	ErrRecoveryCodeInvalid = errors.New("恢复码无效")
+	// ErrTOTPReplay 验证码所在时间步已被使用过
+	ErrTOTPReplay = errors.New("验证码已被使用")
 )
 
 type userDAO interface {
 	CreateUserDAO(req *api.RegisterReq) error
 	GetUserByUsernameDAO(username string) (*model.User, error)
 	GetUserByIdDAO(id uint) (*model.User, error)
+
+	// 密码管理
+	UpdatePasswordDAO(userID uint, password string) error
+	CreatePasswordResetDAO(token *model.PasswordResetToken) error
+	GetPasswordResetDAO(tokenHash string) (*model.PasswordResetToken, error)
+	ResetPasswordDAO(tokenID uint, password string) error
+	RecentPasswordHashesDAO(userID uint, limit int) ([]string, error)
+
+	// 两步验证
+	EnableTOTPDAO(userID uint, codeHashes []string) error
+	DisableTOTPDAO(userID uint) error
+	ReplaceRecoveryCodesDAO(userID uint, codeHashes []string) error
+	UseRecoveryCodeDAO(userID uint, codeHash string) error
+	UseTOTPStepDAO(userID uint, step int64) error
+	RecordMFAFailureDAO(userID uint, maxFailures int, lockedUntil time.Time) (bool, error)
+	ResetMFAFailuresDAO(userID uint) error
+
+	// 管理员用户管理
+	ListUsersDAO(req *api.UserListReq) (*api.UserListResp, error)
+	GetUserWithDeletedDAO(id uint) (*model.User, error)
+	UpdateUserDAO(id uint, updates map[string]interface{}) error
+	DeleteUserDAO(id uint) error
+	RestoreUserDAO(id uint) error
 }
 
 // CreateUserDAO 创建用户（自动哈希密码）
@@ -50,3 +88,379 @@ func (d *dbService) GetUserByIdDAO(id uint) (*model.User, error) {
 	}
 	return &user, nil
 }
+
+// UpdatePasswordDAO 更新用户密码（自动哈希），同时递增 token_version 使旧令牌失效
+func (d *dbService) UpdatePasswordDAO(userID uint, password string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return updatePassword(tx, userID, password)
+	})
+}
+
+// CreatePasswordResetDAO 保存密码重置令牌
+func (d *dbService) CreatePasswordResetDAO(token *model.PasswordResetToken) error {
+	return d.db.Create(token).Error
+}
+
+// GetPasswordResetDAO 根据令牌哈希查找重置记录
+func (d *dbService) GetPasswordResetDAO(tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := d.db.Where("token_hash = ?", tokenHash).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ResetPasswordDAO 在同一事务中消费重置令牌并更新密码，
+// 该用户其余未使用的令牌一并作废
+func (d *dbService) ResetPasswordDAO(tokenID uint, password string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		var token model.PasswordResetToken
+		if err := tx.First(&token, tokenID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if token.UsedAt != nil || result.RowsAffected == 0 {
+			return ErrResetTokenUsed
+		}
+
+		return updatePassword(tx, token.UserID, password)
+	})
+}
+
+// RecentPasswordHashesDAO 返回当前密码及最近使用过的密码哈希（从新到旧），共不超过 limit 个
+func (d *dbService) RecentPasswordHashesDAO(userID uint, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	var user model.User
+	if err := d.db.Select("password_hash").First(&user, userID).Error; err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, 0, limit)
+	if user.PasswordHash != "" {
+		hashes = append(hashes, user.PasswordHash)
+	}
+	if len(hashes) >= limit {
+		return hashes, nil
+	}
+
+	var history []string
+	err := d.db.Model(&model.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(limit-len(hashes)).
+		Pluck("password_hash", &history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return append(hashes, history...), nil
+}
+
+func updatePassword(tx *gorm.DB, userID uint, password string) error {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err := savePasswordHistory(tx, userID); err != nil {
+		return err
+	}
+
+	result := tx.Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"password_hash": string(hashed),
+			"token_version": gorm.Expr("token_version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// savePasswordHistory 把当前密码写入历史，只保留最近 maxPasswordHistory 条
+func savePasswordHistory(tx *gorm.DB, userID uint) error {
+	var user model.User
+	if err := tx.Select("id", "password_hash").First(&user, userID).Error; err != nil {
+		return err
+	}
+	// 单点登录创建的用户没有本地密码
+	if user.PasswordHash == "" {
+		return nil
+	}
+
+	if err := tx.Create(&model.PasswordHistory{UserID: userID, PasswordHash: user.PasswordHash}).Error; err != nil {
+		return err
+	}
+
+	var keep []uint
+	err := tx.Model(&model.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("id DESC").
+		Limit(maxPasswordHistory).
+		Pluck("id", &keep).Error
+	if err != nil {
+		return err
+	}
+	return tx.Unscoped().
+		Where("user_id = ? AND id NOT IN ?", userID, keep).
+		Delete(&model.PasswordHistory{}).Error
+}
+
+// EnableTOTPDAO 启用两步验证并写入新的恢复码
+func (d *dbService) EnableTOTPDAO(userID uint, codeHashes []string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Update("totp_enabled", true).Error
+		if err != nil {
+			return err
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// DisableTOTPDAO 关闭两步验证，清除密钥和恢复码
+func (d *dbService) DisableTOTPDAO(userID uint) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"totp_secret":    "",
+				"totp_enabled":   false,
+				"totp_last_step": 0,
+
+				"mfa_failed_attempts": 0,
+				"mfa_locked_until":    nil,
+			}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+	})
+}
+
+// ReplaceRecoveryCodesDAO 重新生成恢复码，旧恢复码全部作废
+func (d *dbService) ReplaceRecoveryCodesDAO(userID uint, codeHashes []string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// UseRecoveryCodeDAO 消费一个恢复码
+func (d *dbService) UseRecoveryCodeDAO(userID uint, codeHash string) error {
+	result := d.db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeInvalid
+	}
+	return nil
+}
+
+// UseTOTPStepDAO 记录已使用的时间步，只允许单调递增，防止同一验证码被重放
+func (d *dbService) UseTOTPStepDAO(userID uint, step int64) error {
+	result := d.db.Model(&model.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		Update("totp_last_step", step)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTOTPReplay
+	}
+	return nil
+}
+
+// RecordMFAFailureDAO 记录一次登录验证码错误。连续错误达到 maxFailures 次时锁定到 lockedUntil 并重新计数，
+// 返回本次是否触发了锁定。计数和判断都在数据库中完成，并发请求也不会多算或漏算
+func (d *dbService) RecordMFAFailureDAO(userID uint, maxFailures int, lockedUntil time.Time) (bool, error) {
+	locked := false
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Update("mfa_failed_attempts", gorm.Expr("mfa_failed_attempts + 1")).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND mfa_failed_attempts >= ?", userID, maxFailures).
+			Updates(map[string]interface{}{
+				"mfa_failed_attempts": 0,
+				"mfa_locked_until":    lockedUntil,
+			})
+		locked = result.RowsAffected > 0
+		return result.Error
+	})
+	return locked, err
+}
+
+// ResetMFAFailuresDAO 验证通过后清除错误计数和锁定
+func (d *dbService) ResetMFAFailuresDAO(userID uint) error {
+	return d.db.Model(&model.User{}).Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"mfa_failed_attempts": 0,
+			"mfa_locked_until":    nil,
+		}).Error
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID uint, codeHashes []string) error {
+	if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]model.RecoveryCode, 0, len(codeHashes))
+	for _, h := range codeHashes {
+		codes = append(codes, model.RecoveryCode{UserID: userID, CodeHash: h})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
+
+// ListUsersDAO 分页查询用户列表
+func (d *dbService) ListUsersDAO(req *api.UserListReq) (*api.UserListResp, error) {
+	dbSql := d.db.Model(&model.User{})
+	if req.Deleted {
+		dbSql = dbSql.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if req.Username != "" {
+		dbSql = dbSql.Where("username LIKE ?", "%"+req.Username+"%")
+	}
+	if req.Role != "" {
+		dbSql = dbSql.Where("role = ?", req.Role)
+	}
+	if req.Disabled != nil {
+		dbSql = dbSql.Where("disabled = ?", *req.Disabled)
+	}
+
+	var total int64
+	if err := dbSql.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := req.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (page - 1) * pageSize
+
+	var users []model.User
+	if err := dbSql.Order("id").Offset(offset).Limit(pageSize).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+
+	userResps := make([]api.UserInfoResp, 0, len(users))
+	for i := range users {
+		userResps = append(userResps, ToUserInfoResp(&users[i]))
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &api.UserListResp{
+		Users:      userResps,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// GetUserWithDeletedDAO 根据 ID 查找用户（包含已删除用户）
+func (d *dbService) GetUserWithDeletedDAO(id uint) (*model.User, error) {
+	var user model.User
+	err := d.db.Unscoped().First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUserDAO 更新用户的角色、禁用状态等字段
+func (d *dbService) UpdateUserDAO(id uint, updates map[string]interface{}) error {
+	result := d.db.Model(&model.User{}).Where("id = ?", id).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteUserDAO 软删除用户
+func (d *dbService) DeleteUserDAO(id uint) error {
+	result := d.db.Delete(&model.User{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RestoreUserDAO 恢复已软删除的用户
+func (d *dbService) RestoreUserDAO(id uint) error {
+	result := d.db.Unscoped().Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ToProfileResp 转换为当前用户的个人资料
+func ToProfileResp(user *model.User) *api.ProfileResp {
+	return &api.ProfileResp{
+		ID:                user.ID,
+		Username:          user.Username,
+		Role:              user.Role,
+		DisplayName:       user.DisplayName,
+		Email:             user.Email,
+		EmailVerified:     user.EmailVerified,
+		Phone:             user.Phone,
+		PreferredLanguage: user.PreferredLanguage,
+		CreatedAt:         user.CreatedAt,
+	}
+}
+
+// ToUserInfoResp 转换为管理员视角的用户信息
+func ToUserInfoResp(user *model.User) api.UserInfoResp {
+	resp := api.UserInfoResp{
+		ID:        user.ID,
+		Username:  user.Username,
+		Role:      user.Role,
+		Disabled:  user.Disabled,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+	if user.DeletedAt.Valid {
+		resp.DeletedAt = &user.DeletedAt.Time
+	}
+	return resp
+}
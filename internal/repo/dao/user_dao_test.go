@@ -4,8 +4,10 @@ import (
 	"LibraryManagement/internal/api"
 	"LibraryManagement/internal/model"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // TestCreateUserDAO 测试创建用户
@@ -122,3 +124,244 @@ func TestGetUserByIdDAO_UserNotFound(t *testing.T) {
 	assert.Nil(t, user)
 	assert.Equal(t, "用户不存在", err.Error())
 }
+
+// TestUpdatePasswordDAO 测试修改密码并递增令牌版本
+func TestUpdatePasswordDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	err = dao.CreateUserDAO(&api.RegisterReq{Username: "erin", Password: "oldpass", Role: "user"})
+	assert.NoError(t, err)
+	before, _ := dao.GetUserByUsernameDAO("erin")
+
+	err = dao.UpdatePasswordDAO(before.ID, "newpass")
+	assert.NoError(t, err)
+
+	after, _ := dao.GetUserByUsernameDAO("erin")
+	assert.Equal(t, before.TokenVersion+1, after.TokenVersion)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(after.PasswordHash), []byte("newpass")))
+
+	// 用户不存在
+	err = dao.UpdatePasswordDAO(999, "whatever")
+	assert.Error(t, err)
+}
+
+// TestRecentPasswordHashesDAO 测试修改密码时保存历史密码
+func TestRecentPasswordHashesDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	err = dao.CreateUserDAO(&api.RegisterReq{Username: "grace", Password: "pass-1", Role: "user"})
+	assert.NoError(t, err)
+	u, _ := dao.GetUserByUsernameDAO("grace")
+
+	assert.NoError(t, dao.UpdatePasswordDAO(u.ID, "pass-2"))
+	assert.NoError(t, dao.UpdatePasswordDAO(u.ID, "pass-3"))
+
+	// 当前密码在前，历史密码从新到旧
+	hashes, err := dao.RecentPasswordHashesDAO(u.ID, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(hashes))
+	for i, p := range []string{"pass-3", "pass-2", "pass-1"} {
+		assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hashes[i]), []byte(p)))
+	}
+
+	hashes, err = dao.RecentPasswordHashesDAO(u.ID, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(hashes))
+
+	hashes, err = dao.RecentPasswordHashesDAO(u.ID, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, hashes)
+}
+
+// TestResetPasswordDAO 测试重置令牌只能使用一次
+func TestResetPasswordDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	err = dao.CreateUserDAO(&api.RegisterReq{Username: "frank", Password: "oldpass", Role: "user"})
+	assert.NoError(t, err)
+	u, _ := dao.GetUserByUsernameDAO("frank")
+
+	token := &model.PasswordResetToken{UserID: u.ID, TokenHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+	other := &model.PasswordResetToken{UserID: u.ID, TokenHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, dao.CreatePasswordResetDAO(token))
+	assert.NoError(t, dao.CreatePasswordResetDAO(other))
+
+	found, err := dao.GetPasswordResetDAO("hash-1")
+	assert.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+
+	err = dao.ResetPasswordDAO(token.ID, "resetpass")
+	assert.NoError(t, err)
+
+	after, _ := dao.GetUserByUsernameDAO("frank")
+	assert.Equal(t, u.TokenVersion+1, after.TokenVersion)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(after.PasswordHash), []byte("resetpass")))
+
+	// 同一令牌再次使用失败
+	err = dao.ResetPasswordDAO(token.ID, "again")
+	assert.ErrorIs(t, err, ErrResetTokenUsed)
+
+	// 其余未使用的令牌也已作废
+	err = dao.ResetPasswordDAO(other.ID, "again")
+	assert.ErrorIs(t, err, ErrResetTokenUsed)
+}
+
+// TestListUsersDAO 测试用户列表过滤与分页
+func TestListUsersDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	for _, name := range []string{"alice", "alan", "bob"} {
+		assert.NoError(t, dao.CreateUserDAO(&api.RegisterReq{Username: name, Password: "pass", Role: "user"}))
+	}
+	bob, _ := dao.GetUserByUsernameDAO("bob")
+	assert.NoError(t, dao.UpdateUserDAO(bob.ID, map[string]interface{}{"role": "admin", "disabled": true}))
+
+	resp, err := dao.ListUsersDAO(&api.UserListReq{Username: "al"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), resp.Total)
+
+	disabled := true
+	resp, err = dao.ListUsersDAO(&api.UserListReq{Disabled: &disabled})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.Equal(t, "bob", resp.Users[0].Username)
+	assert.Equal(t, "admin", resp.Users[0].Role)
+
+	resp, err = dao.ListUsersDAO(&api.UserListReq{Page: 2, PageSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), resp.Total)
+	assert.Equal(t, 1, len(resp.Users))
+	assert.Equal(t, 2, resp.TotalPages)
+}
+
+// TestDeleteAndRestoreUserDAO 测试软删除与恢复
+func TestDeleteAndRestoreUserDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.CreateUserDAO(&api.RegisterReq{Username: "gina", Password: "pass", Role: "user"}))
+	u, _ := dao.GetUserByUsernameDAO("gina")
+
+	assert.NoError(t, dao.DeleteUserDAO(u.ID))
+	_, err = dao.GetUserByIdDAO(u.ID)
+	assert.Error(t, err)
+
+	deleted, err := dao.GetUserWithDeletedDAO(u.ID)
+	assert.NoError(t, err)
+	assert.True(t, deleted.DeletedAt.Valid)
+
+	resp, err := dao.ListUsersDAO(&api.UserListReq{Deleted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), resp.Total)
+	assert.NotNil(t, resp.Users[0].DeletedAt)
+
+	assert.NoError(t, dao.RestoreUserDAO(u.ID))
+	_, err = dao.GetUserByIdDAO(u.ID)
+	assert.NoError(t, err)
+
+	// 未删除的用户无法恢复
+	assert.Error(t, dao.RestoreUserDAO(u.ID))
+	assert.Error(t, dao.DeleteUserDAO(999))
+}
+
+// TestToProfileResp 测试个人资料转换
+func TestToProfileResp(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.CreateUserDAO(&api.RegisterReq{Username: "helen", Password: "pass", Role: "user"}))
+	u, _ := dao.GetUserByUsernameDAO("helen")
+	assert.NoError(t, dao.UpdateUserDAO(u.ID, map[string]interface{}{"email": "helen@example.com", "display_name": "Helen"}))
+
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	profile := ToProfileResp(u)
+	assert.Equal(t, "helen", profile.Username)
+	assert.Equal(t, "Helen", profile.DisplayName)
+	assert.Equal(t, "helen@example.com", profile.Email)
+	assert.Equal(t, "zh", profile.PreferredLanguage)
+	assert.False(t, profile.CreatedAt.IsZero())
+}
+
+// TestTOTPDAO 测试两步验证的启用、恢复码与防重放
+func TestTOTPDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.CreateUserDAO(&api.RegisterReq{Username: "ivan", Password: "pass", Role: "admin"}))
+	u, _ := dao.GetUserByUsernameDAO("ivan")
+	assert.NoError(t, dao.UpdateUserDAO(u.ID, map[string]interface{}{"totp_secret": "JBSWY3DPEHPK3PXP"}))
+
+	assert.NoError(t, dao.EnableTOTPDAO(u.ID, []string{"h1", "h2"}))
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.True(t, u.TOTPEnabled)
+
+	// 恢复码只能使用一次
+	assert.NoError(t, dao.UseRecoveryCodeDAO(u.ID, "h1"))
+	assert.ErrorIs(t, dao.UseRecoveryCodeDAO(u.ID, "h1"), ErrRecoveryCodeInvalid)
+	assert.ErrorIs(t, dao.UseRecoveryCodeDAO(u.ID, "unknown"), ErrRecoveryCodeInvalid)
+
+	// 重新生成后旧恢复码作废
+	assert.NoError(t, dao.ReplaceRecoveryCodesDAO(u.ID, []string{"h3"}))
+	assert.ErrorIs(t, dao.UseRecoveryCodeDAO(u.ID, "h2"), ErrRecoveryCodeInvalid)
+	assert.NoError(t, dao.UseRecoveryCodeDAO(u.ID, "h3"))
+
+	// 时间步只能递增
+	assert.NoError(t, dao.UseTOTPStepDAO(u.ID, 100))
+	assert.ErrorIs(t, dao.UseTOTPStepDAO(u.ID, 100), ErrTOTPReplay)
+	assert.ErrorIs(t, dao.UseTOTPStepDAO(u.ID, 99), ErrTOTPReplay)
+	assert.NoError(t, dao.UseTOTPStepDAO(u.ID, 101))
+
+	assert.NoError(t, dao.DisableTOTPDAO(u.ID))
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.False(t, u.TOTPEnabled)
+	assert.Empty(t, u.TOTPSecret)
+	assert.Equal(t, int64(0), u.TOTPLastStep)
+
+	var count int64
+	dao.db.Unscoped().Model(&model.RecoveryCode{}).Where("user_id = ?", u.ID).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+// TestMFAFailureDAO 测试验证码错误计数与锁定
+func TestMFAFailureDAO(t *testing.T) {
+	dao, err := setupTestDB()
+	assert.NoError(t, err)
+
+	assert.NoError(t, dao.CreateUserDAO(&api.RegisterReq{Username: "judy", Password: "pass", Role: "user"}))
+	u, _ := dao.GetUserByUsernameDAO("judy")
+	until := time.Now().Add(15 * time.Minute).Truncate(time.Millisecond)
+
+	for i := 0; i < 2; i++ {
+		locked, err := dao.RecordMFAFailureDAO(u.ID, 3, until)
+		assert.NoError(t, err)
+		assert.False(t, locked)
+	}
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.Equal(t, 2, u.MFAFailedAttempts)
+	assert.Nil(t, u.MFALockedUntil)
+
+	// 第 3 次错误触发锁定，计数清零
+	locked, err := dao.RecordMFAFailureDAO(u.ID, 3, until)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.Equal(t, 0, u.MFAFailedAttempts)
+	if assert.NotNil(t, u.MFALockedUntil) {
+		assert.True(t, until.Equal(*u.MFALockedUntil))
+	}
+
+	assert.NoError(t, dao.ResetMFAFailuresDAO(u.ID))
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.Nil(t, u.MFALockedUntil)
+
+	// 关闭两步验证同样清除计数
+	_, err = dao.RecordMFAFailureDAO(u.ID, 3, until)
+	assert.NoError(t, err)
+	assert.NoError(t, dao.DisableTOTPDAO(u.ID))
+	u, _ = dao.GetUserByIdDAO(u.ID)
+	assert.Equal(t, 0, u.MFAFailedAttempts)
+}
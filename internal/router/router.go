@@ -3,54 +3,197 @@ package router
 import (
 	"LibraryManagement/internal/handler"
 	"LibraryManagement/internal/middleware"
+	"LibraryManagement/internal/model"
 
 	"github.com/gin-gonic/gin"
 )
 
+// Handlers 路由依赖的全部处理器
+type Handlers struct {
+	Book     *handler.BookHandler
+	User     *handler.UserHandler
+	MFA      *handler.MFAHandler
+	APIKey   *handler.APIKeyHandler
+	OIDC     *handler.OIDCHandler
+	Audit    *handler.AuditHandler
+	OAI      *handler.OAIHandler
+	SRU      *handler.SRUHandler
+	OPDS     *handler.OPDSHandler
+	Feed     *handler.FeedHandler
+	Metadata *handler.MetadataHandler
+	Author   *handler.AuthorHandler
+	Category *handler.CategoryHandler
+}
+
 // InitRouter 初始化路由
-func InitRouter(bookHandler *handler.BookHandler, userHandler *handler.UserHandler) *gin.Engine {
+func InitRouter(h *Handlers) *gin.Engine {
 	router := gin.Default()
 
-	register(router, bookHandler, userHandler)
+	register(router, h)
 
 	return router
 }
 
-func register(router *gin.Engine, bookHandler *handler.BookHandler, userHandler *handler.UserHandler) {
+func register(router *gin.Engine, h *Handlers) {
 
 	// 公共路由（无需认证）
 	auth := router.Group("/auth")
 	{
-		auth.POST("/register", userHandler.Register)
-		auth.POST("/login", userHandler.Login)
+		auth.POST("/register", middleware.Audit("user.register", "user"), h.User.Register)
+		auth.POST("/login", middleware.Audit("auth.login", "user"), h.User.Login)
+		auth.POST("/login/mfa", middleware.Audit("auth.login_mfa", "user"), h.MFA.VerifyLogin) // 两步验证
+
+		// 忘记密码
+		auth.POST("/password/forgot", middleware.Audit("auth.password_forgot", "user"), h.User.ForgotPassword)
+		auth.POST("/password/reset", middleware.Audit("auth.password_reset", "user"), h.User.ResetPassword)
+
+		// 单点登录（OIDC）
+		auth.GET("/oidc/login", h.OIDC.Login)
+		auth.GET("/oidc/callback", middleware.Audit("auth.oidc_login", "user"), h.OIDC.Callback)
+	}
+
+	// OAI-PMH 收割接口，供合作馆收割书目（需在配置中启用）
+	router.GET("/oai", h.OAI.Handle)
+	router.POST("/oai", h.OAI.Handle)
+	// SRU 2.0 检索接口，供图书馆客户端用 CQL 检索书目（需在配置中启用）
+	router.GET("/sru", h.SRU.Handle)
+	router.POST("/sru", h.SRU.Handle)
+
+	// 新书和更新订阅 feed，可按作者筛选（需在配置中启用）
+	feeds := router.Group("/feeds")
+	{
+		feeds.GET("/new.atom", h.Feed.NewAtom)
+		feeds.GET("/new.rss", h.Feed.NewRSS)
+		feeds.GET("/updated.atom", h.Feed.UpdatedAtom)
+		feeds.GET("/updated.rss", h.Feed.UpdatedRSS)
+	}
+
+	// OPDS 目录，供电子书阅读器浏览和下载书籍；阅读器可用 Basic 认证以 API Key 作为密码
+	opds := router.Group("/opds", middleware.BasicChallenge("LibraryManagement"), middleware.AuthMiddleware(""), middleware.RequireScope(model.ScopeBooksRead))
+	{
+		opds.GET("", h.OPDS.Root)
+		opds.GET("/new", h.OPDS.NewArrivals)
+		opds.GET("/authors", h.OPDS.Authors)
+		opds.GET("/authors/books", h.OPDS.AuthorBooks)
+		opds.GET("/search", h.OPDS.Search)
+		opds.GET("/opensearch.xml", h.OPDS.OpenSearch)
+		opds.GET("/books/:id/content", h.OPDS.Content)
 	}
 
 	// 受保护路由
 	api := router.Group("/api")
 	api.Use(middleware.AuthMiddleware("")) // 所有登录用户可访问
+
+	// 个人账号相关接口只允许本人登录后访问，不接受 API Key
+	me := api.Group("/me", middleware.SessionOnly())
 	{
-		api.POST("/books/list", bookHandler.BookList)
+		me.GET("", h.User.GetProfile)                                                       // 当前用户资料
+		me.PATCH("", middleware.Audit("user.profile_update", "user"), h.User.UpdateProfile) // 修改个人资料
+		me.POST("/password", middleware.Audit("user.password_change", "user"), h.User.ChangePassword)
 
-		api.GET("/books/:id", bookHandler.GetBook) // 获取单本书籍详情
+		// 两步验证
+		me.POST("/mfa/setup", h.MFA.Setup)
+		me.POST("/mfa/enable", middleware.Audit("mfa.enable", "user"), h.MFA.Enable)
+		me.POST("/mfa/disable", middleware.Audit("mfa.disable", "user"), h.MFA.Disable)
+		me.POST("/mfa/recovery-codes", middleware.Audit("mfa.recovery_codes", "user"), h.MFA.RegenerateRecoveryCodes)
+
+		// API Key
+		me.POST("/api-keys", middleware.Audit("api_key.create", "api_key"), h.APIKey.Create)
+		me.GET("/api-keys", h.APIKey.List)
+		me.DELETE("/api-keys/:id", middleware.Audit("api_key.revoke", "api_key"), h.APIKey.Revoke)
+
+		// 单点登录身份绑定
+		me.GET("/oidc/link", h.OIDC.LinkURL)
+		me.GET("/oidc/identities", h.OIDC.ListIdentities)
+		me.DELETE("/oidc/identities/:id", middleware.Audit("identity.unlink", "identity"), h.OIDC.Unlink)
+	}
+
+	books := api.Group("", middleware.RequireScope(model.ScopeBooksRead))
+	{
+		books.POST("/books/list", h.Book.BookList)
+
+		books.GET("/books/:id", h.Book.GetBook) // 获取单本书籍详情
 
 		// ES搜索功能
-		api.POST("/books/search", bookHandler.SearchBooks)            // 综合搜索
-		api.GET("/books/search/title", bookHandler.SearchByTitle)     // 标题搜索
-		api.GET("/books/search/content", bookHandler.SearchByContent) // 内容搜索
+		books.POST("/books/search", h.Book.SearchBooks)            // 综合搜索
+		books.GET("/books/search/title", h.Book.SearchByTitle)     // 标题搜索
+		books.GET("/books/search/content", h.Book.SearchByContent) // 内容搜索
+
+		// 作者
+		books.GET("/authors", h.Author.List)
+		books.GET("/authors/:id", h.Author.Get)
+		books.GET("/authors/:id/books", h.Author.Books) // 作者参与的书籍
+
+		// 分类和标签
+		books.GET("/categories", h.Category.Tree)
+		books.GET("/categories/:id", h.Category.Get)
+		books.GET("/categories/:id/books", h.Category.Books) // 包含子孙分类中的书籍
+		books.GET("/tags", h.Category.Tags)
 	}
 
 	// 管理员专用路由
 	admin := router.Group("/admin")
-	admin.Use(middleware.AuthMiddleware("admin")) // 仅管理员
+	admin.Use(middleware.AuditDenied("admin.access_denied"), middleware.AuthMiddleware("admin")) // 仅管理员，拒绝的访问记入审计日志
+
+	// 书籍导出：API Key 需要 books:read
+	admin.GET("/books/export", middleware.RequireScope(model.ScopeBooksRead), middleware.Audit("book.export", "book"), h.Book.ExportBooks)
+
+	// 书籍维护：API Key 需要 books:write
+	adminBooks := admin.Group("", middleware.RequireScope(model.ScopeBooksWrite))
 	{
-		admin.POST("/books/add", bookHandler.AddBook)
-		admin.PUT("/books/update", bookHandler.UpdateBook)
-		admin.DELETE("/books/delete", bookHandler.DeleteBook)
+		adminBooks.POST("/books/add", middleware.Audit("book.create", "book"), h.Book.AddBook)
+		adminBooks.POST("/books/import", middleware.Audit("book.import", "book"), h.Book.ImportBooks) // 批量导入
+		adminBooks.GET("/books/metadata", h.Metadata.Lookup)                                          // 按 ISBN 查询外部书目信息
+		adminBooks.PUT("/books/update", middleware.Audit("book.update", "book"), h.Book.UpdateBook)
+		adminBooks.PATCH("/books/:id", middleware.Audit("book.update", "book"), h.Book.PatchBook) // 局部更新
+
+		// 回收站
+		adminBooks.GET("/books/trash", h.Book.ListTrash)
+		adminBooks.POST("/books/:id/restore", middleware.Audit("book.restore", "book"), h.Book.RestoreBook)
+		adminBooks.DELETE("/books/trash", middleware.Audit("book.purge", "book"), h.Book.PurgeBooks)
+
+		// 修订记录
+		adminBooks.GET("/books/:id/revisions", h.Book.ListRevisions)
+		adminBooks.GET("/books/:id/revisions/diff", h.Book.CompareRevisions)
+		adminBooks.GET("/books/:id/revisions/:version", h.Book.GetRevision)
+		adminBooks.POST("/books/:id/revisions/:version/restore", middleware.Audit("book.rollback", "book"), h.Book.RollbackRevision)
+		adminBooks.DELETE("/books/delete", middleware.Audit("book.delete", "book"), h.Book.DeleteBook)
 
+		// 作者维护
+		adminBooks.POST("/authors", middleware.Audit("author.create", "author"), h.Author.Create)
+		adminBooks.PUT("/authors/:id", middleware.Audit("author.update", "author"), h.Author.Update)
+		adminBooks.DELETE("/authors/:id", middleware.Audit("author.delete", "author"), h.Author.Delete)
+
+		// 分类维护
+		adminBooks.POST("/categories", middleware.Audit("category.create", "category"), h.Category.Create)
+		adminBooks.PUT("/categories/:id", middleware.Audit("category.update", "category"), h.Category.Update)
+		adminBooks.DELETE("/categories/:id", middleware.Audit("category.delete", "category"), h.Category.Delete)
+	}
+
+	// 其余管理接口：API Key 需要 admin
+	adminAll := admin.Group("", middleware.RequireScope(model.ScopeAdmin))
+	{
 		// ES索引管理
-		admin.POST("/es/index/init", bookHandler.InitESIndex)     // 初始化ES索引
-		admin.POST("/es/index/reindex", bookHandler.ReindexBooks) // 重新索引所有数据
+		adminAll.POST("/es/index/init", middleware.Audit("es.index_init", "es_index"), h.Book.InitESIndex)  // 初始化ES索引
+		adminAll.POST("/es/index/reindex", middleware.Audit("es.reindex", "es_index"), h.Book.ReindexBooks) // 重新索引所有数据
+
+		// 把书籍的作者字符串迁移为作者和贡献者
+		adminAll.POST("/authors/migrate", middleware.Audit("author.migrate", "author"), h.Author.Migrate)
+
+		// 用户管理
+		adminAll.GET("/users", h.User.ListUsers)
+		adminAll.GET("/users/:id", h.User.GetUser)
+		adminAll.PATCH("/users/:id", middleware.Audit("user.update", "user"), h.User.UpdateUser)
+		adminAll.DELETE("/users/:id", middleware.Audit("user.delete", "user"), h.User.DeleteUser)
+		adminAll.POST("/users/:id/restore", middleware.Audit("user.restore", "user"), h.User.RestoreUser)
+
+		// API Key 管理（服务账号）
+		adminAll.GET("/api-keys", h.APIKey.ListAll)
+		adminAll.POST("/api-keys", middleware.Audit("api_key.create", "api_key"), h.APIKey.CreateService)
+		adminAll.DELETE("/api-keys/:id", middleware.Audit("api_key.revoke", "api_key"), h.APIKey.RevokeAny)
 
+		// 审计日志
+		adminAll.GET("/audit", h.Audit.List)
 	}
 
 }
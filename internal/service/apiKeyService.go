@@ -0,0 +1,217 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// APIKeyPrefix 明文格式：lm_<前缀>_<密钥>
+	APIKeyPrefix         = "lm_"
+	defaultAPIKeyExpires = 90 // 天
+)
+
+var (
+	ErrAPIKeyNotFound   = apperr.NotFound("api_key_not_found", "API Key不存在")
+	ErrScopeNotAllowed  = apperr.Forbidden("scope_not_allowed", "权限范围超出用户角色")
+	ErrScopeMFARequired = apperr.Forbidden("mfa_required", "管理员需先启用两步验证才能申请该权限")
+	ErrInvalidAPIKey    = apperr.Unauthorized("invalid_api_key", "无效或过期的API Key")
+	ErrAPIKeyOwnerState = apperr.Unauthorized("api_key_owner_inactive", "API Key所属账号已禁用或删除")
+)
+
+type APIKeyService interface {
+	// 当前用户的个人 Key
+	Create(userID uint, dto *api.APIKeyCreateReq) (*api.APIKeyCreateResp, error)
+	List(userID uint) ([]api.APIKeyResp, error)
+	Revoke(userID, id uint) error
+
+	// 管理员：服务账号 Key 及全部 Key 管理
+	CreateService(dto *api.ServiceAPIKeyCreateReq) (*api.APIKeyCreateResp, error)
+	ListAll(userID uint) ([]api.APIKeyResp, error)
+	RevokeAny(id uint) error
+}
+
+type apiKeyServiceImpl struct{}
+
+func NewAPIKeyService() APIKeyService {
+	return &apiKeyServiceImpl{}
+}
+
+func (a *apiKeyServiceImpl) Create(userID uint, dto *api.APIKeyCreateReq) (*api.APIKeyCreateResp, error) {
+	return createAPIKey(userID, model.APIKeyPersonal, dto)
+}
+
+func (a *apiKeyServiceImpl) List(userID uint) ([]api.APIKeyResp, error) {
+	keys, err := dao.ApiDao.ListAPIKeysDAO(userID)
+	if err != nil {
+		return nil, err
+	}
+	return toAPIKeyResps(keys), nil
+}
+
+func (a *apiKeyServiceImpl) Revoke(userID, id uint) error {
+	err := dao.ApiDao.RevokeAPIKeyDAO(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+func (a *apiKeyServiceImpl) CreateService(dto *api.ServiceAPIKeyCreateReq) (*api.APIKeyCreateResp, error) {
+	return createAPIKey(dto.UserID, model.APIKeyService, &dto.APIKeyCreateReq)
+}
+
+func (a *apiKeyServiceImpl) ListAll(userID uint) ([]api.APIKeyResp, error) {
+	return a.List(userID)
+}
+
+func (a *apiKeyServiceImpl) RevokeAny(id uint) error {
+	return a.Revoke(0, id)
+}
+
+// AuthenticateAPIKey 校验 API Key 明文，返回 Key 及其所属用户
+func AuthenticateAPIKey(raw string) (*model.APIKey, *model.User, error) {
+	prefix, ok := parseAPIKeyPrefix(raw)
+	if !ok {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	key, err := dao.ApiDao.GetAPIKeyByPrefixDAO(prefix)
+	if err != nil {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if !utils.EqualHash(key.KeyHash, utils.HashToken(raw)) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+	if key.ExpiresAt != nil && time.Now().After(*key.ExpiresAt) {
+		return nil, nil, ErrInvalidAPIKey
+	}
+
+	user, err := dao.ApiDao.GetUserByIdDAO(key.UserID)
+	if err != nil || user.Disabled {
+		return nil, nil, ErrAPIKeyOwnerState
+	}
+
+	// 最近使用时间按分钟粒度更新，避免每个请求都写库
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		_ = dao.ApiDao.TouchAPIKeyDAO(key.ID, now)
+	}
+
+	return key, user, nil
+}
+
+// ---------- 工具函数 ----------
+
+func createAPIKey(userID uint, kind string, dto *api.APIKeyCreateReq) (*api.APIKeyCreateResp, error) {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	// 普通用户只能访问 /api，只允许只读权限
+	scopes := uniqueScopes(dto.Scopes)
+	if user.Role != "admin" {
+		for _, s := range scopes {
+			if s != model.ScopeBooksRead {
+				return nil, ErrScopeNotAllowed
+			}
+		}
+	}
+	// 强制两步验证时，未启用的管理员不能通过 Key 获得管理接口的访问能力
+	if user.Role == "admin" && config.Config.Auth.MFA.EnforceAdmin && !user.TOTPEnabled {
+		for _, s := range scopes {
+			if s != model.ScopeBooksRead {
+				return nil, ErrScopeMFARequired
+			}
+		}
+	}
+
+	days := dto.ExpiresInDays
+	if days <= 0 {
+		days = defaultAPIKeyExpires
+	}
+	expiresAt := time.Now().AddDate(0, 0, days)
+
+	prefix, err := utils.RandomToken(4)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := utils.RandomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	raw := APIKeyPrefix + prefix + "_" + secret
+
+	key := &model.APIKey{
+		UserID:    user.ID,
+		Name:      dto.Name,
+		Kind:      kind,
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(raw),
+		Scopes:    strings.Join(scopes, ","),
+		ExpiresAt: &expiresAt,
+	}
+	if err := dao.ApiDao.CreateAPIKeyDAO(key); err != nil {
+		return nil, err
+	}
+
+	return &api.APIKeyCreateResp{
+		APIKeyResp: toAPIKeyResp(key),
+		Key:        raw,
+	}, nil
+}
+
+func parseAPIKeyPrefix(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, APIKeyPrefix) {
+		return "", false
+	}
+	parts := strings.SplitN(strings.TrimPrefix(raw, APIKeyPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	result := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		if !seen[s] {
+			seen[s] = true
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func toAPIKeyResp(key *model.APIKey) api.APIKeyResp {
+	return api.APIKeyResp{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Kind:       key.Kind,
+		Prefix:     APIKeyPrefix + key.Prefix,
+		Scopes:     key.ScopeList(),
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func toAPIKeyResps(keys []model.APIKey) []api.APIKeyResp {
+	resps := make([]api.APIKeyResp, 0, len(keys))
+	for i := range keys {
+		resps = append(resps, toAPIKeyResp(&keys[i]))
+	}
+	return resps
+}
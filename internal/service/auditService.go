@@ -0,0 +1,70 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"fmt"
+	"log"
+	"time"
+)
+
+type AuditService interface {
+	List(dto *api.AuditListReq) (*api.AuditListResp, error)
+	// PurgeExpired 清理超过保留期的日志，返回删除条数
+	PurgeExpired() (int64, error)
+}
+
+type auditServiceImpl struct{}
+
+func NewAuditService() AuditService {
+	return &auditServiceImpl{}
+}
+
+func (a *auditServiceImpl) List(dto *api.AuditListReq) (*api.AuditListResp, error) {
+	return dao.ApiDao.ListAuditLogsDAO(dto)
+}
+
+func (a *auditServiceImpl) PurgeExpired() (int64, error) {
+	before := time.Now().Add(-config.Config.Audit.Retention)
+	n, err := dao.ApiDao.PurgeAuditLogsDAO(before)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		RecordAudit(&model.AuditLog{
+			ActorType: model.ActorSystem,
+			Action:    "audit.purge",
+			Outcome:   model.AuditSuccess,
+			Detail:    fmt.Sprintf("清理 %d 条 %s 之前的日志", n, before.Format(time.RFC3339)),
+		})
+	}
+	return n, nil
+}
+
+// RecordAudit 写入审计日志。写入失败只记录到应用日志，不影响业务请求
+func RecordAudit(entry *model.AuditLog) {
+	if entry.ActorType == "" {
+		entry.ActorType = model.ActorAnonymous
+		if entry.ActorID != 0 {
+			entry.ActorType = model.ActorUser
+		}
+	}
+	entry.TargetID = truncate(entry.TargetID, 64)
+	entry.UserAgent = truncate(entry.UserAgent, 255)
+	entry.Detail = truncate(entry.Detail, 255)
+
+	if err := dao.ApiDao.CreateAuditLogDAO(entry); err != nil {
+		log.Printf("写入审计日志失败: action=%s actor=%d err=%v", entry.Action, entry.ActorID, err)
+	}
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
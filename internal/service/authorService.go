@@ -0,0 +1,168 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"errors"
+	"log"
+)
+
+var (
+	ErrAuthorNotFound            = apperr.NotFound("author_not_found", "作者不存在")
+	ErrAuthorInUse               = apperr.Conflict("author_in_use", "作者仍关联书籍（包括回收站中的书籍），不能删除")
+	ErrContributorAuthorNotFound = apperr.Validation("contributor_author_not_found", "贡献者引用的作者不存在")
+)
+
+// migrateBatchSize 迁移作者字符串时每个事务处理的书籍数量
+const migrateBatchSize = 500
+
+// AuthorService 作者及书籍贡献者
+type AuthorService interface {
+	Create(dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error)
+	Get(id uint) (*api.AuthorInfoResp, error)
+	List(dto *api.AuthorSearchReq) (*api.AuthorSearchResp, error)
+	// Update 修改作者，改名后重新生成其担任著者的书籍的 author 并同步到 ES
+	Update(id uint, dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error)
+	Delete(id uint) error
+	// ListBooks 作者参与的书籍（任一角色），包含每本书的贡献者
+	ListBooks(id uint, dto *api.AuthorBooksReq) (*api.BookSearchResp, error)
+
+	// MigrateBookAuthors 为还没有贡献者的书籍拆分作者字符串，建立作者和贡献者并同步到 ES，可重复执行
+	MigrateBookAuthors() (*api.AuthorMigrateResp, error)
+}
+
+type authorServiceImpl struct {
+	esService BookESService
+}
+
+func NewAuthorService() AuthorService {
+	return &authorServiceImpl{esService: NewBookESService()}
+}
+
+// authorError 作者相关的数据库错误映射
+func authorError(err error) error {
+	switch {
+	case errors.Is(err, dao.ErrAuthorInUse):
+		return ErrAuthorInUse.Wrap(err)
+	case errors.Is(err, dao.ErrVersionConflict):
+		return ErrBookVersionConflict.Wrap(err)
+	}
+	return dbError(err, ErrAuthorNotFound)
+}
+
+func (a *authorServiceImpl) Create(dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error) {
+	author, err := dao.ApiDao.AuthorCreateDAO(dto)
+	if err != nil {
+		return nil, authorError(err)
+	}
+	return &api.AuthorInfoResp{
+		ID:             author.ID,
+		Name:           author.Name,
+		Disambiguation: author.Disambiguation,
+		Bio:            author.Bio,
+		CreatedAt:      author.CreatedAt,
+		UpdatedAt:      author.UpdatedAt,
+	}, nil
+}
+
+func (a *authorServiceImpl) Get(id uint) (*api.AuthorInfoResp, error) {
+	author, err := dao.ApiDao.AuthorGetDAO(id)
+	if err != nil {
+		return nil, authorError(err)
+	}
+	return author, nil
+}
+
+func (a *authorServiceImpl) List(dto *api.AuthorSearchReq) (*api.AuthorSearchResp, error) {
+	authors, err := dao.ApiDao.AuthorListDAO(dto)
+	if err != nil {
+		return nil, authorError(err)
+	}
+	return authors, nil
+}
+
+func (a *authorServiceImpl) Update(id uint, dto *api.AuthorInfoReq) (*api.AuthorInfoResp, error) {
+	books, err := dao.ApiDao.AuthorUpdateDAO(id, dto)
+	if err != nil {
+		return nil, authorError(err)
+	}
+
+	// 贡献者姓名随作者改变，回收站中的书籍不在 ES 中
+	index := make([]*model.Book, 0, len(books))
+	for i := range books {
+		if !books[i].DeletedAt.Valid {
+			index = append(index, &books[i])
+		}
+	}
+	if failed, esErr := a.esService.BulkIndexBooks(index); esErr != nil || len(failed) > 0 {
+		log.Printf("作者改名后同步书籍到ES失败 (作者ID: %d, 书籍: %v): %v", id, failed, esErr)
+	}
+
+	return a.Get(id)
+}
+
+func (a *authorServiceImpl) Delete(id uint) error {
+	return authorError(dao.ApiDao.AuthorDeleteDAO(id))
+}
+
+func (a *authorServiceImpl) ListBooks(id uint, dto *api.AuthorBooksReq) (*api.BookSearchResp, error) {
+	if _, err := a.Get(id); err != nil {
+		return nil, err
+	}
+
+	books, err := dao.ApiDao.BookListDAO(&api.BookSearchReq{AuthorID: id, Page: dto.Page, PageSize: dto.PageSize})
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+
+	ids := make([]uint, 0, len(books.Books))
+	for _, book := range books.Books {
+		ids = append(ids, book.ID)
+	}
+	contributors, err := dao.ApiDao.BookContributorListDAO(ids)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	for i := range books.Books {
+		books.Books[i].Contributors = toContributorResps(contributors[books.Books[i].ID])
+	}
+	return books, nil
+}
+
+func (a *authorServiceImpl) MigrateBookAuthors() (*api.AuthorMigrateResp, error) {
+	resp := &api.AuthorMigrateResp{}
+	var after uint
+	for {
+		books, created, err := dao.ApiDao.BookContributorMigrateDAO(after, migrateBatchSize)
+		if err != nil {
+			return nil, dbError(err, nil)
+		}
+		resp.AuthorsCreated += created
+
+		index := make([]*model.Book, 0, len(books))
+		for i := range books {
+			if len(books[i].Contributors) == 0 {
+				continue
+			}
+			resp.Books++
+			if !books[i].DeletedAt.Valid {
+				index = append(index, &books[i])
+			}
+		}
+		failed, esErr := a.esService.BulkIndexBooks(index)
+		if esErr != nil {
+			log.Printf("迁移作者后同步书籍到ES失败: %v", esErr)
+		}
+		resp.IndexFailed = append(resp.IndexFailed, failed...)
+
+		if len(books) < migrateBatchSize {
+			break
+		}
+		after = books[len(books)-1].ID
+	}
+
+	log.Printf("作者迁移完成: %d 本书籍, 新建 %d 位作者", resp.Books, resp.AuthorsCreated)
+	return resp, nil
+}
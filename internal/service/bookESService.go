@@ -22,6 +22,9 @@ const (
 	RequestTimeout    = 5 * time.Second
 )
 
+// keywordFields 全文搜索的字段及权重
+var keywordFields = []string{"title^3", "author^2", "content", "summary^1.5"}
+
 type BookESService interface {
 	// 索引管理
 	CreateIndex() error
@@ -30,11 +33,16 @@ type BookESService interface {
 	// 文档操作
 	IndexBook(book *model.Book) error
 	UpdateBook(book *model.Book) error
+	PartialUpdateBook(book *model.Book, fields []string) error
 	DeleteBook(id uint) error
+	// BulkIndexBooks 批量索引书籍，返回索引失败的书籍 ID
+	BulkIndexBooks(books []*model.Book) ([]uint, error)
 	GetBook(id uint) (*model.ESBookDocument, error)
 
 	// 搜索功能
 	SearchBooks(req *api.BookSearchReq) (*api.BookSearchResp, error)
+	// SearchQuery 执行调用方构建的查询（如 SRU 由 CQL 转换的查询）
+	SearchQuery(query map[string]interface{}, from, size int) ([]api.BookInfoResp, int64, error)
 	SearchByTitle(title string, exact bool) ([]model.ESBookDocument, error)
 	SearchByContent(content string) ([]model.ESBookDocument, error)
 }
@@ -78,7 +86,25 @@ func (s *bookESServiceImpl) CreateIndex() error {
 					"type": "text",
 					"analyzer": "ik_max_word",
 					"search_analyzer": "ik_smart"
-				}
+				},
+				"contributors": {
+					"type": "nested",
+					"properties": {
+						"author_id": {"type": "long"},
+						"name": {
+							"type": "text",
+							"analyzer": "ik_max_word",
+							"search_analyzer": "ik_smart",
+							"fields": {
+								"keyword": {"type": "keyword"}
+							}
+						},
+						"role": {"type": "keyword"}
+					}
+				},
+				"category_ids": {"type": "long"},
+				"category_codes": {"type": "keyword"},
+				"tags": {"type": "keyword"}
 			}
 		},
 		"settings": {
@@ -145,15 +171,7 @@ func (s *bookESServiceImpl) IndexBook(book *model.Book) error {
 	}
 
 	// 2. 构建文档
-	doc := model.ESBookDocument{
-		ID:      book.ID,
-		Title:   book.Title,
-		Count:   book.Count,
-		Author:  book.Author,
-		ISBN:    book.ISBN,
-		Content: book.Content,
-		Summary: book.Summary,
-	}
+	doc := esDocument(book)
 
 	// 3. 序列化文档
 	data, err := json.Marshal(doc)
@@ -186,11 +204,159 @@ func (s *bookESServiceImpl) IndexBook(book *model.Book) error {
 	return nil
 }
 
+// esDocument 书籍在 ES 中的文档
+func esDocument(book *model.Book) model.ESBookDocument {
+	categoryIDs, categoryCodes := model.ESCategories(book.Categories)
+	return model.ESBookDocument{
+		ID:      book.ID,
+		Title:   book.Title,
+		Count:   book.Count,
+		Author:  book.Author,
+		ISBN:    book.ISBN,
+		Content: book.Content,
+		Summary: book.Summary,
+
+		Contributors: book.Contributors,
+
+		CategoryIDs:   categoryIDs,
+		CategoryCodes: categoryCodes,
+		Tags:          book.Tags,
+	}
+}
+
+func (s *bookESServiceImpl) BulkIndexBooks(books []*model.Book) ([]uint, error) {
+	if es.Client == nil || len(books) == 0 {
+		return nil, nil
+	}
+
+	all := make([]uint, 0, len(books))
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, book := range books {
+		all = append(all, book.ID)
+		action := map[string]interface{}{
+			"index": map[string]interface{}{
+				"_index": BooksIndex,
+				"_id":    strconv.FormatUint(uint64(book.ID), 10),
+			},
+		}
+		// bulk 请求体为 NDJSON：每本书一行操作、一行文档
+		if err := enc.Encode(action); err != nil {
+			return all, fmt.Errorf("序列化文档失败: %w", err)
+		}
+		if err := enc.Encode(esDocument(book)); err != nil {
+			return all, fmt.Errorf("序列化文档失败: %w", err)
+		}
+	}
+
+	req := esapi.BulkRequest{
+		Body:    &body,
+		Refresh: "true",
+	}
+	res, err := req.Do(context.Background(), es.Client)
+	if err != nil {
+		return all, fmt.Errorf("批量索引失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return all, fmt.Errorf("批量索引失败: %s", res.Status())
+	}
+
+	// 请求成功时逐条检查结果
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return all, fmt.Errorf("解析批量索引结果失败: %w", err)
+	}
+	if !result.Errors {
+		log.Printf("成功批量索引书籍: %d 本", len(books))
+		return nil, nil
+	}
+
+	var failed []uint
+	for _, item := range result.Items {
+		for _, r := range item {
+			if r.Status >= 300 {
+				if id, err := strconv.ParseUint(r.ID, 10, 64); err == nil {
+					failed = append(failed, uint(id))
+				}
+			}
+		}
+	}
+	return failed, nil
+}
+
 func (s *bookESServiceImpl) UpdateBook(book *model.Book) error {
 	// 直接重新索引
 	return s.IndexBook(book)
 }
 
+// PartialUpdateBook 只把 fields 中列出的字段写入 ES 文档，文档尚未索引时退回完整索引
+func (s *bookESServiceImpl) PartialUpdateBook(book *model.Book, fields []string) error {
+	if es.Client == nil {
+		return nil
+	}
+
+	categoryIDs, categoryCodes := model.ESCategories(book.Categories)
+	source := map[string]interface{}{
+		"title":   book.Title,
+		"count":   book.Count,
+		"author":  book.Author,
+		"isbn":    book.ISBN,
+		"content": book.Content,
+		"summary": book.Summary,
+
+		"contributors": book.Contributors,
+
+		"category_ids":   categoryIDs,
+		"category_codes": categoryCodes,
+		"tags":           book.Tags,
+	}
+	doc := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := source[field]; ok {
+			doc[field] = value
+		}
+	}
+	if len(doc) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(map[string]interface{}{"doc": doc})
+	if err != nil {
+		return fmt.Errorf("序列化文档失败: %w", err)
+	}
+
+	req := esapi.UpdateRequest{
+		Index:      BooksIndex,
+		DocumentID: strconv.FormatUint(uint64(book.ID), 10),
+		Body:       bytes.NewReader(data),
+		Refresh:    "true",
+	}
+
+	res, err := req.Do(context.Background(), es.Client)
+	if err != nil {
+		return fmt.Errorf("更新文档失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 404 {
+		return s.IndexBook(book)
+	}
+	if res.IsError() {
+		return fmt.Errorf("更新文档失败: %s", res.Status())
+	}
+
+	log.Printf("成功局部更新书籍: %s (ID: %d, 字段: %v)", book.Title, book.ID, fields)
+	return nil
+}
+
 func (s *bookESServiceImpl) DeleteBook(id uint) error {
 	if es.Client == nil {
 		return nil
@@ -217,7 +383,7 @@ func (s *bookESServiceImpl) DeleteBook(id uint) error {
 
 func (s *bookESServiceImpl) GetBook(id uint) (*model.ESBookDocument, error) {
 	if es.Client == nil {
-		return nil, fmt.Errorf("ES客户端未初始化")
+		return nil, ErrSearchUnavailable
 	}
 
 	req := esapi.GetRequest{
@@ -252,7 +418,7 @@ func (s *bookESServiceImpl) GetBook(id uint) (*model.ESBookDocument, error) {
 // SearchBooks 综合搜索书籍
 func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearchResp, error) {
 	if es.Client == nil {
-		return nil, fmt.Errorf("ES客户端未初始化")
+		return nil, ErrSearchUnavailable
 	}
 
 	// 设置默认分页参数
@@ -277,7 +443,7 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 					{
 						"multi_match": map[string]interface{}{
 							"query":  req.Keyword,
-							"fields": []string{"title^3", "author^2", "content", "summary^1.5"},
+							"fields": keywordFields,
 							"type":   "best_fields",
 						},
 					},
@@ -297,14 +463,27 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 			})
 		}
 
+		// 作者字符串或任一贡献者的姓名匹配即可
 		if req.Author != "" {
 			must = append(must, map[string]interface{}{
-				"match": map[string]interface{}{
-					"author": req.Author,
+				"bool": map[string]interface{}{
+					"should": []map[string]interface{}{
+						{"match": map[string]interface{}{"author": req.Author}},
+						contributorQuery(map[string]interface{}{
+							"match": map[string]interface{}{"contributors.name": req.Author},
+						}),
+					},
+					"minimum_should_match": 1,
 				},
 			})
 		}
 
+		if req.AuthorID != 0 {
+			must = append(must, contributorQuery(map[string]interface{}{
+				"term": map[string]interface{}{"contributors.author_id": req.AuthorID},
+			}))
+		}
+
 		if req.ISBN != "" {
 			must = append(must, map[string]interface{}{
 				"term": map[string]interface{}{
@@ -332,11 +511,65 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 		}
 	}
 
+	// 分类和标签只用于筛选，关键词搜索时同样生效
+	var filter []map[string]interface{}
+	if req.CategoryID != 0 {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"category_ids": req.CategoryID},
+		})
+	}
+	if req.Tag != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"tags": req.Tag},
+		})
+	}
+	if len(filter) > 0 {
+		query = map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   query,
+				"filter": filter,
+			},
+		}
+	}
+
+	books, total, err := s.SearchQuery(query, from, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+
+	return &api.BookSearchResp{
+		Books:      books,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: totalPages,
+	}, nil
+}
+
+// contributorQuery 在 nested 的 contributors 字段上执行查询
+func contributorQuery(query map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"nested": map[string]interface{}{
+			"path":  "contributors",
+			"query": query,
+		},
+	}
+}
+
+// SearchQuery 执行已构建的 ES 查询，按相关度排序，返回命中的书籍和总数
+func (s *bookESServiceImpl) SearchQuery(query map[string]interface{}, from, size int) ([]api.BookInfoResp, int64, error) {
+	if es.Client == nil {
+		return nil, 0, ErrSearchUnavailable
+	}
+
 	// 构建搜索请求
 	searchBody := map[string]interface{}{
-		"query": query,
-		"from":  from,
-		"size":  pageSize,
+		"query":            query,
+		"from":             from,
+		"size":             size,
+		"track_total_hits": true,
 		"sort": []map[string]interface{}{
 			{"_score": map[string]string{"order": "desc"}},
 			{"id": map[string]string{"order": "desc"}},
@@ -345,7 +578,7 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(searchBody); err != nil {
-		return nil, fmt.Errorf("编码搜索请求失败: %w", err)
+		return nil, 0, fmt.Errorf("编码搜索请求失败: %w", err)
 	}
 
 	// 执行搜索（带超时）
@@ -358,12 +591,12 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 		es.Client.Search.WithBody(&buf),
 	)
 	if err != nil {
-		return nil, fmt.Errorf("搜索失败: %w", err)
+		return nil, 0, fmt.Errorf("搜索失败: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, fmt.Errorf("搜索失败: %s", res.Status())
+		return nil, 0, fmt.Errorf("搜索失败: %s", res.Status())
 	}
 
 	// 解析响应
@@ -380,13 +613,16 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 					Author  string  `json:"author"`
 					ISBN    string  `json:"isbn"`
 					Summary string  `json:"summary"`
+
+					Contributors []model.Contributor `json:"contributors"`
+					Tags         []string            `json:"tags"`
 				} `json:"_source"`
 			} `json:"hits"`
 		} `json:"hits"`
 	}
 
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("es响应解码失败: %w", err)
+		return nil, 0, fmt.Errorf("es响应解码失败: %w", err)
 	}
 
 	// 转换为响应结构
@@ -399,18 +635,12 @@ func (s *bookESServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearch
 			Author:  hit.Source.Author,
 			ISBN:    hit.Source.ISBN,
 			Summary: hit.Source.Summary,
+
+			Contributors: toContributorResps(hit.Source.Contributors),
+			Tags:         hit.Source.Tags,
 		})
 	}
-
-	totalPages := int((result.Hits.Total.Value + int64(pageSize) - 1) / int64(pageSize))
-
-	return &api.BookSearchResp{
-		Books:      books,
-		Total:      result.Hits.Total.Value,
-		Page:       page,
-		PageSize:   pageSize,
-		TotalPages: totalPages,
-	}, nil
+	return books, result.Hits.Total.Value, nil
 }
 
 // SearchByTitle 根据标题搜索（支持精确和模糊）
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportFields 可导出的字段，默认按此顺序全部导出
+var ExportFields = []string{"id", "title", "count", "isbn", "author", "content", "summary", "version", "created_at", "updated_at"}
+
+// Export 按条件把书籍逐行编码写入 w。
+// 查询失败时返回错误且不向 w 写入任何内容，便于调用方改为返回错误响应
+func (b *bookServiceImpl) Export(dto *api.BookExportReq, w io.Writer) error {
+	if dto.Format == api.FormatMARC || dto.Format == api.FormatMARCXML {
+		return exportMARC(dto, w)
+	}
+
+	fields := dto.Columns
+	if len(fields) == 0 {
+		fields = ExportFields
+	}
+	for _, field := range fields {
+		if !isExportField(field) {
+			return ErrExportFields.WithMessage(fmt.Sprintf("导出字段 %s 不存在", field))
+		}
+	}
+
+	var enc bookEncoder
+	if dto.Format == api.ExportJSONL {
+		enc = newJSONLEncoder(w, fields)
+	} else {
+		enc = newCSVEncoder(w, fields)
+	}
+
+	filter := &api.BookSearchReq{Title: dto.Title, ISBN: dto.ISBN, Author: dto.Author, Content: dto.Content}
+	if err := dao.ApiDao.BookExportDAO(filter, enc.Encode); err != nil {
+		return bookDBError(err)
+	}
+	return enc.Close()
+}
+
+func isExportField(name string) bool {
+	for _, field := range ExportFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// exportValue 书籍指定字段的值，时间为 RFC 3339 格式
+func exportValue(book *model.Book, field string) interface{} {
+	switch field {
+	case "id":
+		return book.ID
+	case "version":
+		return book.Version
+	case "created_at":
+		return book.CreatedAt.Format(time.RFC3339)
+	case "updated_at":
+		return book.UpdatedAt.Format(time.RFC3339)
+	}
+	return book.Snapshot().Field(field)
+}
+
+// bookEncoder 把书籍逐条编码为导出格式。输出经过缓冲，Close 之前可能尚未写入底层 Writer
+type bookEncoder interface {
+	Encode(book *model.Book) error
+	Close() error
+}
+
+// csvEncoder 第一行为字段名
+type csvEncoder struct {
+	w      *csv.Writer
+	fields []string
+	record []string
+}
+
+func newCSVEncoder(w io.Writer, fields []string) *csvEncoder {
+	enc := &csvEncoder{w: csv.NewWriter(w), fields: fields, record: make([]string, len(fields))}
+	// 表头先写入缓冲区，查询失败时不会输出
+	_ = enc.w.Write(fields)
+	return enc
+}
+
+func (e *csvEncoder) Encode(book *model.Book) error {
+	for i, field := range e.fields {
+		switch v := exportValue(book, field).(type) {
+		case string:
+			e.record[i] = v
+		case uint:
+			e.record[i] = strconv.FormatUint(uint64(v), 10)
+		case int:
+			e.record[i] = strconv.Itoa(v)
+		default:
+			e.record[i] = fmt.Sprint(v)
+		}
+	}
+	return e.w.Write(e.record)
+}
+
+func (e *csvEncoder) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// jsonlEncoder 每行一个 JSON 对象，字段顺序与请求一致
+type jsonlEncoder struct {
+	w      *bufio.Writer
+	fields []string
+	keys   [][]byte
+}
+
+func newJSONLEncoder(w io.Writer, fields []string) *jsonlEncoder {
+	keys := make([][]byte, len(fields))
+	for i, field := range fields {
+		keys[i], _ = json.Marshal(field)
+	}
+	return &jsonlEncoder{w: bufio.NewWriter(w), fields: fields, keys: keys}
+}
+
+func (e *jsonlEncoder) Encode(book *model.Book) error {
+	e.w.WriteByte('{')
+	for i, field := range e.fields {
+		if i > 0 {
+			e.w.WriteByte(',')
+		}
+		value, err := json.Marshal(exportValue(book, field))
+		if err != nil {
+			return err
+		}
+		e.w.Write(e.keys[i])
+		e.w.WriteByte(':')
+		e.w.Write(value)
+	}
+	_, err := e.w.WriteString("}\n")
+	return err
+}
+
+func (e *jsonlEncoder) Close() error {
+	return e.w.Flush()
+}
@@ -0,0 +1,429 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/i18n"
+	"LibraryManagement/internal/marc"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/tabular"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// requiredColumns 导入文件必须包含的列
+var requiredColumns = []string{"title", "count", "isbn"}
+
+// Import 流式导入表格或 MARC 文件：逐行解析和校验，每 books.import_batch_size 行查询一次已有 ISBN 并批量写入。
+// 文件级错误（格式、表头、列映射）直接返回错误；行级错误记录在结果中，不影响其他行。
+// 导入过程中数据库不可用时中止，同时返回已处理部分的结果和错误
+func (b *bookServiceImpl) Import(file tabular.File, size int64, dto *api.BookImportReq) (*api.BookImportResp, error) {
+	var src importSource
+	if dto.Format == api.FormatMARC || dto.Format == api.FormatMARCXML {
+		src = newMARCSource(dto.Format, file)
+	} else {
+		var err error
+		if src, err = newTabularSource(file, size, dto); err != nil {
+			return nil, err
+		}
+	}
+
+	mode := dto.Mode
+	if mode == "" {
+		mode = api.ImportSkip
+	}
+	imp := &bookImporter{
+		es:   b.esService,
+		dto:  dto,
+		mode: mode,
+		seen: make(map[string]int),
+		resp: &api.BookImportResp{DryRun: dto.DryRun, Mode: mode, Errors: []api.BookImportRowError{}},
+	}
+
+	maxRows := config.Config.Books.ImportMaxRows
+	batchSize := config.Config.Books.ImportBatchSize
+	for {
+		row, err := src.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			imp.stop(src.Line(), err)
+			break
+		}
+		if imp.resp.Total >= maxRows {
+			imp.stop(src.Line(), ErrImportTooManyRows.WithMessage(fmt.Sprintf("文件超过 %d 行，其余行未导入", maxRows)))
+			break
+		}
+
+		imp.resp.Total++
+		imp.add(row)
+		if len(imp.batch) >= batchSize {
+			if err := imp.flush(); err != nil {
+				imp.resp.Incomplete = true
+				return imp.resp, err
+			}
+		}
+	}
+
+	if err := imp.flush(); err != nil {
+		imp.resp.Incomplete = true
+		return imp.resp, err
+	}
+	return imp.resp, nil
+}
+
+// importSource 逐行读取导入文件并解析为书籍，读完返回 io.EOF；文件无法继续读取时返回错误
+type importSource interface {
+	Next() (*importRow, error)
+	// Line 最近读取的行号，用于报告错误
+	Line() int
+}
+
+// tabularSource 按表头和列映射读取 CSV/TSV/XLSX，跳过空行
+type tabularSource struct {
+	rows    tabular.Reader
+	columns map[string]int // 字段 → 列号
+	fields  []string       // 文件中包含的字段
+}
+
+func newTabularSource(file tabular.File, size int64, dto *api.BookImportReq) (*tabularSource, error) {
+	rows, err := tabular.NewReader(dto.Format, file, size)
+	if errors.Is(err, tabular.ErrUnsupportedFormat) {
+		return nil, ErrImportFormat.Wrap(err)
+	}
+	if err != nil {
+		return nil, ErrImportFile.Wrap(err)
+	}
+
+	header, err := rows.Read()
+	if err == io.EOF {
+		return nil, ErrImportEmpty
+	}
+	if err != nil {
+		return nil, ErrImportFile.Wrap(err)
+	}
+	columns, err := importColumns(header, dto.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &tabularSource{rows: rows, columns: columns}
+	for _, field := range model.BookFields {
+		if _, ok := columns[field]; ok {
+			src.fields = append(src.fields, field)
+		}
+	}
+	return src, nil
+}
+
+func (s *tabularSource) Next() (*importRow, error) {
+	for {
+		cells, err := s.rows.Read()
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, ErrImportFile.Wrap(err)
+		}
+		if blankRow(cells) {
+			continue
+		}
+
+		book, err := s.parse(cells)
+		return &importRow{line: s.rows.Line(), book: book, fields: s.fields, err: err}, nil
+	}
+}
+
+func (s *tabularSource) Line() int {
+	return s.rows.Line()
+}
+
+// importColumns 根据表头和列映射确定每个字段所在的列
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	for field, name := range mapping {
+		if !isBookField(field) || strings.TrimSpace(name) == "" {
+			return nil, ErrImportMapping.WithMessage(fmt.Sprintf("列映射中的字段 %s 无效", field))
+		}
+	}
+
+	columns := make(map[string]int, len(model.BookFields))
+	for _, field := range model.BookFields {
+		name := field
+		if mapped, ok := mapping[field]; ok {
+			name = strings.TrimSpace(mapped)
+		}
+		for i, cell := range header {
+			if strings.EqualFold(strings.TrimSpace(cell), name) {
+				columns[field] = i
+				break
+			}
+		}
+	}
+
+	var missing []string
+	for _, field := range requiredColumns {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, ErrImportColumns.WithMessage(fmt.Sprintf("导入文件缺少必要的列：%s", strings.Join(missing, ", ")))
+	}
+	return columns, nil
+}
+
+func isBookField(name string) bool {
+	for _, field := range model.BookFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// bookImporter 一次导入的状态
+type bookImporter struct {
+	es    BookESService
+	dto   *api.BookImportReq
+	mode  string
+	seen  map[string]int // 文件中已出现的 ISBN → 行号
+	batch []importRow    // 通过校验、等待写入的行
+	resp  *api.BookImportResp
+}
+
+// importRow 解析后的一行
+type importRow struct {
+	line   int
+	book   *api.BookInfoReq
+	fields []string     // 文件中包含的字段，upsert 时只更新这些字段
+	err    error        // 解析错误
+	record *marc.Record // MARC 导入时的原始记录，写入后保存
+}
+
+// rowError 记录失败的行
+func (imp *bookImporter) rowError(line int, isbn string, err error) {
+	imp.resp.Failed++
+	imp.resp.Errors = append(imp.resp.Errors, api.BookImportRowError{Row: line, ISBN: isbn, Err: err})
+}
+
+// stop 文件无法继续读取或超过行数上限，之后的行不再处理
+func (imp *bookImporter) stop(line int, err error) {
+	imp.resp.Incomplete = true
+	imp.resp.Errors = append(imp.resp.Errors, api.BookImportRowError{Row: line, Err: err})
+}
+
+// add 校验一行，通过后加入当前批次
+func (imp *bookImporter) add(row *importRow) {
+	line, book := row.line, row.book
+	if row.err != nil {
+		imp.rowError(line, book.ISBN, row.err)
+		return
+	}
+	if err := i18n.Validate.Struct(book); err != nil {
+		imp.rowError(line, book.ISBN, err)
+		return
+	}
+	if first, ok := imp.seen[book.ISBN]; ok {
+		imp.rowError(line, book.ISBN, ErrImportDuplicateRow.WithMessage(fmt.Sprintf("ISBN与第 %d 行重复", first)))
+		return
+	}
+	imp.seen[book.ISBN] = line
+
+	book.Operator = imp.dto.Operator
+	imp.batch = append(imp.batch, *row)
+}
+
+func (s *tabularSource) parse(cells []string) (*api.BookInfoReq, error) {
+	book := &api.BookInfoReq{}
+	var err error
+	for field, col := range s.columns {
+		value := ""
+		if col < len(cells) {
+			value = strings.TrimSpace(cells[col])
+		}
+		switch field {
+		case "title":
+			book.Title = value
+		case "isbn":
+			book.ISBN = value
+		case "author":
+			book.Author = value
+		case "content":
+			book.Content = value
+		case "summary":
+			book.Summary = value
+		case "count":
+			if value == "" {
+				continue // 交由校验规则报告必填
+			}
+			count, parseErr := strconv.ParseUint(value, 10, 32)
+			if parseErr != nil {
+				err = ErrImportCount.Wrap(parseErr)
+				continue
+			}
+			book.Count = uint(count)
+		}
+	}
+	return book, err
+}
+
+// changedFields 文件中包含的字段与现有书籍不同的字段；文件中没有的字段保持不变
+func changedFields(current *model.Book, row importRow) []string {
+	book := row.book
+	before := current.Snapshot()
+	after := model.BookSnapshot{
+		Title:   book.Title,
+		Count:   book.Count,
+		ISBN:    book.ISBN,
+		Author:  book.Author,
+		Content: book.Content,
+		Summary: book.Summary,
+	}
+
+	var fields []string
+	for _, field := range row.fields {
+		if before.Field(field) != after.Field(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// flush 处理当前批次：按 ISBN 区分新增、更新和跳过，写入数据库后批量同步到 ES
+func (imp *bookImporter) flush() error {
+	batch := imp.batch
+	imp.batch = nil
+	if len(batch) == 0 {
+		return nil
+	}
+
+	isbns := make([]string, 0, len(batch))
+	for _, row := range batch {
+		isbns = append(isbns, row.book.ISBN)
+	}
+	existing, err := dao.ApiDao.BookListByISBNsWithDeletedDAO(isbns)
+	if err != nil {
+		return bookDBError(err)
+	}
+	byISBN := make(map[string]*model.Book, len(existing))
+	for i := range existing {
+		byISBN[existing[i].ISBN] = &existing[i]
+	}
+
+	var creates []importRow
+	var updates []importRow
+	var updateReqs []*api.BookUpdateReq
+	for _, row := range batch {
+		current, ok := byISBN[row.book.ISBN]
+		switch {
+		case !ok:
+			creates = append(creates, row)
+		case current.DeletedAt.Valid:
+			imp.rowError(row.line, row.book.ISBN, ErrISBNInTrash)
+		case imp.mode == api.ImportSkip:
+			imp.resp.Skipped++
+		default:
+			fields := changedFields(current, row)
+			if len(fields) == 0 {
+				imp.resp.Unchanged++
+				continue
+			}
+			updates = append(updates, row)
+			updateReqs = append(updateReqs, &api.BookUpdateReq{ID: current.ID, BookInfoReq: *row.book, Fields: fields})
+		}
+	}
+
+	if imp.dto.DryRun {
+		imp.resp.Created += len(creates)
+		imp.resp.Updated += len(updates)
+		return nil
+	}
+
+	written, err := imp.create(creates)
+	if err == nil {
+		var updated []*model.Book
+		updated, err = imp.update(updates, updateReqs)
+		written = append(written, updated...)
+	}
+
+	// 已写入的部分即使中途出错也要保存原始记录并同步到 ES
+	if len(written) > 0 {
+		saveMARCRecords(batch, written)
+		failed, esErr := imp.es.BulkIndexBooks(written)
+		if esErr != nil {
+			log.Printf("批量导入同步到ES失败: %v", esErr)
+		}
+		imp.resp.IndexSyncFailed += len(failed)
+	}
+	return err
+}
+
+// create 批量新增；整批失败（如其他请求同时写入了相同 ISBN）时逐行写入，定位出错的行
+func (imp *bookImporter) create(rows []importRow) ([]*model.Book, error) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	reqs := make([]*api.BookInfoReq, 0, len(rows))
+	for _, row := range rows {
+		reqs = append(reqs, row.book)
+	}
+	books, err := dao.ApiDao.BookBatchAddDAO(reqs)
+	if err == nil {
+		imp.resp.Created += len(books)
+		return books, nil
+	}
+	if mapped := bookDBError(err); errors.Is(mapped, ErrDBUnavailable) {
+		return nil, mapped
+	}
+
+	written := make([]*model.Book, 0, len(rows))
+	for _, row := range rows {
+		book, err := dao.ApiDao.BookAddDAO(row.book)
+		if err != nil {
+			mapped := isbnError(row.book.ISBN, err)
+			if errors.Is(mapped, ErrDBUnavailable) {
+				return written, mapped
+			}
+			imp.rowError(row.line, row.book.ISBN, mapped)
+			continue
+		}
+		imp.resp.Created++
+		written = append(written, book)
+	}
+	return written, nil
+}
+
+// update 逐行更新已有书籍，只写入文件中与现有内容不同的字段
+func (imp *bookImporter) update(rows []importRow, reqs []*api.BookUpdateReq) ([]*model.Book, error) {
+	written := make([]*model.Book, 0, len(rows))
+	for i, row := range rows {
+		book, err := dao.ApiDao.BookUpdateDAO(reqs[i])
+		if err != nil {
+			mapped := isbnError(row.book.ISBN, err)
+			if errors.Is(mapped, ErrDBUnavailable) {
+				return written, mapped
+			}
+			imp.rowError(row.line, row.book.ISBN, mapped)
+			continue
+		}
+		imp.resp.Updated++
+		written = append(written, book)
+	}
+	return written, nil
+}
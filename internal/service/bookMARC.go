@@ -0,0 +1,225 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/marc"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/tabular"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MARC 字段与书籍字段的映射：
+//
+//	020 $a  isbn（取第一个空格前的部分，去掉装帧等限定说明）
+//	100 $a  author
+//	245 $a $b  title（$a : $b，去掉 ISBD 标识符）
+//	520 $a  summary
+//	949 $c  count（本地字段，缺省为 1）
+//
+// 正文不做映射：505 是内容附注，单个字段最长 9999 字节，放不下书籍正文。
+// 导入时保存原始记录，导出时只改写与书籍当前内容不一致的映射字段，其余字段原样输出
+const (
+	marcCountTag  = "949"
+	marcCountCode = "c"
+	marc005Layout = "20060102150405.0"
+)
+
+// isbdPunctuation 字段末尾的 ISBD 标识符
+const isbdPunctuation = " /:;=,."
+
+// ImportFormatOf 根据文件扩展名判断导入格式，无法识别时返回空字符串
+func ImportFormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".mrc", ".marc":
+		return api.FormatMARC
+	case ".xml":
+		return api.FormatMARCXML
+	}
+	return tabular.FormatOf(filename)
+}
+
+// bookFromMARC 从 MARC 记录读取书籍，同时返回记录中存在的字段
+func bookFromMARC(rec *marc.Record) (*api.BookInfoReq, []string, error) {
+	book := &api.BookInfoReq{Count: 1}
+	var fields []string
+
+	if isbn := strings.Fields(rec.SubfieldValue("020", "a")); len(isbn) > 0 {
+		book.ISBN = isbn[0]
+		fields = append(fields, "isbn")
+	}
+	if author := trimISBD(rec.SubfieldValue("100", "a")); author != "" {
+		book.Author = author
+		fields = append(fields, "author")
+	}
+	if f := rec.Field("245"); f != nil {
+		title := trimISBD(f.Subfield("a"))
+		if sub := trimISBD(f.Subfield("b")); sub != "" {
+			title += " : " + sub
+		}
+		if title != "" {
+			book.Title = title
+			fields = append(fields, "title")
+		}
+	}
+	if summary := strings.TrimSpace(rec.SubfieldValue("520", "a")); summary != "" {
+		book.Summary = summary
+		fields = append(fields, "summary")
+	}
+
+	var err error
+	if value := strings.TrimSpace(rec.SubfieldValue(marcCountTag, marcCountCode)); value != "" {
+		count, parseErr := strconv.ParseUint(value, 10, 32)
+		if parseErr != nil {
+			err = ErrImportCount.Wrap(parseErr)
+		} else {
+			book.Count = uint(count)
+			fields = append(fields, "count")
+		}
+	}
+	return book, fields, err
+}
+
+func trimISBD(s string) string {
+	return strings.TrimRight(strings.TrimSpace(s), isbdPunctuation)
+}
+
+// marcFromBook 在原始记录（没有时新建）上写入书籍当前内容。
+// 只改写与原始记录解析结果不同的字段，未改动的字段保留原有的标识符和子字段
+func marcFromBook(book *model.Book, original *marc.Record) *marc.Record {
+	rec := original
+	if rec == nil {
+		rec = &marc.Record{Leader: marc.DefaultLeader}
+	}
+	if rec.Field("001") == nil {
+		rec.SetControl("001", strconv.FormatUint(uint64(book.ID), 10))
+	}
+	rec.SetControl("005", book.UpdatedAt.Format(marc005Layout))
+
+	current, _, _ := bookFromMARC(rec)
+	if current.ISBN != book.ISBN {
+		rec.SetSubfield("020", "a", book.ISBN)
+	}
+	if current.Author != book.Author {
+		rec.SetSubfield("100", "a", book.Author)
+	}
+	if current.Title != book.Title {
+		created := rec.Field("245") == nil
+		rec.RemoveSubfield("245", "b")
+		rec.SetSubfield("245", "a", book.Title)
+		if f := rec.Field("245"); f != nil && created {
+			f.Ind1, f.Ind2 = "0", "0"
+		}
+	}
+	if current.Summary != book.Summary {
+		rec.SetSubfield("520", "a", book.Summary)
+	}
+	if current.Count != book.Count {
+		rec.SetSubfield(marcCountTag, marcCountCode, strconv.FormatUint(uint64(book.Count), 10))
+	}
+	return rec
+}
+
+// parseMARC 解析保存的原始记录，没有或无法解析时返回 nil，按新记录输出
+func parseMARC(bookID uint, record string) *marc.Record {
+	if record == "" {
+		return nil
+	}
+	rec := &marc.Record{}
+	if err := json.Unmarshal([]byte(record), rec); err != nil {
+		log.Printf("书籍 %d 的MARC原始记录无法解析，按新记录输出: %v", bookID, err)
+		return nil
+	}
+	return rec
+}
+
+// marcSource 逐条读取 MARC 记录，行号为记录序号
+type marcSource struct {
+	r marc.RecordReader
+	n int
+}
+
+func newMARCSource(format string, file io.Reader) *marcSource {
+	if format == api.FormatMARCXML {
+		return &marcSource{r: marc.NewXMLReader(file)}
+	}
+	return &marcSource{r: marc.NewReader(file)}
+}
+
+func (s *marcSource) Next() (*importRow, error) {
+	rec, err := s.r.Read()
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	s.n++
+	if err != nil {
+		return nil, ErrImportFile.Wrap(err)
+	}
+
+	book, fields, err := bookFromMARC(rec)
+	return &importRow{line: s.n, book: book, fields: fields, err: err, record: rec}, nil
+}
+
+func (s *marcSource) Line() int {
+	return s.n
+}
+
+// saveMARCRecords 保存已写入书籍的原始记录。书籍已经写入，保存失败只记录日志
+func saveMARCRecords(rows []importRow, written []*model.Book) {
+	byISBN := make(map[string]*marc.Record, len(rows))
+	for _, row := range rows {
+		if row.record != nil {
+			byISBN[row.book.ISBN] = row.record
+		}
+	}
+	if len(byISBN) == 0 {
+		return
+	}
+
+	records := make([]model.BookMARC, 0, len(written))
+	for _, book := range written {
+		rec, ok := byISBN[book.ISBN]
+		if !ok {
+			continue
+		}
+		data, err := json.Marshal(rec)
+		if err != nil {
+			log.Printf("编码MARC原始记录失败: %v", err)
+			continue
+		}
+		records = append(records, model.BookMARC{BookID: book.ID, Record: string(data)})
+	}
+	if err := dao.ApiDao.BookMARCSaveDAO(records); err != nil {
+		log.Printf("保存MARC原始记录失败: %v", err)
+	}
+}
+
+// exportMARC 以 MARC21 或 MARCXML 导出，有原始记录的书籍在原始记录上更新
+func exportMARC(dto *api.BookExportReq, w io.Writer) error {
+	var mw marc.RecordWriter
+	if dto.Format == api.FormatMARCXML {
+		mw = marc.NewXMLWriter(w)
+	} else {
+		mw = marc.NewWriter(w)
+	}
+
+	filter := &api.BookSearchReq{Title: dto.Title, ISBN: dto.ISBN, Author: dto.Author, Content: dto.Content}
+	err := dao.ApiDao.BookMARCExportDAO(filter, func(book *model.Book, record string) error {
+		err := mw.Write(marcFromBook(book, parseMARC(book.ID, record)))
+		if errors.Is(err, marc.ErrRecordTooLong) {
+			return ErrMARCTooLong.WithMessage(fmt.Sprintf("书籍 %d 超过 MARC 记录的长度上限，请改用 MARCXML 导出", book.ID)).Wrap(err)
+		}
+		return err
+	})
+	if err != nil {
+		return bookDBError(err)
+	}
+	return mw.Close()
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"LibraryManagement/internal/marc"
+	"LibraryManagement/internal/model"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMARCFromBookLargeContent(t *testing.T) {
+	book := &model.Book{Title: "Go", ISBN: "9787111213826", Author: "Alan", Count: 2, Summary: "简介", Content: strings.Repeat("正文", 100000)}
+	book.ID = 1
+
+	// 正文远超单个字段 9999 字节的上限，仍能导出
+	var buf bytes.Buffer
+	w := marc.NewWriter(&buf)
+	require.NoError(t, w.Write(marcFromBook(book, nil)))
+	require.NoError(t, w.Close())
+
+	rec, err := marc.NewReader(&buf).Read()
+	require.NoError(t, err)
+	assert.Nil(t, rec.Field("505"))
+	assert.Equal(t, "简介", rec.SubfieldValue("520", "a"))
+
+	// 原始记录中的内容附注原样输出，也不会导入为正文
+	original := &marc.Record{Leader: marc.DefaultLeader, Fields: []marc.Field{
+		{Tag: "505", Ind1: "0", Ind2: " ", Subfields: []marc.Subfield{{Code: "a", Value: "第一章 -- 第二章"}}},
+	}}
+	out := marcFromBook(book, original)
+	assert.Equal(t, "第一章 -- 第二章", out.SubfieldValue("505", "a"))
+	imported, fields, err := bookFromMARC(out)
+	require.NoError(t, err)
+	assert.Empty(t, imported.Content)
+	assert.NotContains(t, fields, "content")
+}
@@ -2,15 +2,29 @@ package service
 
 import (
 	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
 	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/tabular"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type BookService interface {
 	Add(dto *api.BookInfoReq) error
-	Delete(ids []string) error
-	Update(dto *api.BookUpdateReq) error
+	// Enrich 添加前按 ISBN 补全为空的书名、作者和简介，见 MetadataService.Enrich
+	Enrich(dto *api.BookInfoReq) error
+	// Delete 批量删除，返回每个 ID 的处理结果；atomic 为 true 时任一 ID 失败则整批不删除，
+	// 此时同时返回结果和 ErrBatchRejected
+	Delete(ids []string, atomic bool) (*api.BookDeleteResp, error)
+	Update(dto *api.BookUpdateReq) (*api.BookInfoResp, error)
 	List(dto *api.BookSearchReq) (*api.BookSearchResp, error)
 	GetByID(id uint) (*api.BookInfoResp, error)
 
@@ -19,18 +33,39 @@ type BookService interface {
 	SearchByTitle(title string, exact bool) ([]api.BookInfoResp, error)
 	SearchByContent(content string) ([]api.BookInfoResp, error)
 
+	// 回收站
+	ListTrash(dto *api.BookTrashListReq) (*api.BookSearchResp, error)
+	Restore(id uint) (*api.BookInfoResp, error)
+	Purge(ids []uint) (int64, error)
+	// PurgeExpiredTrash 彻底删除超过保留期的已删除书籍，返回删除条数
+	PurgeExpiredTrash() (int64, error)
+
+	// 修订记录
+	ListRevisions(bookID uint, dto *api.BookRevisionListReq) (*api.BookRevisionListResp, error)
+	GetRevision(bookID uint, version int) (*api.BookRevisionResp, error)
+	CompareRevisions(bookID uint, from, to int) (*api.BookRevisionDiffResp, error)
+	// RollbackRevision 把书籍内容恢复为指定版本，作为新版本保存；expected 为客户端读取时的版本号，可为空
+	RollbackRevision(bookID uint, version int, expected *int, operator api.Operator) (*api.BookInfoResp, error)
+
+	// 批量导入导出
+	Import(file tabular.File, size int64, dto *api.BookImportReq) (*api.BookImportResp, error)
+	// Export 流式导出，w 在查询成功后才会被写入
+	Export(dto *api.BookExportReq, w io.Writer) error
+
 	// 索引管理
 	InitializeESIndex() error
 	ReindexAllBooks() error
 }
 
 type bookServiceImpl struct {
-	esService BookESService
+	esService       BookESService
+	metadataService MetadataService
 }
 
 func NewBookService() BookService {
 	return &bookServiceImpl{
-		esService: NewBookESService(),
+		esService:       NewBookESService(),
+		metadataService: NewMetadataService(),
 	}
 }
 
@@ -39,7 +74,7 @@ func (b *bookServiceImpl) Add(dto *api.BookInfoReq) error {
 	// 先保存到数据库
 	book, err := dao.ApiDao.BookAddDAO(dto)
 	if err != nil {
-		return err
+		return isbnError(dto.ISBN, err)
 	}
 
 	// 同步到ES
@@ -52,56 +87,124 @@ func (b *bookServiceImpl) Add(dto *api.BookInfoReq) error {
 
 }
 
-func (b *bookServiceImpl) Delete(ids []string) error {
-	// 先从数据库删除
-	err := dao.ApiDao.BookDeleteDAO(ids)
-	if err != nil {
-		return err
+func (b *bookServiceImpl) Enrich(dto *api.BookInfoReq) error {
+	return b.metadataService.Enrich(dto)
+}
+
+func (b *bookServiceImpl) Delete(ids []string, atomic bool) (*api.BookDeleteResp, error) {
+	if max := config.Config.Books.MaxBatchSize; len(ids) > max {
+		return nil, ErrBatchTooLarge.WithMessage(fmt.Sprintf("单次最多删除 %d 本书籍", max))
 	}
 
-	// 从ES中删除
+	// 解析 ID，重复的 ID 只处理一次；index 记录 ID 在结果中的位置
+	resp := &api.BookDeleteResp{Atomic: atomic, Results: make([]api.BookDeleteResult, 0, len(ids))}
+	index := make(map[uint]int, len(ids))
+	valid := make([]uint, 0, len(ids))
+	invalid := make(map[string]bool)
 	for _, idStr := range ids {
-		if id, parseErr := strconv.ParseUint(idStr, 10, 64); parseErr == nil {
-			if esErr := b.esService.DeleteBook(uint(id)); esErr != nil {
-				log.Printf("从ES删除书籍失败 (ID: %d): %v", id, esErr)
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil || id == 0 {
+			if !invalid[idStr] {
+				invalid[idStr] = true
+				resp.Results = append(resp.Results, api.BookDeleteResult{ID: idStr, Status: api.DeleteInvalid})
 			}
+			continue
+		}
+		if _, ok := index[uint(id)]; ok {
+			continue
 		}
+		index[uint(id)] = len(resp.Results)
+		valid = append(valid, uint(id))
+		resp.Results = append(resp.Results, api.BookDeleteResult{ID: idStr, Status: api.DeleteNotFound})
 	}
 
-	return nil
+	// 事务模式下有无效 ID 时不访问数据库，所有有效 ID 均未删除
+	if atomic && len(invalid) > 0 {
+		markSkipped(resp, index, valid)
+		return resp, ErrBatchRejected
+	}
+
+	// 先从数据库删除
+	deleted, err := dao.ApiDao.BookDeleteDAO(valid, atomic)
+	if errors.Is(err, dao.ErrBooksNotFound) {
+		markSkipped(resp, index, deleted)
+		return resp, ErrBatchRejected
+	}
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	resp.Deleted = len(deleted)
+
+	// 从ES中删除，失败的单独标记，便于重新索引
+	for _, id := range deleted {
+		status := api.DeleteDeleted
+		if esErr := b.esService.DeleteBook(id); esErr != nil {
+			log.Printf("从ES删除书籍失败 (ID: %d): %v", id, esErr)
+			status = api.DeleteIndexSyncFailed
+		}
+		resp.Results[index[id]].Status = status
+	}
+
+	return resp, nil
+}
 
+// markSkipped 事务模式整批回滚时，把存在的书籍标记为未删除，其余保持 not_found / invalid
+func markSkipped(resp *api.BookDeleteResp, index map[uint]int, found []uint) {
+	for _, id := range found {
+		resp.Results[index[id]].Status = api.DeleteSkipped
+	}
 }
 
-// 数据库乐观锁更新
-func (b *bookServiceImpl) Update(dto *api.BookUpdateReq) error {
+// 数据库乐观锁更新（dto.Fields 不为 nil 时为局部更新），返回更新后的书籍（含新版本号）
+func (b *bookServiceImpl) Update(dto *api.BookUpdateReq) (*api.BookInfoResp, error) {
 
 	// 先更新数据库
 	book, err := dao.ApiDao.BookUpdateDAO(dto)
 	if err != nil {
-		return err
+		return nil, isbnError(dto.ISBN, err)
 	}
 
-	// 同步更新到ES
-	if err := b.esService.UpdateBook(book); err != nil {
+	// 同步更新到ES，局部更新时只写入修改过的字段；作者变化时贡献者随之变化，分类变化时分类号随之变化
+	if dto.Fields != nil {
+		fields := dto.Fields
+		if dto.Contributors != nil || slices.Contains(fields, "author") {
+			fields = append(fields[:len(fields):len(fields)], "author", "contributors")
+		}
+		if dto.CategoryIDs != nil {
+			fields = append(fields[:len(fields):len(fields)], "category_ids", "category_codes")
+		}
+		err = b.esService.PartialUpdateBook(book, fields)
+	} else {
+		err = b.esService.UpdateBook(book)
+	}
+	if err != nil {
 		log.Printf("同步更新书籍到ES失败: %v", err)
 	}
 
-	return nil
+	return toBookInfoResp(book), nil
 }
 
 func (b *bookServiceImpl) List(dto *api.BookSearchReq) (*api.BookSearchResp, error) {
 
 	books, err := dao.ApiDao.BookListDAO(dto)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
 
-	return books, err
+	return books, nil
 }
 
 func (b *bookServiceImpl) GetByID(id uint) (*api.BookInfoResp, error) {
 	book, err := dao.ApiDao.BookGetByIDDAO(id)
 	if err != nil {
-		return nil, err
+		return nil, bookDBError(err)
 	}
 
+	return toBookInfoResp(book), nil
+}
+
+// toBookInfoResp 书籍详情响应，包含内容和版本号
+func toBookInfoResp(book *model.Book) *api.BookInfoResp {
 	return &api.BookInfoResp{
 		ID:      book.ID,
 		Title:   book.Title,
@@ -110,19 +213,273 @@ func (b *bookServiceImpl) GetByID(id uint) (*api.BookInfoResp, error) {
 		Author:  book.Author,
 		Content: book.Content,
 		Summary: book.Summary,
+		Version: book.Version,
+
+		Contributors: toContributorResps(book.Contributors),
+		Categories:   toCategoryRefs(book.Categories),
+		Tags:         book.Tags,
+	}
+}
+
+func toContributorResps(contributors []model.Contributor) []api.BookContributor {
+	if len(contributors) == 0 {
+		return nil
+	}
+	resps := make([]api.BookContributor, 0, len(contributors))
+	for _, c := range contributors {
+		resps = append(resps, api.BookContributor{AuthorID: c.AuthorID, Name: c.Name, Role: c.Role})
+	}
+	return resps
+}
+
+func toCategoryRefs(categories []model.Category) []api.CategoryRef {
+	if len(categories) == 0 {
+		return nil
+	}
+	refs := make([]api.CategoryRef, 0, len(categories))
+	for _, c := range categories {
+		refs = append(refs, api.CategoryRef{ID: c.ID, Code: c.Code, Name: c.Name})
+	}
+	return refs
+}
+
+// ListTrash 分页查询回收站
+func (b *bookServiceImpl) ListTrash(dto *api.BookTrashListReq) (*api.BookSearchResp, error) {
+	books, err := dao.ApiDao.BookTrashListDAO(dto)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	return books, nil
+}
+
+// Restore 从回收站恢复书籍并重新写入ES
+func (b *bookServiceImpl) Restore(id uint) (*api.BookInfoResp, error) {
+	book, err := dao.ApiDao.BookRestoreDAO(id)
+	if err != nil {
+		return nil, dbError(err, ErrBookNotInTrash)
+	}
+
+	if err := b.esService.IndexBook(book); err != nil {
+		log.Printf("恢复书籍后同步到ES失败 (ID: %d): %v", id, err)
+	}
+
+	return toBookInfoResp(book), nil
+}
+
+// Purge 彻底删除回收站中的书籍（已从ES删除，无需再同步）
+func (b *bookServiceImpl) Purge(ids []uint) (int64, error) {
+	n, err := dao.ApiDao.BookPurgeDAO(ids)
+	if err != nil {
+		return 0, dbError(err, nil)
+	}
+	return n, nil
+}
+
+func (b *bookServiceImpl) PurgeExpiredTrash() (int64, error) {
+	before := time.Now().Add(-config.Config.Trash.Retention)
+	n, err := dao.ApiDao.BookPurgeDeletedBeforeDAO(before)
+	if err != nil {
+		return 0, err
+	}
+
+	if n > 0 {
+		RecordAudit(&model.AuditLog{
+			ActorType:  model.ActorSystem,
+			Action:     "book.purge",
+			TargetType: "book",
+			Outcome:    model.AuditSuccess,
+			Detail:     fmt.Sprintf("清理 %d 本 %s 之前删除的书籍", n, before.Format(time.RFC3339)),
+		})
+	}
+	return n, nil
+}
+
+// ListRevisions 分页查询修订记录（新版本在前），每条附带与上一条记录的字段差异
+func (b *bookServiceImpl) ListRevisions(bookID uint, dto *api.BookRevisionListReq) (*api.BookRevisionListResp, error) {
+	page := dto.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := dto.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
+	// 多取一条，用于计算本页最后一条记录的差异
+	revisions, total, err := dao.ApiDao.BookRevisionListDAO(bookID, (page-1)*pageSize, pageSize+1)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	if total == 0 {
+		// 区分书籍不存在与启用修订记录后尚未修改过
+		if _, err := dao.ApiDao.BookGetByIDDAO(bookID); err != nil {
+			return nil, bookDBError(err)
+		}
+	}
+
+	resps := make([]api.BookRevisionResp, 0, len(revisions))
+	for i := 0; i < len(revisions) && i < pageSize; i++ {
+		current, err := decodeSnapshot(&revisions[i])
+		if err != nil {
+			return nil, err
+		}
+
+		var changes []api.FieldChange
+		switch {
+		case i+1 < len(revisions):
+			previous, err := decodeSnapshot(&revisions[i+1])
+			if err != nil {
+				return nil, err
+			}
+			changes = diffSnapshots(previous, current)
+		case revisions[i].Action == model.RevisionCreate:
+			changes = diffSnapshots(model.BookSnapshot{}, current)
+		}
+		resps = append(resps, toRevisionResp(&revisions[i], changes, nil))
+	}
+
+	return &api.BookRevisionListResp{
+		BookID:     bookID,
+		Revisions:  resps,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}, nil
+}
+
+// GetRevision 查询指定版本的完整内容
+func (b *bookServiceImpl) GetRevision(bookID uint, version int) (*api.BookRevisionResp, error) {
+	revision, err := dao.ApiDao.BookRevisionGetDAO(bookID, version)
+	if err != nil {
+		return nil, dbError(err, ErrRevisionNotFound)
+	}
+	snapshot, err := decodeSnapshot(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := toRevisionResp(revision, nil, &api.BookInfoReq{
+		Title:   snapshot.Title,
+		Count:   snapshot.Count,
+		ISBN:    snapshot.ISBN,
+		Author:  snapshot.Author,
+		Content: snapshot.Content,
+		Summary: snapshot.Summary,
+	})
+	return &resp, nil
+}
+
+// CompareRevisions 比较两个版本的字段差异
+func (b *bookServiceImpl) CompareRevisions(bookID uint, from, to int) (*api.BookRevisionDiffResp, error) {
+	snapshots := make([]model.BookSnapshot, 0, 2)
+	for _, version := range []int{from, to} {
+		revision, err := dao.ApiDao.BookRevisionGetDAO(bookID, version)
+		if err != nil {
+			return nil, dbError(err, ErrRevisionNotFound.WithMessage(fmt.Sprintf("书籍版本 %d 不存在", version)))
+		}
+		snapshot, err := decodeSnapshot(revision)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return &api.BookRevisionDiffResp{
+		BookID:  bookID,
+		From:    from,
+		To:      to,
+		Changes: diffSnapshots(snapshots[0], snapshots[1]),
 	}, nil
 }
 
+func (b *bookServiceImpl) RollbackRevision(bookID uint, version int, expected *int, operator api.Operator) (*api.BookInfoResp, error) {
+	revision, err := dao.ApiDao.BookRevisionGetDAO(bookID, version)
+	if err != nil {
+		return nil, dbError(err, ErrRevisionNotFound)
+	}
+	target, err := decodeSnapshot(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	book, err := dao.ApiDao.BookGetByIDDAO(bookID)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	if expected == nil {
+		expected = &book.Version
+	}
+
+	// 只写入与当前内容不同的字段，ES 同样局部更新
+	return b.Update(&api.BookUpdateReq{
+		ID:      bookID,
+		Version: expected,
+		BookInfoReq: api.BookInfoReq{
+			Title:    target.Title,
+			Count:    target.Count,
+			ISBN:     target.ISBN,
+			Author:   target.Author,
+			Content:  target.Content,
+			Summary:  target.Summary,
+			Operator: operator,
+		},
+		Fields:       book.Snapshot().ChangedFields(target),
+		RestoredFrom: version,
+	})
+}
+
+// decodeSnapshot 解析修订记录中的快照
+func decodeSnapshot(revision *model.BookRevision) (model.BookSnapshot, error) {
+	var snapshot model.BookSnapshot
+	if err := json.Unmarshal([]byte(revision.Snapshot), &snapshot); err != nil {
+		return snapshot, fmt.Errorf("解析书籍 %d 版本 %d 的快照失败: %w", revision.BookID, revision.Version, err)
+	}
+	return snapshot, nil
+}
+
+// diffSnapshots 返回 from 到 to 之间取值变化的字段
+func diffSnapshots(from, to model.BookSnapshot) []api.FieldChange {
+	fields := from.ChangedFields(to)
+	changes := make([]api.FieldChange, 0, len(fields))
+	for _, field := range fields {
+		changes = append(changes, api.FieldChange{Field: field, Old: from.Field(field), New: to.Field(field)})
+	}
+	return changes
+}
+
+func toRevisionResp(revision *model.BookRevision, changes []api.FieldChange, snapshot *api.BookInfoReq) api.BookRevisionResp {
+	fields := []string{}
+	if revision.Fields != "" {
+		fields = strings.Split(revision.Fields, ",")
+	}
+	return api.BookRevisionResp{
+		Version:      revision.Version,
+		Action:       revision.Action,
+		ActorID:      revision.ActorID,
+		APIKeyID:     revision.APIKeyID,
+		CreatedAt:    revision.CreatedAt,
+		Fields:       fields,
+		RestoredFrom: revision.RestoredFrom,
+		Changes:      changes,
+		Snapshot:     snapshot,
+	}
+}
+
 // SearchBooks ES综合搜索
 func (b *bookServiceImpl) SearchBooks(req *api.BookSearchReq) (*api.BookSearchResp, error) {
-	return b.esService.SearchBooks(req)
+	books, err := b.esService.SearchBooks(req)
+	if err != nil {
+		return nil, searchError(err)
+	}
+	return books, nil
 }
 
 // SearchByTitle 标题搜索（精确或模糊）
 func (b *bookServiceImpl) SearchByTitle(title string, exact bool) ([]api.BookInfoResp, error) {
 	docs, err := b.esService.SearchByTitle(title, exact)
 	if err != nil {
-		return nil, err
+		return nil, searchError(err)
 	}
 
 	books := make([]api.BookInfoResp, 0, len(docs))
@@ -145,7 +502,7 @@ func (b *bookServiceImpl) SearchByTitle(title string, exact bool) ([]api.BookInf
 func (b *bookServiceImpl) SearchByContent(content string) ([]api.BookInfoResp, error) {
 	docs, err := b.esService.SearchByContent(content)
 	if err != nil {
-		return nil, err
+		return nil, searchError(err)
 	}
 
 	books := make([]api.BookInfoResp, 0, len(docs))
@@ -165,7 +522,7 @@ func (b *bookServiceImpl) SearchByContent(content string) ([]api.BookInfoResp, e
 
 // InitializeESIndex 初始化ES索引
 func (b *bookServiceImpl) InitializeESIndex() error {
-	return b.esService.CreateIndex()
+	return searchError(b.esService.CreateIndex())
 }
 
 // ReindexAllBooks 重新索引所有书籍数据
@@ -179,7 +536,7 @@ func (b *bookServiceImpl) ReindexAllBooks() error {
 
 	// 创建新索引
 	if err := b.esService.CreateIndex(); err != nil {
-		return err
+		return searchError(err)
 	}
 
 	// 从数据库获取所有书籍并重新索引
@@ -193,7 +550,7 @@ func (b *bookServiceImpl) ReindexAllBooks() error {
 		searchReq.Page = page
 		listResp, err := dao.ApiDao.BookListDAO(searchReq)
 		if err != nil {
-			return err
+			return bookDBError(err)
 		}
 
 		books := listResp.Books
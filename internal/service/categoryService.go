@@ -0,0 +1,204 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"errors"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound       = apperr.NotFound("category_not_found", "分类不存在")
+	ErrCategoryCodeExists     = apperr.Conflict("category_code_exists", "分类号已存在")
+	ErrCategoryParentNotFound = apperr.Validation("category_parent_not_found", "上级分类不存在")
+	ErrCategoryCycle          = apperr.Validation("category_cycle", "不能把分类移动到自身或其子孙分类下")
+	ErrCategoryTooDeep        = apperr.Validation("category_too_deep", "分类层级超过上限")
+	ErrCategoryHasChildren    = apperr.Conflict("category_has_children", "分类仍有子分类，不能删除")
+	ErrCategoryInUse          = apperr.Conflict("category_in_use", "分类仍关联书籍（包括回收站中的书籍），不能删除")
+	ErrBookCategoryNotFound   = apperr.Validation("book_category_not_found", "书籍引用的分类不存在")
+)
+
+// CategoryService 分类树和标签
+type CategoryService interface {
+	// Tree 完整的分类树，每个分类带书籍数量
+	Tree() ([]api.CategoryResp, error)
+	// Get 分类详情，包含上级分类和直接子分类
+	Get(id uint) (*api.CategoryResp, error)
+	Create(dto *api.CategoryInfoReq) (*api.CategoryResp, error)
+	// Update 修改分类，分类号或位置变化后把受影响的书籍同步到 ES
+	Update(id uint, dto *api.CategoryInfoReq) (*api.CategoryResp, error)
+	Delete(id uint) error
+	// ListBooks 分类及其子孙分类中的书籍
+	ListBooks(id uint, dto *api.CategoryBooksReq) (*api.BookSearchResp, error)
+
+	// Tags 有书籍的标签及其书籍数量
+	Tags(dto *api.TagSearchReq) (*api.TagListResp, error)
+}
+
+type categoryServiceImpl struct {
+	esService BookESService
+}
+
+func NewCategoryService() CategoryService {
+	return &categoryServiceImpl{esService: NewBookESService()}
+}
+
+// categoryError 分类相关的数据库错误映射，重复键只可能来自分类号唯一索引
+func categoryError(err error) error {
+	switch {
+	case errors.Is(err, dao.ErrCategoryParentNotFound):
+		return ErrCategoryParentNotFound.Wrap(err)
+	case errors.Is(err, dao.ErrCategoryCycle):
+		return ErrCategoryCycle.Wrap(err)
+	case errors.Is(err, dao.ErrCategoryTooDeep):
+		return ErrCategoryTooDeep.Wrap(err)
+	case errors.Is(err, dao.ErrCategoryHasChildren):
+		return ErrCategoryHasChildren.Wrap(err)
+	case errors.Is(err, dao.ErrCategoryInUse):
+		return ErrCategoryInUse.Wrap(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrCategoryCodeExists.Wrap(err)
+	}
+	return dbError(err, ErrCategoryNotFound)
+}
+
+func toCategoryResp(c *model.Category, counts map[uint]int64) api.CategoryResp {
+	return api.CategoryResp{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Code:      c.Code,
+		Name:      c.Name,
+		BookCount: counts[c.ID],
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
+
+func (s *categoryServiceImpl) Tree() ([]api.CategoryResp, error) {
+	categories, err := dao.ApiDao.CategoryListDAO()
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	counts, err := dao.ApiDao.CategoryBookCountDAO(nil)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	// 分类已按分类号排序，子分类保持同样的顺序
+	exists := make(map[uint]bool, len(categories))
+	children := make(map[uint][]*model.Category)
+	for i := range categories {
+		exists[categories[i].ID] = true
+	}
+	for i := range categories {
+		parent := categories[i].ParentID
+		if !exists[parent] {
+			parent = 0
+		}
+		children[parent] = append(children[parent], &categories[i])
+	}
+
+	var build func(parent uint) []api.CategoryResp
+	build = func(parent uint) []api.CategoryResp {
+		nodes := make([]api.CategoryResp, 0, len(children[parent]))
+		for _, c := range children[parent] {
+			node := toCategoryResp(c, counts)
+			node.Children = build(c.ID)
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	return build(0), nil
+}
+
+func (s *categoryServiceImpl) Get(id uint) (*api.CategoryResp, error) {
+	category, err := dao.ApiDao.CategoryGetDAO(id)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	counts, err := dao.ApiDao.CategoryBookCountDAO(category)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	resp := toCategoryResp(category, counts)
+
+	ancestorIDs := category.AncestorIDs()
+	ancestorIDs = ancestorIDs[:len(ancestorIDs)-1]
+	ancestors, err := dao.ApiDao.CategoryListByIDsDAO(ancestorIDs)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	byID := make(map[uint]model.Category, len(ancestors))
+	for _, c := range ancestors {
+		byID[c.ID] = c
+	}
+	for _, ancestorID := range ancestorIDs {
+		if c, ok := byID[ancestorID]; ok {
+			resp.Ancestors = append(resp.Ancestors, api.CategoryRef{ID: c.ID, Code: c.Code, Name: c.Name})
+		}
+	}
+
+	children, err := dao.ApiDao.CategoryChildrenDAO(id)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	for i := range children {
+		resp.Children = append(resp.Children, toCategoryResp(&children[i], counts))
+	}
+	return &resp, nil
+}
+
+func (s *categoryServiceImpl) Create(dto *api.CategoryInfoReq) (*api.CategoryResp, error) {
+	category, err := dao.ApiDao.CategoryCreateDAO(dto)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+	resp := toCategoryResp(category, nil)
+	return &resp, nil
+}
+
+func (s *categoryServiceImpl) Update(id uint, dto *api.CategoryInfoReq) (*api.CategoryResp, error) {
+	books, err := dao.ApiDao.CategoryUpdateDAO(id, dto)
+	if err != nil {
+		return nil, categoryError(err)
+	}
+
+	// 书籍文档中的上级分类和分类号随之改变
+	index := make([]*model.Book, 0, len(books))
+	for i := range books {
+		index = append(index, &books[i])
+	}
+	if failed, esErr := s.esService.BulkIndexBooks(index); esErr != nil || len(failed) > 0 {
+		log.Printf("修改分类后同步书籍到ES失败 (分类ID: %d, 书籍: %v): %v", id, failed, esErr)
+	}
+
+	return s.Get(id)
+}
+
+func (s *categoryServiceImpl) Delete(id uint) error {
+	return categoryError(dao.ApiDao.CategoryDeleteDAO(id))
+}
+
+func (s *categoryServiceImpl) ListBooks(id uint, dto *api.CategoryBooksReq) (*api.BookSearchResp, error) {
+	if _, err := dao.ApiDao.CategoryGetDAO(id); err != nil {
+		return nil, categoryError(err)
+	}
+
+	books, err := dao.ApiDao.BookListDAO(&api.BookSearchReq{CategoryID: id, Page: dto.Page, PageSize: dto.PageSize})
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	return books, nil
+}
+
+func (s *categoryServiceImpl) Tags(dto *api.TagSearchReq) (*api.TagListResp, error) {
+	tags, err := dao.ApiDao.TagListDAO(dto)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	return tags, nil
+}
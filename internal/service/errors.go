@@ -0,0 +1,113 @@
+package service
+
+import (
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/repo/dao"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"gorm.io/gorm"
+)
+
+// 书籍及通用依赖相关的业务错误，用户、两步验证等错误定义在各自的服务文件中
+var (
+	ErrBookNotFound        = apperr.NotFound("book_not_found", "书籍不存在")
+	ErrBookVersionConflict = apperr.Conflict("book_version_conflict", "数据已被其他用户修改，请刷新后重试")
+	ErrISBNExists          = apperr.Conflict("isbn_exists", "ISBN已存在")
+	ErrISBNInTrash         = apperr.Conflict("isbn_in_trash", "该ISBN的书籍在回收站中，请先恢复或彻底删除")
+	ErrBookNotInTrash      = apperr.NotFound("book_not_in_trash", "回收站中不存在该书籍")
+	ErrRevisionNotFound    = apperr.NotFound("revision_not_found", "书籍版本不存在")
+	ErrBookNoContent       = apperr.NotFound("book_content_not_found", "书籍没有正文")
+	ErrBatchTooLarge       = apperr.Validation("batch_too_large", "批量操作的数量超过上限")
+	ErrBatchRejected       = apperr.Conflict("batch_rejected", "部分书籍不存在或ID无效，整批未删除")
+	ErrDuplicateEntry      = apperr.Conflict("duplicate_entry", "数据已存在")
+	ErrDBUnavailable       = apperr.Unavailable("database_unavailable", "数据库暂不可用，请稍后重试")
+	ErrSearchUnavailable   = apperr.Unavailable("search_unavailable", "搜索服务暂不可用，请稍后重试")
+)
+
+// 批量导入导出相关的错误，导入的行级错误出现在导入结果的 errors 中
+var (
+	ErrImportFormat       = apperr.New(apperr.KindUnsupportedMediaType, "unsupported_import_format", "不支持的导入文件格式，请上传 CSV、TSV、XLSX、MARC 或 MARCXML 文件")
+	ErrImportFile         = apperr.Validation("invalid_import_file", "导入文件内容无法解析")
+	ErrImportEmpty        = apperr.Validation("import_empty", "导入文件为空")
+	ErrImportMapping      = apperr.Validation("invalid_import_mapping", "列映射格式错误")
+	ErrImportColumns      = apperr.Validation("import_missing_columns", "导入文件缺少必要的列")
+	ErrImportTooManyRows  = apperr.Validation("import_too_many_rows", "导入文件的行数超过上限")
+	ErrImportDuplicateRow = apperr.Validation("duplicate_isbn_in_file", "文件中ISBN重复")
+	ErrImportCount        = apperr.Validation("invalid_count", "数量必须为正整数")
+	ErrExportFields       = apperr.Validation("invalid_export_fields", "导出字段不存在")
+	ErrMARCTooLong        = apperr.Validation("marc_record_too_long", "书籍内容超过 MARC 记录的长度上限，请改用 MARCXML 导出")
+)
+
+// dbError 把 DAO 返回的底层错误映射为业务错误：
+// 记录不存在映射为 notFound（为 nil 时保持原样），唯一索引冲突映射为 ErrDuplicateEntry，
+// 连接类错误映射为 ErrDBUnavailable，其余错误原样返回，由处理器按系统错误处理
+func dbError(err error, notFound *apperr.Error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := apperr.As(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		if notFound != nil {
+			return notFound.Wrap(err)
+		}
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrDuplicateEntry.Wrap(err)
+	case isUnavailable(err):
+		return ErrDBUnavailable.Wrap(err)
+	}
+	return err
+}
+
+// bookDBError 书籍相关的数据库错误映射，重复键只可能来自 ISBN 唯一索引
+func bookDBError(err error) error {
+	switch {
+	case errors.Is(err, dao.ErrVersionConflict):
+		return ErrBookVersionConflict.Wrap(err)
+	case errors.Is(err, dao.ErrContributorAuthorNotFound):
+		return ErrContributorAuthorNotFound.Wrap(err)
+	case errors.Is(err, dao.ErrBookCategoryNotFound):
+		return ErrBookCategoryNotFound.Wrap(err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return ErrISBNExists.Wrap(err)
+	}
+	return dbError(err, ErrBookNotFound)
+}
+
+// isbnError 写入书籍时的错误映射：ISBN 冲突且占用者在回收站中时提示先恢复或彻底删除
+func isbnError(isbn string, err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		if book, getErr := dao.ApiDao.BookGetByISBNWithDeletedDAO(isbn); getErr == nil && book.DeletedAt.Valid {
+			return ErrISBNInTrash.Wrap(err)
+		}
+	}
+	return bookDBError(err)
+}
+
+// searchError ES 调用失败统一视为搜索服务不可用
+func searchError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := apperr.As(err); ok {
+		return err
+	}
+	return ErrSearchUnavailable.Wrap(err)
+}
+
+// isUnavailable 判断是否为连接失败、超时等依赖不可用错误
+func isUnavailable(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/feed"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrFeedDisabled = apperr.NotFound("feed_disabled", "未启用订阅 feed")
+
+// 订阅 feed 的种类
+const (
+	FeedNew     = "new"     // 新书上架，按上架时间倒序
+	FeedUpdated = "updated" // 最近更新，按更新时间倒序
+)
+
+// FeedService 新书和更新订阅 feed，未启用时各方法返回 ErrFeedDisabled
+type FeedService interface {
+	// LastModified feed 内容最后一次变化的时间，用于条件请求；没有书籍时为零值，
+	// 筛选的作者、分类不存在时返回 ErrAuthorNotFound、ErrCategoryNotFound
+	LastModified(req *api.FeedReq) (time.Time, error)
+	// Channel 生成 feed，标题为站点名称，由调用方补充；按作者、分类筛选时 Author、Category 为作者姓名、分类名称。
+	// self 为请求路径（含查询参数），updated 为 LastModified 的结果
+	Channel(kind string, req *api.FeedReq, self string, updated time.Time) (*feed.Channel, error)
+}
+
+type feedServiceImpl struct{}
+
+func NewFeedService() FeedService {
+	return &feedServiceImpl{}
+}
+
+func feedEnabled() error {
+	if !config.Config.Feed.Enabled {
+		return ErrFeedDisabled
+	}
+	return nil
+}
+
+func (f *feedServiceImpl) LastModified(req *api.FeedReq) (time.Time, error) {
+	if err := feedEnabled(); err != nil {
+		return time.Time{}, err
+	}
+	if req.AuthorID != 0 {
+		if _, err := dao.ApiDao.AuthorGetDAO(req.AuthorID); err != nil {
+			return time.Time{}, authorError(err)
+		}
+	}
+	if req.Category != 0 {
+		if _, err := dao.ApiDao.CategoryGetDAO(req.Category); err != nil {
+			return time.Time{}, categoryError(err)
+		}
+	}
+	modified, err := dao.ApiDao.BookLastModifiedDAO(req.AuthorID, req.Category)
+	if err != nil {
+		return time.Time{}, bookDBError(err)
+	}
+	return modified, nil
+}
+
+func (f *feedServiceImpl) Channel(kind string, req *api.FeedReq, self string, updated time.Time) (*feed.Channel, error) {
+	if err := feedEnabled(); err != nil {
+		return nil, err
+	}
+	cfg := config.Config.Feed
+	var author *api.AuthorInfoResp
+	if req.AuthorID != 0 {
+		var err error
+		if author, err = dao.ApiDao.AuthorGetDAO(req.AuthorID); err != nil {
+			return nil, authorError(err)
+		}
+	}
+	var category *model.Category
+	if req.Category != 0 {
+		var err error
+		if category, err = dao.ApiDao.CategoryGetDAO(req.Category); err != nil {
+			return nil, categoryError(err)
+		}
+	}
+	filter := &api.BookFeedReq{AuthorID: req.AuthorID, CategoryID: req.Category, ByUpdated: kind == FeedUpdated, Page: 1, PageSize: cfg.Limit}
+	books, _, err := dao.ApiDao.BookFeedDAO(filter)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+
+	id := "urn:librarymanagement:feed:" + kind
+	if author != nil {
+		id += ":author:" + strconv.FormatUint(uint64(author.ID), 10)
+	}
+	if category != nil {
+		id += ":category:" + strconv.FormatUint(uint64(category.ID), 10)
+	}
+	if updated.IsZero() {
+		updated = time.Unix(0, 0)
+	}
+	ch := &feed.Channel{
+		ID:      id,
+		Title:   cfg.Title,
+		Self:    strings.TrimSuffix(cfg.BaseURL, "/") + self,
+		Updated: updated,
+	}
+	if author != nil {
+		ch.Author = author.Name
+	}
+	if category != nil {
+		ch.Category = category.Name
+	}
+	for _, book := range books {
+		item := feed.Item{
+			ID:      fmt.Sprintf("urn:librarymanagement:book:%d", book.ID),
+			Title:   book.Title,
+			Author:  book.Author,
+			Summary: book.Summary,
+			Updated: book.UpdatedAt,
+		}
+		if cfg.BookURL != "" {
+			item.Link = strings.ReplaceAll(cfg.BookURL, "{id}", strconv.FormatUint(uint64(book.ID), 10))
+		}
+		if kind == FeedUpdated {
+			// 每次修改在 RSS 中都是新条目，pubDate 为修改时间
+			item.GUID = fmt.Sprintf("%s:%d", item.ID, book.UpdatedAt.Unix())
+		} else {
+			item.Published = book.CreatedAt
+		}
+		ch.Items = append(ch.Items, item)
+	}
+	return ch, nil
+}
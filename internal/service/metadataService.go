@@ -0,0 +1,143 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/metadata"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMetadataDisabled    = apperr.NotFound("metadata_disabled", "未启用书目信息查询")
+	ErrMetadataNotFound    = apperr.NotFound("metadata_not_found", "未查到该 ISBN 的书目信息")
+	ErrMetadataUnavailable = apperr.Unavailable("metadata_unavailable", "书目信息服务暂不可用，请稍后重试")
+	ErrInvalidISBN         = apperr.Validation("invalid_isbn", "ISBN 格式或校验位错误")
+)
+
+// MetadataService 按 ISBN 从配置的外部服务查询书目信息，结果缓存在数据库中
+type MetadataService interface {
+	Lookup(isbn string) (*api.BookMetadataResp, error)
+	// Enrich 用查询结果填写 dto 中为空的书名、作者和简介。
+	// 查不到或外部服务不可用时不填写，也不返回错误；未启用时返回 ErrMetadataDisabled
+	Enrich(dto *api.BookInfoReq) error
+}
+
+type metadataServiceImpl struct {
+	chain *metadata.Chain
+}
+
+// NewMetadataService 按配置创建提供方，未知类型的提供方记录日志后忽略
+func NewMetadataService() MetadataService {
+	cfg := config.Config.Metadata
+	chain := &metadata.Chain{Cache: metadataCache{}, CacheTTL: cfg.CacheTTL}
+	for _, p := range cfg.Providers {
+		provider, err := metadata.NewProvider(p.Type, p.Name, p.BaseURL, p.Timeout)
+		if err != nil {
+			log.Printf("书目信息提供方配置错误 (%s): %v", p.Name, err)
+			continue
+		}
+		chain.Providers = append(chain.Providers, provider)
+	}
+	return &metadataServiceImpl{chain: chain}
+}
+
+func (m *metadataServiceImpl) lookup(isbn string) (*metadata.Metadata, bool, error) {
+	if !config.Config.Metadata.Enabled {
+		return nil, false, ErrMetadataDisabled
+	}
+	md, cached, err := m.chain.Lookup(context.Background(), isbn)
+	switch {
+	case errors.Is(err, metadata.ErrInvalidISBN):
+		return nil, false, ErrInvalidISBN
+	case errors.Is(err, metadata.ErrNotFound):
+		return nil, false, ErrMetadataNotFound
+	case err != nil:
+		return nil, false, ErrMetadataUnavailable.Wrap(err)
+	}
+	return md, cached, nil
+}
+
+func (m *metadataServiceImpl) Lookup(isbn string) (*api.BookMetadataResp, error) {
+	md, cached, err := m.lookup(isbn)
+	if err != nil {
+		return nil, err
+	}
+	return &api.BookMetadataResp{
+		ISBN:    md.ISBN,
+		Title:   md.Title,
+		Author:  model.JoinAuthors(md.Authors),
+		Summary: md.Summary,
+		Sources: md.Sources,
+		Cached:  cached,
+	}, nil
+}
+
+func (m *metadataServiceImpl) Enrich(dto *api.BookInfoReq) error {
+	if dto.Title != "" && dto.Author != "" && dto.Summary != "" {
+		return nil
+	}
+	md, _, err := m.lookup(dto.ISBN)
+	if errors.Is(err, ErrMetadataDisabled) {
+		return err
+	}
+	if err != nil {
+		log.Printf("补全书目信息失败 (ISBN: %s): %v", dto.ISBN, err)
+		return nil
+	}
+	fillMetadata(dto, md)
+	return nil
+}
+
+// fillMetadata 填写 dto 中为空的字段。外部数据超出列宽时不填写，避免写库失败；
+// 作者由 JoinAuthors 按列宽截断
+func fillMetadata(dto *api.BookInfoReq, md *metadata.Metadata) {
+	if dto.Title == "" && utf8.RuneCountInString(md.Title) <= model.MaxTitleLength {
+		dto.Title = md.Title
+	}
+	if dto.Author == "" {
+		dto.Author = model.JoinAuthors(md.Authors)
+	}
+	if dto.Summary == "" && len(md.Summary) <= model.MaxSummaryBytes {
+		dto.Summary = md.Summary
+	}
+}
+
+// metadataCache 以 book_metadata_cache 表作为查询结果缓存
+type metadataCache struct{}
+
+func (metadataCache) Get(isbn string) (*metadata.Metadata, time.Time, error) {
+	entry, err := dao.ApiDao.BookMetadataCacheGetDAO(isbn)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, time.Time{}, metadata.ErrNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	md := &metadata.Metadata{}
+	if err := json.Unmarshal([]byte(entry.Data), md); err != nil {
+		return nil, time.Time{}, err
+	}
+	return md, entry.FetchedAt, nil
+}
+
+func (metadataCache) Put(md *metadata.Metadata) error {
+	data, err := json.Marshal(md)
+	if err != nil {
+		return err
+	}
+	return dao.ApiDao.BookMetadataCacheSaveDAO(&model.BookMetadataCache{
+		ISBN:      md.ISBN,
+		Data:      string(data),
+		FetchedAt: time.Now(),
+	})
+}
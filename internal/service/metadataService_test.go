@@ -0,0 +1,32 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/metadata"
+	"LibraryManagement/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFillMetadata(t *testing.T) {
+	md := &metadata.Metadata{Title: "Go程序设计语言", Authors: []string{"Alan A. A. Donovan", "Brian W. Kernighan"}, Summary: "简介"}
+	dto := &api.BookInfoReq{Summary: "已有简介"}
+	fillMetadata(dto, md)
+	assert.Equal(t, "Go程序设计语言", dto.Title)
+	assert.Equal(t, "Alan A. A. Donovan, Brian W. Kernighan", dto.Author)
+	assert.Equal(t, "已有简介", dto.Summary)
+
+	// 超出列宽的书名、简介不填写，作者只保留放得下的部分
+	md = &metadata.Metadata{
+		Title:   strings.Repeat("书", model.MaxTitleLength+1),
+		Authors: []string{"Alan", strings.Repeat("a", model.MaxAuthorLength)},
+		Summary: strings.Repeat("x", model.MaxSummaryBytes+1),
+	}
+	dto = &api.BookInfoReq{}
+	fillMetadata(dto, md)
+	assert.Empty(t, dto.Title)
+	assert.Equal(t, "Alan", dto.Author)
+	assert.Empty(t, dto.Summary)
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/utils"
+	"errors"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+var (
+	ErrMFAAlreadyEnabled = apperr.Conflict("mfa_already_enabled", "两步验证已启用")
+	ErrMFANotEnabled     = apperr.Conflict("mfa_not_enabled", "两步验证未启用")
+	ErrMFASetupRequired  = apperr.Conflict("mfa_setup_required", "请先生成两步验证密钥")
+	ErrMFAEnforced       = apperr.Forbidden("mfa_enforced", "管理员账号必须启用两步验证")
+	ErrInvalidMFACode    = apperr.Validation("invalid_mfa_code", "验证码错误")
+	ErrInvalidMFAToken   = apperr.Unauthorized("invalid_mfa_token", "登录已过期，请重新登录")
+	ErrMFALocked         = apperr.TooManyRequests("mfa_locked", "验证码错误次数过多，请稍后再试")
+)
+
+type MFAService interface {
+	// 注册流程：Setup 生成密钥 -> 验证器扫码 -> Enable 提交验证码确认
+	Setup(userID uint) (*api.MFASetupResp, error)
+	Enable(userID uint, dto *api.MFACodeReq) (*api.RecoveryCodesResp, error)
+	Disable(userID uint, dto *api.MFADisableReq) error
+	RegenerateRecoveryCodes(userID uint, dto *api.MFACodeReq) (*api.RecoveryCodesResp, error)
+
+	// 登录第二步
+	VerifyLogin(dto *api.MFALoginReq) (*api.LoginResp, error)
+}
+
+type mfaServiceImpl struct{}
+
+func NewMFAService() MFAService {
+	return &mfaServiceImpl{}
+}
+
+// Setup 生成新的 TOTP 密钥，确认启用前不生效
+func (m *mfaServiceImpl) Setup(userID uint) (*api.MFASetupResp, error) {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	err = dao.ApiDao.UpdateUserDAO(user.ID, map[string]interface{}{"totp_secret": secret})
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.MFASetupResp{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.Config.Auth.MFA.Issuer, user.Username, secret),
+	}, nil
+}
+
+// Enable 校验验证器生成的验证码后启用两步验证，并返回恢复码
+func (m *mfaServiceImpl) Enable(userID uint, dto *api.MFACodeReq) (*api.RecoveryCodesResp, error) {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFASetupRequired
+	}
+
+	if err := verifyTOTP(user, dto.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.ApiDao.EnableTOTPDAO(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &api.RecoveryCodesResp{RecoveryCodes: codes}, nil
+}
+
+// Disable 校验密码和验证码后关闭两步验证；强制模式下管理员不能关闭
+func (m *mfaServiceImpl) Disable(userID uint, dto *api.MFADisableReq) error {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+	if user.Role == "admin" && config.Config.Auth.MFA.EnforceAdmin {
+		return ErrMFAEnforced
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(dto.Password)); err != nil {
+		return ErrWrongPassword
+	}
+	if err := verifyMFACode(user, dto.Code); err != nil {
+		return err
+	}
+
+	return dao.ApiDao.DisableTOTPDAO(user.ID)
+}
+
+// RegenerateRecoveryCodes 重新生成恢复码，旧恢复码立即作废
+func (m *mfaServiceImpl) RegenerateRecoveryCodes(userID uint, dto *api.MFACodeReq) (*api.RecoveryCodesResp, error) {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := verifyTOTP(user, dto.Code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := dao.ApiDao.ReplaceRecoveryCodesDAO(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &api.RecoveryCodesResp{RecoveryCodes: codes}, nil
+}
+
+// VerifyLogin 校验挑战令牌和验证码（或恢复码），通过后签发正式令牌
+func (m *mfaServiceImpl) VerifyLogin(dto *api.MFALoginReq) (*api.LoginResp, error) {
+	claims, err := utils.ParseToken(dto.MFAToken)
+	if err != nil || claims.Purpose != utils.PurposeMFA {
+		return nil, ErrInvalidMFAToken
+	}
+
+	user, err := dao.ApiDao.GetUserByIdDAO(claims.UserID)
+	if err != nil || user.TokenVersion != claims.TokenVersion || !user.TOTPEnabled {
+		return nil, ErrInvalidMFAToken
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// 挑战令牌可以通过重新登录反复获取，错误次数按用户累计，锁定期间不再校验验证码
+	now := time.Now()
+	if user.MFALockedUntil != nil && now.Before(*user.MFALockedUntil) {
+		return nil, ErrMFALocked
+	}
+
+	if err := verifyMFACode(user, dto.Code); err != nil {
+		if !errors.Is(err, ErrInvalidMFACode) {
+			return nil, err
+		}
+		cfg := config.Config.Auth.MFA
+		locked, lockErr := dao.ApiDao.RecordMFAFailureDAO(user.ID, cfg.MaxFailures, now.Add(cfg.Lockout))
+		if lockErr != nil {
+			return nil, lockErr
+		}
+		if locked {
+			return nil, ErrMFALocked
+		}
+		return nil, err
+	}
+
+	if user.MFAFailedAttempts > 0 || user.MFALockedUntil != nil {
+		if err := dao.ApiDao.ResetMFAFailuresDAO(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return newLoginResp(user)
+}
+
+// ---------- 工具函数 ----------
+
+// verifyMFACode 6 位数字按 TOTP 校验，其余按恢复码校验
+func verifyMFACode(user *model.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == utils.TOTPDigits {
+		return verifyTOTP(user, code)
+	}
+
+	err := dao.ApiDao.UseRecoveryCodeDAO(user.ID, utils.HashToken(normalizeRecoveryCode(code)))
+	if errors.Is(err, dao.ErrRecoveryCodeInvalid) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+func verifyTOTP(user *model.User, code string) error {
+	step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+
+	err := dao.ApiDao.UseTOTPStepDAO(user.ID, step)
+	if errors.Is(err, dao.ErrTOTPReplay) {
+		return ErrInvalidMFACode
+	}
+	return err
+}
+
+// newRecoveryCodes 生成恢复码明文（xxxxx-xxxxx）及其哈希
+func newRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := utils.RandomToken(5)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, utils.HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
@@ -0,0 +1,311 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/dc"
+	"LibraryManagement/internal/marc"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/oai"
+	"LibraryManagement/internal/repo/dao"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var ErrOAIDisabled = apperr.NotFound("oai_disabled", "未启用 OAI-PMH 收割接口")
+
+// PrefixMARC21 MARCXML 元数据格式，记录内容与 MARC 导出相同
+const PrefixMARC21 = "marc21"
+
+// oaiFormats 支持的元数据格式
+var oaiFormats = []oai.MetadataFormat{
+	{Prefix: oai.PrefixDC, Schema: oai.DCSchema, Namespace: oai.DCNamespace},
+	{Prefix: PrefixMARC21, Schema: "http://www.loc.gov/standards/marcxml/schema/MARC21slim.xsd", Namespace: marc.Namespace},
+}
+
+// OAIService OAI-PMH 收割。协议错误以 *oai.Error 返回，其余错误与书籍服务相同
+type OAIService interface {
+	// BaseURL 响应中回显的接口地址，未启用时返回 ErrOAIDisabled
+	BaseURL() (string, error)
+	Identify() (*oai.Identify, error)
+	ListMetadataFormats(identifier string) (*oai.ListMetadataFormats, error)
+	// ListSets 不支持集合，总是返回 noSetHierarchy
+	ListSets() error
+	ListIdentifiers(dto *api.OAIListReq) (*oai.ListIdentifiers, error)
+	ListRecords(dto *api.OAIListReq) (*oai.ListRecords, error)
+	GetRecord(identifier, metadataPrefix string) (*oai.GetRecord, error)
+}
+
+type oaiServiceImpl struct{}
+
+func NewOAIService() OAIService {
+	return &oaiServiceImpl{}
+}
+
+func oaiEnabled() error {
+	if !config.Config.OAI.Enabled {
+		return ErrOAIDisabled
+	}
+	return nil
+}
+
+func (o *oaiServiceImpl) BaseURL() (string, error) {
+	if err := oaiEnabled(); err != nil {
+		return "", err
+	}
+	return config.Config.OAI.BaseURL, nil
+}
+
+func (o *oaiServiceImpl) Identify() (*oai.Identify, error) {
+	if err := oaiEnabled(); err != nil {
+		return nil, err
+	}
+	earliest, err := dao.ApiDao.BookEarliestDatestampDAO()
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	if earliest.IsZero() {
+		earliest = time.Now()
+	}
+
+	cfg := config.Config.OAI
+	return &oai.Identify{
+		RepositoryName:    cfg.RepositoryName,
+		BaseURL:           cfg.BaseURL,
+		ProtocolVersion:   "2.0",
+		AdminEmails:       cfg.AdminEmails,
+		EarliestDatestamp: oai.FormatDate(earliest),
+		// 回收站中的书籍以删除状态出现，彻底删除后不再出现
+		DeletedRecord: "transient",
+		Granularity:   oai.Granularity,
+	}, nil
+}
+
+func (o *oaiServiceImpl) ListMetadataFormats(identifier string) (*oai.ListMetadataFormats, error) {
+	if err := oaiEnabled(); err != nil {
+		return nil, err
+	}
+	if identifier != "" {
+		if _, err := oaiBook(identifier); err != nil {
+			return nil, err
+		}
+	}
+	return &oai.ListMetadataFormats{Formats: oaiFormats}, nil
+}
+
+func (o *oaiServiceImpl) ListSets() error {
+	if err := oaiEnabled(); err != nil {
+		return err
+	}
+	return oai.NewError(oai.CodeNoSetHierarchy, "不支持集合")
+}
+
+func (o *oaiServiceImpl) GetRecord(identifier, metadataPrefix string) (*oai.GetRecord, error) {
+	if err := oaiEnabled(); err != nil {
+		return nil, err
+	}
+	if err := checkFormat(metadataPrefix); err != nil {
+		return nil, err
+	}
+	book, err := oaiBook(identifier)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := oaiRecords([]model.Book{*book}, metadataPrefix, true)
+	if err != nil {
+		return nil, err
+	}
+	return &oai.GetRecord{Record: records[0]}, nil
+}
+
+func (o *oaiServiceImpl) ListIdentifiers(dto *api.OAIListReq) (*oai.ListIdentifiers, error) {
+	records, token, err := oaiList(dto, false)
+	if err != nil {
+		return nil, err
+	}
+	headers := make([]oai.Header, 0, len(records))
+	for _, record := range records {
+		headers = append(headers, record.Header)
+	}
+	return &oai.ListIdentifiers{Headers: headers, ResumptionToken: token}, nil
+}
+
+func (o *oaiServiceImpl) ListRecords(dto *api.OAIListReq) (*oai.ListRecords, error) {
+	records, token, err := oaiList(dto, true)
+	if err != nil {
+		return nil, err
+	}
+	return &oai.ListRecords{Records: records, ResumptionToken: token}, nil
+}
+
+// oaiList 读取一页记录。每页多读一条判断是否还有下一页，
+// 最后一页返回空的 resumptionToken（第一页即最后一页时不返回）
+func oaiList(dto *api.OAIListReq, withMetadata bool) ([]oai.Record, *oai.ResumptionToken, error) {
+	if err := oaiEnabled(); err != nil {
+		return nil, nil, err
+	}
+
+	token := &oai.Token{MetadataPrefix: dto.MetadataPrefix, From: dto.From, Until: dto.Until}
+	resumed := dto.ResumptionToken != ""
+	if resumed {
+		var oaiErr *oai.Error
+		if token, oaiErr = oai.DecodeToken(dto.ResumptionToken); oaiErr != nil {
+			return nil, nil, oaiErr
+		}
+	}
+	if dto.Set != "" {
+		return nil, nil, oai.NewError(oai.CodeNoSetHierarchy, "不支持集合")
+	}
+	if err := checkFormat(token.MetadataPrefix); err != nil {
+		return nil, nil, err
+	}
+	r, oaiErr := oai.ParseRange(token.From, token.Until)
+	if oaiErr != nil {
+		if resumed {
+			return nil, nil, oai.NewError(oai.CodeBadResumptionToken, "resumptionToken 无效")
+		}
+		return nil, nil, oaiErr
+	}
+
+	pageSize := config.Config.OAI.PageSize
+	req := &api.BookHarvestReq{From: r.From, Until: r.Until, Limit: pageSize + 1}
+	if resumed {
+		req.AfterTime, req.AfterID = token.After(), token.AfterID
+	}
+	books, total, err := dao.ApiDao.BookHarvestDAO(req)
+	if err != nil {
+		return nil, nil, bookDBError(err)
+	}
+	if len(books) == 0 {
+		return nil, nil, oai.NewError(oai.CodeNoRecordsMatch, "没有符合条件的记录")
+	}
+
+	more := len(books) > pageSize
+	if more {
+		books = books[:pageSize]
+	}
+	records, err := oaiRecords(books, token.MetadataPrefix, withMetadata)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *oai.ResumptionToken
+	if more || resumed {
+		next = &oai.ResumptionToken{CompleteListSize: total, Cursor: token.Cursor}
+	}
+	if more {
+		last := books[len(books)-1]
+		token.AfterTime = last.Datestamp().UnixNano()
+		token.AfterID = last.ID
+		token.Cursor += len(books)
+		next.Value = token.Encode()
+	}
+	return records, next, nil
+}
+
+func checkFormat(prefix string) error {
+	for _, format := range oaiFormats {
+		if format.Prefix == prefix {
+			return nil
+		}
+	}
+	return oai.NewError(oai.CodeCannotDisseminateFormat, "不支持的元数据格式：%s", prefix)
+}
+
+// oaiIdentifier 书籍的记录标识符 oai:<repository_identifier>:book/<id>
+func oaiIdentifier(id uint) string {
+	return fmt.Sprintf("oai:%s:book/%d", config.Config.OAI.RepositoryIdentifier, id)
+}
+
+// oaiBook 根据记录标识符查询书籍（包含回收站中的书籍）
+func oaiBook(identifier string) (*model.Book, error) {
+	notFound := oai.NewError(oai.CodeIDDoesNotExist, "记录不存在：%s", identifier)
+	prefix := fmt.Sprintf("oai:%s:book/", config.Config.OAI.RepositoryIdentifier)
+	if !strings.HasPrefix(identifier, prefix) {
+		return nil, notFound
+	}
+	id, err := strconv.ParseUint(strings.TrimPrefix(identifier, prefix), 10, 64)
+	if err != nil || id == 0 {
+		return nil, notFound
+	}
+
+	book, err := dao.ApiDao.BookGetByIDWithDeletedDAO(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, notFound
+	}
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	return book, nil
+}
+
+// oaiRecords 生成记录，回收站中的书籍只有删除状态的记录头
+func oaiRecords(books []model.Book, prefix string, withMetadata bool) ([]oai.Record, error) {
+	originals := make(map[uint]string)
+	if withMetadata && prefix == PrefixMARC21 {
+		ids := make([]uint, 0, len(books))
+		for _, book := range books {
+			ids = append(ids, book.ID)
+		}
+		records, err := dao.ApiDao.BookMARCListDAO(ids)
+		if err != nil {
+			return nil, bookDBError(err)
+		}
+		for _, record := range records {
+			originals[record.BookID] = record.Record
+		}
+	}
+
+	records := make([]oai.Record, 0, len(books))
+	for i := range books {
+		book := &books[i]
+		record := oai.Record{Header: oai.Header{
+			Identifier: oaiIdentifier(book.ID),
+			Datestamp:  oai.FormatDate(book.Datestamp()),
+		}}
+		if book.DeletedAt.Valid {
+			record.Header.Status = "deleted"
+		} else if withMetadata {
+			content, err := oaiMetadata(book, prefix, originals[book.ID])
+			if err != nil {
+				return nil, err
+			}
+			record.Metadata = &oai.Metadata{Content: content}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// oaiMetadata 按元数据格式编码书籍
+func oaiMetadata(book *model.Book, prefix, original string) ([]byte, error) {
+	if prefix == PrefixMARC21 {
+		return marc.MarshalRecord(marcFromBook(book, parseMARC(book.ID, original)))
+	}
+
+	record := &oai.DC{Elements: dcElements(book)}
+	return record.Marshal()
+}
+
+// dcElements 书籍的都柏林核心元素，OAI-PMH 和 SRU 共用
+func dcElements(book *model.Book) dc.Elements {
+	elements := dc.Elements{
+		Title:      []string{book.Title},
+		Type:       []string{"Text"},
+		Identifier: []string{"urn:isbn:" + book.ISBN},
+	}
+	if book.Author != "" {
+		elements.Creator = []string{book.Author}
+	}
+	if book.Summary != "" {
+		elements.Description = []string{book.Summary}
+	}
+	return elements
+}
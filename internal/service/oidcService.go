@@ -0,0 +1,386 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/audit"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/oidc"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/utils"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+// usernameMaxLen 与 users.username 列长度一致
+const usernameMaxLen = 32
+
+var (
+	ErrOIDCDisabled     = apperr.NotFound("oidc_disabled", "未启用单点登录")
+	ErrInvalidOIDCState = apperr.Validation("invalid_oidc_state", "登录请求已过期，请重新登录")
+	ErrOIDCFailed       = apperr.Unauthorized("oidc_failed", "单点登录失败")
+	ErrIdentityLinked   = apperr.Conflict("identity_linked", "该外部账号已绑定其他用户")
+	ErrIdentityNotFound = apperr.NotFound("identity_not_found", "绑定关系不存在")
+	ErrLastIdentity     = apperr.Conflict("last_identity", "请先设置本地密码再解除唯一的登录方式")
+)
+
+type OIDCService interface {
+	// AuthURL 生成授权地址；linkUserID 非 0 时回调会把身份绑定到该用户。
+	// binding 为需要通过 Cookie 交给浏览器的随机值，回调时原样带回
+	AuthURL(ctx context.Context, linkUserID uint) (authURL, binding string, err error)
+	// Callback 处理提供方回调，完成登录（或绑定后登录）；binding 为浏览器 Cookie 中的随机值
+	Callback(ctx context.Context, code, state, binding string) (*api.LoginResp, error)
+
+	// 当前用户已绑定的外部身份
+	ListIdentities(userID uint) ([]api.IdentityResp, error)
+	Unlink(userID, id uint) error
+}
+
+type oidcServiceImpl struct {
+	states *oidc.StateStore
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+func NewOIDCService() OIDCService {
+	return &oidcServiceImpl{states: oidc.NewStateStore(config.Config.OIDC.StateTTL)}
+}
+
+// getProvider 首次使用时再读取发现文档，提供方暂时不可用不影响服务启动
+func (o *oidcServiceImpl) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	cfg := config.Config.OIDC
+	if !cfg.Enabled {
+		return nil, ErrOIDCDisabled
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.provider != nil {
+		return o.provider, nil
+	}
+
+	provider, err := oidc.Discover(ctx, oidc.Config{
+		Issuer:       cfg.Issuer,
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		RedirectURL:  cfg.RedirectURL,
+		Scopes:       cfg.Scopes,
+	})
+	if err != nil {
+		log.Printf("OIDC 发现失败: %v", err)
+		return nil, ErrOIDCFailed
+	}
+
+	o.provider = provider
+	return provider, nil
+}
+
+func (o *oidcServiceImpl) AuthURL(ctx context.Context, linkUserID uint) (string, string, error) {
+	provider, err := o.getProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := utils.RandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := utils.RandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := utils.RandomToken(32)
+	if err != nil {
+		return "", "", err
+	}
+	binding, err := utils.RandomToken(16)
+	if err != nil {
+		return "", "", err
+	}
+
+	st := oidc.State{
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+	}
+	st.BindBrowser(binding)
+	o.states.Put(state, st)
+
+	return provider.AuthCodeURL(state, nonce, oidc.CodeChallenge(verifier)), binding, nil
+}
+
+func (o *oidcServiceImpl) Callback(ctx context.Context, code, state, binding string) (*api.LoginResp, error) {
+	st, ok := o.states.Take(state)
+	if !ok {
+		return nil, ErrInvalidOIDCState
+	}
+	// state 不是由本浏览器发起的：可能是攻击者诱导用户完成自己的授权，或他人打开了绑定链接
+	if !st.BoundTo(binding) {
+		log.Printf("单点登录回调与发起授权的浏览器不一致: link_user=%d", st.LinkUserID)
+		return nil, ErrInvalidOIDCState
+	}
+
+	provider, err := o.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, code, st.CodeVerifier)
+	if err != nil {
+		log.Printf("OIDC 授权码兑换失败: %v", err)
+		return nil, ErrOIDCFailed
+	}
+
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, st.Nonce)
+	if err != nil {
+		log.Printf("OIDC ID Token 校验失败: %v", err)
+		return nil, ErrOIDCFailed
+	}
+
+	identity := &model.UserIdentity{
+		Provider: config.Config.OIDC.Issuer,
+		Subject:  oidc.StringClaim(claims, "sub"),
+		Email:    oidc.StringClaim(claims, "email"),
+	}
+
+	user, err := o.resolveUser(claims, identity, st.LinkUserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syncRole(user, identity, claims); err != nil {
+		return nil, err
+	}
+
+	// 本地账号启用了两步验证时仍需提交验证码
+	return completeLogin(user)
+}
+
+// resolveUser 按顺序确定本地用户：显式绑定 -> 已有绑定 -> 邮箱自动绑定 -> 新建用户
+func (o *oidcServiceImpl) resolveUser(claims jwt.MapClaims, identity *model.UserIdentity, linkUserID uint) (*model.User, error) {
+	if linkUserID != 0 {
+		user, err := dao.ApiDao.GetUserByIdDAO(linkUserID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		identity.UserID = user.ID
+		if err := linkIdentity(identity); err != nil {
+			return nil, err
+		}
+		log.Printf("用户绑定单点登录身份: id=%d sub=%s", user.ID, identity.Subject)
+		return user, nil
+	}
+
+	existing, err := dao.ApiDao.GetIdentityDAO(identity.Provider, identity.Subject)
+	if err == nil {
+		user, err := dao.ApiDao.GetUserByIdDAO(existing.UserID)
+		if err != nil {
+			return nil, ErrUserNotFound
+		}
+		identity.UserID, identity.AutoLinked = existing.UserID, existing.AutoLinked
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	// 只有提供方确认过的邮箱才能用于自动绑定，否则任何人都能冒用他人邮箱
+	cfg := config.Config.OIDC
+	if cfg.AutoLinkByEmail && identity.Email != "" && oidc.BoolClaim(claims, "email_verified") {
+		user, err := autoLinkCandidate(identity.Email)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			identity.UserID, identity.AutoLinked = user.ID, true
+			if err := linkIdentity(identity); err != nil {
+				return nil, err
+			}
+			log.Printf("按邮箱自动绑定单点登录身份: id=%d sub=%s", user.ID, identity.Subject)
+			return user, nil
+		}
+	}
+
+	return provisionUser(claims, identity)
+}
+
+// autoLinkCandidate 查找可按邮箱自动绑定的本地用户。本地邮箱可在个人资料中随意填写且不唯一，
+// 只有唯一且经过验证的邮箱才能绑定，否则返回 nil，改为创建新用户
+func autoLinkCandidate(email string) (*model.User, error) {
+	users, err := dao.ApiDao.ListUsersByEmailDAO(email, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) != 1 || !users[0].EmailVerified {
+		if len(users) > 0 {
+			log.Printf("邮箱不唯一或未验证，不自动绑定单点登录身份: email=%s matches=%d", email, len(users))
+		}
+		return nil, nil
+	}
+	return &users[0], nil
+}
+
+// provisionUser 首次单点登录时创建本地用户（不设置本地密码）
+func provisionUser(claims jwt.MapClaims, identity *model.UserIdentity) (*model.User, error) {
+	cfg := config.Config.OIDC
+
+	username, err := uniqueUsername(ssoUsername(claims, cfg.UsernameClaim, identity))
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:          username,
+		Role:              oidc.MapRole(claims, cfg.RoleClaim, cfg.RoleMapping, cfg.DefaultRole),
+		DisplayName:       oidc.StringClaim(claims, "name"),
+		Email:             identity.Email,
+		EmailVerified:     identity.Email != "" && oidc.BoolClaim(claims, "email_verified"),
+		PreferredLanguage: "zh",
+	}
+	if err := dao.ApiDao.CreateSSOUserDAO(user, identity); err != nil {
+		if errors.Is(err, dao.ErrIdentityLinked) {
+			return nil, ErrIdentityLinked
+		}
+		return nil, err
+	}
+
+	log.Printf("单点登录创建用户: id=%d username=%s sub=%s", user.ID, user.Username, identity.Subject)
+	RecordAudit(&model.AuditLog{
+		ActorType:  model.ActorSystem,
+		Action:     "user.provision",
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		After:      audit.Snapshot(dao.ToUserInfoResp(user)),
+		Outcome:    model.AuditSuccess,
+		Detail:     "oidc sub=" + identity.Subject,
+	})
+	return user, nil
+}
+
+// syncRole 开启 sync_role 时按映射结果更新本地角色。按邮箱自动绑定的身份只能降低权限，
+// 否则提供方中同邮箱的账号就能把本地账号提升为管理员
+func syncRole(user *model.User, identity *model.UserIdentity, claims jwt.MapClaims) error {
+	cfg := config.Config.OIDC
+	if !cfg.SyncRole {
+		return nil
+	}
+
+	role := oidc.MapRole(claims, cfg.RoleClaim, cfg.RoleMapping, cfg.DefaultRole)
+	if role == user.Role {
+		return nil
+	}
+	if identity.AutoLinked && role == "admin" {
+		log.Printf("自动绑定的单点登录身份不提升本地角色: id=%d sub=%s", user.ID, identity.Subject)
+		return nil
+	}
+
+	if err := dao.ApiDao.UpdateUserDAO(user.ID, map[string]interface{}{"role": role}); err != nil {
+		return err
+	}
+	log.Printf("单点登录同步用户角色: id=%d %s -> %s", user.ID, user.Role, role)
+	RecordAudit(&model.AuditLog{
+		ActorType:  model.ActorSystem,
+		Action:     "user.role_sync",
+		TargetType: "user",
+		TargetID:   strconv.FormatUint(uint64(user.ID), 10),
+		Before:     audit.Snapshot(map[string]string{"role": user.Role}),
+		After:      audit.Snapshot(map[string]string{"role": role}),
+		Outcome:    model.AuditSuccess,
+	})
+	user.Role = role
+	return nil
+}
+
+func linkIdentity(identity *model.UserIdentity) error {
+	err := dao.ApiDao.LinkIdentityDAO(identity)
+	if errors.Is(err, dao.ErrIdentityLinked) {
+		return ErrIdentityLinked
+	}
+	return err
+}
+
+// ssoUsername 依次使用配置的声明、邮箱前缀、sub 作为用户名
+func ssoUsername(claims jwt.MapClaims, claim string, identity *model.UserIdentity) string {
+	name := strings.TrimSpace(oidc.StringClaim(claims, claim))
+	if name == "" && identity.Email != "" {
+		name = strings.SplitN(identity.Email, "@", 2)[0]
+	}
+	if name == "" {
+		name = "sso_" + identity.Subject
+	}
+
+	runes := []rune(name)
+	if len(runes) > usernameMaxLen-4 {
+		runes = runes[:usernameMaxLen-4] // 为重名后缀预留位置
+	}
+	return string(runes)
+}
+
+// uniqueUsername 用户名已被占用时追加数字后缀
+func uniqueUsername(base string) (string, error) {
+	name := base
+	for i := 2; i < 1000; i++ {
+		_, err := dao.ApiDao.GetUserByUsernameDAO(name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return name, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	return "", ErrUserExists
+}
+
+func (o *oidcServiceImpl) ListIdentities(userID uint) ([]api.IdentityResp, error) {
+	identities, err := dao.ApiDao.ListIdentitiesDAO(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]api.IdentityResp, 0, len(identities))
+	for _, identity := range identities {
+		resps = append(resps, api.IdentityResp{
+			ID:        identity.ID,
+			Provider:  identity.Provider,
+			Subject:   identity.Subject,
+			Email:     identity.Email,
+			CreatedAt: identity.CreatedAt,
+		})
+	}
+	return resps, nil
+}
+
+// Unlink 解除绑定。单点登录创建的用户没有本地密码，不能解除最后一个身份
+func (o *oidcServiceImpl) Unlink(userID, id uint) error {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return err
+	}
+	if user.PasswordHash == "" {
+		identities, err := dao.ApiDao.ListIdentitiesDAO(userID)
+		if err != nil {
+			return err
+		}
+		if len(identities) <= 1 {
+			return ErrLastIdentity
+		}
+	}
+
+	err = dao.ApiDao.UnlinkIdentityDAO(id, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrIdentityNotFound
+	}
+	return err
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/repo/dao"
+)
+
+// OPDSService OPDS 目录的数据：新书和作者列表来自数据库，关键词搜索使用 ES。
+// 返回的书目带有贡献者，用于生成作者链接
+type OPDSService interface {
+	NewArrivals(page int) (*api.BookFeedResp, error)
+	Authors(page int) (*api.AuthorListResp, error)
+	// AuthorBooks 作者参与的书籍（不区分角色），作者不存在时返回 ErrAuthorNotFound
+	AuthorBooks(authorID uint, page int) (*api.AuthorInfoResp, *api.BookFeedResp, error)
+	Search(query string, page int) (*api.BookFeedResp, error)
+	// Content 书籍正文，供阅读器下载；没有正文时返回 ErrBookNoContent
+	Content(id uint) (*api.BookInfoResp, error)
+}
+
+type opdsServiceImpl struct {
+	esService BookESService
+}
+
+func NewOPDSService() OPDSService {
+	return &opdsServiceImpl{esService: NewBookESService()}
+}
+
+func opdsPage(page int) int {
+	if page <= 0 {
+		return 1
+	}
+	return page
+}
+
+func (o *opdsServiceImpl) NewArrivals(page int) (*api.BookFeedResp, error) {
+	return bookFeed(0, page)
+}
+
+func (o *opdsServiceImpl) AuthorBooks(authorID uint, page int) (*api.AuthorInfoResp, *api.BookFeedResp, error) {
+	author, err := dao.ApiDao.AuthorGetDAO(authorID)
+	if err != nil {
+		return nil, nil, authorError(err)
+	}
+	books, err := bookFeed(authorID, page)
+	if err != nil {
+		return nil, nil, err
+	}
+	return author, books, nil
+}
+
+func bookFeed(authorID uint, page int) (*api.BookFeedResp, error) {
+	req := &api.BookFeedReq{AuthorID: authorID, Page: opdsPage(page), PageSize: config.Config.OPDS.PageSize}
+	books, total, err := dao.ApiDao.BookFeedDAO(req)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	if err := withContributors(books); err != nil {
+		return nil, err
+	}
+	return &api.BookFeedResp{Books: books, Total: total, Page: req.Page, PageSize: req.PageSize}, nil
+}
+
+// withContributors 为书目补充贡献者
+func withContributors(books []api.BookFeedItem) error {
+	ids := make([]uint, 0, len(books))
+	for _, book := range books {
+		ids = append(ids, book.ID)
+	}
+	contributors, err := dao.ApiDao.BookContributorListDAO(ids)
+	if err != nil {
+		return bookDBError(err)
+	}
+	for i := range books {
+		books[i].Contributors = toContributorResps(contributors[books[i].ID])
+	}
+	return nil
+}
+
+func (o *opdsServiceImpl) Authors(page int) (*api.AuthorListResp, error) {
+	page, pageSize := opdsPage(page), config.Config.OPDS.PageSize
+	authors, total, err := dao.ApiDao.BookAuthorListDAO(page, pageSize)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	return &api.AuthorListResp{Authors: authors, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// Search 按综合搜索的关键词规则检索，结果顺序与 ES 相同；
+// 索引中有但数据库中已删除的书籍不返回
+func (o *opdsServiceImpl) Search(query string, page int) (*api.BookFeedResp, error) {
+	req := &api.BookSearchReq{Keyword: query, Page: opdsPage(page), PageSize: config.Config.OPDS.PageSize}
+	found, err := o.esService.SearchBooks(req)
+	if err != nil {
+		return nil, searchError(err)
+	}
+
+	ids := make([]uint, 0, len(found.Books))
+	for _, book := range found.Books {
+		ids = append(ids, book.ID)
+	}
+	items, err := dao.ApiDao.BookFeedByIDsDAO(ids)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	byID := make(map[uint]api.BookFeedItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	books := make([]api.BookFeedItem, 0, len(ids))
+	for _, id := range ids {
+		if item, ok := byID[id]; ok {
+			books = append(books, item)
+		}
+	}
+	if err := withContributors(books); err != nil {
+		return nil, err
+	}
+	return &api.BookFeedResp{Books: books, Total: found.Total, Page: found.Page, PageSize: found.PageSize}, nil
+}
+
+func (o *opdsServiceImpl) Content(id uint) (*api.BookInfoResp, error) {
+	book, err := dao.ApiDao.BookGetByIDDAO(id)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	if book.Content == "" {
+		return nil, ErrBookNoContent
+	}
+	return toBookInfoResp(book), nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/password"
+	"LibraryManagement/internal/repo/dao"
+	"fmt"
+	"log"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordPolicy 新密码校验：强度规则、泄露密码库、历史密码
+type PasswordPolicy struct {
+	rules    password.Policy
+	history  int
+	breached *password.BreachList
+}
+
+// NewPasswordPolicy 根据配置创建密码策略，配置了泄露密码库时一并加载
+func NewPasswordPolicy() (*PasswordPolicy, error) {
+	cfg := config.Config.Auth.Password
+	p := &PasswordPolicy{
+		rules: password.Policy{
+			MinLength:        cfg.MinLength,
+			RequireUpper:     cfg.RequireUpper,
+			RequireLower:     cfg.RequireLower,
+			RequireDigit:     cfg.RequireDigit,
+			RequireSymbol:    cfg.RequireSymbol,
+			MinClasses:       cfg.MinClasses,
+			DisallowUsername: cfg.DisallowUsername,
+		},
+		history: cfg.History,
+	}
+
+	if cfg.BreachedFile != "" {
+		list, err := password.LoadBreachList(cfg.BreachedFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载泄露密码库失败: %w", err)
+		}
+		log.Printf("已加载泄露密码库: %d 条", list.Len())
+		p.breached = list
+	}
+
+	return p, nil
+}
+
+// Check 校验新密码；userID 为 0（注册）时不检查历史密码。
+// 不符合策略时返回 *password.PolicyError
+func (p *PasswordPolicy) Check(userID uint, username, newPassword string) error {
+	if p == nil {
+		return nil
+	}
+
+	if err := p.rules.Check(newPassword, username); err != nil {
+		return err
+	}
+
+	if p.breached.Contains(newPassword) {
+		return password.NewPolicyError("该密码已出现在公开泄露的密码库中，请更换")
+	}
+
+	if userID == 0 || p.history <= 0 {
+		return nil
+	}
+
+	hashes, err := dao.ApiDao.RecentPasswordHashesDAO(userID, p.history)
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(h), []byte(newPassword)) == nil {
+			return password.NewPolicyError(fmt.Sprintf("不能与最近 %d 次使用过的密码相同", p.history))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// RunEvery 按固定间隔执行后台任务，直到 ctx 取消。启动时先执行一次
+func RunEvery(ctx context.Context, interval time.Duration, job func()) {
+	job()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			job()
+		}
+	}
+}
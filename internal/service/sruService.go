@@ -0,0 +1,389 @@
+package service
+
+import (
+	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
+	"LibraryManagement/internal/cql"
+	"LibraryManagement/internal/marc"
+	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/repo/dao"
+	"LibraryManagement/internal/sru"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+var ErrSRUDisabled = apperr.NotFound("sru_disabled", "未启用 SRU 检索接口")
+
+// sruMaxWindow ES 默认的 index.max_result_window，startRecord 加返回条数不能超过它
+const sruMaxWindow = 10000
+
+// sruSchema 记录格式，recordSchema 可以是短名称或标识符
+type sruSchema struct {
+	name       string
+	identifier string
+	title      string
+}
+
+// sruSchemas 支持的记录格式，第一个为默认格式
+var sruSchemas = []sruSchema{
+	{name: "dc", identifier: sru.SchemaDC, title: "Dublin Core"},
+	{name: "marcxml", identifier: sru.SchemaMARCXML, title: "MARCXML"},
+}
+
+// sruContextSets 支持的上下文集
+var sruContextSets = []sru.ContextSet{
+	{Name: "cql", Identifier: "info:srw/cql-context-set/1/cql-v1.2"},
+	{Name: "dc", Identifier: "info:srw/cql-context-set/1/dc-v1.1"},
+	{Name: "bath", Identifier: "http://zing.z3950.org/cql/bath/2.0/"},
+}
+
+// sruIndex 检索点。field 为 ES 字段，anywhere 在书名、作者、内容和简介中检索；
+// names 为带上下文集的检索点名称，不带前缀时也可以直接使用 field
+type sruIndex struct {
+	field string
+	title string
+	names []string
+}
+
+var sruIndexes = []sruIndex{
+	{field: "anywhere", title: "任意字段", names: []string{"cql.serverChoice", "cql.anywhere"}},
+	{field: "title", title: "书名", names: []string{"dc.title"}},
+	{field: "author", title: "作者", names: []string{"dc.creator"}},
+	{field: "isbn", title: "ISBN", names: []string{"bath.isbn", "dc.identifier"}},
+}
+
+// allRecords cql.allRecords 检索全部记录，检索词被忽略
+const allRecords = "cql.allRecords"
+
+// SRUService SRU 检索。协议错误以 *sru.Diagnostic 返回，其余错误与书籍服务相同
+type SRUService interface {
+	// Enabled 未启用时返回 ErrSRUDisabled
+	Enabled() error
+	// Explain 返回 ZeeRex 说明记录
+	Explain() (*sru.Record, error)
+	SearchRetrieve(req *sru.Request) (*sru.SearchRetrieveResponse, error)
+}
+
+type sruServiceImpl struct {
+	esService BookESService
+}
+
+func NewSRUService() SRUService {
+	return &sruServiceImpl{esService: NewBookESService()}
+}
+
+func (s *sruServiceImpl) Enabled() error {
+	if !config.Config.SRU.Enabled {
+		return ErrSRUDisabled
+	}
+	return nil
+}
+
+func (s *sruServiceImpl) Explain() (*sru.Record, error) {
+	if err := s.Enabled(); err != nil {
+		return nil, err
+	}
+
+	cfg := config.Config.SRU
+	explain := &sru.Explain{
+		DatabaseInfo: sru.DatabaseInfo{Title: cfg.DatabaseTitle},
+		IndexInfo:    sru.IndexInfo{Sets: sruContextSets},
+		ConfigInfo: sru.ConfigInfo{
+			Defaults: []sru.Setting{{Type: "numberOfRecords", Value: strconv.Itoa(cfg.DefaultRecords)}},
+			Settings: []sru.Setting{{Type: "maximumRecords", Value: strconv.Itoa(cfg.MaxRecords)}},
+		},
+	}
+	if u, err := url.Parse(cfg.BaseURL); err == nil {
+		explain.ServerInfo.Host = u.Hostname()
+		explain.ServerInfo.Database = strings.TrimPrefix(u.Path, "/")
+		explain.ServerInfo.Port, _ = strconv.Atoi(u.Port())
+		if explain.ServerInfo.Port == 0 {
+			explain.ServerInfo.Port = 80
+			if u.Scheme == "https" {
+				explain.ServerInfo.Port = 443
+			}
+		}
+	}
+	for _, index := range sruIndexes {
+		item := sru.Index{Title: index.title}
+		for _, name := range index.names {
+			set, name, _ := strings.Cut(name, ".")
+			item.Maps = append(item.Maps, sru.IndexMap{Name: sru.IndexName{Set: set, Name: name}})
+		}
+		explain.IndexInfo.Indexes = append(explain.IndexInfo.Indexes, item)
+	}
+	for _, schema := range sruSchemas {
+		explain.SchemaInfo.Schemas = append(explain.SchemaInfo.Schemas, sru.Schema{Identifier: schema.identifier, Name: schema.name, Title: schema.title})
+	}
+
+	data, err := explain.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	record := sru.NewRecord(sru.ExplainNamespace, sru.EscapingXML, data, 0)
+	return &record, nil
+}
+
+// SearchRetrieve 把 CQL 查询转换为 ES 查询，按相关度返回 startRecord 开始的一页记录
+func (s *sruServiceImpl) SearchRetrieve(req *sru.Request) (*sru.SearchRetrieveResponse, error) {
+	if err := s.Enabled(); err != nil {
+		return nil, err
+	}
+	schema, err := sruSchemaOf(req.RecordSchema)
+	if err != nil {
+		return nil, err
+	}
+	query, err := parseCQL(req.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := config.Config.SRU
+	size := cfg.DefaultRecords
+	if req.HasMaximumRecords() {
+		size = min(req.MaximumRecords, cfg.MaxRecords)
+	}
+	from := req.StartRecord - 1
+	if from+size > sruMaxWindow {
+		return nil, sru.NewDiagnostic(sru.CodeFirstRecordOutOfRange, strconv.Itoa(req.StartRecord))
+	}
+
+	hits, total, err := s.esService.SearchQuery(query, from, size)
+	if err != nil {
+		return nil, searchError(err)
+	}
+	if req.StartRecord > 1 && int64(from) >= total {
+		return nil, sru.NewDiagnostic(sru.CodeFirstRecordOutOfRange, strconv.Itoa(req.StartRecord))
+	}
+
+	records, err := sruRecords(hits, schema, req.RecordEscaping, req.StartRecord)
+	if err != nil {
+		return nil, err
+	}
+	resp := sru.NewSearchRetrieveResponse()
+	resp.NumberOfRecords = total
+	if len(records) > 0 {
+		resp.Records = &sru.Records{Items: records}
+		if int64(from+len(records)) < total {
+			resp.NextRecordPosition = req.StartRecord + len(records)
+		}
+	}
+	return resp, nil
+}
+
+func sruSchemaOf(name string) (sruSchema, error) {
+	if name == "" {
+		return sruSchemas[0], nil
+	}
+	for _, schema := range sruSchemas {
+		if name == schema.name || name == schema.identifier {
+			return schema, nil
+		}
+	}
+	return sruSchema{}, sru.NewDiagnostic(sru.CodeUnknownSchema, name)
+}
+
+// parseCQL 解析 CQL 并转换为 ES 查询；不支持前缀声明和 sortby
+func parseCQL(query string) (map[string]interface{}, error) {
+	q, err := cql.Parse(query)
+	var syntaxErr *cql.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// details 为出错位置之后的查询内容
+		return nil, sru.NewDiagnostic(sru.CodeQuerySyntax, query[syntaxErr.Pos:])
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(q.Prefixes) > 0 {
+		return nil, sru.NewDiagnostic(sru.CodeQueryFeatureUnsupported, ">")
+	}
+	if len(q.SortKeys) > 0 {
+		return nil, sru.NewDiagnostic(sru.CodeSortUnsupported, "sortby")
+	}
+	return cqlToES(q.Root)
+}
+
+// cqlToES 布尔运算转换为 bool 查询，检索子句按检索点和关系转换
+func cqlToES(node cql.Node) (map[string]interface{}, error) {
+	switch n := node.(type) {
+	case *cql.Boolean:
+		if n.Op == "prox" {
+			return nil, sru.NewDiagnostic(sru.CodeUnsupportedBoolean, n.Op)
+		}
+		if len(n.Modifiers) > 0 {
+			return nil, sru.NewDiagnostic(sru.CodeUnsupportedBooleanMod, n.Modifiers[0].Name)
+		}
+		left, err := cqlToES(n.Left)
+		if err != nil {
+			return nil, err
+		}
+		right, err := cqlToES(n.Right)
+		if err != nil {
+			return nil, err
+		}
+		var query map[string]interface{}
+		switch n.Op {
+		case "and":
+			query = map[string]interface{}{"must": []interface{}{left, right}}
+		case "or":
+			query = map[string]interface{}{"should": []interface{}{left, right}, "minimum_should_match": 1}
+		default: // not
+			query = map[string]interface{}{"must": []interface{}{left}, "must_not": []interface{}{right}}
+		}
+		return map[string]interface{}{"bool": query}, nil
+	case *cql.Clause:
+		return clauseToES(n)
+	}
+	return nil, sru.NewDiagnostic(sru.CodeGeneralError, "")
+}
+
+// clauseToES 关系的含义：
+//   - adj 短语匹配；= 在检索词有多个词时按短语匹配，否则与 any 相同
+//   - all 包含全部词，any 包含任一词
+//   - == 与整个字段完全相同，anywhere 不支持
+func clauseToES(clause *cql.Clause) (map[string]interface{}, error) {
+	field, err := sruField(clause.Index)
+	if err != nil {
+		return nil, err
+	}
+	relation := strings.TrimPrefix(clause.Relation.Name, "cql.")
+	if len(clause.Relation.Modifiers) > 0 {
+		return nil, sru.NewDiagnostic(sru.CodeUnsupportedRelationMod, clause.Relation.Modifiers[0].Name)
+	}
+	if field == allRecords {
+		if relation != "=" {
+			return nil, sru.NewDiagnostic(sru.CodeUnsupportedRelation, clause.Relation.Name)
+		}
+		return map[string]interface{}{"match_all": map[string]interface{}{}}, nil
+	}
+
+	term, masked, anchored := clause.Value()
+	switch {
+	case masked:
+		return nil, sru.NewDiagnostic(sru.CodeMaskingUnsupported, clause.Term)
+	case anchored:
+		return nil, sru.NewDiagnostic(sru.CodeAnchoringUnsupported, clause.Term)
+	case strings.TrimSpace(term) == "":
+		return nil, sru.NewDiagnostic(sru.CodeEmptyTerm, clause.Index)
+	}
+	phrase := relation == "adj" || (relation == "=" && len(strings.Fields(term)) > 1)
+
+	switch field {
+	case "isbn":
+		switch relation {
+		case "=", "==":
+			return map[string]interface{}{"term": map[string]interface{}{"isbn": term}}, nil
+		case "any":
+			return map[string]interface{}{"terms": map[string]interface{}{"isbn": strings.Fields(term)}}, nil
+		}
+	case "anywhere":
+		match := map[string]interface{}{"query": term, "fields": keywordFields, "type": "best_fields"}
+		switch {
+		case phrase:
+			match["type"] = "phrase"
+		case relation == "all":
+			match["type"], match["operator"] = "cross_fields", "and"
+		case relation != "=" && relation != "any":
+			return nil, sru.NewDiagnostic(sru.CodeUnsupportedRelation, clause.Relation.Name)
+		}
+		return map[string]interface{}{"multi_match": match}, nil
+	default:
+		switch {
+		case phrase:
+			return map[string]interface{}{"match_phrase": map[string]interface{}{field: term}}, nil
+		case relation == "=" || relation == "any" || relation == "all":
+			operator := "or"
+			if relation == "all" {
+				operator = "and"
+			}
+			return map[string]interface{}{"match": map[string]interface{}{field: map[string]interface{}{"query": term, "operator": operator}}}, nil
+		case relation == "==":
+			return map[string]interface{}{"term": map[string]interface{}{field + ".keyword": term}}, nil
+		}
+	}
+	return nil, sru.NewDiagnostic(sru.CodeUnsupportedRelation, clause.Relation.Name)
+}
+
+// sruField 查找检索点对应的 ES 字段；上下文集不支持时返回 15，检索点不支持时返回 16
+func sruField(index string) (string, error) {
+	if strings.EqualFold(index, allRecords) {
+		return allRecords, nil
+	}
+	for _, item := range sruIndexes {
+		if strings.EqualFold(index, item.field) {
+			return item.field, nil
+		}
+		for _, name := range item.names {
+			if strings.EqualFold(index, name) {
+				return item.field, nil
+			}
+		}
+	}
+
+	set, _ := (&cql.Clause{Index: index}).IndexName()
+	if set != "" {
+		known := false
+		for _, s := range sruContextSets {
+			known = known || strings.EqualFold(set, s.Name)
+		}
+		if !known {
+			return "", sru.NewDiagnostic(sru.CodeUnsupportedContextSet, set)
+		}
+	}
+	return "", sru.NewDiagnostic(sru.CodeUnsupportedIndex, index)
+}
+
+// sruRecords 按检索结果的顺序生成记录。记录内容取自数据库，
+// 索引中有但数据库中已删除的书籍使用索引中的字段
+func sruRecords(hits []api.BookInfoResp, schema sruSchema, escaping string, start int) ([]sru.Record, error) {
+	if len(hits) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	books, err := dao.ApiDao.BookListByIDsDAO(ids)
+	if err != nil {
+		return nil, bookDBError(err)
+	}
+	byID := make(map[uint]*model.Book, len(books))
+	for i := range books {
+		byID[books[i].ID] = &books[i]
+	}
+
+	originals := make(map[uint]string)
+	if schema.identifier == sru.SchemaMARCXML {
+		marcRecords, err := dao.ApiDao.BookMARCListDAO(ids)
+		if err != nil {
+			return nil, bookDBError(err)
+		}
+		for _, record := range marcRecords {
+			originals[record.BookID] = record.Record
+		}
+	}
+
+	records := make([]sru.Record, 0, len(hits))
+	for i, hit := range hits {
+		book, ok := byID[hit.ID]
+		if !ok {
+			book = &model.Book{Title: hit.Title, Count: hit.Count, ISBN: hit.ISBN, Author: hit.Author, Summary: hit.Summary}
+			book.ID = hit.ID
+		}
+
+		var content []byte
+		if schema.identifier == sru.SchemaMARCXML {
+			content, err = marc.MarshalRecord(marcFromBook(book, parseMARC(book.ID, originals[book.ID])))
+		} else {
+			content, err = (&sru.DC{Elements: dcElements(book)}).Marshal()
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, sru.NewRecord(schema.identifier, escaping, content, start+i))
+	}
+	return records, nil
+}
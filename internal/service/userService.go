@@ -2,29 +2,59 @@ package service
 
 import (
 	"LibraryManagement/internal/api"
+	"LibraryManagement/internal/apperr"
+	"LibraryManagement/internal/config"
 	"LibraryManagement/internal/model"
+	"LibraryManagement/internal/notify"
 	"LibraryManagement/internal/repo/dao"
 	"LibraryManagement/internal/utils"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrUserExists         = errors.New("user already exists")
-	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserExists         = apperr.Conflict("user_exists", "用户名已存在")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "用户名或密码错误")
+	ErrWrongPassword      = apperr.Validation("wrong_password", "当前密码错误")
+	ErrInvalidResetToken  = apperr.Validation("invalid_reset_token", "重置令牌无效或已过期")
+	ErrUserDisabled       = apperr.Forbidden("user_disabled", "账号已被禁用")
+	ErrUserNotFound       = apperr.NotFound("user_not_found", "用户不存在")
+	ErrModifySelf         = apperr.Forbidden("modify_self", "不能修改或删除自己的账号")
 )
 
 type UserService interface {
 	CreateUser(user *api.RegisterReq) error
 	Login(dto *api.LoginReq) (*api.LoginResp, error)
+
+	// 个人资料
+	GetProfile(userID uint) (*api.ProfileResp, error)
+	UpdateProfile(userID uint, dto *api.ProfileUpdateReq) (*api.ProfileResp, error)
+
+	// 密码管理
+	ChangePassword(userID uint, dto *api.ChangePasswordReq) error
+	RequestPasswordReset(dto *api.ForgotPasswordReq) error
+	ResetPassword(dto *api.ResetPasswordReq) error
+
+	// 管理员用户管理
+	ListUsers(dto *api.UserListReq) (*api.UserListResp, error)
+	GetUser(id uint) (*api.UserInfoResp, error)
+	UpdateUser(operatorID, id uint, dto *api.UserUpdateReq) (*api.UserInfoResp, error)
+	DeleteUser(operatorID, id uint) error
+	RestoreUser(id uint) error
 }
 
-type userServiceImpl struct{}
+type userServiceImpl struct {
+	notifier notify.Notifier
+	policy   *PasswordPolicy
+}
 
-func NewUserService() UserService {
-	return &userServiceImpl{}
+func NewUserService(notifier notify.Notifier, policy *PasswordPolicy) UserService {
+	return &userServiceImpl{notifier: notifier, policy: policy}
 }
 
 func (u userServiceImpl) CreateUser(user *api.RegisterReq) error {
@@ -41,9 +71,16 @@ func (u userServiceImpl) CreateUser(user *api.RegisterReq) error {
 		return ErrUserExists
 	}
 
-	err = dao.ApiDao.CreateUserDAO(user)
+	if err := u.policy.Check(0, user.Username, user.Password); err != nil {
+		return err
+	}
 
-	return err
+	err = dao.ApiDao.CreateUserDAO(user)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		// 并发注册同名用户时由唯一索引兜底
+		return ErrUserExists
+	}
+	return dbError(err, nil)
 
 }
 
@@ -58,21 +95,253 @@ func (u userServiceImpl) Login(dto *api.LoginReq) (*api.LoginResp, error) {
 		return nil, ErrInvalidCredentials
 	}
 
-	//生成 JWT Token
-	token, err := utils.GenerateToken(user.ID, user.Role)
+	return completeLogin(user)
+
+}
+
+// completeLogin 第一因素验证通过后的登录处理（本地密码与单点登录共用）
+func completeLogin(user *model.User) (*api.LoginResp, error) {
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// 已启用两步验证：先下发挑战令牌，验证码通过后再签发正式令牌
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID, user.TokenVersion, config.Config.Auth.MFA.ChallengeTTL)
+		if err != nil {
+			return nil, err
+		}
+		return &api.LoginResp{
+			UserID:      user.ID,
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return newLoginResp(user)
+}
+
+// newLoginResp 生成 JWT Token 并组装登录响应
+func newLoginResp(user *model.User) (*api.LoginResp, error) {
+	token, err := utils.GenerateToken(user.ID, user.Role, user.TokenVersion)
 	if err != nil {
 		return nil, err
 	}
 
 	return &api.LoginResp{
-		Token:  token,
-		UserID: user.ID,
-		Role:   user.Role,
+		Token:   token,
+		UserID:  user.ID,
+		Role:    user.Role,
+		Profile: dao.ToProfileResp(user),
 	}, nil
-
 }
 
 // GetUserByID 获取用户信息（用于中间件或后续接口）
 func (u userServiceImpl) GetUserByID(id uint) (*model.User, error) {
 	return dao.ApiDao.GetUserByIdDAO(id)
 }
+
+// GetProfile 获取当前用户的个人资料
+func (u userServiceImpl) GetProfile(userID uint) (*api.ProfileResp, error) {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return nil, dbError(err, ErrUserNotFound)
+	}
+	return dao.ToProfileResp(user), nil
+}
+
+// UpdateProfile 修改当前用户的个人资料，只更新请求中出现的字段
+func (u userServiceImpl) UpdateProfile(userID uint, dto *api.ProfileUpdateReq) (*api.ProfileResp, error) {
+	updates := map[string]interface{}{}
+	if dto.DisplayName != nil {
+		updates["display_name"] = *dto.DisplayName
+	}
+	if dto.Email != nil {
+		user, err := dao.ApiDao.GetUserByIdDAO(userID)
+		if err != nil {
+			return nil, dbError(err, ErrUserNotFound)
+		}
+		// 自行填写的邮箱未经验证，不能再用于单点登录按邮箱自动绑定
+		if *dto.Email != user.Email {
+			updates["email"] = *dto.Email
+			updates["email_verified"] = false
+		}
+	}
+	if dto.Phone != nil {
+		updates["phone"] = *dto.Phone
+	}
+	if dto.PreferredLanguage != nil {
+		updates["preferred_language"] = *dto.PreferredLanguage
+	}
+
+	if len(updates) > 0 {
+		if err := dao.ApiDao.UpdateUserDAO(userID, updates); err != nil {
+			return nil, dbError(err, ErrUserNotFound)
+		}
+	}
+
+	return u.GetProfile(userID)
+}
+
+// ChangePassword 已登录用户修改密码，需校验当前密码；成功后旧令牌全部失效
+func (u userServiceImpl) ChangePassword(userID uint, dto *api.ChangePasswordReq) error {
+	user, err := dao.ApiDao.GetUserByIdDAO(userID)
+	if err != nil {
+		return dbError(err, ErrUserNotFound)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(dto.OldPassword)); err != nil {
+		return ErrWrongPassword
+	}
+
+	if err := u.policy.Check(user.ID, user.Username, dto.NewPassword); err != nil {
+		return err
+	}
+
+	return dao.ApiDao.UpdatePasswordDAO(user.ID, dto.NewPassword)
+}
+
+// RequestPasswordReset 生成一次性重置令牌并通过通知渠道发送。
+// 用户不存在时同样返回成功，避免泄露账号是否存在
+func (u userServiceImpl) RequestPasswordReset(dto *api.ForgotPasswordReq) error {
+	user, err := dao.ApiDao.GetUserByUsernameDAO(dto.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("密码重置请求的用户不存在: %s", dto.Username)
+			return nil
+		}
+		return err
+	}
+
+	token, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	ttl := config.Config.Auth.ResetTokenTTL
+	err = dao.ApiDao.CreatePasswordResetDAO(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("您的密码重置令牌为：%s\n有效期 %s，仅可使用一次。", token, ttl)
+	if config.Config.Auth.ResetURL != "" {
+		body += "\n重置链接：" + fmt.Sprintf(config.Config.Auth.ResetURL, token)
+	}
+
+	// 优先发送到邮箱，未填写邮箱时使用用户名
+	to := user.Username
+	if user.Email != "" {
+		to = user.Email
+	}
+
+	return u.notifier.Send(notify.Message{
+		To:      to,
+		Subject: "重置密码",
+		Body:    body,
+	})
+}
+
+// ResetPassword 使用重置令牌设置新密码，令牌只能使用一次
+func (u userServiceImpl) ResetPassword(dto *api.ResetPasswordReq) error {
+	token, err := dao.ApiDao.GetPasswordResetDAO(utils.HashToken(dto.Token))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidResetToken
+		}
+		return err
+	}
+
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	user, err := dao.ApiDao.GetUserByIdDAO(token.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+	if err := u.policy.Check(user.ID, user.Username, dto.NewPassword); err != nil {
+		return err
+	}
+
+	err = dao.ApiDao.ResetPasswordDAO(token.ID, dto.NewPassword)
+	if errors.Is(err, dao.ErrResetTokenUsed) {
+		return ErrInvalidResetToken
+	}
+	return err
+}
+
+// ListUsers 分页查询用户
+func (u userServiceImpl) ListUsers(dto *api.UserListReq) (*api.UserListResp, error) {
+	users, err := dao.ApiDao.ListUsersDAO(dto)
+	if err != nil {
+		return nil, dbError(err, nil)
+	}
+	return users, nil
+}
+
+// GetUser 查看用户详情（包含已删除用户）
+func (u userServiceImpl) GetUser(id uint) (*api.UserInfoResp, error) {
+	user, err := dao.ApiDao.GetUserWithDeletedDAO(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	resp := dao.ToUserInfoResp(user)
+	return &resp, nil
+}
+
+// UpdateUser 修改用户角色或禁用状态，管理员不能修改自己，避免把自己锁在外面
+func (u userServiceImpl) UpdateUser(operatorID, id uint, dto *api.UserUpdateReq) (*api.UserInfoResp, error) {
+	if operatorID == id {
+		return nil, ErrModifySelf
+	}
+
+	updates := map[string]interface{}{}
+	if dto.Role != nil {
+		updates["role"] = *dto.Role
+	}
+	if dto.Disabled != nil {
+		updates["disabled"] = *dto.Disabled
+	}
+
+	if len(updates) > 0 {
+		if err := dao.ApiDao.UpdateUserDAO(id, updates); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+	}
+
+	return u.GetUser(id)
+}
+
+// DeleteUser 软删除用户
+func (u userServiceImpl) DeleteUser(operatorID, id uint) error {
+	if operatorID == id {
+		return ErrModifySelf
+	}
+
+	err := dao.ApiDao.DeleteUserDAO(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
+
+// RestoreUser 恢复已删除的用户
+func (u userServiceImpl) RestoreUser(id uint) error {
+	err := dao.ApiDao.RestoreUserDAO(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrUserNotFound
+	}
+	return err
+}
@@ -0,0 +1,28 @@
+package sru
+
+import (
+	"LibraryManagement/internal/dc"
+	"encoding/xml"
+)
+
+// 记录格式标识符
+const (
+	SchemaDC      = "info:srw/schema/1/dc-v1.1"
+	SchemaMARCXML = "info:srw/schema/1/marcxml-v1.1"
+	dcNamespace   = "info:srw/schema/1/dc-schema"
+)
+
+// DC dc 记录格式（srw_dc）
+type DC struct {
+	XMLName    xml.Name `xml:"srw_dc:dc"`
+	XmlnsSRWDC string   `xml:"xmlns:srw_dc,attr"`
+	XmlnsDC    string   `xml:"xmlns:dc,attr"`
+	dc.Elements
+}
+
+// Marshal 编码为 <srw_dc:dc> 元素
+func (d *DC) Marshal() ([]byte, error) {
+	d.XmlnsSRWDC = dcNamespace
+	d.XmlnsDC = dc.Namespace
+	return xml.Marshal(d)
+}
@@ -0,0 +1,82 @@
+package sru
+
+import "encoding/xml"
+
+// ExplainNamespace ZeeRex 说明文档的命名空间，也是 explain 记录的 recordSchema
+const ExplainNamespace = "http://explain.z3950.org/dtd/2.0/"
+
+// Explain ZeeRex 说明文档，描述服务地址、检索点、记录格式和默认参数
+type Explain struct {
+	XMLName      xml.Name     `xml:"zr:explain"`
+	Xmlns        string       `xml:"xmlns:zr,attr"`
+	ServerInfo   ServerInfo   `xml:"zr:serverInfo"`
+	DatabaseInfo DatabaseInfo `xml:"zr:databaseInfo"`
+	IndexInfo    IndexInfo    `xml:"zr:indexInfo"`
+	SchemaInfo   SchemaInfo   `xml:"zr:schemaInfo"`
+	ConfigInfo   ConfigInfo   `xml:"zr:configInfo"`
+}
+
+type ServerInfo struct {
+	Protocol string `xml:"protocol,attr"`
+	Version  string `xml:"version,attr"`
+	Host     string `xml:"zr:host"`
+	Port     int    `xml:"zr:port"`
+	Database string `xml:"zr:database"`
+}
+
+type DatabaseInfo struct {
+	Title string `xml:"zr:title"`
+}
+
+// IndexInfo 上下文集和检索点
+type IndexInfo struct {
+	Sets    []ContextSet `xml:"zr:set"`
+	Indexes []Index      `xml:"zr:index"`
+}
+
+type ContextSet struct {
+	Name       string `xml:"name,attr"`
+	Identifier string `xml:"identifier,attr"`
+}
+
+// Index 一个检索点，每个 Map 为一个可用的 上下文集.名称
+type Index struct {
+	Title string     `xml:"zr:title"`
+	Maps  []IndexMap `xml:"zr:map"`
+}
+
+type IndexMap struct {
+	Name IndexName `xml:"zr:name"`
+}
+
+type IndexName struct {
+	Set  string `xml:"set,attr"`
+	Name string `xml:",chardata"`
+}
+
+type SchemaInfo struct {
+	Schemas []Schema `xml:"zr:schema"`
+}
+
+type Schema struct {
+	Identifier string `xml:"identifier,attr"`
+	Name       string `xml:"name,attr"`
+	Title      string `xml:"zr:title"`
+}
+
+type ConfigInfo struct {
+	Defaults []Setting `xml:"zr:default"`
+	Settings []Setting `xml:"zr:setting"`
+}
+
+type Setting struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Marshal 编码为 <zr:explain> 元素
+func (e *Explain) Marshal() ([]byte, error) {
+	e.Xmlns = ExplainNamespace
+	e.ServerInfo.Protocol, e.ServerInfo.Version = "SRU", Version
+	return xml.Marshal(e)
+}
@@ -0,0 +1,270 @@
+// Package sru 实现 SRU 2.0 协议层：请求参数解析、诊断信息和 XML 响应结构。
+// 查询的执行由调用方负责
+package sru
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 协议版本和命名空间
+const (
+	Version             = "2.0"
+	ResponseNamespace   = "http://docs.oasis-open.org/ns/search-ws/sruResponse"
+	DiagnosticNamespace = "http://docs.oasis-open.org/ns/search-ws/diagnostic"
+	diagnosticURI       = "info:srw/diagnostic/1/"
+)
+
+// 操作
+const (
+	OperationSearchRetrieve = "searchRetrieve"
+	OperationExplain        = "explain"
+)
+
+// 记录内容的编码方式
+const (
+	EscapingXML    = "xml"
+	EscapingString = "string"
+)
+
+// 诊断码，见 http://www.loc.gov/standards/sru/diagnostics/diagnosticsList.html
+const (
+	CodeGeneralError              = 1
+	CodeUnsupportedOperation      = 4
+	CodeUnsupportedVersion        = 5
+	CodeUnsupportedParameterValue = 6
+	CodeMandatoryParameter        = 7
+	CodeUnsupportedParameter      = 8
+	CodeQuerySyntax               = 10
+	CodeUnsupportedContextSet     = 15
+	CodeUnsupportedIndex          = 16
+	CodeUnsupportedRelation       = 19
+	CodeUnsupportedRelationMod    = 20
+	CodeEmptyTerm                 = 27
+	CodeMaskingUnsupported        = 28
+	CodeAnchoringUnsupported      = 32
+	CodeUnsupportedBoolean        = 37
+	CodeUnsupportedBooleanMod     = 46
+	CodeQueryFeatureUnsupported   = 48
+	CodeFirstRecordOutOfRange     = 61
+	CodeUnknownSchema             = 66
+	CodeUnsupportedEscaping       = 71
+	CodeSortUnsupported           = 80
+)
+
+// messages 诊断码对应的说明，由调用方按请求语言翻译
+var messages = map[int]string{
+	CodeGeneralError:              "系统错误",
+	CodeUnsupportedOperation:      "不支持的操作",
+	CodeUnsupportedVersion:        "不支持的协议版本",
+	CodeUnsupportedParameterValue: "不支持的参数值",
+	CodeMandatoryParameter:        "缺少必填参数",
+	CodeUnsupportedParameter:      "不支持的参数",
+	CodeQuerySyntax:               "查询语法错误",
+	CodeUnsupportedContextSet:     "不支持的上下文集",
+	CodeUnsupportedIndex:          "不支持的检索点",
+	CodeUnsupportedRelation:       "不支持的关系",
+	CodeUnsupportedRelationMod:    "不支持的关系修饰符",
+	CodeEmptyTerm:                 "不支持空检索词",
+	CodeMaskingUnsupported:        "不支持截词符",
+	CodeAnchoringUnsupported:      "不支持定位符",
+	CodeUnsupportedBoolean:        "不支持的布尔运算符",
+	CodeUnsupportedBooleanMod:     "不支持的布尔修饰符",
+	CodeQueryFeatureUnsupported:   "不支持的查询功能",
+	CodeFirstRecordOutOfRange:     "起始记录位置超出范围",
+	CodeUnknownSchema:             "不支持的记录格式",
+	CodeUnsupportedEscaping:       "不支持的记录编码方式",
+	CodeSortUnsupported:           "不支持排序",
+}
+
+// Diagnostic 诊断信息，致命错误时代替记录返回。Details 为出错的参数、检索点等
+type Diagnostic struct {
+	XMLName xml.Name `xml:"diag:diagnostic"`
+	Xmlns   string   `xml:"xmlns:diag,attr"`
+	URI     string   `xml:"diag:uri"`
+	Details string   `xml:"diag:details,omitempty"`
+	Message string   `xml:"diag:message,omitempty"`
+}
+
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s（%s）", d.URI, d.Message, d.Details)
+}
+
+func NewDiagnostic(code int, details string) *Diagnostic {
+	return &Diagnostic{
+		Xmlns:   DiagnosticNamespace,
+		URI:     diagnosticURI + strconv.Itoa(code),
+		Details: details,
+		Message: messages[code],
+	}
+}
+
+// Request searchRetrieve 或 explain 请求
+type Request struct {
+	Operation      string
+	Query          string
+	StartRecord    int // 从 1 开始
+	MaximumRecords int
+	RecordSchema   string // 为空时使用默认格式
+	RecordEscaping string
+	maximumRecords bool
+}
+
+// HasMaximumRecords 请求中是否指定了 maximumRecords
+func (r *Request) HasMaximumRecords() bool {
+	return r.maximumRecords
+}
+
+// parameters 支持的参数；x- 开头的扩展参数忽略。
+// 不保存结果集，resultSetTTL 忽略；只支持 packed，recordPacking 单独校验
+var parameters = map[string]bool{
+	"operation": true, "version": true, "query": true, "queryType": true,
+	"startRecord": true, "maximumRecords": true, "recordSchema": true,
+	"recordXMLEscaping": true, "recordPacking": true, "resultSetTTL": true,
+	"sortKeys": true,
+}
+
+// ParseRequest 解析请求参数。没有 operation 时按 SRU 2.0 的约定，
+// 有 query 为 searchRetrieve，否则为 explain
+func ParseRequest(args url.Values) (*Request, *Diagnostic) {
+	for name, values := range args {
+		if strings.HasPrefix(name, "x-") {
+			continue
+		}
+		if !parameters[name] {
+			return nil, NewDiagnostic(CodeUnsupportedParameter, name)
+		}
+		if len(values) > 1 {
+			return nil, NewDiagnostic(CodeUnsupportedParameterValue, name)
+		}
+	}
+
+	if v := args.Get("version"); v != "" && v != Version {
+		return nil, NewDiagnostic(CodeUnsupportedVersion, Version)
+	}
+	req := &Request{
+		Operation:      args.Get("operation"),
+		Query:          args.Get("query"),
+		StartRecord:    1,
+		RecordSchema:   args.Get("recordSchema"),
+		RecordEscaping: EscapingXML,
+	}
+	switch req.Operation {
+	case "":
+		req.Operation = OperationExplain
+		if _, ok := args["query"]; ok {
+			req.Operation = OperationSearchRetrieve
+		}
+	case OperationSearchRetrieve, OperationExplain:
+	default:
+		return nil, NewDiagnostic(CodeUnsupportedOperation, req.Operation)
+	}
+	if req.Operation == OperationExplain {
+		return req, nil
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, NewDiagnostic(CodeMandatoryParameter, "query")
+	}
+	if v := args.Get("queryType"); v != "" && v != "cql" {
+		return nil, NewDiagnostic(CodeUnsupportedParameterValue, "queryType")
+	}
+	if args.Get("sortKeys") != "" {
+		return nil, NewDiagnostic(CodeSortUnsupported, "sortKeys")
+	}
+	if v := args.Get("recordPacking"); v != "" && v != "packed" {
+		return nil, NewDiagnostic(CodeUnsupportedParameterValue, "recordPacking")
+	}
+	if v := args.Get("recordXMLEscaping"); v != "" {
+		if v != EscapingXML && v != EscapingString {
+			return nil, NewDiagnostic(CodeUnsupportedEscaping, v)
+		}
+		req.RecordEscaping = v
+	}
+	if v := args.Get("startRecord"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, NewDiagnostic(CodeUnsupportedParameterValue, "startRecord")
+		}
+		req.StartRecord = n
+	}
+	if v := args.Get("maximumRecords"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return nil, NewDiagnostic(CodeUnsupportedParameterValue, "maximumRecords")
+		}
+		req.MaximumRecords, req.maximumRecords = n, true
+	}
+	return req, nil
+}
+
+// Record 一条记录，Data 为已编码的记录内容
+type Record struct {
+	Schema   string     `xml:"sruResponse:recordSchema"`
+	Escaping string     `xml:"sruResponse:recordXMLEscaping"`
+	Data     RecordData `xml:"sruResponse:recordData"`
+	Position int        `xml:"sruResponse:recordPosition,omitempty"`
+}
+
+type RecordData struct {
+	Content []byte `xml:",innerxml"`
+}
+
+// NewRecord 按编码方式放入记录：xml 直接嵌入，string 转义为文本
+func NewRecord(schema, escaping string, content []byte, position int) Record {
+	if escaping == EscapingString {
+		var b strings.Builder
+		xml.EscapeText(&b, content)
+		content = []byte(b.String())
+	}
+	return Record{Schema: schema, Escaping: escaping, Data: RecordData{Content: content}, Position: position}
+}
+
+type Records struct {
+	Items []Record `xml:"sruResponse:record"`
+}
+
+type Diagnostics struct {
+	Items []*Diagnostic `xml:"diag:diagnostic"`
+}
+
+// SearchRetrieveResponse 检索响应；出现致命诊断时没有记录
+type SearchRetrieveResponse struct {
+	XMLName            xml.Name     `xml:"sruResponse:searchRetrieveResponse"`
+	Xmlns              string       `xml:"xmlns:sruResponse,attr"`
+	Version            string       `xml:"sruResponse:version"`
+	NumberOfRecords    int64        `xml:"sruResponse:numberOfRecords"`
+	Records            *Records     `xml:"sruResponse:records,omitempty"`
+	NextRecordPosition int          `xml:"sruResponse:nextRecordPosition,omitempty"`
+	Diagnostics        *Diagnostics `xml:"sruResponse:diagnostics,omitempty"`
+}
+
+// ExplainResponse 说明响应，记录内容为 ZeeRex 文档
+type ExplainResponse struct {
+	XMLName     xml.Name     `xml:"sruResponse:explainResponse"`
+	Xmlns       string       `xml:"xmlns:sruResponse,attr"`
+	Version     string       `xml:"sruResponse:version"`
+	Record      *Record      `xml:"sruResponse:record,omitempty"`
+	Diagnostics *Diagnostics `xml:"sruResponse:diagnostics,omitempty"`
+}
+
+func NewSearchRetrieveResponse() *SearchRetrieveResponse {
+	return &SearchRetrieveResponse{Xmlns: ResponseNamespace, Version: Version}
+}
+
+func NewExplainResponse() *ExplainResponse {
+	return &ExplainResponse{Xmlns: ResponseNamespace, Version: Version}
+}
+
+// Fail 设置致命诊断，清空记录
+func (r *SearchRetrieveResponse) Fail(d *Diagnostic) {
+	r.NumberOfRecords, r.Records, r.NextRecordPosition = 0, nil, 0
+	r.Diagnostics = &Diagnostics{Items: []*Diagnostic{d}}
+}
+
+func (r *ExplainResponse) Fail(d *Diagnostic) {
+	r.Diagnostics = &Diagnostics{Items: []*Diagnostic{d}}
+}
@@ -0,0 +1,91 @@
+package sru
+
+import (
+	"encoding/xml"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRequest(t *testing.T) {
+	args, _ := url.ParseQuery("query=dc.title%3Dgo&startRecord=11&maximumRecords=5&recordSchema=marcxml&x-debug=1")
+	req, diag := ParseRequest(args)
+	require.Nil(t, diag)
+	assert.Equal(t, OperationSearchRetrieve, req.Operation)
+	assert.Equal(t, "dc.title=go", req.Query)
+	assert.Equal(t, 11, req.StartRecord)
+	assert.Equal(t, 5, req.MaximumRecords)
+	assert.True(t, req.HasMaximumRecords())
+	assert.Equal(t, "marcxml", req.RecordSchema)
+	assert.Equal(t, EscapingXML, req.RecordEscaping)
+
+	req, diag = ParseRequest(url.Values{})
+	require.Nil(t, diag)
+	assert.Equal(t, OperationExplain, req.Operation)
+
+	cases := []struct {
+		query string
+		uri   string
+	}{
+		{"version=1.2&query=go", "info:srw/diagnostic/1/5"},
+		{"operation=scan", "info:srw/diagnostic/1/4"},
+		{"operation=searchRetrieve", "info:srw/diagnostic/1/7"},
+		{"query=go&startRecord=0", "info:srw/diagnostic/1/6"},
+		{"query=go&maximumRecords=-1", "info:srw/diagnostic/1/6"},
+		{"query=go&query=java", "info:srw/diagnostic/1/6"},
+		{"query=go&stylesheet=a.xsl", "info:srw/diagnostic/1/8"},
+		{"query=go&sortKeys=title", "info:srw/diagnostic/1/80"},
+		{"query=go&recordXMLEscaping=json", "info:srw/diagnostic/1/71"},
+	}
+	for _, tc := range cases {
+		args, _ := url.ParseQuery(tc.query)
+		_, diag := ParseRequest(args)
+		if assert.NotNil(t, diag, tc.query) {
+			assert.Equal(t, tc.uri, diag.URI, tc.query)
+		}
+	}
+}
+
+func TestSearchRetrieveResponse(t *testing.T) {
+	resp := NewSearchRetrieveResponse()
+	resp.NumberOfRecords = 12
+	resp.Records = &Records{Items: []Record{
+		NewRecord("info:srw/schema/1/dc-v1.1", EscapingXML, []byte(`<dc>Go</dc>`), 1),
+		NewRecord("info:srw/schema/1/dc-v1.1", EscapingString, []byte(`<dc>Go</dc>`), 2),
+	}}
+	resp.NextRecordPosition = 3
+	data, err := xml.Marshal(resp)
+	require.NoError(t, err)
+	out := string(data)
+
+	assert.Contains(t, out, `<sruResponse:searchRetrieveResponse xmlns:sruResponse="http://docs.oasis-open.org/ns/search-ws/sruResponse"><sruResponse:version>2.0</sruResponse:version><sruResponse:numberOfRecords>12</sruResponse:numberOfRecords><sruResponse:records><sruResponse:record>`)
+	assert.Contains(t, out, `<sruResponse:recordData><dc>Go</dc></sruResponse:recordData><sruResponse:recordPosition>1</sruResponse:recordPosition>`)
+	assert.Contains(t, out, `<sruResponse:recordData>&lt;dc&gt;Go&lt;/dc&gt;</sruResponse:recordData>`)
+	assert.Contains(t, out, `<sruResponse:nextRecordPosition>3</sruResponse:nextRecordPosition>`)
+	assert.NotContains(t, out, "diagnostics")
+
+	// 致命诊断时不返回记录
+	resp.Fail(NewDiagnostic(CodeUnsupportedIndex, "dc.publisher"))
+	data, _ = xml.Marshal(resp)
+	out = string(data)
+	assert.NotContains(t, out, "<sruResponse:records>")
+	assert.Contains(t, out, `<sruResponse:numberOfRecords>0</sruResponse:numberOfRecords><sruResponse:diagnostics><diag:diagnostic xmlns:diag="http://docs.oasis-open.org/ns/search-ws/diagnostic"><diag:uri>info:srw/diagnostic/1/16</diag:uri><diag:details>dc.publisher</diag:details><diag:message>不支持的检索点</diag:message></diag:diagnostic></sruResponse:diagnostics>`)
+}
+
+func TestExplain(t *testing.T) {
+	explain := &Explain{
+		ServerInfo:   ServerInfo{Host: "localhost", Port: 8080, Database: "sru"},
+		DatabaseInfo: DatabaseInfo{Title: "LibraryManagement"},
+		IndexInfo: IndexInfo{
+			Sets:    []ContextSet{{Name: "dc", Identifier: "info:srw/cql-context-set/1/dc-v1.1"}},
+			Indexes: []Index{{Title: "title", Maps: []IndexMap{{Name: IndexName{Set: "dc", Name: "title"}}}}},
+		},
+	}
+	data, err := explain.Marshal()
+	require.NoError(t, err)
+	out := string(data)
+	assert.Contains(t, out, `<zr:explain xmlns:zr="http://explain.z3950.org/dtd/2.0/"><zr:serverInfo protocol="SRU" version="2.0"><zr:host>localhost</zr:host><zr:port>8080</zr:port><zr:database>sru</zr:database></zr:serverInfo>`)
+	assert.Contains(t, out, `<zr:index><zr:title>title</zr:title><zr:map><zr:name set="dc">title</zr:name></zr:map></zr:index>`)
+}
@@ -0,0 +1,108 @@
+// Package tabular 逐行读取 CSV、TSV 和 XLSX 表格，供批量导入使用。
+// 读取是流式的：CSV/TSV 边读边解析，XLSX 只把共享字符串表读入内存，工作表按行解码
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// 支持的文件格式
+const (
+	CSV  = "csv"
+	TSV  = "tsv"
+	XLSX = "xlsx"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("不支持的文件格式")
+	ErrInvalidFile       = errors.New("文件内容无法解析")
+)
+
+// Reader 逐行读取表格，读完后返回 io.EOF
+type Reader interface {
+	Read() ([]string, error)
+	// Line 最近一次读取的行在文件中的行号（从 1 开始）
+	Line() int
+}
+
+// File 上传的文件，XLSX 是 zip 包，需要随机读取
+type File interface {
+	io.Reader
+	io.ReaderAt
+}
+
+// FormatOf 根据文件扩展名判断格式，无法识别时返回空字符串
+func FormatOf(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return CSV
+	case ".tsv", ".tab":
+		return TSV
+	case ".xlsx":
+		return XLSX
+	}
+	return ""
+}
+
+// NewReader 按格式打开表格，size 为文件大小（XLSX 需要）
+func NewReader(format string, f File, size int64) (Reader, error) {
+	switch format {
+	case CSV:
+		return newDelimited(f, ','), nil
+	case TSV:
+		return newDelimited(f, '\t'), nil
+	case XLSX:
+		return newXLSX(f, size)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// ---------- CSV / TSV ----------
+
+type delimitedReader struct {
+	r    *csv.Reader
+	line int
+}
+
+func newDelimited(r io.Reader, comma rune) *delimitedReader {
+	br := bufio.NewReader(r)
+	// Excel 导出的 UTF-8 CSV 带 BOM
+	if bom, err := br.Peek(3); err == nil && string(bom) == "\xef\xbb\xbf" {
+		_, _ = br.Discard(3)
+	}
+
+	cr := csv.NewReader(br)
+	cr.Comma = comma
+	cr.FieldsPerRecord = -1 // 允许各行列数不同，缺失的列按空值处理
+	if comma == '\t' {
+		// TSV 一般不使用引号转义
+		cr.LazyQuotes = true
+	}
+	return &delimitedReader{r: cr}
+}
+
+func (d *delimitedReader) Read() ([]string, error) {
+	record, err := d.r.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, io.EOF
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			d.line = parseErr.StartLine
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	d.line, _ = d.r.FieldPos(0)
+	return record, nil
+}
+
+func (d *delimitedReader) Line() int {
+	return d.line
+}
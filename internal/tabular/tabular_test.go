@@ -0,0 +1,135 @@
+package tabular
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readAll 读取全部行及其行号
+func readAll(t *testing.T, r Reader) ([][]string, []int) {
+	var rows [][]string
+	var lines []int
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, lines
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+		lines = append(lines, r.Line())
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	assert.Equal(t, CSV, FormatOf("books.CSV"))
+	assert.Equal(t, TSV, FormatOf("books.tsv"))
+	assert.Equal(t, XLSX, FormatOf("/tmp/books.xlsx"))
+	assert.Equal(t, "", FormatOf("books.xls"))
+
+	_, err := NewReader("xls", strings.NewReader(""), 0)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestCSV(t *testing.T) {
+	data := "\xef\xbb\xbftitle,isbn,count\n\"Go, 第二版\",978-1,3\n\n\"多行\n标题\",978-2\n"
+	r, err := NewReader(CSV, strings.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	rows, lines := readAll(t, r)
+	assert.Equal(t, [][]string{
+		{"title", "isbn", "count"},
+		{"Go, 第二版", "978-1", "3"},
+		{"多行\n标题", "978-2"},
+	}, rows)
+	// 空行被跳过，行号仍与文件一致
+	assert.Equal(t, []int{1, 2, 4}, lines)
+
+	// 格式错误时报告出错的行
+	bad := "title\n\"Go\"x\n"
+	r, _ = NewReader(CSV, strings.NewReader(bad), int64(len(bad)))
+	_, err = r.Read()
+	require.NoError(t, err)
+	_, err = r.Read()
+	assert.ErrorIs(t, err, ErrInvalidFile)
+	assert.Equal(t, 2, r.Line())
+}
+
+func TestTSV(t *testing.T) {
+	data := "title\tisbn\nGo \"语言\"\t978-1\n"
+	r, err := NewReader(TSV, strings.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	rows, _ := readAll(t, r)
+	assert.Equal(t, [][]string{{"title", "isbn"}, {`Go "语言"`, "978-1"}}, rows)
+}
+
+// buildXLSX 生成只包含必要部件的 XLSX 文件
+func buildXLSX(t *testing.T, parts map[string]string) *bytes.Reader {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestXLSX(t *testing.T) {
+	f := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+			xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+			<sheets><sheet name="图书" sheetId="1" r:id="rId3"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+			<Relationship Id="rId1" Target="styles.xml"/>
+			<Relationship Id="rId3" Target="worksheets/books.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+			<si><t>title</t></si><si><t>isbn</t></si><si><t>count</t></si>
+			<si><r><t>Go</t></r><r><t>语言</t></r></si></sst>`,
+		"xl/worksheets/books.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+			<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c></row>
+			<row r="2"><c r="A2" t="s"><v>3</v></c><c r="B2"><v>9.787111111111E12</v></c><c r="C2"><v>5</v></c></row>
+			<row r="4"><c r="A4" t="inlineStr"><is><t>Rust</t></is></c><c r="C4" t="b"><v>1</v></c></row>
+		</sheetData></worksheet>`,
+	})
+
+	r, err := NewReader(XLSX, f, f.Size())
+	require.NoError(t, err)
+
+	rows, lines := readAll(t, r)
+	assert.Equal(t, [][]string{
+		{"title", "isbn", "count"},
+		{"Go语言", "9787111111111", "5"},
+		{"Rust", "", "TRUE"},
+	}, rows)
+	assert.Equal(t, []int{1, 2, 4}, lines)
+}
+
+func TestXLSXInvalid(t *testing.T) {
+	_, err := NewReader(XLSX, strings.NewReader("not a zip"), 9)
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	// 共享字符串下标越界
+	f := buildXLSX(t, map[string]string{
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row><c r="A1" t="s"><v>7</v></c></row></sheetData></worksheet>`,
+	})
+	r, err := NewReader(XLSX, f, f.Size())
+	require.NoError(t, err)
+	_, err = r.Read()
+	assert.ErrorIs(t, err, ErrInvalidFile)
+
+	// 非法的单元格引用
+	_, err = columnIndex("1A")
+	assert.ErrorIs(t, err, ErrInvalidFile)
+	col, err := columnIndex("AB3")
+	require.NoError(t, err)
+	assert.Equal(t, 27, col)
+}
@@ -0,0 +1,281 @@
+package tabular
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// 读取 XLSX（Office Open XML）第一个工作表，只取单元格的值，忽略样式和公式
+type xlsxReader struct {
+	sheet  io.ReadCloser
+	dec    *xml.Decoder
+	shared []string
+	line   int
+}
+
+// xlsxText 共享字符串或行内字符串：纯文本在 <t> 中，富文本分为多个 <r><t>
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxCell struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline xlsxText `xml:"is"`
+}
+
+type xlsxRow struct {
+	Num   int        `xml:"r,attr"`
+	Cells []xlsxCell `xml:"c"`
+}
+
+func newXLSX(f io.ReaderAt, size int64) (*xlsxReader, error) {
+	zr, err := zip.NewReader(f, size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, file := range zr.File {
+		files[file.Name] = file
+	}
+
+	sheetName, err := firstSheet(files)
+	if err != nil {
+		return nil, err
+	}
+	sheetFile, ok := files[sheetName]
+	if !ok {
+		return nil, fmt.Errorf("%w: 缺少工作表 %s", ErrInvalidFile, sheetName)
+	}
+
+	shared, err := sharedStrings(files["xl/sharedStrings.xml"])
+	if err != nil {
+		return nil, err
+	}
+
+	sheet, err := sheetFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return &xlsxReader{sheet: sheet, dec: xml.NewDecoder(sheet), shared: shared}, nil
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if x.dec == nil {
+		return nil, io.EOF
+	}
+
+	for {
+		tok, err := x.dec.Token()
+		if err == io.EOF {
+			x.close()
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+
+		var row xlsxRow
+		if err := x.dec.DecodeElement(&row, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		// 行号省略时按顺序递增；空行不会出现在文件中，行号可能跳跃
+		if row.Num > 0 {
+			x.line = row.Num
+		} else {
+			x.line++
+		}
+		return x.cells(row.Cells)
+	}
+}
+
+func (x *xlsxReader) Line() int {
+	return x.line
+}
+
+func (x *xlsxReader) close() {
+	x.sheet.Close()
+	x.dec = nil
+}
+
+// cells 按单元格引用（如 C2）放到对应列，省略的单元格为空字符串
+func (x *xlsxReader) cells(cells []xlsxCell) ([]string, error) {
+	record := make([]string, 0, len(cells))
+	for _, c := range cells {
+		col := len(record)
+		if c.Ref != "" {
+			var err error
+			if col, err = columnIndex(c.Ref); err != nil {
+				return nil, err
+			}
+		}
+		for len(record) < col {
+			record = append(record, "")
+		}
+
+		value, err := x.value(c)
+		if err != nil {
+			return nil, err
+		}
+		if col < len(record) {
+			record[col] = value
+		} else {
+			record = append(record, value)
+		}
+	}
+	return record, nil
+}
+
+func (x *xlsxReader) value(c xlsxCell) (string, error) {
+	switch c.Type {
+	case "s":
+		i, err := strconv.Atoi(c.Value)
+		if err != nil || i < 0 || i >= len(x.shared) {
+			return "", fmt.Errorf("%w: 单元格 %s 引用了不存在的共享字符串", ErrInvalidFile, c.Ref)
+		}
+		return x.shared[i], nil
+	case "inlineStr":
+		return c.Inline.String(), nil
+	case "b":
+		if c.Value == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "", "n":
+		// 较长的数字（如 ISBN）可能以科学计数法保存
+		if strings.ContainsAny(c.Value, "eE") {
+			if f, err := strconv.ParseFloat(c.Value, 64); err == nil {
+				return strconv.FormatFloat(f, 'f', -1, 64), nil
+			}
+		}
+	}
+	return c.Value, nil
+}
+
+// columnIndex 把单元格引用中的列字母转换为从 0 开始的列号，如 A1→0、AB3→27
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	// Excel 最多 16384 列（XFD）
+	if i == 0 || i > 3 || col > 16384 {
+		return 0, fmt.Errorf("%w: 非法的单元格引用 %q", ErrInvalidFile, ref)
+	}
+	return col - 1, nil
+}
+
+// firstSheet 从 workbook.xml 及其关系文件找到第一个工作表的路径
+func firstSheet(files map[string]*zip.File) (string, error) {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook struct {
+		Sheets []struct {
+			RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err := decodeFile(files["xl/workbook.xml"], &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return fallback, nil
+	}
+
+	var rels struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "", err
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		// Target 一般相对于 xl/，也可能是以 / 开头的包内绝对路径
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return fallback, nil
+}
+
+// sharedStrings 读取共享字符串表，文件中没有文本单元格时可能不存在
+func sharedStrings(file *zip.File) ([]string, error) {
+	if file == nil {
+		return nil, nil
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer rc.Close()
+
+	var shared []string
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return shared, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "si" {
+			continue
+		}
+		var si xlsxText
+		if err := dec.DecodeElement(&si, &start); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		shared = append(shared, si.String())
+	}
+}
+
+// decodeFile 解码包内的 XML 文件，文件不存在时 v 保持零值
+func decodeFile(file *zip.File, v interface{}) error {
+	if file == nil {
+		return nil
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return nil
+}
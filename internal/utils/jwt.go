@@ -9,17 +9,23 @@ import (
 
 var jwtSecret = []byte("key") // 建议从环境变量读取
 
+// PurposeMFA 两步验证挑战令牌的用途标识，只能用于提交验证码，不能访问业务接口
+const PurposeMFA = "mfa"
+
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Role   string `json:"role"`
+	UserID       uint   `json:"user_id"`
+	Role         string `json:"role"`
+	TokenVersion uint   `json:"token_version"`     // 与用户当前版本不一致时令牌失效
+	Purpose      string `json:"purpose,omitempty"` // 为空表示普通访问令牌
 	jwt.RegisteredClaims
 }
 
 // GenerateToken 生成 JWT Token
-var GenerateToken = func(userID uint, role string) (string, error) {
+var GenerateToken = func(userID uint, role string, tokenVersion uint) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:       userID,
+		Role:         role,
+		TokenVersion: tokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24小时过期
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -31,6 +37,23 @@ var GenerateToken = func(userID uint, role string) (string, error) {
 	return token.SignedString(jwtSecret)
 }
 
+// GenerateMFAToken 生成两步验证挑战令牌，有效期较短
+func GenerateMFAToken(userID uint, tokenVersion uint, ttl time.Duration) (string, error) {
+	claims := &Claims{
+		UserID:       userID,
+		TokenVersion: tokenVersion,
+		Purpose:      PurposeMFA,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			NotBefore: jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
 // ParseToken 解析 JWT Token
 func ParseToken(tokenStr string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -12,7 +12,7 @@ func TestGenerateAndParseToken_Success(t *testing.T) {
 	userID := uint(123)
 	role := "admin"
 
-	token, err := GenerateToken(userID, role)
+	token, err := GenerateToken(userID, role, 3)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, token)
 
@@ -20,6 +20,7 @@ func TestGenerateAndParseToken_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, userID, claims.UserID)
 	assert.Equal(t, role, claims.Role)
+	assert.Equal(t, uint(3), claims.TokenVersion)
 	assert.WithinDuration(t, time.Now(), claims.RegisteredClaims.IssuedAt.Time, time.Second)
 	assert.WithinDuration(t, time.Now().Add(24*time.Hour), claims.RegisteredClaims.ExpiresAt.Time, time.Second)
 }
@@ -53,3 +54,15 @@ func TestParseToken_InvalidSignature(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestGenerateMFAToken(t *testing.T) {
+	token, err := GenerateMFAToken(7, 2, 5*time.Minute)
+	assert.NoError(t, err)
+
+	claims, err := ParseToken(token)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(7), claims.UserID)
+	assert.Equal(t, PurposeMFA, claims.Purpose)
+	assert.Empty(t, claims.Role)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), claims.ExpiresAt.Time, time.Second)
+}
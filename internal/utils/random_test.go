@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomToken(t *testing.T) {
+	a, err := RandomToken(32)
+	assert.NoError(t, err)
+	assert.Len(t, a, 64)
+
+	b, err := RandomToken(32)
+	assert.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestHashToken(t *testing.T) {
+	h := HashToken("abc")
+	assert.Len(t, h, 64)
+	assert.Equal(t, h, HashToken("abc"))
+	assert.NotEqual(t, h, HashToken("abd"))
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 附录 B 的 SHA1 测试向量（取后 6 位）
+func TestTOTPCode_RFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:          "287082",
+		1111111109:  "081804",
+		1111111111:  "050471",
+		1234567890:  "005924",
+		2000000000:  "279037",
+		20000000000: "353130",
+	}
+	for ts, want := range cases {
+		code, err := TOTPCode(secret, TOTPStep(time.Unix(ts, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, want, code, "time=%d", ts)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+	code, _ := TOTPCode(secret, TOTPStep(now))
+
+	step, ok := ValidateTOTP(secret, code, now, 0)
+	assert.True(t, ok)
+	assert.Equal(t, TOTPStep(now), step)
+
+	// 允许一个时间步的时钟偏移
+	_, ok = ValidateTOTP(secret, code, now.Add(TOTPPeriod*time.Second), 0)
+	assert.True(t, ok)
+
+	// 超出偏移范围
+	_, ok = ValidateTOTP(secret, code, now.Add(3*TOTPPeriod*time.Second), 0)
+	assert.False(t, ok)
+
+	// 已使用过的时间步不能重放
+	_, ok = ValidateTOTP(secret, code, now, step)
+	assert.False(t, ok)
+
+	_, ok = ValidateTOTP(secret, "12345", now, 0)
+	assert.False(t, ok)
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Library", "alice", "JBSWY3DPEHPK3PXP")
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Library:alice?"))
+	assert.Contains(t, uri, "secret=JBSWY3DPEHPK3PXP")
+	assert.Contains(t, uri, "issuer=Library")
+}
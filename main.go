@@ -1,9 +1,11 @@
 package main
 
 import (
+	"LibraryManagement/internal/api/result"
 	"LibraryManagement/internal/config"
 	"LibraryManagement/internal/es"
 	"LibraryManagement/internal/handler"
+	"LibraryManagement/internal/notify"
 	"LibraryManagement/internal/repo/dao"
 	"LibraryManagement/internal/router"
 	"LibraryManagement/internal/service"
@@ -24,6 +26,7 @@ func main() {
 	if err := config.LoadConfig("./config.yaml"); err != nil {
 		log.Fatal("加载配置文件失败: ", err)
 	}
+	result.ProblemJSON = config.Config.Server.ProblemJSON
 
 	// 初始化数据库连接
 	if err := dao.SetupDBLink(); err != nil {
@@ -41,7 +44,23 @@ func main() {
 	// 依赖注入
 	// init service
 	bookService := service.NewBookService()
-	userService := service.NewUserService()
+	notifier := notify.NewNotifier(config.Config.Notify.Type, config.Config.Notify.FilePath)
+	passwordPolicy, err := service.NewPasswordPolicy()
+	if err != nil {
+		log.Fatal(err)
+	}
+	userService := service.NewUserService(notifier, passwordPolicy)
+	mfaService := service.NewMFAService()
+	apiKeyService := service.NewAPIKeyService()
+	oidcService := service.NewOIDCService()
+	auditService := service.NewAuditService()
+	oaiService := service.NewOAIService()
+	sruService := service.NewSRUService()
+	opdsService := service.NewOPDSService()
+	feedService := service.NewFeedService()
+	metadataService := service.NewMetadataService()
+	authorService := service.NewAuthorService()
+	categoryService := service.NewCategoryService()
 
 	// 初始化ES索引（如果ES可用）
 	if es.Client != nil {
@@ -53,10 +72,45 @@ func main() {
 	}
 
 	// init handler
-	bookHandler := handler.NewBookHandler(bookService)
-	userHandler := handler.NewUserHandler(userService)
+	handlers := &router.Handlers{
+		Book:     handler.NewBookHandler(bookService),
+		User:     handler.NewUserHandler(userService),
+		MFA:      handler.NewMFAHandler(mfaService),
+		APIKey:   handler.NewAPIKeyHandler(apiKeyService),
+		OIDC:     handler.NewOIDCHandler(oidcService),
+		Audit:    handler.NewAuditHandler(auditService),
+		OAI:      handler.NewOAIHandler(oaiService),
+		SRU:      handler.NewSRUHandler(sruService),
+		OPDS:     handler.NewOPDSHandler(opdsService),
+		Feed:     handler.NewFeedHandler(feedService),
+		Metadata: handler.NewMetadataHandler(metadataService),
+		Author:   handler.NewAuthorHandler(authorService),
+		Category: handler.NewCategoryHandler(categoryService),
+	}
+
+	gin := router.InitRouter(handlers)
+
+	// 后台任务，随服务关闭一起停止
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
 
-	gin := router.InitRouter(bookHandler, userHandler)
+	// 按保留期清理审计日志
+	go service.RunEvery(jobCtx, config.Config.Audit.PurgeInterval, func() {
+		if n, err := auditService.PurgeExpired(); err != nil {
+			log.Printf("清理审计日志失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已清理过期审计日志 %d 条", n)
+		}
+	})
+
+	// 彻底删除超过保留期的回收站书籍
+	go service.RunEvery(jobCtx, config.Config.Trash.PurgeInterval, func() {
+		if n, err := bookService.PurgeExpiredTrash(); err != nil {
+			log.Printf("清理回收站失败: %v", err)
+		} else if n > 0 {
+			log.Printf("已彻底删除回收站中过期书籍 %d 本", n)
+		}
+	})
 
 	//创建HTTP服务器
 	server := &http.Server{
@@ -72,10 +126,11 @@ func main() {
 	}()
 
 	//等待退出信号
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutdown Server ...")
+	stopJobs()
 
 	//创建超时上下文，Shutdown可以让未处理的连接在这个时间内关闭
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
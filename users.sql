@@ -3,10 +3,120 @@ CREATE TABLE IF NOT EXISTS users (
                        username VARCHAR(32) NOT NULL UNIQUE COMMENT '用户名',
                        password_hash VARCHAR(255) NOT NULL COMMENT '密码哈希',
                        role ENUM('user', 'admin') NOT NULL DEFAULT 'user' COMMENT '用户角色',
+                       token_version INT UNSIGNED NOT NULL DEFAULT 0 COMMENT '令牌版本，修改密码后递增',
+                       disabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否禁用',
+                       display_name VARCHAR(64) NULL COMMENT '显示名称',
+                       email VARCHAR(128) NULL COMMENT '邮箱',
+                       email_verified TINYINT(1) NOT NULL DEFAULT 0 COMMENT '邮箱是否由身份提供方确认',
+                       phone VARCHAR(20) NULL COMMENT '手机号',
+                       preferred_language VARCHAR(8) NOT NULL DEFAULT 'zh' COMMENT '偏好语言',
+                       totp_secret VARCHAR(64) NULL COMMENT '两步验证密钥',
+                       totp_enabled TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否启用两步验证',
+                       totp_last_step BIGINT NOT NULL DEFAULT 0 COMMENT '最近使用的TOTP时间步',
+                       mfa_failed_attempts INT NOT NULL DEFAULT 0 COMMENT '两步验证连续失败次数',
+                       mfa_locked_until DATETIME(3) NULL DEFAULT NULL COMMENT '两步验证锁定截止时间',
                        created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
                        updated_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3) ON UPDATE CURRENT_TIMESTAMP(3),
                        deleted_at DATETIME(3) NULL DEFAULT NULL,
 
                        INDEX idx_username (username),
-                       INDEX idx_role (role)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
+                       INDEX idx_role (role),
+                       INDEX idx_users_email (email)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NULL DEFAULT NULL,
+                       updated_at DATETIME(3) NULL DEFAULT NULL,
+                       deleted_at DATETIME(3) NULL DEFAULT NULL,
+                       user_id BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+                       token_hash CHAR(64) NOT NULL COMMENT '令牌SHA-256哈希',
+                       expires_at DATETIME(3) NOT NULL COMMENT '过期时间',
+                       used_at DATETIME(3) NULL DEFAULT NULL COMMENT '使用时间',
+
+                       UNIQUE INDEX idx_token_hash (token_hash),
+                       INDEX idx_password_reset_tokens_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NULL DEFAULT NULL,
+                       updated_at DATETIME(3) NULL DEFAULT NULL,
+                       deleted_at DATETIME(3) NULL DEFAULT NULL,
+                       user_id BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+                       code_hash CHAR(64) NOT NULL COMMENT '恢复码SHA-256哈希',
+                       used_at DATETIME(3) NULL DEFAULT NULL COMMENT '使用时间',
+
+                       INDEX idx_recovery_codes_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NULL DEFAULT NULL,
+                       updated_at DATETIME(3) NULL DEFAULT NULL,
+                       deleted_at DATETIME(3) NULL DEFAULT NULL COMMENT '吊销时间',
+                       user_id BIGINT UNSIGNED NOT NULL COMMENT '所属用户',
+                       name VARCHAR(64) NOT NULL COMMENT '名称',
+                       kind VARCHAR(16) NOT NULL DEFAULT 'personal' COMMENT 'personal/service',
+                       prefix VARCHAR(16) NOT NULL COMMENT '公开前缀',
+                       key_hash CHAR(64) NOT NULL COMMENT 'Key SHA-256哈希',
+                       scopes VARCHAR(255) NOT NULL COMMENT '权限范围，逗号分隔',
+                       expires_at DATETIME(3) NULL DEFAULT NULL COMMENT '过期时间',
+                       last_used_at DATETIME(3) NULL DEFAULT NULL COMMENT '最近使用时间',
+
+                       UNIQUE INDEX idx_api_keys_prefix (prefix),
+                       INDEX idx_api_keys_user_id (user_id),
+                       INDEX idx_api_keys_deleted_at (deleted_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS user_identities (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NULL DEFAULT NULL,
+                       updated_at DATETIME(3) NULL DEFAULT NULL,
+                       deleted_at DATETIME(3) NULL DEFAULT NULL,
+                       user_id BIGINT UNSIGNED NOT NULL COMMENT '本地用户ID',
+                       provider VARCHAR(255) NOT NULL COMMENT '身份提供方 issuer',
+                       subject VARCHAR(255) NOT NULL COMMENT 'ID Token 中的 sub',
+                       email VARCHAR(128) NULL COMMENT '提供方返回的邮箱',
+                       auto_linked TINYINT(1) NOT NULL DEFAULT 0 COMMENT '是否按邮箱自动绑定',
+
+                       UNIQUE INDEX idx_identity_subject (provider, subject),
+                       INDEX idx_user_identities_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS password_histories (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NULL DEFAULT NULL,
+                       updated_at DATETIME(3) NULL DEFAULT NULL,
+                       deleted_at DATETIME(3) NULL DEFAULT NULL,
+                       user_id BIGINT UNSIGNED NOT NULL COMMENT '用户ID',
+                       password_hash VARCHAR(255) NOT NULL COMMENT '曾使用的密码哈希',
+
+                       INDEX idx_password_histories_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 审计日志：只追加，保留期外的记录由服务定期清理
+CREATE TABLE IF NOT EXISTS audit_logs (
+                       id BIGINT UNSIGNED AUTO_INCREMENT PRIMARY KEY,
+                       created_at DATETIME(3) NOT NULL DEFAULT CURRENT_TIMESTAMP(3),
+                       actor_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '操作者用户ID，0表示匿名或系统',
+                       actor_type VARCHAR(16) NOT NULL COMMENT 'user/api_key/system/anonymous',
+                       api_key_id BIGINT UNSIGNED NOT NULL DEFAULT 0 COMMENT '使用的API Key',
+                       action VARCHAR(64) NOT NULL COMMENT '操作，如 book.delete',
+                       target_type VARCHAR(32) NULL COMMENT '操作对象类型',
+                       target_id VARCHAR(64) NULL COMMENT '操作对象ID',
+                       ip VARCHAR(45) NULL COMMENT '来源IP',
+                       user_agent VARCHAR(255) NULL COMMENT 'User-Agent',
+                       before_data MEDIUMTEXT NULL COMMENT '变更前快照(JSON)，书籍正文只记录长度和哈希',
+                       after_data MEDIUMTEXT NULL COMMENT '变更后快照(JSON)',
+                       outcome VARCHAR(16) NOT NULL COMMENT 'success/failure',
+                       detail VARCHAR(255) NULL COMMENT '补充信息',
+
+                       INDEX idx_audit_logs_created_at (created_at),
+                       INDEX idx_audit_logs_actor_id (actor_id),
+                       INDEX idx_audit_logs_action (action)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- 禁止修改已写入的审计日志
+CREATE TRIGGER audit_logs_append_only BEFORE UPDATE ON audit_logs
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit_logs is append-only';